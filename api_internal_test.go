@@ -2,6 +2,11 @@ package openai
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -107,6 +112,57 @@ func TestRequestAuthHeader(t *testing.T) {
 	}
 }
 
+func TestRequestOrganizationAndProjectHeaders(t *testing.T) {
+	cases := []struct {
+		Name          string
+		APIType       APIType
+		OrgID         string
+		ProjectID     string
+		Override      bool
+		ContextID     string
+		ExpectOrg     string
+		ExpectProject string
+	}{
+		{"None", APITypeOpenAI, "", "", false, "", "", ""},
+		{"OrgOnly", APITypeOpenAI, "org-1", "", false, "", "org-1", ""},
+		{"OrgAndProject", APITypeOpenAI, "org-1", "proj-1", false, "", "org-1", "proj-1"},
+		{"ProjectOverride", APITypeOpenAI, "org-1", "proj-1", true, "proj-2", "org-1", "proj-2"},
+		{"ProjectOverrideWithoutDefault", APITypeOpenAI, "", "", true, "proj-2", "", "proj-2"},
+		{"ProjectOverrideRemoves", APITypeOpenAI, "", "proj-1", true, "", "", ""},
+		{"Ollama", APITypeOllama, "", "proj-1", false, "", "", "proj-1"},
+		{"AzureNoProject", APITypeAzure, "", "proj-1", true, "proj-2", "", ""},
+		{"AzureADNoProject", APITypeAzureAD, "", "proj-1", false, "", "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			config := DefaultConfig("dummy-token")
+			config.APIType = c.APIType
+			config.OrgID = c.OrgID
+			config.ProjectID = c.ProjectID
+
+			ctx := context.Background()
+			if c.Override {
+				ctx = WithProjectID(ctx, c.ContextID)
+			}
+			cli := NewClientWithConfig(config)
+			req, err := cli.newRequest(ctx, "POST", "/chat/completions")
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if actual := req.Header.Get("OpenAI-Organization"); actual != c.ExpectOrg {
+				t.Errorf("Expected organization %q, got %q", c.ExpectOrg, actual)
+			}
+			if actual := req.Header.Get("OpenAI-Project"); actual != c.ExpectProject {
+				t.Errorf("Expected project %q, got %q", c.ExpectProject, actual)
+			}
+			if _, ok := req.Header["Openai-Project"]; ok && c.ExpectProject == "" {
+				t.Error("Expected no OpenAI-Project header")
+			}
+		})
+	}
+}
+
 func TestAzureFullURL(t *testing.T) {
 	cases := []struct {
 		Name             string
@@ -122,7 +178,7 @@ func TestAzureFullURL(t *testing.T) {
 			"chatgpt-demo",
 			"https://httpbin.org/" +
 				"openai/deployments/chatgpt-demo" +
-				"/chat/completions?api-version=2023-05-15",
+				"/chat/completions?api-version=2024-10-21",
 		},
 		{
 			"AzureBaseURLWithoutSlashOK",
@@ -131,7 +187,7 @@ func TestAzureFullURL(t *testing.T) {
 			"chatgpt-demo",
 			"https://httpbin.org/" +
 				"openai/deployments/chatgpt-demo" +
-				"/chat/completions?api-version=2023-05-15",
+				"/chat/completions?api-version=2024-10-21",
 		},
 	}
 
@@ -148,3 +204,177 @@ func TestAzureFullURL(t *testing.T) {
 		})
 	}
 }
+
+// urlRecorder records the URLs of requests and answers them with an empty
+// JSON object.
+type urlRecorder struct {
+	urls []string
+}
+
+func (r *urlRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.urls = append(r.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestAzureDeploymentURLs(t *testing.T) {
+	recorder := &urlRecorder{}
+	config := DefaultAzureConfig("dummy", "https://test.openai.azure.com/")
+	config.AzureDeployments = map[string]string{
+		"gpt-4o":                "prod-gpt4o",
+		"gpt-4o-mini":           "cheap-mini",
+		CreateImageModelDallE3:  "images",
+		Whisper1:                "speech-to-text",
+		AdaEmbeddingV2.String(): "embeddings",
+	}
+	config.HTTPClient = &http.Client{Transport: recorder}
+	client := NewClientWithConfig(config)
+	ctx := context.Background()
+	messages := []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "Hello!"}}
+
+	calls := []struct {
+		name   string
+		call   func() error
+		expect string
+	}{
+		{"MappedChat", func() error {
+			_, err := client.CreateChatCompletion(ctx, ChatCompletionRequest{Model: "gpt-4o", Messages: messages})
+			return err
+		}, "prod-gpt4o/chat/completions"},
+		{"SecondMappedChat", func() error {
+			_, err := client.CreateChatCompletion(ctx, ChatCompletionRequest{Model: "gpt-4o-mini", Messages: messages})
+			return err
+		}, "cheap-mini/chat/completions"},
+		{"UnmappedChatIsSanitized", func() error {
+			_, err := client.CreateChatCompletion(ctx, ChatCompletionRequest{Model: GPT3Dot5Turbo, Messages: messages})
+			return err
+		}, "gpt-35-turbo/chat/completions"},
+		{"MappedEmbeddings", func() error {
+			_, err := client.CreateEmbeddings(ctx, EmbeddingRequest{Input: []string{"hi"}, Model: AdaEmbeddingV2})
+			return err
+		}, "embeddings/embeddings"},
+		{"UnmappedEmbeddings", func() error {
+			_, err := client.CreateEmbeddings(ctx, EmbeddingRequest{Input: []string{"hi"}, Model: BabbageSimilarity})
+			return err
+		}, "text-similarity-babbage-001/embeddings"},
+		{"MappedImage", func() error {
+			_, err := client.CreateImage(ctx, ImageRequest{Prompt: "a cat", Model: CreateImageModelDallE3})
+			return err
+		}, "images/images/generations"},
+		{"MappedAudio", func() error {
+			_, err := client.CreateTranscription(ctx, AudioRequest{
+				Model:    Whisper1,
+				FilePath: "audio.mp3",
+				Reader:   strings.NewReader("audio"),
+			})
+			return err
+		}, "speech-to-text/audio/transcriptions"},
+	}
+
+	for _, c := range calls {
+		t.Run(c.name, func(t *testing.T) {
+			recorder.urls = nil
+			if err := c.call(); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			expect := "https://test.openai.azure.com/openai/deployments/" + c.expect + "?api-version=2024-10-21"
+			if len(recorder.urls) != 1 || recorder.urls[0] != expect {
+				t.Errorf("Expected %s, got %v", expect, recorder.urls)
+			}
+		})
+	}
+}
+
+func TestQueryParamsURLs(t *testing.T) {
+	params := url.Values{"flag": {"a", "b"}, "api-version": {"preview"}, "route": {"eu west"}}
+	client := NewClientWithConfig(DefaultConfig("dummy"), WithQueryParams(params))
+	if actual, expect := client.fullURL("/chat/completions"),
+		"https://api.openai.com/v1/chat/completions?api-version=preview&flag=a&flag=b&route=eu+west"; actual != expect {
+		t.Errorf("Expected %s, got %s", expect, actual)
+	}
+	if actual, expect := client.fullURL("/files?purpose=batch"),
+		"https://api.openai.com/v1/files?purpose=batch&api-version=preview&flag=a&flag=b&route=eu+west"; actual != expect {
+		t.Errorf("Expected %s, got %s", expect, actual)
+	}
+
+	recorder := &urlRecorder{}
+	config := DefaultAzureConfig("dummy", "https://test.openai.azure.com/")
+	config.HTTPClient = &http.Client{Transport: recorder}
+	client = NewClientWithConfig(config, WithQueryParams(params), WithQueryParams(url.Values{"route": {"us"}}))
+	ctx := context.Background()
+	request := ChatCompletionRequest{Model: "gpt-4o", Messages: []ChatCompletionMessage{UserMessage("Hello!")}}
+	_, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		t.Fatalf("CreateChatCompletion failed: %v", err)
+	}
+	stream, err := client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+	stream.Close()
+	_, err = client.CreateTranscription(ctx, AudioRequest{Model: Whisper1, FilePath: "audio.mp3", Reader: strings.NewReader("audio")})
+	if err != nil {
+		t.Fatalf("CreateTranscription failed: %v", err)
+	}
+
+	// The api-version of the client wins.
+	query := "?api-version=2024-10-21&flag=a&flag=b&route=us"
+	expect := []string{
+		"https://test.openai.azure.com/openai/deployments/gpt-4o/chat/completions" + query,
+		"https://test.openai.azure.com/openai/deployments/gpt-4o/chat/completions" + query,
+		"https://test.openai.azure.com/openai/deployments/whisper-1/audio/transcriptions" + query,
+	}
+	if !slices.Equal(recorder.urls, expect) {
+		t.Errorf("Expected %v, got %v", expect, recorder.urls)
+	}
+	if params.Get("route") != "eu west" {
+		t.Error("WithQueryParams changed the values passed to an earlier call")
+	}
+}
+
+func TestRequestAPIVersion(t *testing.T) {
+	recorder := &urlRecorder{}
+	azure := DefaultAzureConfig("dummy", "https://test.openai.azure.com/")
+	azure.HTTPClient = &http.Client{Transport: recorder}
+	unversioned := DefaultConfig("dummy")
+	unversioned.APIType = APITypeAzure
+	unversioned.BaseURL = "https://test.openai.azure.com"
+	unversioned.HTTPClient = azure.HTTPClient
+	openAI := DefaultConfig("dummy")
+	openAI.HTTPClient = azure.HTTPClient
+
+	preview := WithRequestAPIVersion(context.Background(), "2025-01-01-preview")
+	deployment := "https://test.openai.azure.com/openai/deployments/gpt-4o/chat/completions"
+	cases := []struct {
+		name   string
+		config ClientConfig
+		ctx    context.Context
+		expect string
+	}{
+		{"AzureDefault", azure, context.Background(), deployment + "?api-version=2024-10-21"},
+		{"AzureOverride", azure, preview, deployment + "?api-version=2025-01-01-preview"},
+		{"AzureEmptyOverride", azure, WithRequestAPIVersion(context.Background(), ""),
+			deployment + "?api-version=2024-10-21"},
+		{"AzureWithoutVersion", unversioned, context.Background(), deployment + "?api-version=2024-10-21"},
+		{"OpenAIIgnoresOverride", openAI, preview, "https://api.openai.com/v1/chat/completions"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder.urls = nil
+			_, err := NewClientWithConfig(c.config).CreateChatCompletion(c.ctx, ChatCompletionRequest{
+				Model:    "gpt-4o",
+				Messages: []ChatCompletionMessage{UserMessage("Hello!")},
+			})
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if len(recorder.urls) != 1 || recorder.urls[0] != c.expect {
+				t.Errorf("Expected %s, got %v", c.expect, recorder.urls)
+			}
+		})
+	}
+}
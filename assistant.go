@@ -32,11 +32,48 @@ const (
 	AssistantToolTypeCodeInterpreter AssistantToolType = "code_interpreter"
 	AssistantToolTypeRetrieval       AssistantToolType = "retrieval"
 	AssistantToolTypeFunction        AssistantToolType = "function"
+	// AssistantToolTypeFileSearch replaces AssistantToolTypeRetrieval in v2 of
+	// the Assistants API.
+	AssistantToolTypeFileSearch AssistantToolType = "file_search"
 )
 
 type AssistantTool struct {
 	Type     AssistantToolType   `json:"type"`
 	Function *FunctionDefinition `json:"function,omitempty"`
+	// FileSearch configures tools of type AssistantToolTypeFileSearch.
+	FileSearch *FileSearchToolConfig `json:"file_search,omitempty"`
+}
+
+// FileSearchToolConfig overrides the defaults of the file search tool.
+type FileSearchToolConfig struct {
+	// MaxNumResults is the number of results the tool returns, between 1 and
+	// 50. Zero means the default of the model.
+	MaxNumResults  int                       `json:"max_num_results,omitempty"`
+	RankingOptions *FileSearchRankingOptions `json:"ranking_options,omitempty"`
+}
+
+// FileSearchRankingOptions select the results of the file search tool.
+type FileSearchRankingOptions struct {
+	// Ranker is "auto" or "default_2024_08_21", auto if empty.
+	Ranker string `json:"ranker,omitempty"`
+	// ScoreThreshold is the minimum score of the results, between 0 and 1.
+	ScoreThreshold float64 `json:"score_threshold"`
+}
+
+// NewCodeInterpreterTool returns a tool letting the assistant write and run
+// Python code.
+func NewCodeInterpreterTool() AssistantTool {
+	return AssistantTool{Type: AssistantToolTypeCodeInterpreter}
+}
+
+// NewFileSearchTool returns a tool letting the assistant search its files,
+// returning up to maxNumResults results, or the default number if zero.
+func NewFileSearchTool(maxNumResults int) AssistantTool {
+	tool := AssistantTool{Type: AssistantToolTypeFileSearch}
+	if maxNumResults > 0 {
+		tool.FileSearch = &FileSearchToolConfig{MaxNumResults: maxNumResults}
+	}
+	return tool
 }
 
 type AssistantRequest struct {
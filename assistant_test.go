@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -200,3 +201,33 @@ When asked a question, write and run Python code to answer the question.`
 	err = client.DeleteAssistantFile(ctx, assistantID, assistantFileID)
 	checks.NoError(t, err, "DeleteAssistantFile error")
 }
+
+func TestAssistantToolsJSON(t *testing.T) {
+	fileSearch := openai.NewFileSearchTool(10)
+	fileSearch.FileSearch.RankingOptions = &openai.FileSearchRankingOptions{Ranker: "auto", ScoreThreshold: 0.5}
+	testCases := []struct {
+		tool openai.AssistantTool
+		want string
+	}{
+		{openai.NewCodeInterpreterTool(), `{"type":"code_interpreter"}`},
+		{openai.NewFileSearchTool(0), `{"type":"file_search"}`},
+		{
+			fileSearch,
+			`{"type":"file_search","file_search":{"max_num_results":10,` +
+				`"ranking_options":{"ranker":"auto","score_threshold":0.5}}}`,
+		},
+	}
+	for _, testCase := range testCases {
+		data, err := json.Marshal(testCase.tool)
+		checks.NoError(t, err, "Marshal error")
+		if string(data) != testCase.want {
+			t.Errorf("got %s, want %s", data, testCase.want)
+		}
+
+		var tool openai.AssistantTool
+		checks.NoError(t, json.Unmarshal(data, &tool), "Unmarshal error")
+		if !reflect.DeepEqual(tool, testCase.tool) {
+			t.Errorf("got tool %+v after a round trip, want %+v", tool, testCase.tool)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultAsyncPollInterval = time.Second
+
+// AsyncOperationStatus is the status of an AsyncOperation.
+type AsyncOperationStatus string
+
+const (
+	AsyncOperationStatusNotRunning AsyncOperationStatus = "notRunning"
+	AsyncOperationStatusRunning    AsyncOperationStatus = "running"
+	AsyncOperationStatusSucceeded  AsyncOperationStatus = "succeeded"
+	AsyncOperationStatusFailed     AsyncOperationStatus = "failed"
+	AsyncOperationStatusCanceled   AsyncOperationStatus = "canceled"
+)
+
+// ErrAsyncOperationCanceled is returned by AsyncOperation.Wait for operations
+// that were canceled.
+var ErrAsyncOperationCanceled = errors.New("async operation was canceled")
+
+// AsyncOperation is a request processed in the background, such as the ones of
+// CreateChatCompletionAsync.
+type AsyncOperation[T any] struct {
+	// ID and URL identify the operation, URL being its Operation-Location. They
+	// are empty for requests the server answered right away.
+	ID     string
+	URL    string
+	Status AsyncOperationStatus
+
+	client *Client
+	// model and endpoint are those of the request, for the usage recorder.
+	model    string
+	endpoint string
+	// delay is the wait asked for by the last response, if any.
+	delay    time.Duration
+	hasDelay bool
+	// done is set once result has been received.
+	done   bool
+	result T
+}
+
+// asyncOperationState is the body of the responses about an operation.
+type asyncOperationState struct {
+	ID     string               `json:"id"`
+	Status AsyncOperationStatus `json:"status"`
+	Result json.RawMessage      `json:"result,omitempty"`
+	Error  *APIError            `json:"error,omitempty"`
+
+	httpHeader
+}
+
+// asyncSubmission is the response to a request sent with Prefer:
+// respond-async, which is either an operation or, if the server does not
+// support them, the result.
+type asyncSubmission struct {
+	body []byte
+
+	httpHeader
+}
+
+func (s *asyncSubmission) readBody(body io.Reader) (err error) {
+	s.body, err = io.ReadAll(body)
+	return
+}
+
+// CreateChatCompletionAsync sends request with a Prefer: respond-async header,
+// for Azure deployments answering with an operation to poll rather than
+// holding the connection until the completion is done. Deployments that do not
+// support it answer as CreateChatCompletion does, and Wait then returns their
+// response right away. Cached responses are not used.
+func (c *Client) CreateChatCompletionAsync(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (*AsyncOperation[ChatCompletionResponse], error) {
+	if request.Stream {
+		return nil, ErrChatCompletionStreamNotSupported
+	}
+	if !c.supportsModel(chatCompletionsSuffix, request.Model) {
+		return nil, ErrChatCompletionInvalidModel
+	}
+
+	req, err := c.newRequest(
+		ctx,
+		http.MethodPost,
+		c.fullURL(chatCompletionsSuffix, request.Model),
+		withBody(request),
+		withPreferAsync(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var submission asyncSubmission
+	if err = c.sendRequest(req, &submission); err != nil {
+		return nil, err
+	}
+
+	op := &AsyncOperation[ChatCompletionResponse]{
+		URL:      submission.Header().Get("Operation-Location"),
+		client:   c,
+		model:    request.Model,
+		endpoint: chatCompletionsSuffix,
+	}
+	if op.URL == "" {
+		if err = json.Unmarshal(submission.body, &op.result); err != nil {
+			return nil, err
+		}
+		op.Status, op.done = AsyncOperationStatusSucceeded, true
+		op.result.SetHeader(submission.Header())
+		op.recordUsage(ctx)
+		return op, nil
+	}
+	var state asyncOperationState
+	if len(submission.body) > 0 {
+		if err = json.Unmarshal(submission.body, &state); err != nil {
+			return nil, err
+		}
+	}
+	state.SetHeader(submission.Header())
+	op.update(&state)
+	return op, nil
+}
+
+func withPreferAsync() requestOption {
+	return func(args *requestOptions) {
+		args.header.Set("Prefer", "respond-async")
+	}
+}
+
+// Wait polls the operation every pollInterval, or defaultAsyncPollInterval if
+// zero, until it is done, and returns its result. The server's Retry-After
+// header, if any, overrides pollInterval. Operations that failed return their
+// error as an *APIError, and those that were canceled
+// ErrAsyncOperationCanceled.
+func (op *AsyncOperation[T]) Wait(ctx context.Context, pollInterval time.Duration) (result T, err error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultAsyncPollInterval
+	}
+	for !op.done {
+		delay := pollInterval
+		if op.hasDelay {
+			delay = op.delay
+		}
+		if err = sleepContext(ctx, delay); err != nil {
+			return
+		}
+		var state *asyncOperationState
+		if state, err = op.poll(ctx); err != nil {
+			return
+		}
+		switch state.Status {
+		case AsyncOperationStatusSucceeded:
+			if err = json.Unmarshal(state.Result, &op.result); err != nil {
+				return
+			}
+			if r, ok := any(&op.result).(response); ok {
+				r.SetHeader(state.Header())
+			}
+			op.done = true
+			op.recordUsage(ctx)
+		case AsyncOperationStatusFailed:
+			if state.Error == nil {
+				state.Error = &APIError{Message: "async operation failed"}
+			}
+			return result, state.Error
+		case AsyncOperationStatusCanceled:
+			return result, ErrAsyncOperationCanceled
+		}
+	}
+	return op.result, nil
+}
+
+// Cancel asks the server to cancel the operation. It does nothing for requests
+// the server answered right away.
+func (op *AsyncOperation[T]) Cancel(ctx context.Context) error {
+	if op.URL == "" {
+		return nil
+	}
+	req, err := op.client.newRequest(ctx, http.MethodDelete, op.URL)
+	if err != nil {
+		return err
+	}
+	return op.client.sendRequest(req, nil)
+}
+
+func (op *AsyncOperation[T]) poll(ctx context.Context) (*asyncOperationState, error) {
+	req, err := op.client.newRequest(ctx, http.MethodGet, op.URL)
+	if err != nil {
+		return nil, err
+	}
+	var state asyncOperationState
+	if err = op.client.sendRequest(req, &state); err != nil {
+		return nil, err
+	}
+	op.update(&state)
+	return &state, nil
+}
+
+func (op *AsyncOperation[T]) update(state *asyncOperationState) {
+	if state.ID != "" {
+		op.ID = state.ID
+	}
+	if state.Status != "" {
+		op.Status = state.Status
+	}
+	op.delay, op.hasDelay = retryAfter(state.Header())
+}
+
+// recordUsage reports the usage of the result to the usage recorder, as
+// sendRequest does for other responses.
+func (op *AsyncOperation[T]) recordUsage(ctx context.Context) {
+	if recorder := op.client.config.UsageRecorder; recorder != nil {
+		if usage, ok := usageOf(&op.result); ok {
+			recorder.Record(ctx, op.endpoint, op.model, usage)
+		}
+	}
+}
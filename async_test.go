@@ -0,0 +1,134 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+var asyncTestRequest = openai.ChatCompletionRequest{
+	Model:    openai.GPT4,
+	Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+}
+
+// registerAsyncSubmission answers chat completions with the operation op,
+// polled at /openai/operations/chat/{op}.
+func registerAsyncSubmission(t *testing.T, server *test.ServerTest, op string) {
+	server.RegisterHandler("/openai/deployments/gpt-4/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Prefer") != "respond-async" {
+			t.Errorf("Prefer = %q, want respond-async", r.Header.Get("Prefer"))
+		}
+		w.Header().Set("Operation-Location", fmt.Sprintf("http://%s/openai/operations/chat/%s", r.Host, op))
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"id":%q,"status":"notRunning"}`, op)
+	})
+}
+
+func TestCreateChatCompletionAsync(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	registerAsyncSubmission(t, server, "op-1")
+	var polls int
+	server.RegisterHandler("/openai/operations/chat/op-1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("operation was requested with %s, want GET", r.Method)
+		}
+		polls++
+		// Wait would block for the hour of its poll interval without Retry-After.
+		w.Header().Set("Retry-After", "0")
+		if polls < 3 {
+			_, _ = w.Write([]byte(`{"id":"op-1","status":"running"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"op-1","status":"succeeded","result":` +
+			`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}}`))
+	})
+
+	op, err := client.CreateChatCompletionAsync(context.Background(), asyncTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionAsync error")
+	if op.ID != "op-1" || op.Status != openai.AsyncOperationStatusNotRunning {
+		t.Errorf("operation is %q with status %q, want op-1 not running", op.ID, op.Status)
+	}
+	resp, err := op.Wait(context.Background(), time.Hour)
+	checks.NoError(t, err, "Wait error")
+	if resp.ID != "chatcmpl-1" || resp.Choices[0].Message.Content != "Hi" {
+		t.Errorf("Wait returned %+v, want the result of the operation", resp)
+	}
+	if polls != 3 || op.Status != openai.AsyncOperationStatusSucceeded {
+		t.Errorf("operation was polled %d times until %q, want 3 until succeeded", polls, op.Status)
+	}
+}
+
+func TestCreateChatCompletionAsyncFailure(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	registerAsyncSubmission(t, server, "op-2")
+	server.RegisterHandler("/openai/operations/chat/op-2", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"op-2","status":"failed",` +
+			`"error":{"code":"contentFilter","message":"The response was filtered"}}`))
+	})
+
+	op, err := client.CreateChatCompletionAsync(context.Background(), asyncTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionAsync error")
+	_, err = op.Wait(context.Background(), time.Millisecond)
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "contentFilter" || apiErr.Message != "The response was filtered" {
+		t.Errorf("Wait returned %v, want the error of the operation", err)
+	}
+}
+
+func TestCreateChatCompletionAsyncCancel(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	registerAsyncSubmission(t, server, "op-3")
+	var canceled bool
+	server.RegisterHandler("/openai/operations/chat/op-3", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			canceled = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"op-3","status":"canceled"}`))
+	})
+
+	op, err := client.CreateChatCompletionAsync(context.Background(), asyncTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionAsync error")
+	checks.NoError(t, op.Cancel(context.Background()), "Cancel error")
+	if !canceled {
+		t.Error("Cancel did not delete the operation")
+	}
+	_, err = op.Wait(context.Background(), time.Millisecond)
+	checks.ErrorIs(t, err, openai.ErrAsyncOperationCanceled, "Wait did not report the cancellation")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = op.Wait(ctx, time.Millisecond)
+	checks.ErrorIs(t, err, context.Canceled, "Wait did not stop with its context")
+}
+
+func TestCreateChatCompletionAsyncAnsweredRightAway(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	server.RegisterHandler("/openai/deployments/gpt-4/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("x-request-id", "req-1")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-2","object":"chat.completion","model":"gpt-4"}`))
+	})
+
+	op, err := client.CreateChatCompletionAsync(context.Background(), asyncTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionAsync error")
+	checks.NoError(t, op.Cancel(context.Background()), "Cancel error")
+	resp, err := op.Wait(context.Background(), time.Hour)
+	checks.NoError(t, err, "Wait error")
+	if resp.ID != "chatcmpl-2" || resp.Header().Get("x-request-id") != "req-1" || op.URL != "" {
+		t.Errorf("Wait returned %+v, want the response of the server", resp)
+	}
+}
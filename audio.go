@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 
 	utils "github.com/zquestz/go-openai/internal"
 )
@@ -27,6 +28,129 @@ const (
 	AudioResponseFormatVTT         AudioResponseFormat = "vtt"
 )
 
+// The ISO-639-1 codes of the languages Whisper officially supports, for
+// AudioRequest.Language. Telling the language of the audio improves the
+// accuracy and latency of Whisper.
+const (
+	AudioLanguageAfrikaans   = "af"
+	AudioLanguageArabic      = "ar"
+	AudioLanguageArmenian    = "hy"
+	AudioLanguageAzerbaijani = "az"
+	AudioLanguageBelarusian  = "be"
+	AudioLanguageBosnian     = "bs"
+	AudioLanguageBulgarian   = "bg"
+	AudioLanguageCatalan     = "ca"
+	AudioLanguageChinese     = "zh"
+	AudioLanguageCroatian    = "hr"
+	AudioLanguageCzech       = "cs"
+	AudioLanguageDanish      = "da"
+	AudioLanguageDutch       = "nl"
+	AudioLanguageEnglish     = "en"
+	AudioLanguageEstonian    = "et"
+	AudioLanguageFinnish     = "fi"
+	AudioLanguageFrench      = "fr"
+	AudioLanguageGalician    = "gl"
+	AudioLanguageGerman      = "de"
+	AudioLanguageGreek       = "el"
+	AudioLanguageHebrew      = "he"
+	AudioLanguageHindi       = "hi"
+	AudioLanguageHungarian   = "hu"
+	AudioLanguageIcelandic   = "is"
+	AudioLanguageIndonesian  = "id"
+	AudioLanguageItalian     = "it"
+	AudioLanguageJapanese    = "ja"
+	AudioLanguageKannada     = "kn"
+	AudioLanguageKazakh      = "kk"
+	AudioLanguageKorean      = "ko"
+	AudioLanguageLatvian     = "lv"
+	AudioLanguageLithuanian  = "lt"
+	AudioLanguageMacedonian  = "mk"
+	AudioLanguageMalay       = "ms"
+	AudioLanguageMarathi     = "mr"
+	AudioLanguageMaori       = "mi"
+	AudioLanguageNepali      = "ne"
+	AudioLanguageNorwegian   = "no"
+	AudioLanguagePersian     = "fa"
+	AudioLanguagePolish      = "pl"
+	AudioLanguagePortuguese  = "pt"
+	AudioLanguageRomanian    = "ro"
+	AudioLanguageRussian     = "ru"
+	AudioLanguageSerbian     = "sr"
+	AudioLanguageSlovak      = "sk"
+	AudioLanguageSlovenian   = "sl"
+	AudioLanguageSpanish     = "es"
+	AudioLanguageSwahili     = "sw"
+	AudioLanguageSwedish     = "sv"
+	AudioLanguageTagalog     = "tl"
+	AudioLanguageTamil       = "ta"
+	AudioLanguageThai        = "th"
+	AudioLanguageTurkish     = "tr"
+	AudioLanguageUkrainian   = "uk"
+	AudioLanguageUrdu        = "ur"
+	AudioLanguageVietnamese  = "vi"
+	AudioLanguageWelsh       = "cy"
+)
+
+var audioLanguages = map[string]struct{}{
+	AudioLanguageAfrikaans:   {},
+	AudioLanguageArabic:      {},
+	AudioLanguageArmenian:    {},
+	AudioLanguageAzerbaijani: {},
+	AudioLanguageBelarusian:  {},
+	AudioLanguageBosnian:     {},
+	AudioLanguageBulgarian:   {},
+	AudioLanguageCatalan:     {},
+	AudioLanguageChinese:     {},
+	AudioLanguageCroatian:    {},
+	AudioLanguageCzech:       {},
+	AudioLanguageDanish:      {},
+	AudioLanguageDutch:       {},
+	AudioLanguageEnglish:     {},
+	AudioLanguageEstonian:    {},
+	AudioLanguageFinnish:     {},
+	AudioLanguageFrench:      {},
+	AudioLanguageGalician:    {},
+	AudioLanguageGerman:      {},
+	AudioLanguageGreek:       {},
+	AudioLanguageHebrew:      {},
+	AudioLanguageHindi:       {},
+	AudioLanguageHungarian:   {},
+	AudioLanguageIcelandic:   {},
+	AudioLanguageIndonesian:  {},
+	AudioLanguageItalian:     {},
+	AudioLanguageJapanese:    {},
+	AudioLanguageKannada:     {},
+	AudioLanguageKazakh:      {},
+	AudioLanguageKorean:      {},
+	AudioLanguageLatvian:     {},
+	AudioLanguageLithuanian:  {},
+	AudioLanguageMacedonian:  {},
+	AudioLanguageMalay:       {},
+	AudioLanguageMarathi:     {},
+	AudioLanguageMaori:       {},
+	AudioLanguageNepali:      {},
+	AudioLanguageNorwegian:   {},
+	AudioLanguagePersian:     {},
+	AudioLanguagePolish:      {},
+	AudioLanguagePortuguese:  {},
+	AudioLanguageRomanian:    {},
+	AudioLanguageRussian:     {},
+	AudioLanguageSerbian:     {},
+	AudioLanguageSlovak:      {},
+	AudioLanguageSlovenian:   {},
+	AudioLanguageSpanish:     {},
+	AudioLanguageSwahili:     {},
+	AudioLanguageSwedish:     {},
+	AudioLanguageTagalog:     {},
+	AudioLanguageTamil:       {},
+	AudioLanguageThai:        {},
+	AudioLanguageTurkish:     {},
+	AudioLanguageUkrainian:   {},
+	AudioLanguageUrdu:        {},
+	AudioLanguageVietnamese:  {},
+	AudioLanguageWelsh:       {},
+}
+
 // AudioRequest represents a request structure for audio API.
 // ResponseFormat is not supported for now. We only return JSON text, which may be sufficient.
 type AudioRequest struct {
@@ -42,6 +166,11 @@ type AudioRequest struct {
 	Temperature float32
 	Language    string // For translation, just do not use it. It seems "en" works, not confirmed...
 	Format      AudioResponseFormat
+
+	// ChunkDuration and OverlapDuration split the files too large for the API
+	// transcribed with TranscribeFile, see SplitAudio.
+	ChunkDuration   time.Duration
+	OverlapDuration time.Duration
 }
 
 // AudioResponse represents a response structure for audio API.
@@ -111,7 +240,7 @@ func (c *Client) callAudioAPI(
 
 	urlSuffix := fmt.Sprintf("/audio/%s", endpointSuffix)
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
-		withBody(&formBody), withContentType(builder.FormDataContentType()))
+		withBody(&formBody), withContentType(builder.FormDataContentType()), withModel(request.Model))
 	if err != nil {
 		return AudioResponse{}, err
 	}
@@ -129,6 +258,22 @@ func (c *Client) callAudioAPI(
 	return
 }
 
+// Validate checks that the language of the request, if set, is one Whisper
+// officially supports. It returns ValidationErrors listing all violations, or
+// nil. Requests are not validated when sent, as the API accepts more
+// languages, e.g. bn or yue.
+func (r AudioRequest) Validate() error {
+	var errs ValidationErrors
+	if _, ok := audioLanguages[r.Language]; r.Language != "" && !ok {
+		errs.add("language", "must be the ISO-639-1 code of a supported language, got %q", r.Language)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
 // HasJSONResponse returns true if the response format is JSON.
 func (r AudioRequest) HasJSONResponse() bool {
 	return r.Format == "" || r.Format == AudioResponseFormatJSON || r.Format == AudioResponseFormatVerboseJSON
@@ -160,3 +160,44 @@ func handleAudioEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func TestAudioLanguage(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		if language := r.FormValue("language"); language != "es" && language != "yue" {
+			t.Errorf("got language %q, want the one of the request", language)
+		}
+		_, _ = w.Write([]byte(`{"text": "hola"}`))
+	})
+	ctx := context.Background()
+	req := openai.AudioRequest{
+		Model:    openai.Whisper1,
+		FilePath: "grabacion.mp3",
+		Reader:   strings.NewReader("fake audio"),
+		Language: openai.AudioLanguageSpanish,
+	}
+
+	checks.NoError(t, req.Validate(), "Validate error")
+	resp, err := client.CreateTranscription(ctx, req)
+	checks.NoError(t, err, "CreateTranscription error")
+	if resp.Text != "hola" {
+		t.Errorf("got text %q", resp.Text)
+	}
+
+	for _, language := range []string{"", openai.AudioLanguageWelsh, openai.AudioLanguageChinese} {
+		checks.NoError(t, openai.AudioRequest{Language: language}.Validate(), "Validate error")
+	}
+	for _, language := range []string{"spanish", "ES", "xx"} {
+		req.Language = language
+		var validationErrs openai.ValidationErrors
+		if err = req.Validate(); !errors.As(err, &validationErrs) || validationErrs[0].Field != "language" {
+			t.Errorf("Validate of language %q returned %v, want a language ValidationError", language, err)
+		}
+	}
+
+	// Languages outside the official list are left to the API.
+	req.Language = "yue"
+	_, err = client.CreateTranscription(ctx, req)
+	checks.NoError(t, err, "CreateTranscription error")
+}
@@ -0,0 +1,182 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// MaxAudioFileSize is the size of the largest file the audio endpoints
+	// accept, above which TranscribeFile splits the audio.
+	MaxAudioFileSize = 25 << 20
+
+	// DefaultAudioChunkDuration and DefaultAudioOverlapDuration split audio
+	// for AudioRequest fields left zero.
+	DefaultAudioChunkDuration   = 10 * time.Minute
+	DefaultAudioOverlapDuration = 5 * time.Second
+
+	// minStitchWords and maxStitchWords bound the words compared at chunk
+	// boundaries. A single matching word is likely a coincidence.
+	minStitchWords = 2
+	maxStitchWords = 50
+)
+
+// ErrFFmpegNotFound is returned by SplitAudio, and thus TranscribeFile for
+// large files, when ffmpeg or ffprobe are not in the PATH.
+var ErrFFmpegNotFound = errors.New("ffmpeg is not installed")
+
+// TranscribeFile transcribes the audio file at filePath with the settings of
+// opts, whose FilePath and Reader are ignored. Files larger than
+// MaxAudioFileSize are split with SplitAudio into chunks of
+// opts.ChunkDuration overlapping by opts.OverlapDuration, which are
+// transcribed in order and stitched back together, dropping the words
+// repeated at their boundaries. As only text can be stitched, opts.Format is
+// ignored for large files.
+func (c *Client) TranscribeFile(ctx context.Context, filePath string, opts AudioRequest) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("opening audio file: %w", err)
+	}
+	opts.FilePath, opts.Reader = filePath, nil
+	if info.Size() <= MaxAudioFileSize {
+		response, transcribeErr := c.CreateTranscription(ctx, opts)
+		return response.Text, transcribeErr
+	}
+
+	dir, err := os.MkdirTemp("", "openai-audio-")
+	if err != nil {
+		return "", fmt.Errorf("creating chunk directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	chunks, err := SplitAudio(ctx, filePath, dir, opts.ChunkDuration, opts.OverlapDuration)
+	if err != nil {
+		return "", err
+	}
+
+	opts.Format = AudioResponseFormatJSON
+	var transcript string
+	for i, chunk := range chunks {
+		opts.FilePath = chunk
+		response, transcribeErr := c.CreateTranscription(ctx, opts)
+		if transcribeErr != nil {
+			return "", fmt.Errorf("transcribing chunk %d of %d: %w", i+1, len(chunks), transcribeErr)
+		}
+		transcript = stitchTranscripts(transcript, response.Text)
+	}
+	return transcript, nil
+}
+
+// SplitAudio splits the audio file at filePath into chunks of chunkDuration,
+// each starting overlap before the end of the previous one so that words cut
+// at a boundary are whole in one of the chunks. The chunks are written to dir
+// as mono MP3 files small enough for the audio endpoints, and their paths are
+// returned in order. Zero durations use DefaultAudioChunkDuration and
+// DefaultAudioOverlapDuration. It runs ffprobe and ffmpeg, which must be in
+// the PATH.
+func SplitAudio(ctx context.Context, filePath, dir string, chunkDuration, overlap time.Duration) ([]string, error) {
+	if chunkDuration <= 0 {
+		chunkDuration = DefaultAudioChunkDuration
+	}
+	if overlap <= 0 {
+		overlap = DefaultAudioOverlapDuration
+	}
+	if overlap >= chunkDuration {
+		return nil, fmt.Errorf("chunk overlap %s must be shorter than the chunks of %s", overlap, chunkDuration)
+	}
+	duration, err := audioDuration(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []string
+	for start := time.Duration(0); start < duration; start += chunkDuration - overlap {
+		chunk := filepath.Join(dir, fmt.Sprintf("chunk_%03d.mp3", len(chunks)))
+		//nolint:gosec // the arguments are not interpreted by a shell
+		cmd := exec.CommandContext(ctx, "ffmpeg", "-v", "error", "-y",
+			"-ss", formatSeconds(start), "-t", formatSeconds(chunkDuration), "-i", filePath,
+			"-vn", "-ac", "1", "-ar", "16000", "-b:a", "64k", chunk)
+		if output, runErr := cmd.CombinedOutput(); runErr != nil {
+			return nil, ffmpegError("ffmpeg", runErr, output)
+		}
+		chunks = append(chunks, chunk)
+		if start+chunkDuration >= duration {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// audioDuration returns the duration of the audio file at filePath.
+func audioDuration(ctx context.Context, filePath string) (time.Duration, error) {
+	//nolint:gosec // the arguments are not interpreted by a shell
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error",
+		"-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", filePath)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, ffmpegError("ffprobe", err, output)
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("reading audio duration: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func ffmpegError(name string, err error, output []byte) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrFFmpegNotFound, err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		output = exitErr.Stderr
+	}
+	return fmt.Errorf("running %s: %w: %s", name, err, strings.TrimSpace(string(output)))
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// stitchTranscripts appends next to transcript, dropping the longest run of
+// words that ends transcript and starts next, which the overlap of their
+// chunks transcribed twice. Words are compared ignoring case and punctuation.
+func stitchTranscripts(transcript, next string) string {
+	next = strings.TrimSpace(next)
+	if transcript == "" || next == "" {
+		return transcript + next
+	}
+	tail, head := strings.Fields(transcript), strings.Fields(next)
+	for n := min(len(tail), len(head), maxStitchWords); n >= minStitchWords; n-- {
+		if wordsEqual(tail[len(tail)-n:], head[:n]) {
+			head = head[n:]
+			break
+		}
+	}
+	if len(head) == 0 {
+		return transcript
+	}
+	return transcript + " " + strings.Join(head, " ")
+}
+
+func wordsEqual(a, b []string) bool {
+	for i := range a {
+		if normalizeWord(a[i]) != normalizeWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r)
+	}))
+}
@@ -0,0 +1,94 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// fakeFFmpeg puts in the PATH an ffprobe reporting 25 seconds of audio and an
+// ffmpeg writing the start time of each chunk into it.
+func fakeFFmpeg(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"ffprobe": "#!/bin/sh\necho 25.0\n",
+		"ffmpeg": "#!/bin/sh\nwhile [ $# -gt 0 ]; do\n" +
+			"  case \"$1\" in -ss) start=\"$2\"; shift;; esac\n" +
+			"  out=\"$1\"; shift\ndone\necho \"$start\" > \"$out\"\n",
+	}
+	for name, script := range scripts {
+		checks.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755), "WriteFile error")
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestTranscribeFile(t *testing.T) {
+	fakeFFmpeg(t)
+	transcripts := map[string]string{
+		"small":  "A short file.",
+		"0.000":  "The quick brown fox jumps",
+		"8.000":  "fox jumps over the lazy",
+		"16.000": "The lazy dog.",
+	}
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/audio/transcriptions", func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		checks.NoError(t, err, "FormFile error")
+		content, _ := io.ReadAll(file)
+		_ = json.NewEncoder(w).Encode(map[string]string{"text": transcripts[strings.TrimSpace(string(content))]})
+	})
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.mp3")
+	checks.NoError(t, os.WriteFile(small, []byte("small"), 0o600), "WriteFile error")
+	text, err := client.TranscribeFile(context.Background(), small, openai.AudioRequest{Model: openai.Whisper1})
+	checks.NoError(t, err, "TranscribeFile error")
+	if text != "A short file." {
+		t.Errorf("small file transcript = %q", text)
+	}
+
+	large := filepath.Join(dir, "large.wav")
+	checks.NoError(t, os.WriteFile(large, nil, 0o600), "WriteFile error")
+	checks.NoError(t, os.Truncate(large, openai.MaxAudioFileSize+1), "Truncate error")
+	text, err = client.TranscribeFile(context.Background(), large, openai.AudioRequest{
+		Model:           openai.Whisper1,
+		ChunkDuration:   10 * time.Second,
+		OverlapDuration: 2 * time.Second,
+	})
+	checks.NoError(t, err, "TranscribeFile error")
+	if want := "The quick brown fox jumps over the lazy dog."; text != want {
+		t.Errorf("stitched transcript = %q, want %q", text, want)
+	}
+}
+
+func TestSplitAudio(t *testing.T) {
+	fakeFFmpeg(t)
+	dir := t.TempDir()
+	chunks, err := openai.SplitAudio(context.Background(), "audio.wav", dir, 0, 0)
+	checks.NoError(t, err, "SplitAudio error")
+	if len(chunks) != 1 || chunks[0] != filepath.Join(dir, "chunk_000.mp3") {
+		t.Errorf("chunks = %v, want a single default chunk", chunks)
+	}
+
+	_, err = openai.SplitAudio(context.Background(), "audio.wav", dir, time.Second, time.Second)
+	checks.HasError(t, err, "overlap as long as the chunks")
+
+	t.Setenv("PATH", t.TempDir())
+	_, err = openai.SplitAudio(context.Background(), "audio.wav", dir, 0, 0)
+	checks.ErrorIs(t, err, openai.ErrFFmpegNotFound, "SplitAudio without ffmpeg")
+}
@@ -0,0 +1,102 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before their expiry tokens are refreshed.
+const tokenRefreshMargin = 5 * time.Minute
+
+// TokenProvider supplies the bearer token of each request, e.g. a Microsoft
+// Entra ID token for Azure OpenAI. It must be safe for concurrent use.
+type TokenProvider interface {
+	GetToken(ctx context.Context) (string, error)
+}
+
+// ExpiringTokenProvider is a TokenProvider whose tokens expire. The client
+// caches its tokens and fetches a new one shortly before they expire.
+type ExpiringTokenProvider interface {
+	TokenProvider
+	GetTokenWithExpiry(ctx context.Context) (token string, expiresOn time.Time, err error)
+}
+
+// TokenProviderFunc is a TokenProvider calling the function for every request.
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+func (f TokenProviderFunc) GetToken(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// AuthError is returned when the TokenProvider of a client fails.
+type AuthError struct {
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("error getting auth token: %s", e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// tokenCache fetches tokens from a TokenProvider, caching those of an
+// ExpiringTokenProvider until shortly before they expire.
+type tokenCache struct {
+	provider TokenProvider
+
+	mu        sync.Mutex
+	token     string
+	expiresOn time.Time
+}
+
+func newTokenCache(provider TokenProvider) *tokenCache {
+	if provider == nil {
+		return nil
+	}
+	return &tokenCache{provider: provider}
+}
+
+// sameTokenProvider reports whether a and b are the same provider. Providers of
+// uncomparable types, e.g. TokenProviderFunc, are never the same, which is fine
+// since their tokens are not cached.
+func sameTokenProvider(a, b TokenProvider) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	expiring, ok := c.provider.(ExpiringTokenProvider)
+	if !ok {
+		token, err := c.provider.GetToken(ctx)
+		return token, wrapAuthError(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Until(c.expiresOn) > tokenRefreshMargin {
+		return c.token, nil
+	}
+	token, expiresOn, err := expiring.GetTokenWithExpiry(ctx)
+	if err != nil {
+		return "", wrapAuthError(err)
+	}
+	c.token, c.expiresOn = token, expiresOn
+	return token, nil
+}
+
+func wrapAuthError(err error) error {
+	var authErr *AuthError
+	if err == nil || errors.As(err, &authErr) {
+		return err
+	}
+	return &AuthError{Err: err}
+}
@@ -0,0 +1,156 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// rotatingTokenProvider returns a new token on every call, valid for lifetime.
+type rotatingTokenProvider struct {
+	calls    atomic.Int32
+	lifetime time.Duration
+	err      error
+}
+
+func (p *rotatingTokenProvider) GetToken(ctx context.Context) (string, error) {
+	token, _, err := p.GetTokenWithExpiry(ctx)
+	return token, err
+}
+
+func (p *rotatingTokenProvider) GetTokenWithExpiry(context.Context) (string, time.Time, error) {
+	if p.err != nil {
+		return "", time.Time{}, p.err
+	}
+	n := p.calls.Add(1)
+	return fmt.Sprintf("token-%d", n), time.Now().Add(p.lifetime), nil
+}
+
+func setupTokenTestServer(
+	t *testing.T,
+	config openai.ClientConfig,
+	provider openai.TokenProvider,
+) (client *openai.Client, requests *atomic.Int32) {
+	requests = &atomic.Int32{}
+	server := test.NewTestServer()
+	server.RegisterHandler("/models", func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	config.BaseURL = ts.URL + "/v1"
+	config.HTTPClient = &http.Client{Transport: tokenCheckingTransport{}}
+	config.TokenProvider = provider
+	client = openai.NewClientWithConfig(config)
+	return
+}
+
+// tokenCheckingTransport sends the test token that the test server accepts, and
+// returns the credentials the client sent in the X-Token response header.
+type tokenCheckingTransport struct{}
+
+func (tokenCheckingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	got := req.Header.Get("Authorization") + req.Header.Get(openai.AzureAPIKeyHeader)
+	req.Header.Del(openai.AzureAPIKeyHeader)
+	req.Header.Set("Authorization", "Bearer "+test.GetTestToken())
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if resp != nil {
+		resp.Header.Set("X-Token", got)
+	}
+	return resp, err
+}
+
+func listModelsToken(t *testing.T, client *openai.Client) string {
+	t.Helper()
+	models, err := client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+	return models.Header().Get("X-Token")
+}
+
+func TestTokenProviderRotates(t *testing.T) {
+	var calls int
+	provider := openai.TokenProviderFunc(func(context.Context) (string, error) {
+		calls++
+		return fmt.Sprintf("token-%d", calls), nil
+	})
+	client, _ := setupTokenTestServer(t, openai.DefaultConfig("static"), provider)
+
+	for i := 1; i <= 3; i++ {
+		want := fmt.Sprintf("Bearer token-%d", i)
+		if got := listModelsToken(t, client); got != want {
+			t.Errorf("request %d: Authorization = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestTokenProviderCachesUntilExpiry(t *testing.T) {
+	fresh := &rotatingTokenProvider{lifetime: time.Hour}
+	client, _ := setupTokenTestServer(t, openai.DefaultAzureConfig("static", ""), fresh)
+	for i := 0; i < 3; i++ {
+		if got := listModelsToken(t, client); got != "Bearer token-1" {
+			t.Errorf("cached token not used, got %q", got)
+		}
+	}
+	if fresh.calls.Load() != 1 {
+		t.Errorf("provider was called %d times, want once", fresh.calls.Load())
+	}
+
+	// Tokens expiring within the refresh margin are replaced.
+	expiring := &rotatingTokenProvider{lifetime: time.Minute}
+	client, _ = setupTokenTestServer(t, openai.DefaultConfig("static"), expiring)
+	first := listModelsToken(t, client)
+	second := listModelsToken(t, client)
+	if first != "Bearer token-1" || second != "Bearer token-2" {
+		t.Errorf("expiring token was not refreshed: %q, %q", first, second)
+	}
+}
+
+func TestTokenProviderConcurrent(t *testing.T) {
+	provider := &rotatingTokenProvider{lifetime: time.Hour}
+	client, _ := setupTokenTestServer(t, openai.DefaultConfig("static"), provider)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ListModels(context.Background())
+			checks.NoError(t, err, "ListModels error")
+		}()
+	}
+	wg.Wait()
+	if provider.calls.Load() != 1 {
+		t.Errorf("provider was called %d times, want once", provider.calls.Load())
+	}
+}
+
+func TestTokenProviderError(t *testing.T) {
+	errProvider := errors.New("credential unavailable")
+	provider := &rotatingTokenProvider{err: errProvider}
+	client, requests := setupTokenTestServer(t, openai.DefaultConfig("static"), provider)
+
+	_, err := client.ListModels(context.Background())
+	var authErr *openai.AuthError
+	if !errors.As(err, &authErr) || !errors.Is(err, errProvider) {
+		t.Fatalf("ListModels returned %v, want AuthError wrapping the provider error", err)
+	}
+	if !openai.IsAuthError(err) {
+		t.Error("IsAuthError should report provider errors")
+	}
+	if requests.Load() != 0 {
+		t.Error("no request should be sent without a token")
+	}
+}
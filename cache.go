@@ -0,0 +1,144 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CacheStats counts the lookups and evictions of a CachedClient.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// CachedClient is a Client caching the responses of CreateChatCompletion in
+// memory, so that identical requests are only sent once within the TTL. Two
+// requests are identical if they serialize to the same JSON, ignoring User;
+// set Seed to a unique value to bypass the cache for a call. Streams and
+// failed requests are never cached. It is safe for concurrent use.
+type CachedClient struct {
+	*Client
+
+	// MaxEntries is the number of responses kept, evicting the least recently
+	// used one when exceeded. Zero means no limit. It must be set before the
+	// client is used.
+	MaxEntries int
+
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries *lru[[sha256.Size]byte, ChatCompletionResponse]
+	stats   CacheStats
+}
+
+// NewCachedClient returns a CachedClient sending requests with inner and
+// keeping responses for ttl. Responses do not expire if ttl is zero or less.
+func NewCachedClient(inner *Client, ttl time.Duration) *CachedClient {
+	return &CachedClient{
+		Client:  inner,
+		ttl:     ttl,
+		entries: newLRU[[sha256.Size]byte, ChatCompletionResponse](),
+	}
+}
+
+// CreateChatCompletion returns the cached response of an identical request, or
+// sends the request and caches its response.
+func (c *CachedClient) CreateChatCompletion(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (ChatCompletionResponse, error) {
+	if request.Stream {
+		return c.Client.CreateChatCompletion(ctx, request)
+	}
+	key, err := chatCacheKey(request)
+	if err != nil {
+		return c.Client.CreateChatCompletion(ctx, request)
+	}
+	if response, ok := c.get(key); ok {
+		return response, nil
+	}
+
+	response, err := c.Client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	c.add(key, response)
+	return response, nil
+}
+
+// Stats returns the counts of the cache since it was created.
+func (c *CachedClient) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func chatCacheKey(request ChatCompletionRequest) ([sha256.Size]byte, error) {
+	request.User = ""
+	data, err := json.Marshal(request)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+func (c *CachedClient) get(key [sha256.Size]byte) (ChatCompletionResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.entries.get(key)
+	if !ok {
+		c.stats.Misses++
+		return ChatCompletionResponse{}, false
+	}
+	c.stats.Hits++
+	return cloneChatCompletionResponse(response), true
+}
+
+func (c *CachedClient) add(key [sha256.Size]byte, response ChatCompletionResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+	// An identical request sent concurrently replaces the response.
+	evicted := c.entries.add(key, cloneChatCompletionResponse(response), expires, c.MaxEntries)
+	c.stats.Evictions += int64(evicted)
+}
+
+// cloneChatCompletionResponse copies the choices, annotations and usage details
+// of response, so that callers modifying them do not modify the cache.
+func cloneChatCompletionResponse(response ChatCompletionResponse) ChatCompletionResponse {
+	if response.Choices != nil {
+		choices := make([]ChatCompletionChoice, len(response.Choices))
+		for i, choice := range response.Choices {
+			choice.Message = choice.Message.clone()
+			if choice.ContentFilterResults != nil {
+				results := choice.ContentFilterResults.clone()
+				choice.ContentFilterResults = &results
+			}
+			choices[i] = choice
+		}
+		response.Choices = choices
+	}
+	if response.PromptAnnotations != nil {
+		annotations := make([]PromptAnnotation, len(response.PromptAnnotations))
+		for i, annotation := range response.PromptAnnotations {
+			annotation.ContentFilterResults = annotation.ContentFilterResults.clone()
+			annotations[i] = annotation
+		}
+		response.PromptAnnotations = annotations
+	}
+	if details := response.Usage.PromptTokensDetails; details != nil {
+		clone := *details
+		response.Usage.PromptTokensDetails = &clone
+	}
+	if details := response.Usage.CompletionTokensDetails; details != nil {
+		clone := *details
+		response.Usage.CompletionTokensDetails = &clone
+	}
+	return response
+}
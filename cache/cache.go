@@ -0,0 +1,172 @@
+// Package openaicache caches go-openai chat completions in a SQLite database,
+// so that identical requests are not paid for again after a restart. It lives
+// in its own module so that the openai package does not depend on SQLite.
+//
+//	client, err := openaicache.NewSQLiteCacheClient(openai.NewClient(token), "openai-cache.db", 24*time.Hour)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+package openaicache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS chat_completions (
+	request_hash      TEXT PRIMARY KEY,
+	model             TEXT NOT NULL,
+	response          TEXT NOT NULL,
+	prompt_tokens     INTEGER NOT NULL,
+	completion_tokens INTEGER NOT NULL,
+	expires_at        INTEGER, -- Unix milliseconds, NULL if the response does not expire
+	hits              INTEGER NOT NULL DEFAULT 0
+)`
+
+// CacheStats describes the contents of the cache database.
+type CacheStats struct {
+	// Entries is the number of stored responses, including expired ones.
+	Entries int64
+	// Expired is the number of stored responses that expired.
+	Expired int64
+	// Hits is the number of calls answered from the cache, by every client
+	// sharing the database.
+	Hits int64
+	// Misses is the number of calls this client sent to the API.
+	Misses int64
+	// SavedTokens is the number of prompt and completion tokens of the hits.
+	SavedTokens int64
+}
+
+// CachedClient is an openai.Client caching the responses of
+// CreateChatCompletion in a SQLite database. Two requests are identical if
+// they serialize to the same JSON, ignoring User; set Seed to a unique value
+// to bypass the cache for a call. Streams and failed requests are never
+// cached. Database errors do not fail calls, which are then sent uncached. It
+// is safe for concurrent use.
+type CachedClient struct {
+	*openai.Client
+
+	db     *sql.DB
+	ttl    time.Duration
+	misses atomic.Int64
+}
+
+// NewSQLiteCacheClient returns a CachedClient sending requests with inner and
+// keeping responses for ttl in the SQLite database at dbPath, which is created
+// if needed. Responses do not expire if ttl is zero or less.
+func NewSQLiteCacheClient(inner *openai.Client, dbPath string, ttl time.Duration) (*CachedClient, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening cache database: %w", err)
+	}
+	// SQLite allows one writer at a time.
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating cache table: %w", err)
+	}
+	return &CachedClient{Client: inner, db: db, ttl: ttl}, nil
+}
+
+// Close closes the cache database.
+func (c *CachedClient) Close() error {
+	return c.db.Close()
+}
+
+// CreateChatCompletion returns the stored response of an identical request
+// that has not expired, or sends the request and stores its response.
+func (c *CachedClient) CreateChatCompletion(
+	ctx context.Context,
+	request openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	if request.Stream {
+		return c.Client.CreateChatCompletion(ctx, request)
+	}
+	hash, err := requestHash(request)
+	if err != nil {
+		return c.Client.CreateChatCompletion(ctx, request)
+	}
+	if response, ok := c.get(ctx, hash); ok {
+		return response, nil
+	}
+
+	c.misses.Add(1)
+	response, err := c.Client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	c.put(ctx, hash, request.Model, response)
+	return response, nil
+}
+
+// PurgeExpired deletes the expired responses from the database.
+func (c *CachedClient) PurgeExpired() error {
+	_, err := c.db.Exec(`DELETE FROM chat_completions WHERE expires_at <= ?`, time.Now().UnixMilli())
+	return err
+}
+
+// Stats returns the counts of the cache.
+func (c *CachedClient) Stats() (CacheStats, error) {
+	stats := CacheStats{Misses: c.misses.Load()}
+	err := c.db.QueryRow(`SELECT
+		COUNT(*),
+		COALESCE(SUM(expires_at <= ?), 0),
+		COALESCE(SUM(hits), 0),
+		COALESCE(SUM(hits * (prompt_tokens + completion_tokens)), 0)
+		FROM chat_completions`, time.Now().UnixMilli(),
+	).Scan(&stats.Entries, &stats.Expired, &stats.Hits, &stats.SavedTokens)
+	return stats, err
+}
+
+func requestHash(request openai.ChatCompletionRequest) (string, error) {
+	request.User = ""
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *CachedClient) get(ctx context.Context, hash string) (openai.ChatCompletionResponse, bool) {
+	var data string
+	err := c.db.QueryRowContext(ctx, `UPDATE chat_completions SET hits = hits + 1
+		WHERE request_hash = ? AND (expires_at IS NULL OR expires_at > ?)
+		RETURNING response`, hash, time.Now().UnixMilli(),
+	).Scan(&data)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, false
+	}
+	var response openai.ChatCompletionResponse
+	if json.Unmarshal([]byte(data), &response) != nil {
+		return openai.ChatCompletionResponse{}, false
+	}
+	return response, true
+}
+
+func (c *CachedClient) put(ctx context.Context, hash, model string, response openai.ChatCompletionResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	var expiresAt sql.NullInt64
+	if c.ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: time.Now().Add(c.ttl).UnixMilli(), Valid: true}
+	}
+	_, _ = c.db.ExecContext(ctx, `INSERT OR REPLACE INTO chat_completions
+		(request_hash, model, response, prompt_tokens, completion_tokens, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		hash, model, string(data), response.Usage.PromptTokens, response.Usage.CompletionTokens, expiresAt,
+	)
+}
@@ -0,0 +1,143 @@
+package openaicache_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	openaicache "github.com/zquestz/go-openai/cache"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func setupCacheTestServer(t *testing.T) (inner *openai.Client, requests *atomic.Int64) {
+	server := test.NewTestServer()
+	requests = &atomic.Int64{}
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		var request openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		resBytes, _ := json.Marshal(openai.ChatCompletionResponse{
+			ID:    "chatcmpl-" + strconv.FormatInt(n, 10),
+			Model: request.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Hi"},
+			}},
+			Usage: openai.Usage{PromptTokens: 9, CompletionTokens: 3, TotalTokens: 12},
+		})
+		_, _ = w.Write(resBytes)
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	return openai.NewClientWithConfig(config), requests
+}
+
+func cacheTestRequest(content string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: content}},
+	}
+}
+
+func TestSQLiteCacheClient(t *testing.T) {
+	inner, requests := setupCacheTestServer(t)
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	client, err := openaicache.NewSQLiteCacheClient(inner, dbPath, time.Hour)
+	checks.NoError(t, err, "NewSQLiteCacheClient error")
+	first, err := client.CreateChatCompletion(ctx, cacheTestRequest("Hello!"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	checks.NoError(t, client.Close(), "Close error")
+
+	// The response survives reopening the database.
+	client, err = openaicache.NewSQLiteCacheClient(inner, dbPath, time.Hour)
+	checks.NoError(t, err, "NewSQLiteCacheClient error")
+	defer client.Close()
+	request := cacheTestRequest("Hello!")
+	request.User = "another-user"
+	cached, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if cached.ID != first.ID || cached.Choices[0].Message.Content != "Hi" || cached.Usage != first.Usage {
+		t.Errorf("got response %+v, want the cached %+v", cached, first)
+	}
+
+	seed := 1
+	request.Seed = &seed
+	_, err = client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if n := requests.Load(); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+	stats, err := client.Stats()
+	checks.NoError(t, err, "Stats error")
+	if stats != (openaicache.CacheStats{Entries: 2, Hits: 1, Misses: 1, SavedTokens: 12}) {
+		t.Errorf("got stats %+v", stats)
+	}
+}
+
+func TestSQLiteCacheClientExpiry(t *testing.T) {
+	inner, requests := setupCacheTestServer(t)
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	shortLived, err := openaicache.NewSQLiteCacheClient(inner, dbPath, time.Millisecond)
+	checks.NoError(t, err, "NewSQLiteCacheClient error")
+	defer shortLived.Close()
+	ctx := context.Background()
+
+	_, err = shortLived.CreateChatCompletion(ctx, cacheTestRequest("a"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	_, err = shortLived.CreateChatCompletion(ctx, cacheTestRequest("b"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	time.Sleep(5 * time.Millisecond)
+
+	stats, err := shortLived.Stats()
+	checks.NoError(t, err, "Stats error")
+	if stats.Entries != 2 || stats.Expired != 2 {
+		t.Errorf("got stats %+v, want 2 expired entries", stats)
+	}
+
+	client, err := openaicache.NewSQLiteCacheClient(inner, dbPath, time.Hour)
+	checks.NoError(t, err, "NewSQLiteCacheClient error")
+	defer client.Close()
+	_, err = client.CreateChatCompletion(ctx, cacheTestRequest("a"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if n := requests.Load(); n != 3 {
+		t.Errorf("sent %d requests, want the expired response to be requested again", n)
+	}
+
+	checks.NoError(t, client.PurgeExpired(), "PurgeExpired error")
+	stats, err = client.Stats()
+	checks.NoError(t, err, "Stats error")
+	if stats.Entries != 1 || stats.Expired != 0 {
+		t.Errorf("got stats %+v after PurgeExpired, want only the fresh entry", stats)
+	}
+}
+
+func TestSQLiteCacheClientStream(t *testing.T) {
+	inner, requests := setupCacheTestServer(t)
+	client, err := openaicache.NewSQLiteCacheClient(inner, filepath.Join(t.TempDir(), "cache.db"), 0)
+	checks.NoError(t, err, "NewSQLiteCacheClient error")
+	defer client.Close()
+
+	request := cacheTestRequest("Hello!")
+	request.Stream = true
+	_, err = client.CreateChatCompletion(context.Background(), request)
+	checks.ErrorIs(t, err, openai.ErrChatCompletionStreamNotSupported, "CreateChatCompletion with Stream")
+
+	stats, err := client.Stats()
+	checks.NoError(t, err, "Stats error")
+	if requests.Load() != 0 || stats != (openaicache.CacheStats{}) {
+		t.Errorf("the stream request was cached: %+v", stats)
+	}
+}
@@ -0,0 +1,155 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func setupCachedClient(t *testing.T, ttl time.Duration) (client *openai.CachedClient, requests *atomic.Int64) {
+	inner, server, teardown := setupOpenAITestServer()
+	t.Cleanup(teardown)
+	requests = &atomic.Int64{}
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		var request openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		resBytes, _ := json.Marshal(openai.ChatCompletionResponse{
+			ID:    "chatcmpl-" + string(rune('0'+n)),
+			Model: request.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Hi"},
+			}},
+		})
+		_, _ = w.Write(resBytes)
+	})
+	return openai.NewCachedClient(inner, ttl), requests
+}
+
+func cacheTestRequest(content string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:       openai.GPT3Dot5Turbo,
+		Temperature: 0.5,
+		Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: content}},
+	}
+}
+
+func TestCachedClient(t *testing.T) {
+	client, requests := setupCachedClient(t, time.Hour)
+	ctx := context.Background()
+
+	first, err := client.CreateChatCompletion(ctx, cacheTestRequest("Hello!"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	first.Choices[0].Message.Content = "modified"
+
+	request := cacheTestRequest("Hello!")
+	request.User = "another-user"
+	second, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if second.ID != first.ID || second.Choices[0].Message.Content != "Hi" {
+		t.Errorf("got response %+v, want the unmodified cached one", second)
+	}
+
+	request.Temperature = 1
+	_, err = client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	seed := 42
+	request.Seed = &seed
+	_, err = client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if n := requests.Load(); n != 3 {
+		t.Errorf("sent %d requests, want 3", n)
+	}
+	if stats := client.Stats(); stats != (openai.CacheStats{Hits: 1, Misses: 3}) {
+		t.Errorf("got stats %+v", stats)
+	}
+}
+
+func TestCachedClientTTL(t *testing.T) {
+	client, requests := setupCachedClient(t, time.Millisecond)
+	ctx := context.Background()
+
+	_, err := client.CreateChatCompletion(ctx, cacheTestRequest("Hello!"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	time.Sleep(5 * time.Millisecond)
+	_, err = client.CreateChatCompletion(ctx, cacheTestRequest("Hello!"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if n := requests.Load(); n != 2 {
+		t.Errorf("sent %d requests, want the expired response to be requested again", n)
+	}
+}
+
+func TestCachedClientEviction(t *testing.T) {
+	client, requests := setupCachedClient(t, 0)
+	client.MaxEntries = 2
+	ctx := context.Background()
+
+	for _, content := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, err := client.CreateChatCompletion(ctx, cacheTestRequest(content))
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+
+	// "c" evicts "b", the least recently used, so "b" is requested again.
+	if n := requests.Load(); n != 4 {
+		t.Errorf("sent %d requests, want 4", n)
+	}
+	if stats := client.Stats(); stats != (openai.CacheStats{Hits: 2, Misses: 4, Evictions: 2}) {
+		t.Errorf("got stats %+v", stats)
+	}
+}
+
+func TestCachedClientStreamsAndErrors(t *testing.T) {
+	client, requests := setupCachedClient(t, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		stream, err := client.CreateChatCompletionStream(ctx, cacheTestRequest("Hello!"))
+		checks.NoError(t, err, "CreateChatCompletionStream error")
+		stream.Close()
+
+		request := cacheTestRequest("Hello!")
+		request.Stream = true
+		_, err = client.CreateChatCompletion(ctx, request)
+		checks.ErrorIs(t, err, openai.ErrChatCompletionStreamNotSupported, "CreateChatCompletion with Stream")
+	}
+
+	if n := requests.Load(); n != 2 {
+		t.Errorf("sent %d requests, want every stream to be requested", n)
+	}
+	if stats := client.Stats(); stats != (openai.CacheStats{}) {
+		t.Errorf("streams were counted in the stats: %+v", stats)
+	}
+}
+
+func TestCachedClientConcurrent(t *testing.T) {
+	client, _ := setupCachedClient(t, time.Hour)
+	client.MaxEntries = 3
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := client.CreateChatCompletion(context.Background(), cacheTestRequest(string(rune('a'+i%5))))
+			checks.NoError(t, err, "CreateChatCompletion error")
+		}(i)
+	}
+	wg.Wait()
+
+	if stats := client.Stats(); stats.Hits+stats.Misses != 20 {
+		t.Errorf("got stats %+v for 20 lookups", stats)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
 )
 
 type ContentType string
@@ -45,11 +47,27 @@ type Violence struct {
 	Severity string `json:"severity,omitempty"`
 }
 
+// JailbreakFilter is the verdict of the Azure OpenAI jailbreak filter on a
+// prompt attack. It only detects attacks and never filters them.
+type JailbreakFilter struct {
+	Detected bool `json:"detected"`
+}
+
+// ProfanityFilter is the verdict of the Azure OpenAI profanity filter.
+type ProfanityFilter struct {
+	Filtered bool `json:"filtered"`
+	Detected bool `json:"detected"`
+}
+
 type ContentFilterResults struct {
 	Hate     Hate     `json:"hate,omitempty"`
 	SelfHarm SelfHarm `json:"self_harm,omitempty"`
 	Sexual   Sexual   `json:"sexual,omitempty"`
 	Violence Violence `json:"violence,omitempty"`
+	// Jailbreak and Profanity are nil unless these optional filters are
+	// enabled for the deployment.
+	Jailbreak *JailbreakFilter `json:"jailbreak,omitempty"`
+	Profanity *ProfanityFilter `json:"profanity,omitempty"`
 }
 
 type PromptAnnotation struct {
@@ -57,10 +75,64 @@ type PromptAnnotation struct {
 	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
 }
 
+// ImageDetail controls the resolution at which the model sees an image part.
+type ImageDetail string
+
+const (
+	ImageDetailAuto ImageDetail = "auto"
+	ImageDetailLow  ImageDetail = "low"
+	ImageDetailHigh ImageDetail = "high"
+)
+
 type Part struct {
 	Type     ContentType `json:"type"`
 	ImageUrl string      `json:"image_url,omitempty"`
 	Text     string      `json:"text,omitempty"`
+	// Detail is only sent for image parts. When set, image_url is sent as an
+	// object carrying the URL and the detail.
+	Detail ImageDetail `json:"-"`
+}
+
+type partImageURL struct {
+	URL    string      `json:"url"`
+	Detail ImageDetail `json:"detail,omitempty"`
+}
+
+func (p Part) MarshalJSON() ([]byte, error) {
+	type part Part
+	if p.Detail == "" {
+		return json.Marshal(part(p))
+	}
+	return json.Marshal(struct {
+		Type     ContentType  `json:"type"`
+		ImageURL partImageURL `json:"image_url"`
+	}{p.Type, partImageURL{URL: p.ImageUrl, Detail: p.Detail}})
+}
+
+func (p *Part) UnmarshalJSON(bs []byte) error {
+	var raw struct {
+		Type     ContentType     `json:"type"`
+		ImageURL json.RawMessage `json:"image_url,omitempty"`
+		Text     string          `json:"text,omitempty"`
+	}
+	err := json.Unmarshal(bs, &raw)
+	if err != nil {
+		return err
+	}
+	*p = Part{Type: raw.Type, Text: raw.Text}
+	if len(raw.ImageURL) == 0 {
+		return nil
+	}
+	if raw.ImageURL[0] == '"' {
+		return json.Unmarshal(raw.ImageURL, &p.ImageUrl)
+	}
+	var imageURL partImageURL
+	err = json.Unmarshal(raw.ImageURL, &imageURL)
+	if err != nil {
+		return err
+	}
+	p.ImageUrl, p.Detail = imageURL.URL, imageURL.Detail
+	return nil
 }
 
 type Parts []Part
@@ -105,7 +177,7 @@ func (ps *Parts) UnmarshalJSON(bs []byte) error {
 type ChatCompletionMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
-	Parts   Parts  `json:"content"`
+	Parts   Parts  `json:"-"`
 
 	// This property isn't in the official documentation, but it's in
 	// the documentation for the official library for python:
@@ -115,6 +187,14 @@ type ChatCompletionMessage struct {
 
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+
+	// ToolCallID is required for messages with the tool role, answering the
+	// tool call of the same ID.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// Refusal is the explanation of the model declining to answer, sent instead
+	// of Content, e.g. by requests with a json_schema response format.
+	Refusal string `json:"refusal,omitempty"`
 }
 
 func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
@@ -125,6 +205,8 @@ func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
 		Name         string        `json:"name,omitempty"`
 		FunctionCall *FunctionCall `json:"function_call,omitempty"`
 		ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+		ToolCallID   string        `json:"tool_call_id,omitempty"`
+		Refusal      string        `json:"refusal,omitempty"`
 	}(*m)
 	err := json.Unmarshal(bs, &msg)
 	if err != nil {
@@ -145,6 +227,8 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 		Name         string        `json:"name,omitempty"`
 		FunctionCall *FunctionCall `json:"function_call,omitempty"`
 		ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+		ToolCallID   string        `json:"tool_call_id,omitempty"`
+		Refusal      string        `json:"refusal,omitempty"`
 	}(m)
 	if msg.Content != "" && len(msg.Parts) == 1 && msg.Parts[0].Type == ContentTypeText && msg.Parts[0].Text == msg.Content {
 	} else if msg.Content != "" && len(msg.Parts) > 0 {
@@ -156,8 +240,52 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 }
 
 type ToolCall struct {
-	ID       string       `json:"id"`
+	// Index is the position of the call in the message, which stream chunks
+	// set so that the chunks of parallel calls can be told apart.
+	Index *int   `json:"index,omitempty"`
+	ID    string `json:"id"`
+	// Type is ToolTypeFunction, or empty in the stream chunks continuing a call.
+	Type     ToolType     `json:"type"`
 	Function FunctionCall `json:"function"`
+	// Raw is the JSON of a call of a Type this package does not know, which
+	// newer API versions may send. Only its Index, ID and Type are decoded, and
+	// it is marshaled back as is.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (c *ToolCall) UnmarshalJSON(data []byte) error {
+	type toolCall ToolCall
+	var header struct {
+		Index *int     `json:"index"`
+		ID    string   `json:"id"`
+		Type  ToolType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	if header.Type != "" && header.Type != ToolTypeFunction {
+		*c = ToolCall{Index: header.Index, ID: header.ID, Type: header.Type, Raw: slices.Clone(json.RawMessage(data))}
+		return nil
+	}
+	var call toolCall
+	if err := json.Unmarshal(data, &call); err != nil {
+		return err
+	}
+	*c = ToolCall(call)
+	return nil
+}
+
+// MarshalJSON marshals calls without a Type as function calls, which the API
+// requires in the messages sending them back.
+func (c ToolCall) MarshalJSON() ([]byte, error) {
+	if len(c.Raw) > 0 {
+		return c.Raw, nil
+	}
+	type toolCall ToolCall
+	if c.Type == "" {
+		c.Type = ToolTypeFunction
+	}
+	return json.Marshal(toolCall(c))
 }
 
 type FunctionCall struct {
@@ -171,10 +299,34 @@ type ChatCompletionResponseFormatType string
 const (
 	ChatCompletionResponseFormatTypeJSONObject ChatCompletionResponseFormatType = "json_object"
 	ChatCompletionResponseFormatTypeText       ChatCompletionResponseFormatType = "text"
+	// ChatCompletionResponseFormatTypeJSONSchema makes the model answer with
+	// JSON matching ChatCompletionResponseFormat.JSONSchema.
+	ChatCompletionResponseFormatTypeJSONSchema ChatCompletionResponseFormatType = "json_schema"
 )
 
 type ChatCompletionResponseFormat struct {
 	Type ChatCompletionResponseFormatType `json:"type"`
+	// JSONSchema is required with ChatCompletionResponseFormatTypeJSONSchema,
+	// see NewJSONSchemaResponseFormat.
+	JSONSchema *ResponseFormatJSONSchema `json:"json_schema,omitempty"`
+}
+
+// ResponseFormatJSONSchema is the schema of the answers of a request with
+// ChatCompletionResponseFormatTypeJSONSchema.
+type ResponseFormatJSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	// Strict makes the model follow Schema exactly, for schemas meeting the
+	// constraints of structured outputs, see ValidateStrictSchema.
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// StreamOptions configures a stream.
+type StreamOptions struct {
+	// IncludeUsage makes the stream end with a chunk without choices carrying
+	// the usage of the request.
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 // ChatCompletionRequest represents a request structure for chat completion API.
@@ -186,6 +338,7 @@ type ChatCompletionRequest struct {
 	TopP             float32                       `json:"top_p,omitempty"`
 	N                int                           `json:"n,omitempty"`
 	Stream           bool                          `json:"stream,omitempty"`
+	StreamOptions    *StreamOptions                `json:"stream_options,omitempty"`
 	Stop             []string                      `json:"stop,omitempty"`
 	PresencePenalty  float32                       `json:"presence_penalty,omitempty"`
 	ResponseFormat   *ChatCompletionResponseFormat `json:"response_format,omitempty"`
@@ -203,6 +356,17 @@ type ChatCompletionRequest struct {
 	Tools        []Tool `json:"tools,omitempty"`
 	// This can be either a string or an ToolChoice object.
 	ToolChoiche any `json:"tool_choice,omitempty"`
+
+	// ExtraFields are added to the JSON body, for the parameters of
+	// OpenAI-compatible providers that the request has no field for, such as
+	// top_k. Marshaling fails with ErrExtraFieldConflict if one is named like a
+	// field of the request.
+	ExtraFields map[string]any `json:"-"`
+}
+
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	type chatCompletionRequest ChatCompletionRequest
+	return marshalWithExtraFields(chatCompletionRequest(r), r.ExtraFields)
 }
 
 type ToolType string
@@ -234,6 +398,9 @@ type FunctionDefinition struct {
 	// The jsonschema package is provided for convenience, but you should
 	// consider another specialized library if you require more complex schemas.
 	Parameters any `json:"parameters"`
+	// Strict makes the model follow Parameters exactly, for schemas meeting the
+	// constraints of structured outputs, which ValidateStrictSchema checks.
+	Strict *bool `json:"strict,omitempty"`
 }
 
 // Deprecated: use FunctionDefinition instead.
@@ -247,6 +414,7 @@ const (
 	FinishReasonFunctionCall  FinishReason = "function_call"
 	FinishReasonToolCalls     FinishReason = "tool_calls"
 	FinishReasonContentFilter FinishReason = "content_filter"
+	FinishReasonRefusal       FinishReason = "refusal"
 	FinishReasonNull          FinishReason = "null"
 )
 
@@ -268,6 +436,32 @@ type ChatCompletionChoice struct {
 	// content_filter: Omitted content due to a flag from our content filters
 	// null: API response still in progress or incomplete
 	FinishReason FinishReason `json:"finish_reason"`
+	// ContentFilterResults are the verdicts of the Azure OpenAI content filters
+	// on the message. OpenAI does not send them.
+	ContentFilterResults *ContentFilterResults `json:"content_filter_results,omitempty"`
+	// Delta is the message of responses, from some proxies and test servers,
+	// sending it as delta, like stream chunks do, instead of message. It is
+	// copied to an empty Message when decoding, see EffectiveMessage.
+	Delta *ChatCompletionMessage `json:"delta,omitempty"`
+}
+
+func (c *ChatCompletionChoice) UnmarshalJSON(data []byte) error {
+	type choice ChatCompletionChoice
+	var decoded choice
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*c = ChatCompletionChoice(decoded)
+	c.Message = c.EffectiveMessage()
+	return nil
+}
+
+// EffectiveMessage returns Message, or Delta if Message is empty.
+func (c ChatCompletionChoice) EffectiveMessage() ChatCompletionMessage {
+	if c.Delta != nil && reflect.ValueOf(c.Message).IsZero() {
+		return *c.Delta
+	}
+	return c.Message
 }
 
 // ChatCompletionResponse represents a response structure for chat completion API.
@@ -278,6 +472,13 @@ type ChatCompletionResponse struct {
 	Model   string                 `json:"model"`
 	Choices []ChatCompletionChoice `json:"choices"`
 	Usage   Usage                  `json:"usage"`
+	// SystemFingerprint identifies the backend configuration the model ran
+	// with. Responses to requests with the same Seed are only expected to be
+	// the same while it does not change, see ReproducibilityCheck.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	// PromptAnnotations are the verdicts of the Azure OpenAI content filters on
+	// the prompt.
+	PromptAnnotations []PromptAnnotation `json:"prompt_annotations,omitempty"`
 
 	httpHeader
 }
@@ -291,9 +492,12 @@ func (c *Client) CreateChatCompletion(
 		err = ErrChatCompletionStreamNotSupported
 		return
 	}
+	for _, middleware := range c.chatMiddleware {
+		request = middleware(request)
+	}
 
 	urlSuffix := chatCompletionsSuffix
-	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
+	if !c.supportsModel(urlSuffix, request.Model) {
 		err = ErrChatCompletionInvalidModel
 		return
 	}
@@ -303,6 +507,12 @@ func (c *Client) CreateChatCompletion(
 		return
 	}
 
+	if key, ok := c.chatCompletionCacheKey(ctx, request); ok {
+		return cachedSend(ctx, c.config, key, func() (response ChatCompletionResponse, err error) {
+			err = c.sendRequest(req, &response)
+			return
+		})
+	}
 	err = c.sendRequest(req, &response)
 	return
 }
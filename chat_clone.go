@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the request that can be modified, e.g. by
+// appending to Messages, without affecting r or other clones.
+//
+// Every slice, map and pointer of the request, its messages, tools and
+// response format is newly allocated. Fields of type any
+// (FunctionCall, ToolChoiche and FunctionDefinition.Parameters) are shared
+// with r, as the request never modifies them.
+func (r ChatCompletionRequest) Clone() ChatCompletionRequest {
+	c := r
+	if r.Messages != nil {
+		c.Messages = make([]ChatCompletionMessage, len(r.Messages))
+		for i, m := range r.Messages {
+			c.Messages[i] = m.clone()
+		}
+	}
+	c.Stop = slices.Clone(r.Stop)
+	c.Functions = slices.Clone(r.Functions)
+	for i := range c.Functions {
+		c.Functions[i].Strict = clonePointer(r.Functions[i].Strict)
+	}
+	c.Tools = slices.Clone(r.Tools)
+	for i := range c.Tools {
+		c.Tools[i].Function.Strict = clonePointer(r.Tools[i].Function.Strict)
+	}
+	c.LogitBias = maps.Clone(r.LogitBias)
+	c.ExtraFields = maps.Clone(r.ExtraFields)
+	c.Seed = clonePointer(r.Seed)
+	c.StreamOptions = clonePointer(r.StreamOptions)
+	if r.ResponseFormat != nil {
+		format := *r.ResponseFormat
+		if format.JSONSchema != nil {
+			schema := *format.JSONSchema
+			schema.Schema = slices.Clone(schema.Schema)
+			schema.Strict = clonePointer(schema.Strict)
+			format.JSONSchema = &schema
+		}
+		c.ResponseFormat = &format
+	}
+	return c
+}
+
+func (m ChatCompletionMessage) clone() ChatCompletionMessage {
+	c := m
+	c.Parts = slices.Clone(m.Parts)
+	c.ToolCalls = slices.Clone(m.ToolCalls)
+	for i := range c.ToolCalls {
+		c.ToolCalls[i].Raw = slices.Clone(m.ToolCalls[i].Raw)
+		c.ToolCalls[i].Index = clonePointer(m.ToolCalls[i].Index)
+	}
+	c.FunctionCall = clonePointer(m.FunctionCall)
+	return c
+}
+
+func (r ContentFilterResults) clone() ContentFilterResults {
+	c := r
+	if r.Jailbreak != nil {
+		jailbreak := *r.Jailbreak
+		c.Jailbreak = &jailbreak
+	}
+	if r.Profanity != nil {
+		profanity := *r.Profanity
+		c.Profanity = &profanity
+	}
+	return c
+}
+
+// clonePointer returns a pointer to a copy of *p, or nil if p is nil.
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
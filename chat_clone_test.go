@@ -0,0 +1,153 @@
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+)
+
+func TestChatCompletionRequestClone(t *testing.T) {
+	seed, strict := 42, true
+	base := openai.ChatCompletionRequest{
+		Model: openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are helpful."},
+			{
+				Role:         openai.ChatMessageRoleAssistant,
+				FunctionCall: &openai.FunctionCall{Name: "f"},
+				ToolCalls:    []openai.ToolCall{{ID: "call_1"}},
+			},
+		},
+		Stop:          []string{"\n"},
+		LogitBias:     map[string]int{"1639": 6},
+		Seed:          &seed,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ResponseFormatJSONSchema{Name: "answer", Schema: []byte(`{}`), Strict: &strict},
+		},
+		Functions: []openai.FunctionDefinition{{Name: "f", Strict: &strict}},
+		Tools: []openai.Tool{{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionDefinition{Strict: &strict},
+		}},
+	}
+
+	clone := base.Clone()
+	if !reflect.DeepEqual(clone, base) {
+		t.Fatalf("clone differs from the original:\n%+v\n%+v", clone, base)
+	}
+
+	clone.Messages[0].Content = "changed"
+	clone.Messages[1].FunctionCall.Name = "changed"
+	clone.Messages[1].ToolCalls[0].ID = "changed"
+	clone.Stop[0] = "changed"
+	clone.LogitBias["1639"] = 0
+	*clone.Seed = 0
+	clone.StreamOptions.IncludeUsage = false
+	clone.ResponseFormat.Type = openai.ChatCompletionResponseFormatTypeJSONObject
+	clone.Functions[0].Name = "changed"
+	clone.Tools[0].Type = "changed"
+	*clone.Functions[0].Strict = false
+	*clone.Tools[0].Function.Strict = false
+	*clone.ResponseFormat.JSONSchema.Strict = false
+
+	if base.Messages[0].Content != "You are helpful." || base.Messages[1].FunctionCall.Name != "f" ||
+		base.Messages[1].ToolCalls[0].ID != "call_1" || base.Stop[0] != "\n" || base.LogitBias["1639"] != 6 ||
+		*base.Seed != 42 || !base.StreamOptions.IncludeUsage ||
+		base.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeJSONSchema ||
+		base.Functions[0].Name != "f" || base.Tools[0].Type != openai.ToolTypeFunction || !strict {
+		t.Errorf("modifying the clone changed the original: %+v", base)
+	}
+
+	if empty := (openai.ChatCompletionRequest{}).Clone(); empty.Messages != nil || empty.LogitBias != nil {
+		t.Errorf("nil fields should stay nil: %+v", empty)
+	}
+}
+
+// checkNoSharedMemory reports the pointers, slices and maps of clone that
+// point to the memory of base. It also reports the nil ones of base, so that
+// the test sets every pointer field, including future ones. Fields of type any
+// are documented to be shared.
+func checkNoSharedMemory(t *testing.T, path string, base, clone reflect.Value) {
+	t.Helper()
+	switch base.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if base.IsNil() || (base.Kind() != reflect.Pointer && base.Len() == 0) {
+			t.Errorf("%s is empty in the test request, set it to test its copy", path)
+			return
+		}
+		if base.Pointer() == clone.Pointer() {
+			t.Errorf("%s is shared by the clone", path)
+		}
+	}
+	switch base.Kind() {
+	case reflect.Pointer:
+		checkNoSharedMemory(t, path, base.Elem(), clone.Elem())
+	case reflect.Slice:
+		for i := 0; i < base.Len(); i++ {
+			checkNoSharedMemory(t, fmt.Sprintf("%s[%d]", path, i), base.Index(i), clone.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < base.NumField(); i++ {
+			if field := base.Type().Field(i); field.IsExported() {
+				checkNoSharedMemory(t, path+"."+field.Name, base.Field(i), clone.Field(i))
+			}
+		}
+	default:
+	}
+}
+
+func TestChatCompletionRequestCloneSharesNoMemory(t *testing.T) {
+	seed, strict, index := 1, true, 0
+	base := openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{
+			Parts:        openai.Parts{{Type: openai.ContentTypeText, Text: "Hi"}},
+			FunctionCall: &openai.FunctionCall{Name: "f"},
+			ToolCalls:    []openai.ToolCall{{Index: &index, ID: "call_1", Raw: json.RawMessage(`{}`)}},
+		}},
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+		Stop:          []string{"\n"},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ResponseFormatJSONSchema{Name: "answer", Schema: json.RawMessage(`{}`), Strict: &strict},
+		},
+		Seed:        &seed,
+		LogitBias:   map[string]int{"1639": 6},
+		Functions:   []openai.FunctionDefinition{{Name: "f", Strict: &strict}},
+		Tools:       []openai.Tool{{Type: openai.ToolTypeFunction, Function: openai.FunctionDefinition{Strict: &strict}}},
+		ExtraFields: map[string]any{"top_k": 5},
+	}
+	checkNoSharedMemory(t, "request", reflect.ValueOf(base), reflect.ValueOf(base.Clone()))
+	checkNoSharedMemory(t, "request", reflect.ValueOf(base), reflect.ValueOf(base.WithTemperature(0.5)))
+}
+
+func TestChatCompletionRequestCloneConcurrentAppend(t *testing.T) {
+	base := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: make([]openai.ChatCompletionMessage, 1, 10),
+	}
+
+	var wg sync.WaitGroup
+	clones := make([]openai.ChatCompletionRequest, 8)
+	for i := range clones {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clone := base.Clone()
+			clone.Messages = append(clone.Messages, openai.ChatCompletionMessage{Name: string(rune('a' + i))})
+			clones[i] = clone
+		}(i)
+	}
+	wg.Wait()
+
+	for i, clone := range clones {
+		if clone.Messages[1].Name != string(rune('a'+i)) {
+			t.Errorf("clone %d has message %q of another goroutine", i, clone.Messages[1].Name)
+		}
+	}
+}
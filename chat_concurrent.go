@@ -0,0 +1,64 @@
+package openai
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrencyLimit is the number of requests CreateChatCompletionConcurrent
+// sends at once when ClientConfig.ConcurrencyLimit is zero.
+const DefaultConcurrencyLimit = 10
+
+// ConcurrentResult is the outcome of one request of
+// CreateChatCompletionConcurrent.
+type ConcurrentResult struct {
+	Request  ChatCompletionRequest
+	Response ChatCompletionResponse
+	Err      error
+}
+
+// WithConcurrencyLimit sets ClientConfig.ConcurrencyLimit.
+func WithConcurrencyLimit(limit int) ClientOption {
+	return func(config *ClientConfig) {
+		config.ConcurrencyLimit = limit
+	}
+}
+
+// CreateChatCompletionConcurrent sends requests in parallel, at most
+// ClientConfig.ConcurrencyLimit at once, e.g. to compare the answers of several
+// models to one prompt. It returns once every request completed, with their
+// results in the order of requests. The error of each request is in its
+// result; the returned error is only set if ctx is done, in which case the
+// requests that were not sent yet fail with the context's error.
+func (c *Client) CreateChatCompletionConcurrent(
+	ctx context.Context,
+	requests []ChatCompletionRequest,
+) ([]ConcurrentResult, error) {
+	limit := c.config.ConcurrencyLimit
+	if limit <= 0 {
+		limit = DefaultConcurrencyLimit
+	}
+	semaphore := make(chan struct{}, limit)
+	results := make([]ConcurrentResult, len(requests))
+
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		results[i].Request = request
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(result *ConcurrentResult) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			result.Response, result.Err = c.CreateChatCompletion(ctx, result.Request)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
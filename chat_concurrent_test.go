@@ -0,0 +1,114 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func setupConcurrentTestServer(t *testing.T, opts ...openai.ClientOption) (
+	client *openai.Client,
+	maxInFlight *atomic.Int64,
+) {
+	server := test.NewTestServer()
+	var inFlight atomic.Int64
+	maxInFlight = &atomic.Int64{}
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+
+		var request openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Model == "unknown-model" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"The model does not exist","type":"invalid_request_error"}}`))
+			return
+		}
+		// Answer the later requests first.
+		time.Sleep(time.Duration(10-len(request.Messages[0].Content)) * 5 * time.Millisecond)
+		resBytes, _ := json.Marshal(openai.ChatCompletionResponse{
+			Model: request.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: request.Messages[0].Content},
+			}},
+		})
+		_, _ = w.Write(resBytes)
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	return openai.NewClientWithConfig(config, opts...), maxInFlight
+}
+
+func concurrentTestRequest(model, content string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: content}},
+	}
+}
+
+func TestCreateChatCompletionConcurrent(t *testing.T) {
+	client, maxInFlight := setupConcurrentTestServer(t, openai.WithConcurrencyLimit(3))
+	var requests []openai.ChatCompletionRequest
+	for _, content := range []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff"} {
+		requests = append(requests, concurrentTestRequest(openai.GPT3Dot5Turbo, content))
+	}
+	requests = append(requests, concurrentTestRequest("unknown-model", "g"))
+
+	results, err := client.CreateChatCompletionConcurrent(context.Background(), requests)
+	checks.NoError(t, err, "CreateChatCompletionConcurrent error")
+	if len(results) != len(requests) {
+		t.Fatalf("got %d results for %d requests", len(results), len(requests))
+	}
+	for i, result := range results[:6] {
+		checks.NoError(t, result.Err, "request error")
+		content := requests[i].Messages[0].Content
+		if result.Request.Messages[0].Content != content || result.Response.Choices[0].Message.Content != content {
+			t.Errorf("result %d is for %q, want %q", i, result.Response.Choices[0].Message.Content, content)
+		}
+	}
+	checks.HasError(t, results[6].Err, "the unknown model should fail")
+
+	if peak := maxInFlight.Load(); peak > 3 || peak < 2 {
+		t.Errorf("up to %d requests were in flight, want at most the limit of 3", peak)
+	}
+}
+
+func TestCreateChatCompletionConcurrentCancel(t *testing.T) {
+	client, _ := setupConcurrentTestServer(t, openai.WithConcurrencyLimit(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	requests := []openai.ChatCompletionRequest{
+		concurrentTestRequest(openai.GPT3Dot5Turbo, "a"),
+		concurrentTestRequest(openai.GPT3Dot5Turbo, "b"),
+		concurrentTestRequest(openai.GPT3Dot5Turbo, "c"),
+	}
+
+	results, err := client.CreateChatCompletionConcurrent(ctx, requests)
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "CreateChatCompletionConcurrent should be cancelled")
+	if len(results) != 3 {
+		t.Fatalf("got %d results for 3 requests", len(results))
+	}
+	for i, result := range results {
+		checks.ErrorIs(t, result.Err, context.DeadlineExceeded, "request error")
+		if result.Request.Messages[0].Content != requests[i].Messages[0].Content {
+			t.Errorf("result %d holds the wrong request", i)
+		}
+	}
+}
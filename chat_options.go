@@ -0,0 +1,52 @@
+package openai
+
+// The With methods return a modified copy of the request, made with Clone, so
+// variants of a base request can be built without aliasing its slices:
+//
+//	creative := base.WithTemperature(1.2).WithMaxTokens(256)
+
+// WithTools returns a copy of the request using tools.
+func (r ChatCompletionRequest) WithTools(tools ...Tool) ChatCompletionRequest {
+	c := r.Clone()
+	c.Tools = append([]Tool(nil), tools...)
+	return c
+}
+
+// WithSystemPrompt returns a copy of the request whose first message is a
+// system message with text, replacing the existing first system message if
+// there is one.
+func (r ChatCompletionRequest) WithSystemPrompt(text string) ChatCompletionRequest {
+	c := r.Clone()
+	system := SystemMessage(text)
+	if len(c.Messages) > 0 && c.Messages[0].Role == ChatMessageRoleSystem {
+		c.Messages[0] = system
+		return c
+	}
+	c.Messages = append([]ChatCompletionMessage{system}, c.Messages...)
+	return c
+}
+
+// WithTemperature returns a copy of the request using temperature t.
+func (r ChatCompletionRequest) WithTemperature(t float32) ChatCompletionRequest {
+	c := r.Clone()
+	c.Temperature = t
+	return c
+}
+
+// WithMaxTokens returns a copy of the request limited to n tokens.
+func (r ChatCompletionRequest) WithMaxTokens(n int) ChatCompletionRequest {
+	c := r.Clone()
+	c.MaxTokens = n
+	return c
+}
+
+// WithExtraField returns a copy of the request adding the field key with value
+// to its JSON body. See ChatCompletionRequest.ExtraFields.
+func (r ChatCompletionRequest) WithExtraField(key string, value any) ChatCompletionRequest {
+	c := r.Clone()
+	if c.ExtraFields == nil {
+		c.ExtraFields = map[string]any{}
+	}
+	c.ExtraFields[key] = value
+	return c
+}
@@ -0,0 +1,36 @@
+package openai_test
+
+import (
+	"testing"
+
+	"github.com/zquestz/go-openai"
+)
+
+func TestChatCompletionRequestWith(t *testing.T) {
+	base := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{openai.UserMessage("Hello!")},
+	}
+
+	variant := base.WithSystemPrompt("Be brief.").
+		WithTemperature(0.5).
+		WithMaxTokens(100).
+		WithTools(openai.Tool{Type: openai.ToolTypeFunction, Function: openai.FunctionDefinition{Name: "f"}})
+
+	if len(base.Messages) != 1 || base.Temperature != 0 || base.MaxTokens != 0 || base.Tools != nil {
+		t.Fatalf("base request was modified: %+v", base)
+	}
+	if len(variant.Messages) != 2 || variant.Messages[0].Role != openai.ChatMessageRoleSystem ||
+		variant.Messages[0].Content != "Be brief." || variant.Messages[1].Content != "Hello!" {
+		t.Errorf("system prompt was not prepended: %+v", variant.Messages)
+	}
+	if variant.Temperature != 0.5 || variant.MaxTokens != 100 || len(variant.Tools) != 1 {
+		t.Errorf("unexpected variant: %+v", variant)
+	}
+
+	replaced := variant.WithSystemPrompt("Be verbose.")
+	if len(replaced.Messages) != 2 || replaced.Messages[0].Content != "Be verbose." ||
+		variant.Messages[0].Content != "Be brief." {
+		t.Errorf("system prompt was not replaced in a copy: %+v", replaced.Messages)
+	}
+}
@@ -0,0 +1,126 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// defaultSchemaName names the schemas of types without a name.
+	defaultSchemaName = "response"
+	// maxSchemaNameLength is the longest name of a response format schema.
+	maxSchemaNameLength = 64
+)
+
+// ErrNoChoices is returned when parsing a chat completion without choices.
+var ErrNoChoices = errors.New("chat completion has no choices")
+
+// RefusalError is returned by CreateChatCompletionWithSchema when the model
+// refused to answer, which structured outputs report instead of the JSON.
+type RefusalError struct {
+	Refusal string
+}
+
+func (e *RefusalError) Error() string {
+	return "model refused to answer: " + e.Refusal
+}
+
+// CreateChatCompletionWithSchema sends req with a strict json_schema response
+// format describing T, see NewJSONSchemaResponseFormat, replacing its
+// ResponseFormat, and decodes the content of the first choice into a T. The
+// schema is named after T. If the model refuses to answer, it returns a
+// RefusalError.
+func CreateChatCompletionWithSchema[T any](
+	ctx context.Context,
+	client *Client,
+	req ChatCompletionRequest,
+) (T, error) {
+	var result T
+	req, err := withSchemaResponseFormat[T](req)
+	if err != nil {
+		return result, err
+	}
+	response, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return result, err
+	}
+	return decodeSchemaResponse[T](response)
+}
+
+// CreateChatCompletionStreamWithSchema is CreateChatCompletionWithSchema
+// streaming the response, which is accumulated with AccumulateStream and only
+// decoded once complete.
+func CreateChatCompletionStreamWithSchema[T any](
+	ctx context.Context,
+	client *Client,
+	req ChatCompletionRequest,
+) (T, error) {
+	var result T
+	req, err := withSchemaResponseFormat[T](req)
+	if err != nil {
+		return result, err
+	}
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return result, err
+	}
+	defer stream.Close()
+	response, err := AccumulateStream(stream)
+	if err != nil {
+		return result, err
+	}
+	return decodeSchemaResponse[T](response)
+}
+
+func withSchemaResponseFormat[T any](req ChatCompletionRequest) (ChatCompletionRequest, error) {
+	format, err := jsonSchemaResponseFormat[T](schemaName(reflect.TypeOf((*T)(nil)).Elem()), true)
+	if err != nil {
+		return req, fmt.Errorf("describing the response type: %w", err)
+	}
+	req.ResponseFormat = &format
+	return req, nil
+}
+
+func decodeSchemaResponse[T any](response ChatCompletionResponse) (T, error) {
+	var result T
+	if len(response.Choices) == 0 {
+		return result, ErrNoChoices
+	}
+	message := response.Choices[0].Message
+	if message.Refusal != "" {
+		return result, &RefusalError{Refusal: message.Refusal}
+	}
+	if err := json.Unmarshal([]byte(message.Content), &result); err != nil {
+		return result, fmt.Errorf("decoding the response (finish reason %q): %w",
+			response.Choices[0].FinishReason, err)
+	}
+	return result, nil
+}
+
+// schemaName returns the name of t, made of the characters schema names
+// accept.
+func schemaName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, t.Name())
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return defaultSchemaName
+	}
+	if len(name) > maxSchemaNameLength {
+		name = name[:maxSchemaNameLength]
+	}
+	return name
+}
@@ -0,0 +1,130 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+var schemaTestRequest = openai.ChatCompletionRequest{
+	Model:    openai.GPT4TurboPreview,
+	Messages: []openai.ChatCompletionMessage{openai.UserMessage("What's the weather in Paris?")},
+}
+
+func schemaTestResponse(message openai.ChatCompletionMessage) openai.ChatCompletionResponse {
+	message.Role = openai.ChatMessageRoleAssistant
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: openai.FinishReasonStop}},
+	}
+}
+
+func TestCreateChatCompletionWithSchema(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(schemaTestResponse(openai.ChatCompletionMessage{
+		Content: `{"location":"Paris","unit":"celsius","days":["monday"],"wind":{"speed":3.5}}`,
+	}))
+	client := server.Client()
+
+	report, err := openai.CreateChatCompletionWithSchema[weatherReport](context.Background(), client, schemaTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionWithSchema error")
+	if report.Location != "Paris" || report.Unit != "celsius" || len(report.Days) != 1 || report.Wind.Speed != 3.5 {
+		t.Errorf("got report %+v", report)
+	}
+	if schemaTestRequest.ResponseFormat != nil {
+		t.Error("the request of the caller was modified")
+	}
+
+	var body openai.ChatCompletionRequest
+	checks.NoError(t, json.Unmarshal(server.Requests()[0].Body, &body), "Unmarshal error")
+	format := body.ResponseFormat
+	if format == nil || format.Type != openai.ChatCompletionResponseFormatTypeJSONSchema ||
+		format.JSONSchema == nil || format.JSONSchema.Name != "weatherReport" || !*format.JSONSchema.Strict {
+		t.Fatalf("got response format %+v", format)
+	}
+	def := openai.FunctionDefinition{Name: "weather_report", Parameters: format.JSONSchema.Schema}
+	checks.NoError(t, openai.ValidateStrictSchema(def), "strict schema is invalid")
+}
+
+func TestCreateChatCompletionWithSchemaErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response openai.ChatCompletionResponse
+		check    func(t *testing.T, err error)
+	}{
+		{
+			name:     "refusal",
+			response: schemaTestResponse(openai.ChatCompletionMessage{Refusal: "I can't help with that."}),
+			check: func(t *testing.T, err error) {
+				var refusal *openai.RefusalError
+				if !errors.As(err, &refusal) || refusal.Refusal != "I can't help with that." {
+					t.Fatalf("expected RefusalError, got %v", err)
+				}
+			},
+		},
+		{
+			name:     "no choices",
+			response: openai.ChatCompletionResponse{},
+			check: func(t *testing.T, err error) {
+				checks.ErrorIs(t, err, openai.ErrNoChoices, "expected ErrNoChoices")
+			},
+		},
+		{
+			name:     "truncated content",
+			response: schemaTestResponse(openai.ChatCompletionMessage{Content: `{"location":"Par`}),
+			check: func(t *testing.T, err error) {
+				var syntaxErr *json.SyntaxError
+				if !errors.As(err, &syntaxErr) {
+					t.Fatalf("expected a JSON syntax error, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := openaitest.NewServer(t)
+			server.OnChatCompletion(tc.response)
+			_, err := openai.CreateChatCompletionWithSchema[weatherReport](
+				context.Background(), server.Client(), schemaTestRequest)
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestCreateChatCompletionStreamWithSchema(t *testing.T) {
+	server := openaitest.NewServer(t)
+	var chunks []openai.ChatCompletionStreamResponse
+	for _, part := range []string{`{"location":`, `"Paris","unit":"celsius",`, `"days":[],"wind":{"speed":1}}`} {
+		chunks = append(chunks, openai.ChatCompletionStreamResponse{
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: part}}},
+		})
+	}
+	server.OnChatCompletionStream(chunks, 0)
+
+	report, err := openai.CreateChatCompletionStreamWithSchema[weatherReport](
+		context.Background(), server.Client(), schemaTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStreamWithSchema error")
+	if report.Location != "Paris" || report.Unit != "celsius" || report.Wind.Speed != 1 {
+		t.Errorf("got report %+v", report)
+	}
+
+	refusal := []openai.ChatCompletionStreamResponse{{
+		Choices: []openai.ChatCompletionStreamChoice{{
+			Delta:        openai.ChatCompletionStreamChoiceDelta{Refusal: "I can't help with that."},
+			FinishReason: openai.FinishReasonRefusal,
+		}},
+	}}
+	server = openaitest.NewServer(t)
+	server.OnChatCompletionStream(refusal, 0)
+	_, err = openai.CreateChatCompletionStreamWithSchema[weatherReport](
+		context.Background(), server.Client(), schemaTestRequest)
+	var refusalErr *openai.RefusalError
+	if !errors.As(err, &refusalErr) {
+		t.Fatalf("expected RefusalError, got %v", err)
+	}
+}
@@ -2,7 +2,10 @@ package openai
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
+	"sync"
 )
 
 type ChatCompletionStreamChoiceDelta struct {
@@ -10,6 +13,7 @@ type ChatCompletionStreamChoiceDelta struct {
 	Role         string        `json:"role,omitempty"`
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+	Refusal      string        `json:"refusal,omitempty"`
 }
 
 type ChatCompletionStreamChoice struct {
@@ -25,13 +29,155 @@ type ChatCompletionStreamResponse struct {
 	Created           int64                        `json:"created"`
 	Model             string                       `json:"model"`
 	Choices           []ChatCompletionStreamChoice `json:"choices"`
+	SystemFingerprint string                       `json:"system_fingerprint,omitempty"`
 	PromptAnnotations []PromptAnnotation           `json:"prompt_annotations,omitempty"`
+	// Usage is only set on the last chunk of streams requested with
+	// StreamOptions.IncludeUsage.
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // ChatCompletionStream
 // Note: Perhaps it is more elegant to abstract Stream using generics.
 type ChatCompletionStream struct {
 	*streamReader[ChatCompletionStreamResponse]
+
+	resume *chatStreamResume
+	// onUsage reports the usage chunk to ClientConfig.UsageRecorder.
+	onUsage func(Usage)
+
+	// mu guards streamReader, which resuming replaces, and the channel of Chan
+	// against Close, which may be called while Chan's goroutine reads.
+	mu       sync.Mutex
+	closed   bool
+	chunks   chan ChatCompletionStreamChunk
+	stop     chan struct{}
+	stopped  chan struct{}
+	chanOnce sync.Once
+}
+
+// ChatCompletionStreamChunk is a response of the channel of
+// ChatCompletionStream.Chan, or the error that ended the stream.
+type ChatCompletionStreamChunk struct {
+	Response ChatCompletionStreamResponse
+	Err      error
+}
+
+// chatStreamChanSize is the buffer of the channel of ChatCompletionStream.Chan.
+const chatStreamChanSize = 16
+
+// NewChatCompletionStream returns a stream reading the server-sent events of
+// body, e.g. a recorded response or chunks scripted by a fake ChatCompleter.
+// Closing the stream closes body.
+func NewChatCompletionStream(body io.ReadCloser) *ChatCompletionStream {
+	return &ChatCompletionStream{streamReader: newStreamReader[ChatCompletionStreamResponse](body)}
+}
+
+// Recv returns the next response of the stream. When ClientConfig.StreamResumeLimit
+// is set, dropped connections are resumed transparently, see ResumeCount.
+func (stream *ChatCompletionStream) Recv() (response ChatCompletionStreamResponse, err error) {
+	if stream.resume == nil {
+		response, err = stream.streamReader.Recv()
+	} else {
+		response, err = stream.recvResumable()
+	}
+	if err == nil && response.Usage != nil && stream.onUsage != nil {
+		stream.onUsage(*response.Usage)
+	}
+	return
+}
+
+// Chan returns a channel receiving the responses of the stream, for use in
+// select statements. The first call starts a goroutine receiving them with
+// Recv, which must not be called anymore. The channel is closed once the
+// stream ends; if it ends with an error other than io.EOF, a last chunk
+// carries it. Close stops the goroutine, discards the responses not received
+// yet, and closes the channel.
+func (stream *ChatCompletionStream) Chan() <-chan ChatCompletionStreamChunk {
+	stream.chanOnce.Do(func() {
+		stream.mu.Lock()
+		defer stream.mu.Unlock()
+		stream.chunks = make(chan ChatCompletionStreamChunk, chatStreamChanSize)
+		if stream.closed {
+			close(stream.chunks)
+			return
+		}
+		stream.stop = make(chan struct{})
+		stream.stopped = make(chan struct{})
+		go stream.sendChunks()
+	})
+	return stream.chunks
+}
+
+func (stream *ChatCompletionStream) sendChunks() {
+	defer close(stream.stopped)
+	defer close(stream.chunks)
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		select {
+		case stream.chunks <- ChatCompletionStreamChunk{Response: response, Err: err}:
+		case <-stream.stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Close closes the stream, and the channel of Chan if it was called.
+func (stream *ChatCompletionStream) Close() {
+	_ = stream.close()
+}
+
+// CloseWithCancel cancels the request of the stream before closing it like
+// Close, so that the transport aborts it at once, resetting its HTTP/2 stream
+// or closing its HTTP/1 connection, and the server stops generating. It
+// returns the error of closing the response body. A Recv in progress fails
+// with a TimeoutError wrapping context.Canceled, where after Close it may
+// still return a chunk; later calls fail either way.
+func (stream *ChatCompletionStream) CloseWithCancel() error {
+	stream.mu.Lock()
+	stream.streamReader.timer.abort()
+	stream.mu.Unlock()
+	return stream.close()
+}
+
+func (stream *ChatCompletionStream) close() error {
+	stream.mu.Lock()
+	alreadyClosed, stop := stream.closed, stream.stop
+	stream.closed = true
+	if stop != nil && !alreadyClosed {
+		close(stop)
+		// Interrupts the read of the goroutine of Chan.
+		stream.response.Body.Close()
+	}
+	stream.mu.Unlock()
+
+	if stop != nil {
+		<-stream.stopped
+		// Discard the responses not received yet.
+		for len(stream.chunks) > 0 {
+			<-stream.chunks
+		}
+	}
+	return stream.streamReader.close()
+}
+
+// replaceReader makes the stream read resp, reporting false, after closing
+// resp, if the stream was closed meanwhile.
+func (stream *ChatCompletionStream) replaceReader(resp *streamReader[ChatCompletionStreamResponse]) bool {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if stream.closed {
+		resp.Close()
+		return false
+	}
+	stream.streamReader.Close()
+	stream.streamReader = resp
+	return true
 }
 
 // CreateChatCompletionStream — API call to create a chat completion w/ streaming
@@ -43,7 +189,7 @@ func (c *Client) CreateChatCompletionStream(
 	request ChatCompletionRequest,
 ) (stream *ChatCompletionStream, err error) {
 	urlSuffix := chatCompletionsSuffix
-	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
+	if !c.supportsModel(urlSuffix, request.Model) {
 		err = ErrChatCompletionInvalidModel
 		return
 	}
@@ -61,5 +207,14 @@ func (c *Client) CreateChatCompletionStream(
 	stream = &ChatCompletionStream{
 		streamReader: resp,
 	}
+	if recorder := c.config.UsageRecorder; recorder != nil {
+		meta := requestMetaOf(req)
+		stream.onUsage = func(usage Usage) {
+			recorder.Record(ctx, meta.Endpoint, meta.Model, usage)
+		}
+	}
+	if c.config.StreamResumeLimit > 0 {
+		stream.resume = newChatStreamResume(ctx, c, request)
+	}
 	return
 }
@@ -0,0 +1,155 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ErrStreamNegativeIndex is returned by AccumulateStream for chunks with a
+// negative choice or tool call index.
+var ErrStreamNegativeIndex = errors.New("stream chunk has a negative index")
+
+// AccumulateStream receives the rest of stream and reassembles the response
+// CreateChatCompletion would have returned: the content, refusal and tool
+// calls of each choice are joined, and the usage is the one of the last chunk
+// of streams requested with StreamOptions.IncludeUsage. It does not close the
+// stream, and must not be used with Chan.
+func AccumulateStream(stream *ChatCompletionStream) (ChatCompletionResponse, error) {
+	var (
+		response ChatCompletionResponse
+		choices  []*streamChoice
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ChatCompletionResponse{}, err
+		}
+
+		if response.ID == "" {
+			response.ID, response.Created, response.Model = chunk.ID, chunk.Created, chunk.Model
+		}
+		if chunk.SystemFingerprint != "" {
+			response.SystemFingerprint = chunk.SystemFingerprint
+		}
+		response.PromptAnnotations = append(response.PromptAnnotations, chunk.PromptAnnotations...)
+		if chunk.Usage != nil {
+			response.Usage = *chunk.Usage
+		}
+		for _, delta := range chunk.Choices {
+			if delta.Index < 0 {
+				return ChatCompletionResponse{}, fmt.Errorf("%w: choice %d", ErrStreamNegativeIndex, delta.Index)
+			}
+			for len(choices) <= delta.Index {
+				choices = append(choices, &streamChoice{})
+			}
+			if err = choices[delta.Index].add(delta); err != nil {
+				return ChatCompletionResponse{}, err
+			}
+		}
+	}
+
+	response.Object = "chat.completion"
+	response.Choices = make([]ChatCompletionChoice, len(choices))
+	for i, choice := range choices {
+		response.Choices[i] = choice.build(i)
+	}
+	response.httpHeader = stream.httpHeader
+	return response, nil
+}
+
+// streamChoice accumulates the deltas of a choice of a stream.
+type streamChoice struct {
+	choice    ChatCompletionChoice
+	content   strings.Builder
+	refusal   strings.Builder
+	arguments []*strings.Builder
+}
+
+func (c *streamChoice) add(delta ChatCompletionStreamChoice) error {
+	message := &c.choice.Message
+	if delta.Delta.Role != "" {
+		message.Role = delta.Delta.Role
+	}
+	c.content.WriteString(delta.Delta.Content)
+	c.refusal.WriteString(delta.Delta.Refusal)
+	if call := delta.Delta.FunctionCall; call != nil {
+		if message.FunctionCall == nil {
+			message.FunctionCall = &FunctionCall{}
+		}
+		message.FunctionCall.Name += call.Name
+		message.FunctionCall.Arguments += call.Arguments
+	}
+	for _, call := range delta.Delta.ToolCalls {
+		if err := c.addToolCall(call); err != nil {
+			return err
+		}
+	}
+	if delta.FinishReason != "" {
+		c.choice.FinishReason = delta.FinishReason
+	}
+	if results := delta.ContentFilterResults; results != (ContentFilterResults{}) {
+		c.choice.ContentFilterResults = &results
+	}
+	return nil
+}
+
+// addToolCall adds a chunk of a tool call, which continues the call of the
+// same index, or the last call for chunks without index nor ID.
+func (c *streamChoice) addToolCall(call ToolCall) error {
+	if call.Index != nil && *call.Index < 0 {
+		return fmt.Errorf("%w: tool call %d", ErrStreamNegativeIndex, *call.Index)
+	}
+	calls := c.choice.Message.ToolCalls
+	i := len(calls) - 1
+	switch {
+	case call.Index != nil:
+		i = slices.IndexFunc(calls, func(existing ToolCall) bool {
+			return existing.Index != nil && *existing.Index == *call.Index
+		})
+	case call.ID != "":
+		i = -1
+	}
+	if i < 0 {
+		arguments := &strings.Builder{}
+		arguments.WriteString(call.Function.Arguments)
+		c.arguments = append(c.arguments, arguments)
+		c.choice.Message.ToolCalls = append(calls, call)
+		return nil
+	}
+
+	existing := &calls[i]
+	if existing.ID == "" {
+		existing.ID = call.ID
+	}
+	if existing.Type == "" {
+		existing.Type = call.Type
+	}
+	existing.Function.Name += call.Function.Name
+	c.arguments[i].WriteString(call.Function.Arguments)
+	return nil
+}
+
+func (c *streamChoice) build(index int) ChatCompletionChoice {
+	choice := c.choice
+	choice.Index = index
+	if choice.Message.Role == "" {
+		choice.Message.Role = ChatMessageRoleAssistant
+	}
+	choice.Message.Content = c.content.String()
+	choice.Message.Refusal = c.refusal.String()
+	for i := range choice.Message.ToolCalls {
+		call := &choice.Message.ToolCalls[i]
+		call.Function.Arguments = c.arguments[i].String()
+		call.Index = nil
+		if call.Type == "" {
+			call.Type = ToolTypeFunction
+		}
+	}
+	return choice
+}
@@ -0,0 +1,124 @@
+package openai_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func sseBody(payloads ...string) io.ReadCloser {
+	var body strings.Builder
+	for _, payload := range payloads {
+		body.WriteString("data: " + payload + "\n\n")
+	}
+	body.WriteString("data: [DONE]\n\n")
+	return io.NopCloser(strings.NewReader(body.String()))
+}
+
+func TestAccumulateStream(t *testing.T) {
+	//nolint:lll
+	stream := openai.NewChatCompletionStream(sseBody(
+		`{"id":"chatcmpl-1","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Let me "}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"check."}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`,
+	))
+	defer stream.Close()
+
+	response, err := openai.AccumulateStream(stream)
+	checks.NoError(t, err, "AccumulateStream error")
+	if response.ID != "chatcmpl-1" || response.Object != "chat.completion" || response.Model != "gpt-4o" ||
+		response.Created != 1700000000 || response.Usage.TotalTokens != 30 || len(response.Choices) != 1 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	choice := response.Choices[0]
+	if choice.FinishReason != openai.FinishReasonToolCalls || choice.Message.Role != openai.ChatMessageRoleAssistant ||
+		choice.Message.Content != "Let me check." {
+		t.Errorf("unexpected choice %+v", choice)
+	}
+	calls := choice.Message.ToolCalls
+	if len(calls) != 2 ||
+		calls[0].ID != "call_1" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` ||
+		calls[1].ID != "call_2" || calls[1].Function.Name != "get_time" || calls[1].Function.Arguments != "{}" {
+		t.Errorf("unexpected tool calls %+v", calls)
+	}
+}
+
+func TestAccumulateStreamUnknownToolType(t *testing.T) {
+	//nolint:lll
+	stream := openai.NewChatCompletionStream(sseBody(
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"web_browser"}]}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"f"}}]}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{}"}}]}}]}`,
+	))
+	defer stream.Close()
+
+	response, err := openai.AccumulateStream(stream)
+	checks.NoError(t, err, "AccumulateStream error")
+	calls := response.Choices[0].Message.ToolCalls
+	if len(calls) != 2 || calls[0].ID != "call_1" || calls[0].Type != "web_browser" || calls[1].ID != "call_2" {
+		t.Errorf("got tool calls %+v, want the chunks merged by index", calls)
+	}
+}
+
+func TestAccumulateStreamNegativeIndex(t *testing.T) {
+	for _, payload := range []string{
+		`{"id":"chatcmpl-1","choices":[{"index":-1,"delta":{"content":"Hi"}}]}`,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":-1,"id":"call_1"}]}}]}`,
+	} {
+		stream := openai.NewChatCompletionStream(sseBody(payload))
+		_, err := openai.AccumulateStream(stream)
+		stream.Close()
+		checks.ErrorIs(t, err, openai.ErrStreamNegativeIndex, "AccumulateStream should reject negative indexes")
+	}
+}
+
+func TestChatCompletionStreamChan(t *testing.T) {
+	stream := openai.NewChatCompletionStream(sseBody(
+		`{"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+		`{"id":"2","choices":[{"index":0,"delta":{"content":" world"}}]}`,
+	))
+	var ids []string
+	for chunk := range stream.Chan() {
+		checks.NoError(t, chunk.Err, "stream error")
+		ids = append(ids, chunk.Response.ID)
+	}
+	stream.Close()
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("received chunks %v, want 1 and 2", ids)
+	}
+
+	// Close ends a stream still waiting for events.
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	stream = openai.NewChatCompletionStream(reader)
+	chunks := stream.Chan()
+	go func() {
+		_, _ = writer.Write([]byte(`data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n"))
+	}()
+	chunk := <-chunks
+	checks.NoError(t, chunk.Err, "stream error")
+	closed := make(chan struct{})
+	go func() {
+		stream.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked")
+	}
+	if _, open := <-chunks; open {
+		t.Error("channel not closed by Close")
+	}
+	if chunks != stream.Chan() {
+		t.Error("Chan returned another channel")
+	}
+}
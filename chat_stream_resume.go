@@ -0,0 +1,216 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// chatStreamResume holds the state needed to re-issue a chat completion stream
+// after the connection drops mid-stream.
+type chatStreamResume struct {
+	ctx     context.Context
+	client  *Client
+	request ChatCompletionRequest
+
+	retriesLeft uint
+	count       int
+	chunks      int
+	last        ChatCompletionStreamResponse
+
+	// received is the assistant text delivered to the caller so far.
+	received strings.Builder
+	// overlap is the text the continuation is expected to repeat, and pending
+	// the part of the continuation buffered while it still matches overlap.
+	overlap string
+	pending string
+
+	// resumable becomes false once the stream carries data that cannot be
+	// replayed as an assistant prefix, e.g. tool calls, refusals or multiple
+	// choices.
+	resumable bool
+	finished  bool
+}
+
+func newChatStreamResume(ctx context.Context, client *Client, request ChatCompletionRequest) *chatStreamResume {
+	return &chatStreamResume{
+		ctx:         ctx,
+		client:      client,
+		request:     request,
+		retriesLeft: client.config.StreamResumeLimit,
+		resumable:   request.N <= 1,
+	}
+}
+
+// ResumeCount returns how many times the stream was re-issued after a dropped
+// connection. It is always zero when ClientConfig.StreamResumeLimit is not set.
+func (stream *ChatCompletionStream) ResumeCount() int {
+	if stream.resume == nil {
+		return 0
+	}
+	return stream.resume.count
+}
+
+func (stream *ChatCompletionStream) recvResumable() (response ChatCompletionStreamResponse, err error) {
+	r := stream.resume
+	for {
+		response, err = stream.streamReader.Recv()
+		if err == nil {
+			if r.splice(&response) {
+				return
+			}
+			continue
+		}
+
+		if errors.Is(err, io.EOF) && r.pending != "" {
+			return r.flush(), nil
+		}
+		if !r.canResume(stream.isFinished, err) {
+			return
+		}
+
+		resp, reissueErr := r.reissue()
+		if reissueErr != nil {
+			err = reissueErr
+			return
+		}
+		if !stream.replaceReader(resp) {
+			return
+		}
+	}
+}
+
+// splice records the response and rewrites it so that a resumed stream reads
+// as a single continuous one. It reports false if the response carries nothing
+// new for the caller and should be skipped.
+func (r *chatStreamResume) splice(response *ChatCompletionStreamResponse) bool {
+	if len(response.Choices) != 1 || response.Choices[0].Index != 0 {
+		r.resumable = false
+		r.chunks++
+		return true
+	}
+
+	choice := &response.Choices[0]
+	if choice.Delta.FunctionCall != nil || len(choice.Delta.ToolCalls) > 0 || choice.Delta.Refusal != "" {
+		r.resumable = false
+	}
+	if choice.FinishReason != "" {
+		r.finished = true
+	}
+
+	if r.count > 0 {
+		if r.chunks > 0 {
+			choice.Delta.Role = ""
+		}
+		choice.Delta.Content = r.dedupe(choice.Delta.Content)
+		if choice.FinishReason != "" {
+			choice.Delta.Content += r.pending
+			r.overlap, r.pending = "", ""
+		}
+		if choice.Delta.Content == "" && choice.Delta.Role == "" && r.resumable && choice.FinishReason == "" {
+			return false
+		}
+	}
+
+	r.received.WriteString(choice.Delta.Content)
+	r.chunks++
+	r.last = *response
+	return true
+}
+
+// flush returns a chunk carrying the text dedupe held back when the stream
+// ended before the continuation diverged from the text already received.
+func (r *chatStreamResume) flush() ChatCompletionStreamResponse {
+	response := ChatCompletionStreamResponse{
+		ID:      r.last.ID,
+		Object:  r.last.Object,
+		Created: r.last.Created,
+		Model:   r.last.Model,
+		Choices: []ChatCompletionStreamChoice{{
+			Delta: ChatCompletionStreamChoiceDelta{Content: r.pending},
+		}},
+	}
+	r.received.WriteString(r.pending)
+	r.overlap, r.pending = "", ""
+	return response
+}
+
+// dedupe drops the part of a continuation that repeats text already delivered
+// to the caller, for models that restart the answer instead of continuing it.
+func (r *chatStreamResume) dedupe(content string) string {
+	if r.overlap == "" {
+		return content
+	}
+
+	r.pending += content
+	switch {
+	case strings.HasPrefix(r.overlap, r.pending):
+		if len(r.pending) == len(r.overlap) {
+			r.overlap, r.pending = "", ""
+		}
+		return ""
+	case strings.HasPrefix(r.pending, r.overlap):
+		content = r.pending[len(r.overlap):]
+	default:
+		content = r.pending
+	}
+	r.overlap, r.pending = "", ""
+	return content
+}
+
+func (r *chatStreamResume) canResume(isFinished bool, err error) bool {
+	if r.retriesLeft == 0 || !r.resumable || r.finished || isFinished || r.ctx.Err() != nil {
+		return false
+	}
+	return isTransportError(err)
+}
+
+// reissue sends the original request again, with the text received so far
+// appended as an assistant message so the model can continue from it.
+func (r *chatStreamResume) reissue() (resp *streamReader[ChatCompletionStreamResponse], err error) {
+	request := r.request
+	r.overlap, r.pending = "", ""
+	if text := r.received.String(); text != "" {
+		request.Messages = make([]ChatCompletionMessage, 0, len(r.request.Messages)+1)
+		request.Messages = append(request.Messages, r.request.Messages...)
+		request.Messages = append(request.Messages, ChatCompletionMessage{
+			Role:    ChatMessageRoleAssistant,
+			Content: text,
+		})
+		r.overlap = text
+	}
+
+	for r.retriesLeft > 0 {
+		r.retriesLeft--
+
+		var req *http.Request
+		req, err = r.client.newRequest(r.ctx, http.MethodPost,
+			r.client.fullURL(chatCompletionsSuffix, request.Model), withBody(request))
+		if err != nil {
+			return
+		}
+
+		resp, err = sendRequestStream[ChatCompletionStreamResponse](r.client, req)
+		if err == nil {
+			r.count++
+			return
+		}
+		if !isTransportError(err) || r.ctx.Err() != nil {
+			return
+		}
+	}
+	return
+}
+
+// isTransportError reports whether err was caused by the connection rather
+// than by the API, e.g. an unexpected EOF or a reset connection.
+func isTransportError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,273 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// droppingStreamScript lists the content chunks to send for one request. A
+// negative dropAfter finishes the stream with [DONE], otherwise the connection
+// is aborted after dropAfter chunks.
+type droppingStreamScript struct {
+	chunks    []string
+	dropAfter int
+}
+
+func droppingStreamHandler(
+	t *testing.T,
+	scripts []droppingStreamScript,
+	requests *[]openai.ChatCompletionRequest,
+) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		*requests = append(*requests, request)
+
+		script := scripts[len(*requests)-1]
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, chunk := range script.chunks {
+			if i == script.dropAfter {
+				break
+			}
+			role := ""
+			if i == 0 {
+				role = `"role":"assistant",`
+			}
+			data := fmt.Sprintf(`{"id":"%d","object":"chat.completion.chunk","model":"gpt-3.5-turbo",`+
+				`"choices":[{"index":0,"delta":{%s"content":%q},"finish_reason":null}]}`, i, role, chunk)
+			_, err = w.Write([]byte("data: " + data + "\n\n"))
+			checks.NoError(t, err, "Write error")
+		}
+		w.(http.Flusher).Flush()
+
+		if script.dropAfter >= 0 {
+			panic(http.ErrAbortHandler)
+		}
+		//nolint:lll
+		data := `{"id":"done","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`
+		_, err = w.Write([]byte("data: " + data + "\n\ndata: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	}
+}
+
+func setupResumeTestServer(
+	limit uint,
+	handler func(w http.ResponseWriter, r *http.Request),
+) (client *openai.Client, teardown func()) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", handler)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	teardown = ts.Close
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	config.StreamResumeLimit = limit
+	client = openai.NewClientWithConfig(config)
+	return
+}
+
+func readResumeTestStream(stream *openai.ChatCompletionStream) (content string, roles int, err error) {
+	var sb strings.Builder
+	for {
+		var resp openai.ChatCompletionStreamResponse
+		resp, err = stream.Recv()
+		if err != nil {
+			return sb.String(), roles, err
+		}
+		if resp.Choices[0].Delta.Role != "" {
+			roles++
+		}
+		sb.WriteString(resp.Choices[0].Delta.Content)
+	}
+}
+
+var resumeTestRequest = openai.ChatCompletionRequest{
+	Model: openai.GPT3Dot5Turbo,
+	Messages: []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: "Hello!",
+		},
+	},
+}
+
+func TestChatCompletionStreamResume(t *testing.T) {
+	testCases := []struct {
+		name         string
+		limit        uint
+		scripts      []droppingStreamScript
+		wantContent  string
+		wantResumes  int
+		wantRequests int
+		wantPrefix   string
+		wantErr      error
+	}{
+		{
+			name:  "continuation",
+			limit: 2,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"Hello", ",", " wor"}, dropAfter: 2},
+				{chunks: []string{" world", "!"}, dropAfter: -1},
+			},
+			wantContent:  "Hello, world!",
+			wantResumes:  1,
+			wantRequests: 2,
+			wantPrefix:   "Hello,",
+			wantErr:      io.EOF,
+		},
+		{
+			name:  "restarted answer is not duplicated",
+			limit: 2,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"Hel", "lo", " wor"}, dropAfter: 2},
+				{chunks: []string{"He", "llo wo", "rld"}, dropAfter: -1},
+			},
+			wantContent:  "Hello world",
+			wantResumes:  1,
+			wantRequests: 2,
+			wantPrefix:   "Hello",
+			wantErr:      io.EOF,
+		},
+		{
+			name:  "continuation repeating the end of the received text",
+			limit: 1,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"a", "b"}, dropAfter: 2},
+				{chunks: []string{"a"}, dropAfter: -1},
+			},
+			wantContent:  "aba",
+			wantResumes:  1,
+			wantRequests: 2,
+			wantPrefix:   "ab",
+			wantErr:      io.EOF,
+		},
+		{
+			name:  "retry from scratch when nothing was received",
+			limit: 1,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"Hello"}, dropAfter: 0},
+				{chunks: []string{"Hello", " world"}, dropAfter: -1},
+			},
+			wantContent:  "Hello world",
+			wantResumes:  1,
+			wantRequests: 2,
+			wantErr:      io.EOF,
+		},
+		{
+			name:  "several drops",
+			limit: 3,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"a", "b"}, dropAfter: 1},
+				{chunks: []string{"b", "c"}, dropAfter: 1},
+				{chunks: []string{"c", "d"}, dropAfter: -1},
+			},
+			wantContent:  "abcd",
+			wantResumes:  2,
+			wantRequests: 3,
+			wantPrefix:   "ab",
+			wantErr:      io.EOF,
+		},
+		{
+			name:  "budget exhausted",
+			limit: 1,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"a", "b"}, dropAfter: 1},
+				{chunks: []string{"b", "c"}, dropAfter: 1},
+			},
+			wantContent:  "ab",
+			wantResumes:  1,
+			wantRequests: 2,
+			wantPrefix:   "a",
+			wantErr:      io.ErrUnexpectedEOF,
+		},
+		{
+			name:  "disabled",
+			limit: 0,
+			scripts: []droppingStreamScript{
+				{chunks: []string{"a", "b"}, dropAfter: 1},
+			},
+			wantContent:  "a",
+			wantRequests: 1,
+			wantErr:      io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := &[]openai.ChatCompletionRequest{}
+			client, teardown := setupResumeTestServer(tc.limit, droppingStreamHandler(t, tc.scripts, requests))
+			defer teardown()
+
+			stream, err := client.CreateChatCompletionStream(context.Background(), resumeTestRequest)
+			checks.NoError(t, err, "CreateChatCompletionStream returned error")
+			defer stream.Close()
+
+			content, roles, err := readResumeTestStream(stream)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("stream.Recv() returned %v, want %v", err, tc.wantErr)
+			}
+			if content != tc.wantContent {
+				t.Errorf("content = %q, want %q", content, tc.wantContent)
+			}
+			if roles != 1 {
+				t.Errorf("role was sent %d times, want once", roles)
+			}
+			if stream.ResumeCount() != tc.wantResumes {
+				t.Errorf("ResumeCount() = %d, want %d", stream.ResumeCount(), tc.wantResumes)
+			}
+			if len(*requests) != tc.wantRequests {
+				t.Fatalf("server received %d requests, want %d", len(*requests), tc.wantRequests)
+			}
+
+			last := (*requests)[len(*requests)-1]
+			switch {
+			case tc.wantPrefix == "" && len(last.Messages) != len(resumeTestRequest.Messages):
+				t.Errorf("resumed request should not carry an assistant prefix: %+v", last.Messages)
+			case tc.wantPrefix != "":
+				prefix := last.Messages[len(last.Messages)-1]
+				if prefix.Role != openai.ChatMessageRoleAssistant || prefix.Content != tc.wantPrefix {
+					t.Errorf("assistant prefix = %+v, want %q", prefix, tc.wantPrefix)
+				}
+			}
+		})
+	}
+}
+
+func TestChatCompletionStreamResumeNotOnAPIError(t *testing.T) {
+	requests := 0
+	client, teardown := setupResumeTestServer(1, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		dataBytes := []byte(`data: {"error":{"message":"The server had an error while processing your request. Sorry about that!", "type":"server_ error", "param":null,"code":null}}`)
+		dataBytes = append(dataBytes, []byte("\n\ndata: [DONE]\n\n")...)
+		_, err := w.Write(dataBytes)
+		checks.NoError(t, err, "Write error")
+	})
+	defer teardown()
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), resumeTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("stream.Recv() did not return APIError: %v", err)
+	}
+	if requests != 1 || stream.ResumeCount() != 0 {
+		t.Errorf("API errors should not be resumed, got %d requests", requests)
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/zquestz/go-openai"
 	"github.com/zquestz/go-openai/internal/test/checks"
@@ -386,6 +388,195 @@ func TestAzureCreateChatCompletionStreamRateLimitError(t *testing.T) {
 	}
 }
 
+func TestAzureCreateChatCompletionStreamContentFilterResults(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	server.RegisterHandler("/openai/deployments/gpt-35-turbo/chat/completions",
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"id":"","object":"","created":0,"model":"","choices":[],` +
+				`"prompt_annotations":[{"prompt_index":0,"content_filter_results":` + azureContentFilterResults + `}]}` +
+				"\n\n" +
+				`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-35-turbo",` +
+				`"choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null,` +
+				`"content_filter_results":` + azureContentFilterResults + `}]}` + "\n\ndata: [DONE]\n\n"))
+		})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		Stream:   true,
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	annotations, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv error")
+	if len(annotations.PromptAnnotations) != 1 ||
+		!reflect.DeepEqual(annotations.PromptAnnotations[0].ContentFilterResults, wantAzureContentFilterResults) {
+		t.Errorf("got prompt annotations %+v", annotations.PromptAnnotations)
+	}
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv error")
+	if !reflect.DeepEqual(chunk.Choices[0].ContentFilterResults, wantAzureContentFilterResults) {
+		t.Errorf("got content filter results %+v", chunk.Choices[0].ContentFilterResults)
+	}
+}
+
+func TestCreateChatCompletionStreamMidStreamError(t *testing.T) {
+	//nolint:lll
+	const chunk = `{"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"response1"},"finish_reason":null}]}`
+
+	testCases := []struct {
+		name       string
+		errorEvent string
+		terminal   string
+		wantStatus int
+		wantType   string
+		wantCode   any
+		wantInner  string
+	}{
+		{
+			name: "openai",
+			errorEvent: `{"error": {"message": "The server had an error while processing your request.",` +
+				` "type": "server_error", "param": null, "code": null}}`,
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "server_error",
+		},
+		{
+			name: "azure",
+			errorEvent: `{"error":{"code":"content_filter","message":"The response was filtered due to the prompt ` +
+				`triggering Azure OpenAI's content management policy.","param":"prompt","status":400,` +
+				`"innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":` +
+				`{"hate":{"filtered":true,"severity":"high"},"self_harm":{"filtered":false,"severity":"safe"},` +
+				`"sexual":{"filtered":false,"severity":"safe"},"violence":{"filtered":false,"severity":"safe"}}}}}`,
+			terminal:   "data: [DONE]\n\n",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "content_filter",
+			wantInner:  "ResponsibleAIPolicyViolation",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server, teardown := setupOpenAITestServer()
+			defer teardown()
+			done := make(chan struct{})
+			defer close(done)
+			server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				dataBytes := []byte("event: message\ndata: " + chunk + "\n\n")
+				dataBytes = append(dataBytes, []byte("event: message\ndata: "+tc.errorEvent+"\n\n")...)
+				dataBytes = append(dataBytes, []byte(tc.terminal)...)
+				_, err := w.Write(dataBytes)
+				checks.NoError(t, err, "Write error")
+				w.(http.Flusher).Flush()
+				if tc.terminal == "" {
+					// Keep the connection open: the error must not wait for more data.
+					<-done
+				}
+			})
+
+			stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+				Model: openai.GPT3Dot5Turbo,
+				Messages: []openai.ChatCompletionMessage{
+					{
+						Role:    openai.ChatMessageRoleUser,
+						Content: "Hello!",
+					},
+				},
+			})
+			checks.NoError(t, err, "CreateCompletionStream returned error")
+			defer stream.Close()
+
+			_, err = stream.Recv()
+			checks.NoError(t, err, "stream.Recv() failed")
+
+			_, err = stream.Recv()
+			var apiErr *openai.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("stream.Recv() did not return APIError: %v", err)
+			}
+			if apiErr.HTTPStatusCode != tc.wantStatus {
+				t.Errorf("HTTPStatusCode = %d, want %d", apiErr.HTTPStatusCode, tc.wantStatus)
+			}
+			if apiErr.Type != tc.wantType || (tc.wantCode != nil && apiErr.Code != tc.wantCode) {
+				t.Errorf("unexpected type or code: %+v", apiErr)
+			}
+			if tc.wantInner != "" {
+				if apiErr.InnerError == nil || apiErr.InnerError.Code != tc.wantInner ||
+					!apiErr.InnerError.ContentFilterResults.Hate.Filtered {
+					t.Errorf("unexpected inner error: %+v", apiErr.InnerError)
+				}
+				_, err = stream.Recv()
+				checks.ErrorIs(t, err, io.EOF, "stream.Recv() did not return EOF after the error")
+			}
+		})
+	}
+}
+
+func TestCreateChatCompletionStreamRecvRaw(t *testing.T) {
+	//nolint:lll
+	payloads := []string{
+		`{"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"llama3-8b-8192","choices":[{"index":0,"delta":{"content":"response1"},"finish_reason":null}]}`,
+		`{"id":"2","object":"chat.completion.chunk","created":1598069255,"model":"llama3-8b-8192","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"x_groq":{"id":"req_1","usage":{"prompt_tokens":3,"completion_tokens":1}}}`,
+	}
+
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		dataBytes := []byte{}
+		for _, payload := range payloads {
+			dataBytes = append(dataBytes, []byte("event: message\ndata: "+payload+"\n\n")...)
+		}
+		dataBytes = append(dataBytes, []byte("data: [DONE]\n\n")...)
+		_, err := w.Write(dataBytes)
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model: openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "Hello!",
+			},
+		},
+	})
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	var received [][]byte
+	for {
+		raw, streamErr := stream.RecvRaw()
+		if errors.Is(streamErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, streamErr, "stream.RecvRaw() failed")
+		received = append(received, raw)
+	}
+
+	if len(received) != len(payloads) {
+		t.Fatalf("received %d payloads, want %d", len(received), len(payloads))
+	}
+	for i, payload := range payloads {
+		if string(received[i]) != payload {
+			t.Errorf("payload %d = %s, want %s", i, received[i], payload)
+		}
+	}
+
+	var extra struct {
+		XGroq struct {
+			Usage openai.Usage `json:"usage"`
+		} `json:"x_groq"`
+	}
+	checks.NoError(t, json.Unmarshal(received[1], &extra), "Unmarshal error")
+	if extra.XGroq.Usage.PromptTokens != 3 {
+		t.Errorf("provider field was not preserved: %+v", extra)
+	}
+}
+
 // Helper funcs.
 func compareChatResponses(r1, r2 openai.ChatCompletionStreamResponse) bool {
 	if r1.ID != r2.ID || r1.Object != r2.Object || r1.Created != r2.Created || r1.Model != r2.Model {
@@ -414,3 +605,53 @@ func compareChatStreamResponseChoices(c1, c2 openai.ChatCompletionStreamChoice)
 	}
 	return true
 }
+
+func TestChatCompletionStreamCloseWithCancel(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	serverDone := make(chan struct{})
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n"))
+		w.(http.Flusher).Flush()
+		// Keep generating until the client goes away.
+		<-r.Context().Done()
+		close(serverDone)
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	_, err = stream.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+
+	errs := make(chan error, 1)
+	go func() {
+		_, recvErr := stream.RecvRaw()
+		errs <- recvErr
+	}()
+	// Let the read block waiting for the next chunk.
+	time.Sleep(20 * time.Millisecond)
+	checks.NoError(t, stream.CloseWithCancel(), "CloseWithCancel error")
+	var timeoutErr *openai.TimeoutError
+	select {
+	case err = <-errs:
+		if !errors.As(err, &timeoutErr) || !errors.Is(err, context.Canceled) {
+			t.Errorf("Recv in progress returned %v, want a TimeoutError wrapping context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Recv in progress was not interrupted")
+	}
+	select {
+	case <-serverDone:
+	case <-time.After(time.Second):
+		t.Fatal("the server did not see the request canceled")
+	}
+	if _, err = stream.Recv(); err == nil {
+		t.Error("Recv after CloseWithCancel succeeded")
+	}
+	// Closing again is harmless.
+	stream.Close()
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -282,6 +283,67 @@ func TestAzureChatCompletions(t *testing.T) {
 	checks.NoError(t, err, "CreateAzureChatCompletion error")
 }
 
+const azureContentFilterResults = `{"hate":{"filtered":false,"severity":"safe"},` +
+	`"self_harm":{"filtered":false,"severity":"low"},` +
+	`"sexual":{"filtered":false,"severity":"medium"},` +
+	`"violence":{"filtered":true,"severity":"high"},` +
+	`"jailbreak":{"detected":true},` +
+	`"profanity":{"filtered":false,"detected":true}}`
+
+var wantAzureContentFilterResults = openai.ContentFilterResults{
+	Hate:      openai.Hate{Filtered: false, Severity: "safe"},
+	SelfHarm:  openai.SelfHarm{Filtered: false, Severity: "low"},
+	Sexual:    openai.Sexual{Filtered: false, Severity: "medium"},
+	Violence:  openai.Violence{Filtered: true, Severity: "high"},
+	Jailbreak: &openai.JailbreakFilter{Detected: true},
+	Profanity: &openai.ProfanityFilter{Filtered: false, Detected: true},
+}
+
+func TestAzureChatCompletionsContentFilterResults(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	server.RegisterHandler("/openai/deployments/*", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-35-turbo",` +
+			`"prompt_annotations":[{"prompt_index":0,"content_filter_results":` + azureContentFilterResults + `}],` +
+			`"choices":[{"index":0,"finish_reason":"content_filter","message":{"role":"assistant","content":""},` +
+			`"content_filter_results":` + azureContentFilterResults + `}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":0,"total_tokens":9}}`))
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if len(resp.PromptAnnotations) != 1 || resp.PromptAnnotations[0].PromptIndex != 0 ||
+		!reflect.DeepEqual(resp.PromptAnnotations[0].ContentFilterResults, wantAzureContentFilterResults) {
+		t.Errorf("got prompt annotations %+v", resp.PromptAnnotations)
+	}
+	choice := resp.Choices[0]
+	if choice.ContentFilterResults == nil ||
+		!reflect.DeepEqual(*choice.ContentFilterResults, wantAzureContentFilterResults) {
+		t.Errorf("got content filter results %+v", choice.ContentFilterResults)
+	}
+	if choice.FinishReason != openai.FinishReasonContentFilter {
+		t.Errorf("got finish reason %q", choice.FinishReason)
+	}
+}
+
+func TestChatCompletionsWithoutContentFilterResults(t *testing.T) {
+	var resp openai.ChatCompletionResponse
+	err := json.Unmarshal([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant"}}]}`), &resp)
+	checks.NoError(t, err, "Unmarshal error")
+	if resp.Choices[0].ContentFilterResults != nil || resp.PromptAnnotations != nil {
+		t.Error("OpenAI responses should have no content filter results")
+	}
+	data, err := json.Marshal(resp)
+	checks.NoError(t, err, "Marshal error")
+	if strings.Contains(string(data), "content_filter_results") || strings.Contains(string(data), "prompt_annotations") {
+		t.Errorf("absent content filter results were marshaled: %s", data)
+	}
+}
+
 // handleChatCompletionEndpoint Handles the ChatGPT completion endpoint by the test server.
 func handleChatCompletionEndpoint(w http.ResponseWriter, r *http.Request) {
 	var err error
@@ -401,6 +463,7 @@ func TestFinishReason(t *testing.T) {
 		openai.FinishReasonLength,
 		openai.FinishReasonFunctionCall,
 		openai.FinishReasonContentFilter,
+		openai.FinishReasonRefusal,
 	}
 	for _, r := range otherReasons {
 		c.FinishReason = r
@@ -410,3 +473,161 @@ func TestFinishReason(t *testing.T) {
 		}
 	}
 }
+
+func TestChatCompletionRefusal(t *testing.T) {
+	var choice openai.ChatCompletionChoice
+	err := json.Unmarshal([]byte(`{"index":0,"message":{"role":"assistant","content":null,`+
+		`"refusal":"I can't help with that."},"finish_reason":"refusal"}`), &choice)
+	checks.NoError(t, err, "Unmarshal error")
+	if choice.Message.Refusal != "I can't help with that." || choice.FinishReason != openai.FinishReasonRefusal {
+		t.Errorf("unexpected choice %+v", choice)
+	}
+
+	// The refusal is sent back with the conversation.
+	data, err := json.Marshal(choice.Message)
+	checks.NoError(t, err, "Marshal error")
+	if !strings.Contains(string(data), `"refusal":"I can't help with that."`) {
+		t.Errorf("refusal dropped from %s", data)
+	}
+
+	var chunk openai.ChatCompletionStreamResponse
+	err = json.Unmarshal([]byte(`{"choices":[{"index":0,"delta":{"refusal":"I can't"}}]}`), &chunk)
+	checks.NoError(t, err, "Unmarshal error")
+	if chunk.Choices[0].Delta.Refusal != "I can't" {
+		t.Errorf("Delta.Refusal = %q", chunk.Choices[0].Delta.Refusal)
+	}
+}
+
+func TestChatCompletionChoiceDelta(t *testing.T) {
+	var response openai.ChatCompletionResponse
+	err := json.Unmarshal([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[`+
+		`{"index":0,"delta":{"role":"assistant","content":"Hello from a proxy"},"finish_reason":"stop"},`+
+		`{"index":1,"message":{"role":"assistant","content":"Hello"},"delta":{"content":"ignored"}},`+
+		`{"index":2,"message":{"role":"assistant","content":"Hi"}}]}`), &response)
+	checks.NoError(t, err, "Unmarshal error")
+
+	for i, want := range []string{"Hello from a proxy", "Hello", "Hi"} {
+		choice := response.Choices[i]
+		if choice.Message.Content != want || choice.EffectiveMessage().Content != want {
+			t.Errorf("choice %d: Message = %+v, EffectiveMessage() = %+v, want content %q",
+				i, choice.Message, choice.EffectiveMessage(), want)
+		}
+	}
+	if first := response.Choices[0]; first.Delta == nil || first.Message.Role != openai.ChatMessageRoleAssistant ||
+		first.FinishReason != openai.FinishReasonStop {
+		t.Errorf("unexpected choice %+v", first)
+	}
+
+	choice := openai.ChatCompletionChoice{Delta: &openai.ChatCompletionMessage{Content: "built by hand"}}
+	if choice.EffectiveMessage().Content != "built by hand" {
+		t.Errorf("EffectiveMessage() = %+v", choice.EffectiveMessage())
+	}
+	data, err := json.Marshal(response.Choices[2])
+	checks.NoError(t, err, "Marshal error")
+	if strings.Contains(string(data), "delta") {
+		t.Errorf("choice without delta marshaled as %s", data)
+	}
+}
+
+func TestPartJSON(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		want openai.Part
+		// marshaled is the JSON the part marshals back to, data if empty.
+		marshaled string
+	}{
+		{
+			name: "legacy string",
+			data: `{"type":"image_url","image_url":"https://example.com/a.png"}`,
+			want: openai.Part{Type: openai.ContentTypeImage, ImageUrl: "https://example.com/a.png"},
+		},
+		{
+			name: "object with detail",
+			data: `{"type":"image_url","image_url":{"url":"https://example.com/a.png","detail":"auto"}}`,
+			want: openai.Part{
+				Type:     openai.ContentTypeImage,
+				ImageUrl: "https://example.com/a.png",
+				Detail:   openai.ImageDetailAuto,
+			},
+		},
+		{
+			name:      "object without detail",
+			data:      `{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo="}}`,
+			want:      openai.Part{Type: openai.ContentTypeImage, ImageUrl: "data:image/png;base64,iVBORw0KGgo="},
+			marshaled: `{"type":"image_url","image_url":"data:image/png;base64,iVBORw0KGgo="}`,
+		},
+		{
+			name: "text",
+			data: `{"type":"text","text":"What is in this image?"}`,
+			want: openai.Part{Type: openai.ContentTypeText, Text: "What is in this image?"},
+		},
+		{
+			name:      "null image_url",
+			data:      `{"type":"image_url","image_url":null}`,
+			want:      openai.Part{Type: openai.ContentTypeImage},
+			marshaled: `{"type":"image_url"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var part openai.Part
+			checks.NoError(t, json.Unmarshal([]byte(tc.data), &part), "Unmarshal error")
+			if part != tc.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", part, tc.want)
+			}
+
+			data, err := json.Marshal(part)
+			checks.NoError(t, err, "Marshal error")
+			want := tc.marshaled
+			if want == "" {
+				want = tc.data
+			}
+			if string(data) != want {
+				t.Errorf("Marshal() = %s, want %s", data, want)
+			}
+		})
+	}
+
+	var part openai.Part
+	if err := json.Unmarshal([]byte(`{"type":"image_url","image_url":42}`), &part); err == nil {
+		t.Error("Unmarshal() of a numeric image_url returned no error")
+	}
+}
+
+func TestToolCallJSON(t *testing.T) {
+	const data = `{"role":"assistant","content":null,"tool_calls":[` +
+		`{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{}"}},` +
+		`{"index":1,"id":"call_2","type":"web_browser","web_browser":{"url":"https://example.com"}}]}`
+	var msg openai.ChatCompletionMessage
+	checks.NoError(t, json.Unmarshal([]byte(data), &msg), "Unmarshal error")
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2", len(msg.ToolCalls))
+	}
+	function, unknown := msg.ToolCalls[0], msg.ToolCalls[1]
+	if function.Type != openai.ToolTypeFunction || function.Function.Name != "get_weather" || function.Raw != nil {
+		t.Errorf("function call decoded as %+v", function)
+	}
+	if unknown.ID != "call_2" || unknown.Type != "web_browser" || unknown.Raw == nil ||
+		unknown.Index == nil || *unknown.Index != 1 {
+		t.Errorf("call of an unknown type decoded as %+v", unknown)
+	}
+
+	out, err := json.Marshal(msg)
+	checks.NoError(t, err, "Marshal error")
+	var got, want struct {
+		ToolCalls any `json:"tool_calls"`
+	}
+	checks.NoError(t, json.Unmarshal(out, &got), "Unmarshal error")
+	checks.NoError(t, json.Unmarshal([]byte(data), &want), "Unmarshal error")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tool calls did not round-trip:\n got %s\nwant %s", out, data)
+	}
+
+	out, err = json.Marshal(openai.ToolCall{ID: "call_3", Function: openai.FunctionCall{Name: "f"}})
+	checks.NoError(t, err, "Marshal error")
+	if !strings.Contains(string(out), `"type":"function"`) {
+		t.Errorf("call without a type marshaled as %s, want a function call", out)
+	}
+}
@@ -0,0 +1,207 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ValidationError describes a field of a request that the API would reject.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// ValidationErrors lists every problem found by a Validate method.
+type ValidationErrors []*ValidationError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, err := range v {
+		msgs[i] = err.Error()
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+func (v *ValidationErrors) add(field, format string, args ...any) {
+	*v = append(*v, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the request against the documented constraints of the chat
+// completion API, so misconfigurations are caught before a round-trip. It
+// returns ValidationErrors listing all violations, or nil.
+func (r ChatCompletionRequest) Validate() error {
+	var errs ValidationErrors
+	if r.Temperature < 0 || r.Temperature > 2 {
+		errs.add("temperature", "must be between 0 and 2, got %v", r.Temperature)
+	}
+	if r.TopP < 0 || r.TopP > 1 {
+		errs.add("top_p", "must be between 0 and 1, got %v", r.TopP)
+	}
+	if r.FrequencyPenalty < -2 || r.FrequencyPenalty > 2 {
+		errs.add("frequency_penalty", "must be between -2 and 2, got %v", r.FrequencyPenalty)
+	}
+	if r.PresencePenalty < -2 || r.PresencePenalty > 2 {
+		errs.add("presence_penalty", "must be between -2 and 2, got %v", r.PresencePenalty)
+	}
+	if len(r.Functions) > 0 && len(r.Tools) > 0 {
+		errs.add("functions", "cannot be set together with tools")
+	}
+	if r.FunctionCall != nil && len(r.Functions) == 0 {
+		errs.add("function_call", "requires functions to be set")
+	}
+	if r.MaxTokens < 0 {
+		errs.add("max_tokens", "must be greater than 0 when set, got %d", r.MaxTokens)
+	}
+	// N is omitted when zero, which the API treats as 1.
+	if r.N < 0 {
+		errs.add("n", "must be at least 1, got %d", r.N)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+const (
+	strictSchemaMaxDepth      = 5
+	strictSchemaMaxProperties = 100
+)
+
+var (
+	strictSchemaTypes = []string{"string", "number", "integer", "boolean", "object", "array", "null"}
+	// strictSchemaUnsupported are the keywords structured outputs reject.
+	strictSchemaUnsupported = []string{
+		"allOf", "oneOf", "not", "if", "then", "else", "dependentRequired", "dependentSchemas",
+		"minLength", "maxLength", "pattern", "format",
+		"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+		"patternProperties", "unevaluatedProperties", "propertyNames", "minProperties", "maxProperties",
+		"unevaluatedItems", "contains", "minContains", "maxContains", "minItems", "maxItems", "uniqueItems",
+	}
+)
+
+// ValidateStrictSchema checks that the Parameters of def meet the constraints
+// of structured outputs, which the API checks when Strict is set: the root is
+// an object, every object lists all its properties as required and sets
+// additionalProperties to false, and only supported types and keywords are
+// used. It returns ValidationErrors listing all violations, or nil. It is not
+// called when sending requests, as it marshals the schema.
+func ValidateStrictSchema(def FunctionDefinition) error {
+	data, err := json.Marshal(def.Parameters)
+	if err != nil {
+		return err
+	}
+	var schema any
+	if err = json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	v := strictSchemaValidator{}
+	root, ok := schema.(map[string]any)
+	switch {
+	case !ok:
+		v.errs.add("parameters", "must be a JSON schema object")
+	case root["anyOf"] != nil:
+		v.errs.add("parameters", "must not be an anyOf at the root")
+	case root["type"] != "object":
+		v.errs.add("parameters", "must be of type object, got %v", root["type"])
+	default:
+		v.validate("parameters", root, 0)
+		for _, defs := range []string{"$defs", "definitions"} {
+			named, _ := root[defs].(map[string]any)
+			for _, name := range sortedKeys(named) {
+				if s, isSchema := named[name].(map[string]any); isSchema {
+					v.validate("parameters."+defs+"."+name, s, 0)
+				}
+			}
+		}
+		if v.properties > strictSchemaMaxProperties {
+			v.errs.add("parameters", "must have at most %d properties, got %d", strictSchemaMaxProperties, v.properties)
+		}
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+type strictSchemaValidator struct {
+	errs       ValidationErrors
+	properties int
+}
+
+func (v *strictSchemaValidator) validate(field string, schema map[string]any, depth int) {
+	for _, keyword := range strictSchemaUnsupported {
+		if _, ok := schema[keyword]; ok {
+			v.errs.add(field, "uses %s, which strict mode does not support", keyword)
+		}
+	}
+
+	types, _ := schema["type"].([]any)
+	if t, ok := schema["type"].(string); ok {
+		types = []any{t}
+	}
+	// jsonschema.Definition sets properties on every schema, so they only make
+	// an object of schemas without a type.
+	isObject := len(types) == 0 && schema["properties"] != nil
+	for _, t := range types {
+		name, _ := t.(string)
+		if !slices.Contains(strictSchemaTypes, name) {
+			v.errs.add(field, "has type %v, which strict mode does not support", t)
+		}
+		isObject = isObject || name == "object"
+	}
+
+	if isObject {
+		v.validateObject(field, schema, depth)
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		v.validate(field+".items", items, depth)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for i, s := range anyOf {
+			if branch, isSchema := s.(map[string]any); isSchema {
+				v.validate(fmt.Sprintf("%s.anyOf[%d]", field, i), branch, depth)
+			}
+		}
+	}
+}
+
+func (v *strictSchemaValidator) validateObject(field string, schema map[string]any, depth int) {
+	if depth++; depth > strictSchemaMaxDepth {
+		v.errs.add(field, "must be nested at most %d levels deep", strictSchemaMaxDepth)
+		return
+	}
+	if schema["additionalProperties"] != false {
+		v.errs.add(field, "must set additionalProperties to false")
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+	v.properties += len(properties)
+	for _, name := range sortedKeys(properties) {
+		if !slices.Contains(required, any(name)) {
+			v.errs.add(field+".properties."+name, "must be listed in required")
+		}
+		if property, ok := properties[name].(map[string]any); ok {
+			v.validate(field+".properties."+name, property, depth)
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in order, so that errors are reported in a
+// stable order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
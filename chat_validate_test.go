@@ -0,0 +1,140 @@
+package openai_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/jsonschema"
+)
+
+func TestChatCompletionRequestValidate(t *testing.T) {
+	valid := openai.ChatCompletionRequest{
+		Model:            openai.GPT3Dot5Turbo,
+		Messages:         []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		Temperature:      2,
+		TopP:             1,
+		FrequencyPenalty: -2,
+		PresencePenalty:  2,
+		MaxTokens:        5,
+		N:                1,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid request returned %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(*openai.ChatCompletionRequest)
+		fields []string
+	}{
+		{"temperature", func(r *openai.ChatCompletionRequest) { r.Temperature = 2.1 }, []string{"temperature"}},
+		{"top_p", func(r *openai.ChatCompletionRequest) { r.TopP = -0.1 }, []string{"top_p"}},
+		{"penalties", func(r *openai.ChatCompletionRequest) {
+			r.FrequencyPenalty = 3
+			r.PresencePenalty = -3
+		}, []string{"frequency_penalty", "presence_penalty"}},
+		{"functions and tools", func(r *openai.ChatCompletionRequest) {
+			r.Functions = []openai.FunctionDefinition{{Name: "f"}}
+			r.Tools = []openai.Tool{{Type: openai.ToolTypeFunction, Function: openai.FunctionDefinition{Name: "f"}}}
+		}, []string{"functions"}},
+		{"function_call without functions", func(r *openai.ChatCompletionRequest) {
+			r.FunctionCall = "auto"
+		}, []string{"function_call"}},
+		{"max_tokens and n", func(r *openai.ChatCompletionRequest) {
+			r.MaxTokens = -1
+			r.N = -1
+		}, []string{"max_tokens", "n"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := valid
+			tc.modify(&request)
+
+			var errs openai.ValidationErrors
+			if err := request.Validate(); !errors.As(err, &errs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(tc.fields) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tc.fields), errs)
+			}
+			for i, field := range tc.fields {
+				if errs[i].Field != field {
+					t.Errorf("error %d is for %q, want %q", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateStrictSchema(t *testing.T) {
+	strict := true
+	valid := openai.FunctionDefinition{
+		Name:   "get_weather",
+		Strict: &strict,
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"location": {Type: jsonschema.String},
+				"unit":     {Type: jsonschema.String, Enum: []string{"celsius", "fahrenheit"}},
+			},
+			Required:             []string{"location", "unit"},
+			AdditionalProperties: false,
+		},
+	}
+	if err := openai.ValidateStrictSchema(valid); err != nil {
+		t.Fatalf("valid definition returned %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		schema string
+		fields []string
+	}{
+		{"nullable and anyOf", `{"type":"object","additionalProperties":false,"required":["a","b"],"properties":{` +
+			`"a":{"type":["string","null"]},"b":{"anyOf":[{"type":"number"},{"$ref":"#/$defs/b"}]}},` +
+			`"$defs":{"b":{"type":"object","properties":{},"additionalProperties":false}}}`, nil},
+		{"root not an object", `{"type":"array","items":{"type":"string"}}`, []string{"parameters"}},
+		{"root anyOf", `{"anyOf":[{"type":"object"}]}`, []string{"parameters"}},
+		{"additionalProperties and required", `{"type":"object","required":["a"],"properties":{` +
+			`"a":{"type":"object","additionalProperties":false,"properties":{"b":{"type":"string"}}}}}`,
+			[]string{"parameters", "parameters.properties.a.properties.b"}},
+		{"unsupported keywords and types", `{"type":"object","additionalProperties":false,"required":["a","b"],` +
+			`"properties":{"a":{"type":"string","minLength":1},"b":{"type":"array","items":{"type":"date"}}}}`,
+			[]string{"parameters.properties.a", "parameters.properties.b.items"}},
+		{"nested too deep", `{"type":"object","additionalProperties":false,"required":["a"],"properties":{` +
+			`"a":{"type":"object","additionalProperties":false,"required":["a"],"properties":{` +
+			`"a":{"type":"object","additionalProperties":false,"required":["a"],"properties":{` +
+			`"a":{"type":"object","additionalProperties":false,"required":["a"],"properties":{` +
+			`"a":{"type":"object","additionalProperties":false,"required":["a"],"properties":{` +
+			`"a":{"type":"object","additionalProperties":false,"properties":{}}}}}}}}}}}}`,
+			[]string{"parameters.properties.a.properties.a.properties.a.properties.a.properties.a"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			def := openai.FunctionDefinition{Name: "f", Strict: &strict, Parameters: json.RawMessage(tc.schema)}
+			err := openai.ValidateStrictSchema(def)
+			if tc.fields == nil {
+				if err != nil {
+					t.Fatalf("valid schema returned %v", err)
+				}
+				return
+			}
+			var errs openai.ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(tc.fields) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tc.fields), errs)
+			}
+			for i, field := range tc.fields {
+				if errs[i].Field != field {
+					t.Errorf("error %d is for %q, want %q", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,16 @@ package openai
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	utils "github.com/zquestz/go-openai/internal"
 )
@@ -15,12 +19,17 @@ import (
 // Client is OpenAI GPT-3 API client.
 type Client struct {
 	config ClientConfig
+	tokens *tokenCache
 
 	requestBuilder    utils.RequestBuilder
 	createFormBuilder func(io.Writer) utils.FormBuilder
+
+	// chatMiddleware rewrites the requests of CreateChatCompletion, see
+	// NewTokenBudgetMiddleware.
+	chatMiddleware []func(ChatCompletionRequest) ChatCompletionRequest
 }
 
-type Response interface {
+type response interface {
 	SetHeader(http.Header)
 }
 
@@ -38,16 +47,36 @@ func (h *httpHeader) GetRateLimitHeaders() RateLimitHeaders {
 	return newRateLimitHeaders(h.Header())
 }
 
+// RequestID returns the ID the API assigned to the request, from the
+// x-request-id header or Azure's apim-request-id header.
+func (h *httpHeader) RequestID() string {
+	return requestID(h.Header())
+}
+
+func requestID(header http.Header) string {
+	if id := header.Get("x-request-id"); id != "" {
+		return id
+	}
+	return header.Get("apim-request-id")
+}
+
 // NewClient creates new OpenAI API client.
 func NewClient(authToken string) *Client {
 	config := DefaultConfig(authToken)
 	return NewClientWithConfig(config)
 }
 
+// ClientOption customizes the config passed to NewClientWithConfig.
+type ClientOption func(*ClientConfig)
+
 // NewClientWithConfig creates new OpenAI API client for specified config.
-func NewClientWithConfig(config ClientConfig) *Client {
+func NewClientWithConfig(config ClientConfig, opts ...ClientOption) *Client {
+	for _, opt := range opts {
+		opt(&config)
+	}
 	return &Client{
 		config:         config,
+		tokens:         newTokenCache(config.TokenProvider),
 		requestBuilder: utils.NewRequestBuilder(),
 		createFormBuilder: func(body io.Writer) utils.FormBuilder {
 			return utils.NewFormBuilder(body)
@@ -55,6 +84,76 @@ func NewClientWithConfig(config ClientConfig) *Client {
 	}
 }
 
+// WithOptions returns a copy of the client with opts applied to its config,
+// e.g. a shorter timeout for interactive calls. The copy shares the HTTP
+// client, and so the connection pool, unless an option replaces it.
+func (c *Client) WithOptions(opts ...ClientOption) *Client {
+	clone := *c
+	for _, opt := range opts {
+		opt(&clone.config)
+	}
+	if !sameTokenProvider(clone.config.TokenProvider, c.config.TokenProvider) {
+		clone.tokens = newTokenCache(clone.config.TokenProvider)
+	}
+	return &clone
+}
+
+// HTTPDoer sends HTTP requests, like *http.Client and the clients of packages
+// such as hashicorp/go-retryablehttp.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(config *ClientConfig) {
+		config.HTTPClient = httpClient
+	}
+}
+
+// WithHTTPDoer sets the HTTPDoer used to send requests.
+func WithHTTPDoer(doer HTTPDoer) ClientOption {
+	return func(config *ClientConfig) {
+		config.HTTPClient = doer
+	}
+}
+
+// WithTransportWrapper wraps the transport of ClientConfig.HTTPClient with
+// wrap, e.g. to add instrumentation. The HTTP client is copied, so clients
+// sharing it are not affected. An HTTPDoer that is not an *http.Client becomes
+// the transport of a new http.Client. wrap gets a nil transport for clients
+// using http.DefaultTransport.
+func WithTransportWrapper(wrap func(base http.RoundTripper) http.RoundTripper) ClientOption {
+	return func(config *ClientConfig) {
+		httpClient := &http.Client{}
+		switch doer := config.HTTPClient.(type) {
+		case nil:
+		case *http.Client:
+			*httpClient = *doer
+		default:
+			httpClient.Transport = doerTransport{doer}
+		}
+		httpClient.Transport = wrap(httpClient.Transport)
+		config.HTTPClient = httpClient
+	}
+}
+
+// doerTransport sends the requests of an http.Client with an HTTPDoer.
+type doerTransport struct {
+	doer HTTPDoer
+}
+
+func (t doerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.doer.Do(req)
+}
+
+// WithTimeout sets ClientConfig.RequestTimeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(config *ClientConfig) {
+		config.RequestTimeout = timeout
+	}
+}
+
 // NewOrgClient creates new OpenAI API client for specified Organization ID.
 //
 // Deprecated: Please use NewClientWithConfig.
@@ -67,6 +166,8 @@ func NewOrgClient(authToken, org string) *Client {
 type requestOptions struct {
 	body   any
 	header http.Header
+	// model is reported in RequestMeta for bodies without a Model field.
+	model string
 }
 
 type requestOption func(*requestOptions)
@@ -98,15 +199,117 @@ func (c *Client) newRequest(ctx context.Context, method, url string, setters ...
 	for _, setter := range setters {
 		setter(args)
 	}
-	req, err := c.requestBuilder.Build(ctx, method, url, args.body, args.header)
+	ctx = c.withRequestMeta(ctx, url, args)
+	// The backend of the request, which differs from c with WithModelRouting.
+	backend, url := c.route(url, args.requestModel())
+	url, err := backend.withAPIVersion(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	req, err := backend.requestBuilder.Build(ctx, method, url, args.body, args.header)
 	if err != nil {
 		return nil, err
 	}
-	c.setCommonHeaders(req)
+	backend.setCommonHeaders(req)
+	if backend.tokens != nil {
+		token, tokenErr := backend.tokens.get(ctx)
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+		req.Header.Del(AzureAPIKeyHeader)
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	return req, nil
 }
 
-func (c *Client) sendRequest(req *http.Request, v Response) error {
+type requestHeadersKey struct{}
+
+// WithRequestHeaders returns a copy of ctx carrying headers to send with every
+// request made with it, e.g. a tenant ID for a gateway. They override the
+// client's headers of the same name. Calling it again on the returned context
+// adds to the headers instead of replacing them.
+func WithRequestHeaders(ctx context.Context, header http.Header) context.Context {
+	merged := make(http.Header, len(header))
+	for k, v := range header {
+		merged[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+	if parent, ok := ctx.Value(requestHeadersKey{}).(http.Header); ok {
+		for k, v := range parent {
+			if _, exists := merged[k]; !exists {
+				merged[k] = v
+			}
+		}
+	}
+	return context.WithValue(ctx, requestHeadersKey{}, merged)
+}
+
+// WithRequestHeader is WithRequestHeaders for a single header, e.g.
+// WithRequestHeader(ctx, "X-Request-ID", id). It replaces the values of key
+// set by earlier calls.
+func WithRequestHeader(ctx context.Context, key, value string) context.Context {
+	return WithRequestHeaders(ctx, http.Header{key: []string{value}})
+}
+
+type requestAPIVersionKey struct{}
+
+// WithRequestAPIVersion returns a copy of ctx whose requests to Azure are sent
+// with the api-version version instead of ClientConfig.APIVersion, e.g. for a
+// preview feature. It has no effect on other API types, nor if version is
+// empty.
+func WithRequestAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, requestAPIVersionKey{}, version)
+}
+
+// withAPIVersion sets the api-version of the URL of an Azure request made
+// with ctx to the one of WithRequestAPIVersion.
+func (c *Client) withAPIVersion(ctx context.Context, rawURL string) (string, error) {
+	version, _ := ctx.Value(requestAPIVersionKey{}).(string)
+	if version == "" || (c.config.APIType != APITypeAzure && c.config.APIType != APITypeAzureAD) {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("api-version", version)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+type (
+	requestOrganizationKey struct{}
+	requestProjectKey      struct{}
+)
+
+// WithOrganization returns a copy of ctx whose requests are sent for the
+// organization orgID instead of ClientConfig.OrgID, e.g. to serve several
+// organizations with one client and connection pool. An empty orgID sends no
+// OpenAI-Organization header.
+func WithOrganization(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, requestOrganizationKey{}, orgID)
+}
+
+// WithProjectID returns a copy of ctx whose requests are sent for the OpenAI
+// project projectID instead of ClientConfig.ProjectID. An empty projectID
+// sends no OpenAI-Project header.
+func WithProjectID(ctx context.Context, projectID string) context.Context {
+	return context.WithValue(ctx, requestProjectKey{}, projectID)
+}
+
+// setContextHeaders applies the headers of WithRequestHeaders. It runs last,
+// right before the request is sent, so they win over every default.
+func setContextHeaders(req *http.Request) {
+	header, ok := req.Context().Value(requestHeadersKey{}).(http.Header)
+	if !ok {
+		return
+	}
+	for k, v := range header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+}
+
+func (c *Client) sendRequest(req *http.Request, v response) error {
 	req.Header.Set("Accept", "application/json; charset=utf-8")
 
 	// Check whether Content-Type is already set, Upload Files API requires
@@ -116,35 +319,88 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	}
 
-	res, err := c.config.HTTPClient.Do(req)
+	setContextHeaders(req)
+	meta := requestMetaOf(req)
+	meta.Start = time.Now()
+	if hookErr := c.runRequestHooks(req, meta); hookErr != nil {
+		return hookErr
+	}
+
+	req, timer := traceRequest(req, c.config.RequestTimeout)
+	defer timer.release()
+	res, err := c.do(req)
 	if err != nil {
-		return err
+		return c.runResponseHooks(req, nil, meta, timer.wrap(err))
 	}
 
 	defer res.Body.Close()
+	res.Body = c.limitBody(res.Body)
 
 	if isFailureStatusCode(res) {
-		return c.handleErrorResp(res)
+		return c.runResponseHooks(req, res, meta, c.handleErrorResp(res))
 	}
 
 	if v != nil {
 		v.SetHeader(res.Header)
 	}
 
-	return decodeResponse(res.Body, v)
+	var body io.Reader = res.Body
+	var buffered []byte
+	if len(c.config.Hooks) > 0 {
+		// Keep the body so that OnResponse hooks can read it too.
+		buffered, err = io.ReadAll(res.Body)
+		if err != nil {
+			return c.runResponseHooks(req, res, meta, timer.wrap(err))
+		}
+		body = bytes.NewReader(buffered)
+	}
+	recorded := &prefixReader{r: body}
+	err = decodeResponse(recorded, v)
+	if isDecodeFailure(err) {
+		err = newDecodeError(res, recorded.snippet(), err)
+	}
+	err = timer.wrap(err)
+	if buffered != nil {
+		res.Body = io.NopCloser(bytes.NewReader(buffered))
+	}
+	if err = c.runResponseHooks(req, res, meta, err); err != nil {
+		return err
+	}
+	if recorder := c.config.UsageRecorder; recorder != nil {
+		if usage, ok := usageOf(v); ok {
+			recorder.Record(req.Context(), meta.Endpoint, meta.Model, usage)
+		}
+	}
+	return nil
 }
 
 func (c *Client) sendRequestRaw(req *http.Request) (body io.ReadCloser, err error) {
-	resp, err := c.config.HTTPClient.Do(req)
+	setContextHeaders(req)
+	meta := requestMetaOf(req)
+	meta.Start = time.Now()
+	if err = c.runRequestHooks(req, meta); err != nil {
+		return
+	}
+
+	req, timer := traceRequest(req, c.config.RequestTimeout)
+	resp, err := c.do(req)
 	if err != nil {
+		timer.release()
+		err = c.runResponseHooks(req, nil, meta, timer.wrap(err))
 		return
 	}
 
 	if isFailureStatusCode(resp) {
-		err = c.handleErrorResp(resp)
+		defer timer.release()
+		err = c.runResponseHooks(req, resp, meta, c.handleErrorResp(resp))
+		return
+	}
+	if err = c.runResponseHooks(req, resp, meta, nil); err != nil {
+		resp.Body.Close()
+		timer.release()
 		return
 	}
-	return resp.Body, nil
+	return releaseOnClose{ReadCloser: resp.Body, timer: timer}, nil
 }
 
 func sendRequestStream[T streamable](client *Client, req *http.Request) (*streamReader[T], error) {
@@ -153,14 +409,43 @@ func sendRequestStream[T streamable](client *Client, req *http.Request) (*stream
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	resp, err := client.config.HTTPClient.Do(req) //nolint:bodyclose // body is closed in stream.Close()
+	setContextHeaders(req)
+	meta := requestMetaOf(req)
+	meta.Stream = true
+	meta.Start = time.Now()
+	ctx := req.Context()
+	if hookErr := client.runRequestHooks(req, meta); hookErr != nil {
+		return new(streamReader[T]), hookErr
+	}
+
+	req, timer := traceRequest(req, client.config.RequestTimeout)
+	timer.awaitFirstChunk(client.config.FirstTokenTimeout)
+	resp, err := client.do(req) //nolint:bodyclose // body is closed in stream.Close()
 	if err != nil {
-		return new(streamReader[T]), err
+		timer.release()
+		return new(streamReader[T]), client.runResponseHooks(req, nil, meta, timer.wrap(err))
 	}
 	if isFailureStatusCode(resp) {
-		return new(streamReader[T]), client.handleErrorResp(resp)
+		defer timer.release()
+		return new(streamReader[T]), client.runResponseHooks(req, resp, meta, client.handleErrorResp(resp))
+	}
+	if err = client.runResponseHooks(req, resp, meta, nil); err != nil {
+		resp.Body.Close()
+		timer.release()
+		return new(streamReader[T]), err
+	}
+	timer.startStream(client.config.StreamIdleTimeout)
+
+	var onClose func(StreamStats)
+	if len(client.config.Hooks) > 0 {
+		onClose = func(stats StreamStats) {
+			client.runStreamCloseHooks(ctx, meta, stats)
+		}
 	}
 	return &streamReader[T]{
+		timer:              timer,
+		start:              meta.Start,
+		onClose:            onClose,
 		emptyMessagesLimit: client.config.EmptyMessagesLimit,
 		reader:             bufio.NewReader(resp.Body),
 		response:           resp,
@@ -179,8 +464,23 @@ func (c *Client) setCommonHeaders(req *http.Request) {
 		// OpenAI or Azure AD authentication
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.authToken))
 	}
-	if c.config.OrgID != "" {
-		req.Header.Set("OpenAI-Organization", c.config.OrgID)
+	orgID := c.config.OrgID
+	if id, ok := req.Context().Value(requestOrganizationKey{}).(string); ok {
+		orgID = id
+	}
+	if orgID != "" {
+		req.Header.Set("OpenAI-Organization", orgID)
+	}
+	// Projects only exist on the OpenAI platform.
+	projectID := c.config.ProjectID
+	if id, ok := req.Context().Value(requestProjectKey{}).(string); ok {
+		projectID = id
+	}
+	if projectID != "" && c.config.APIType != APITypeAzure && c.config.APIType != APITypeAzureAD {
+		req.Header.Set("OpenAI-Project", projectID)
+	}
+	if !c.config.DisableCompression {
+		req.Header.Set("Accept-Encoding", "gzip")
 	}
 }
 
@@ -196,9 +496,17 @@ func decodeResponse(body io.Reader, v any) error {
 	if result, ok := v.(*string); ok {
 		return decodeString(body, result)
 	}
+	if result, ok := v.(bodyReader); ok {
+		return result.readBody(body)
+	}
 	return json.NewDecoder(body).Decode(v)
 }
 
+// bodyReader is implemented by the responses decoding their body themselves.
+type bodyReader interface {
+	readBody(body io.Reader) error
+}
+
 func decodeString(body io.Reader, output *string) error {
 	b, err := io.ReadAll(body)
 	if err != nil {
@@ -211,6 +519,12 @@ func decodeString(body io.Reader, output *string) error {
 // fullURL returns full URL for request.
 // args[0] is model name, if API type is Azure, model name is required to get deployment name.
 func (c *Client) fullURL(suffix string, args ...any) string {
+	return addQueryParams(c.endpointURL(suffix, args...), c.config.QueryParams)
+}
+
+// endpointURL returns the URL of the endpoint suffix, for the model args[0] if
+// the API has deployments, before adding ClientConfig.QueryParams.
+func (c *Client) endpointURL(suffix string, args ...any) string {
 	// /openai/deployments/{model}/chat/completions?api-version={api_version}
 	if c.config.APIType == APITypeAzure || c.config.APIType == APITypeAzureAD {
 		baseURL := c.config.BaseURL
@@ -218,7 +532,7 @@ func (c *Client) fullURL(suffix string, args ...any) string {
 		// if suffix is /models change to {endpoint}/openai/models?api-version=2022-12-01
 		// https://learn.microsoft.com/en-us/rest/api/cognitiveservices/azureopenaistable/models/list?tabs=HTTP
 		if strings.Contains(suffix, "/models") {
-			return fmt.Sprintf("%s/%s%s?api-version=%s", baseURL, azureAPIPrefix, suffix, c.config.APIVersion)
+			return fmt.Sprintf("%s/%s%s?api-version=%s", baseURL, azureAPIPrefix, suffix, c.apiVersion())
 		}
 		azureDeploymentName := "UNKNOWN"
 		if len(args) > 0 {
@@ -229,7 +543,7 @@ func (c *Client) fullURL(suffix string, args ...any) string {
 		}
 		return fmt.Sprintf("%s/%s/%s/%s%s?api-version=%s",
 			baseURL, azureAPIPrefix, azureDeploymentsPrefix,
-			azureDeploymentName, suffix, c.config.APIVersion,
+			azureDeploymentName, suffix, c.apiVersion(),
 		)
 	}
 
@@ -237,20 +551,95 @@ func (c *Client) fullURL(suffix string, args ...any) string {
 	return fmt.Sprintf("%s%s", c.config.BaseURL, suffix)
 }
 
+// apiVersion returns the api-version of Azure requests, which Azure requires,
+// so configs without one use the default of DefaultAzureConfig.
+func (c *Client) apiVersion() string {
+	if c.config.APIVersion == "" {
+		return defaultAzureAPIVersion
+	}
+	return c.config.APIVersion
+}
+
+// addQueryParams appends params to the query of rawURL, except the keys it
+// already has, so that the client's parameters such as api-version are kept.
+func addQueryParams(rawURL string, params url.Values) string {
+	if len(params) == 0 {
+		return rawURL
+	}
+	_, query, hasQuery := strings.Cut(rawURL, "?")
+	existing, _ := url.ParseQuery(query)
+	added := make(url.Values, len(params))
+	for key, values := range params {
+		if !existing.Has(key) {
+			added[key] = values
+		}
+	}
+	if len(added) == 0 {
+		return rawURL
+	}
+	switch {
+	case !hasQuery:
+		rawURL += "?"
+	case query != "":
+		rawURL += "&"
+	}
+	return rawURL + added.Encode()
+}
+
 func (c *Client) handleErrorResp(resp *http.Response) error {
+	id := requestID(resp.Header)
+	body, err := io.ReadAll(c.limitBody(resp.Body))
+	if err != nil {
+		reqErr := &RequestError{APIError: APIError{HTTPStatusCode: resp.StatusCode}, Err: err}
+		reqErr.RequestID = id
+		reqErr.setRequest(resp.Request)
+		return reqErr
+	}
+	// Let OnResponse hooks read the body as well.
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
 	var errRes ErrorResponse
-	err := json.NewDecoder(resp.Body).Decode(&errRes)
+	err = json.Unmarshal(body, &errRes)
 	if err != nil || errRes.Error == nil {
-		reqErr := &RequestError{
-			HTTPStatusCode: resp.StatusCode,
-			Err:            err,
-		}
+		reqErr := &RequestError{Err: err}
 		if errRes.Error != nil {
 			reqErr.Err = errRes.Error
+			reqErr.APIError = *errRes.Error
+		} else if apiErr := decodeBareAPIError(body); apiErr != nil {
+			reqErr.Err = apiErr
+			reqErr.APIError = *apiErr
+		} else {
+			// Keep what proxies sent instead, e.g. an HTML page.
+			reqErr.ContentType = resp.Header.Get("Content-Type")
+			reqErr.Body = bodySnippet(body)
+			reqErr.hasBody = true
+			// The error of decoding the body would hide the status.
+			status := http.StatusText(resp.StatusCode)
+			if status == "" {
+				status = "unexpected status"
+			}
+			reqErr.Err = errors.New(status)
 		}
+		reqErr.APIError.HTTPStatusCode = resp.StatusCode
+		reqErr.RequestID = id
+		reqErr.RetryAfter, _ = retryAfter(resp.Header)
+		reqErr.setRequest(resp.Request)
 		return reqErr
 	}
 
 	errRes.Error.HTTPStatusCode = resp.StatusCode
+	errRes.Error.RequestID = id
+	errRes.Error.RetryAfter, _ = retryAfter(resp.Header)
+	errRes.Error.setRequest(resp.Request)
 	return errRes.Error
 }
+
+// decodeBareAPIError decodes an error object sent without the {"error": ...}
+// envelope, as some proxies do. It returns nil if body is not such an object.
+func decodeBareAPIError(body []byte) *APIError {
+	var apiErr APIError
+	if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+		return nil
+	}
+	return &apiErr
+}
@@ -1,10 +1,113 @@
 package openai
 
+import "encoding/json"
+
 // common.go defines common types used throughout the OpenAI API.
 
 // Usage Represents the total token usage per request to OpenAI.
+//
+// It also decodes the usage of the responses API, and of compatible servers
+// sending it in chat completions, which name the token counts input_tokens
+// and output_tokens.
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// PromptTokensDetails and CompletionTokensDetails break the token counts
+	// down further. They are nil if the API does not send them.
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails counts the prompt tokens billed at other rates.
+type PromptTokensDetails struct {
+	// CachedTokens were read from the prompt cache.
+	CachedTokens int `json:"cached_tokens"`
+	AudioTokens  int `json:"audio_tokens"`
+}
+
+// CompletionTokensDetails counts the completion tokens billed at other rates,
+// or not part of the answer.
+type CompletionTokensDetails struct {
+	AudioTokens int `json:"audio_tokens"`
+	// ReasoningTokens are the hidden tokens reasoning models, such as o1,
+	// generate before answering. They are billed as completion tokens.
+	ReasoningTokens int `json:"reasoning_tokens"`
+	// AcceptedPredictionTokens and RejectedPredictionTokens are the tokens of
+	// a predicted output that did and did not appear in the completion.
+	// Rejected tokens are billed as completion tokens too.
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens"`
+}
+
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	type usage Usage
+	var decoded struct {
+		usage
+		InputTokens         int                      `json:"input_tokens"`
+		OutputTokens        int                      `json:"output_tokens"`
+		InputTokensDetails  *PromptTokensDetails     `json:"input_tokens_details"`
+		OutputTokensDetails *CompletionTokensDetails `json:"output_tokens_details"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*u = Usage(decoded.usage)
+	if u.PromptTokens == 0 {
+		u.PromptTokens = decoded.InputTokens
+	}
+	if u.CompletionTokens == 0 {
+		u.CompletionTokens = decoded.OutputTokens
+	}
+	if u.PromptTokensDetails == nil {
+		u.PromptTokensDetails = decoded.InputTokensDetails
+	}
+	if u.CompletionTokensDetails == nil {
+		u.CompletionTokensDetails = decoded.OutputTokensDetails
+	}
+	return nil
+}
+
+// Add returns the sum of u and other, e.g. to total the usage of several
+// calls. The token details are summed too, and are nil if neither has them.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:            u.PromptTokens + other.PromptTokens,
+		CompletionTokens:        u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:             u.TotalTokens + other.TotalTokens,
+		PromptTokensDetails:     u.PromptTokensDetails.add(other.PromptTokensDetails),
+		CompletionTokensDetails: u.CompletionTokensDetails.add(other.CompletionTokensDetails),
+	}
+}
+
+func (d *PromptTokensDetails) add(other *PromptTokensDetails) *PromptTokensDetails {
+	if d == nil && other == nil {
+		return nil
+	}
+	var sum PromptTokensDetails
+	if d != nil {
+		sum = *d
+	}
+	if other != nil {
+		sum.CachedTokens += other.CachedTokens
+		sum.AudioTokens += other.AudioTokens
+	}
+	return &sum
+}
+
+func (d *CompletionTokensDetails) add(other *CompletionTokensDetails) *CompletionTokensDetails {
+	if d == nil && other == nil {
+		return nil
+	}
+	var sum CompletionTokensDetails
+	if d != nil {
+		sum = *d
+	}
+	if other != nil {
+		sum.AudioTokens += other.AudioTokens
+		sum.ReasoningTokens += other.ReasoningTokens
+		sum.AcceptedPredictionTokens += other.AcceptedPredictionTokens
+		sum.RejectedPredictionTokens += other.RejectedPredictionTokens
+	}
+	return &sum
 }
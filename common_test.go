@@ -0,0 +1,152 @@
+package openai_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// o1ChatCompletion is a chat completion of o1-preview, whose reasoning tokens
+// make up most of the completion tokens.
+const o1ChatCompletion = `{
+  "id": "chatcmpl-AD9kzJmmDMhhnIRCsdoE1qshSiSGq",
+  "object": "chat.completion",
+  "created": 1727554187,
+  "model": "o1-preview-2024-09-12",
+  "choices": [
+    {
+      "index": 0,
+      "message": {
+        "role": "assistant",
+        "content": "The answer is 42.",
+        "refusal": null
+      },
+      "finish_reason": "stop"
+    }
+  ],
+  "usage": {
+    "prompt_tokens": 24,
+    "completion_tokens": 1843,
+    "total_tokens": 1867,
+    "prompt_tokens_details": {
+      "cached_tokens": 0
+    },
+    "completion_tokens_details": {
+      "reasoning_tokens": 1792,
+      "accepted_prediction_tokens": 0,
+      "rejected_prediction_tokens": 0
+    }
+  },
+  "system_fingerprint": "fp_9b7441b27b"
+}`
+
+func TestUsageUnmarshalJSON(t *testing.T) {
+	var response openai.ChatCompletionResponse
+	checks.NoError(t, json.Unmarshal([]byte(o1ChatCompletion), &response), "Unmarshal error")
+	want := openai.Usage{
+		PromptTokens:            24,
+		CompletionTokens:        1843,
+		TotalTokens:             1867,
+		PromptTokensDetails:     &openai.PromptTokensDetails{},
+		CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: 1792},
+	}
+	if !reflect.DeepEqual(response.Usage, want) {
+		t.Errorf("Usage = %+v, want %+v", response.Usage, want)
+	}
+
+	testCases := []struct {
+		name string
+		data string
+		want openai.Usage
+	}{
+		{
+			name: "predicted outputs",
+			data: `{"prompt_tokens":120,"completion_tokens":80,"total_tokens":200,"completion_tokens_details":` +
+				`{"audio_tokens":0,"accepted_prediction_tokens":50,"rejected_prediction_tokens":12}}`,
+			want: openai.Usage{
+				PromptTokens:     120,
+				CompletionTokens: 80,
+				TotalTokens:      200,
+				CompletionTokensDetails: &openai.CompletionTokensDetails{
+					AcceptedPredictionTokens: 50,
+					RejectedPredictionTokens: 12,
+				},
+			},
+		},
+		{
+			name: "responses style",
+			data: `{"input_tokens":36,"input_tokens_details":{"cached_tokens":12},"output_tokens":1186,` +
+				`"output_tokens_details":{"reasoning_tokens":1024},"total_tokens":1222}`,
+			want: openai.Usage{
+				PromptTokens:            36,
+				CompletionTokens:        1186,
+				TotalTokens:             1222,
+				PromptTokensDetails:     &openai.PromptTokensDetails{CachedTokens: 12},
+				CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: 1024},
+			},
+		},
+		{
+			name: "without details",
+			data: `{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}`,
+			want: openai.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var usage openai.Usage
+			checks.NoError(t, json.Unmarshal([]byte(tc.data), &usage), "Unmarshal error")
+			if !reflect.DeepEqual(usage, tc.want) {
+				t.Errorf("Usage = %+v, want %+v", usage, tc.want)
+			}
+		})
+	}
+}
+
+func TestUsageAdd(t *testing.T) {
+	without := openai.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12}
+	if got := without.Add(without); !reflect.DeepEqual(got, openai.Usage{
+		PromptTokens: 10, CompletionTokens: 14, TotalTokens: 24,
+	}) {
+		t.Errorf("Add without details = %+v", got)
+	}
+
+	with := openai.Usage{
+		PromptTokens:            100,
+		CompletionTokens:        50,
+		TotalTokens:             150,
+		PromptTokensDetails:     &openai.PromptTokensDetails{CachedTokens: 64},
+		CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: 32},
+	}
+	want := openai.Usage{
+		PromptTokens:            205,
+		CompletionTokens:        107,
+		TotalTokens:             312,
+		PromptTokensDetails:     &openai.PromptTokensDetails{CachedTokens: 128},
+		CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: 64},
+	}
+	got := with.Add(without).Add(with)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Add with details = %+v, want %+v", got, want)
+	}
+	if got.PromptTokensDetails == with.PromptTokensDetails {
+		t.Error("Add shares the details of its operands")
+	}
+	if with.PromptTokensDetails.CachedTokens != 64 {
+		t.Errorf("Add modified its operand: %+v", with.PromptTokensDetails)
+	}
+}
+
+func TestResponseUsageDetails(t *testing.T) {
+	var usage openai.ResponseUsage
+	err := json.Unmarshal([]byte(`{"input_tokens":36,"input_tokens_details":{"cached_tokens":0},`+
+		`"output_tokens":1186,"output_tokens_details":{"reasoning_tokens":1024},"total_tokens":1222}`), &usage)
+	checks.NoError(t, err, "Unmarshal error")
+	if usage.OutputTokensDetails == nil || usage.OutputTokensDetails.ReasoningTokens != 1024 ||
+		usage.InputTokensDetails == nil || usage.TotalTokens != 1222 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
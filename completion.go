@@ -3,13 +3,19 @@ package openai
 import (
 	"context"
 	"errors"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 )
 
 var (
-	ErrCompletionUnsupportedModel              = errors.New("this model is not supported with this method, please use CreateChatCompletion client method instead") //nolint:lll
+	ErrCompletionInvalidModel                  = errors.New("this model is not supported with this method, please use CreateChatCompletion client method instead") //nolint:lll
 	ErrCompletionStreamNotSupported            = errors.New("streaming is not supported with this method, please use CreateCompletionStream")                      //nolint:lll
 	ErrCompletionRequestPromptTypeNotSupported = errors.New("the type of CompletionRequest.Prompt only supports string and []string")                              //nolint:lll
+
+	// Deprecated: use ErrCompletionInvalidModel, which it is equal to.
+	ErrCompletionUnsupportedModel = ErrCompletionInvalidModel
 )
 
 // GPT3 Defines the models provided by OpenAI to use when generating
@@ -67,44 +73,143 @@ const (
 	CodexCodeDavinci001 = "code-davinci-001"
 )
 
-var disabledModelsForEndpoints = map[string]map[string]bool{
-	"/completions": {
-		GPT3Dot5Turbo:        true,
-		GPT3Dot5Turbo0301:    true,
-		GPT3Dot5Turbo0613:    true,
-		GPT3Dot5Turbo1106:    true,
-		GPT3Dot5Turbo16K:     true,
-		GPT3Dot5Turbo16K0613: true,
-		GPT4:                 true,
-		GPT4TurboPreview:     true,
-		GPT4VisionPreview:    true,
-		GPT40314:             true,
-		GPT40613:             true,
-		GPT432K:              true,
-		GPT432K0314:          true,
-		GPT432K0613:          true,
-	},
-	chatCompletionsSuffix: {
-		CodexCodeDavinci002:     true,
-		CodexCodeCushman001:     true,
-		CodexCodeDavinci001:     true,
-		GPT3TextDavinci003:      true,
-		GPT3TextDavinci002:      true,
-		GPT3TextCurie001:        true,
-		GPT3TextBabbage001:      true,
-		GPT3TextAda001:          true,
-		GPT3TextDavinci001:      true,
-		GPT3DavinciInstructBeta: true,
-		GPT3Davinci:             true,
-		GPT3CurieInstructBeta:   true,
-		GPT3Curie:               true,
-		GPT3Ada:                 true,
-		GPT3Babbage:             true,
-	},
+const completionsSuffix = "/completions"
+
+// completionModels are the models of the legacy completions endpoint, with
+// the Azure spelling of gpt-3.5-turbo-instruct. Others, such as gpt-4 and
+// gpt-3.5-turbo, only support chat completions.
+var completionModels = map[string]bool{
+	GPT3Dot5TurboInstruct:         true,
+	"gpt-3.5-turbo-instruct-0914": true,
+	"gpt-35-turbo-instruct":       true,
+	GPT3Davinci002:                true,
+	GPT3Babbage002:                true,
+	GPT3TextDavinci003:            true,
+	GPT3TextDavinci002:            true,
+	GPT3TextCurie001:              true,
+	GPT3TextBabbage001:            true,
+	GPT3TextAda001:                true,
+	GPT3TextDavinci001:            true,
+	GPT3DavinciInstructBeta:       true,
+	GPT3Davinci:                   true,
+	GPT3CurieInstructBeta:         true,
+	GPT3Curie:                     true,
+	GPT3Curie002:                  true,
+	GPT3Ada:                       true,
+	GPT3Ada002:                    true,
+	GPT3Babbage:                   true,
+	CodexCodeDavinci002:           true,
+	CodexCodeCushman001:           true,
+	CodexCodeDavinci001:           true,
+}
+
+// chatCompletionDisabledModels are the models of the legacy completions
+// endpoint that do not support chat completions.
+var chatCompletionDisabledModels = map[string]bool{
+	CodexCodeDavinci002:     true,
+	CodexCodeCushman001:     true,
+	CodexCodeDavinci001:     true,
+	GPT3TextDavinci003:      true,
+	GPT3TextDavinci002:      true,
+	GPT3TextCurie001:        true,
+	GPT3TextBabbage001:      true,
+	GPT3TextAda001:          true,
+	GPT3TextDavinci001:      true,
+	GPT3DavinciInstructBeta: true,
+	GPT3Davinci:             true,
+	GPT3CurieInstructBeta:   true,
+	GPT3Curie:               true,
+	GPT3Ada:                 true,
+	GPT3Babbage:             true,
+}
+
+// chatModelPrefixes start the names of the families of models that only
+// support chat completions, dated snapshots and fine-tunes included.
+var chatModelPrefixes = []string{"gpt-4", "gpt-3.5-turbo", "gpt-35-turbo", "chatgpt-", "o1", "o3", "o4"}
+
+// checkEndpointSupportsModel reports whether model can be used with endpoint,
+// and whether the model is known to the client at all, as opposed to the
+// models of fine-tunes, gateways or newer launches.
+func checkEndpointSupportsModel(endpoint, model string) (supported, known bool) {
+	if model == "" {
+		// Left to the API to reject.
+		return true, true
+	}
+	// Fine-tuned models are named ft:davinci-002:org::id, or
+	// davinci:ft-org-2023-01-01 for legacy fine-tunes.
+	base, _, _ := strings.Cut(strings.TrimPrefix(model, "ft:"), ":")
+	isChatModel := !completionModels[base] && slices.ContainsFunc(chatModelPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(base, prefix)
+	})
+	switch endpoint {
+	case completionsSuffix:
+		return completionModels[base], completionModels[base] || isChatModel
+	case chatCompletionsSuffix:
+		if chatCompletionDisabledModels[model] {
+			return false, true
+		}
+		return isChatModel, isChatModel
+	}
+	return true, true
 }
 
-func checkEndpointSupportsModel(endpoint, model string) bool {
-	return !disabledModelsForEndpoints[endpoint][model]
+// supportsModel reports whether model can be used with endpoint. Local servers
+// such as Ollama use their own model names, so they are not checked.
+func (c *Client) supportsModel(endpoint, model string) bool {
+	if c.config.APIType == APITypeOllama || c.config.DisableModelValidation {
+		return true
+	}
+	if c.config.ModelSupport != nil {
+		return c.config.ModelSupport(endpoint, model)
+	}
+	if endpoints, ok := c.config.ModelEndpoints[model]; ok {
+		return slices.Contains(endpoints, endpoint)
+	}
+	supported, known := checkEndpointSupportsModel(endpoint, model)
+	return supported || (!known && !c.config.StrictModelValidation)
+}
+
+// WithModelValidation sets whether the client rejects models that do not
+// support the method called, e.g. gpt-4 with CreateCompletion, before sending
+// the request. It is enabled by default, and lets models the client does not
+// know through unless WithStrictModelValidation is enabled.
+func WithModelValidation(enabled bool) ClientOption {
+	return func(config *ClientConfig) {
+		config.DisableModelValidation = !enabled
+	}
+}
+
+// WithStrictModelValidation sets whether model validation also rejects the
+// models the client does not know, such as newly launched ones, unless they
+// are registered with WithModelEndpoints. It is disabled by default.
+func WithStrictModelValidation(enabled bool) ClientOption {
+	return func(config *ClientConfig) {
+		config.StrictModelValidation = enabled
+	}
+}
+
+// WithModelEndpoints makes model validation accept model on endpoints only,
+// such as "/chat/completions" or "/completions", whether the client knows the
+// model or not. It adds to ClientConfig.ModelEndpoints.
+func WithModelEndpoints(model string, endpoints ...string) ClientOption {
+	return func(config *ClientConfig) {
+		// Copy the map, which may be shared with other configs.
+		models := maps.Clone(config.ModelEndpoints)
+		if models == nil {
+			models = make(map[string][]string)
+		}
+		models[model] = slices.Clone(endpoints)
+		config.ModelEndpoints = models
+	}
+}
+
+// WithModelSupport sets ClientConfig.ModelSupport, replacing the model
+// validation of the client with supports, which reports whether model can be
+// used with endpoint.
+func WithModelSupport(supports func(endpoint, model string) bool) ClientOption {
+	return func(config *ClientConfig) {
+		config.ModelSupport = supports
+	}
 }
 
 func checkPromptType(prompt any) bool {
@@ -134,6 +239,16 @@ type CompletionRequest struct {
 	// refs: https://platform.openai.com/docs/api-reference/completions/create#completions/create-logit_bias
 	LogitBias map[string]int `json:"logit_bias,omitempty"`
 	User      string         `json:"user,omitempty"`
+
+	// ExtraFields are added to the JSON body, for the parameters of
+	// OpenAI-compatible providers that the request has no field for. See
+	// ChatCompletionRequest.ExtraFields.
+	ExtraFields map[string]any `json:"-"`
+}
+
+func (r CompletionRequest) MarshalJSON() ([]byte, error) {
+	type completionRequest CompletionRequest
+	return marshalWithExtraFields(completionRequest(r), r.ExtraFields)
 }
 
 // CompletionChoice represents one of possible completions.
@@ -178,9 +293,9 @@ func (c *Client) CreateCompletion(
 		return
 	}
 
-	urlSuffix := "/completions"
-	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
-		err = ErrCompletionUnsupportedModel
+	urlSuffix := completionsSuffix
+	if !c.supportsModel(urlSuffix, request.Model) {
+		err = ErrCompletionInvalidModel
 		return
 	}
 
@@ -33,6 +33,108 @@ func TestCompletionsWrongModel(t *testing.T) {
 	}
 }
 
+func TestCompletionsModelAllowlist(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/completions", handleCompletionEndpoint)
+	ctx := context.Background()
+
+	for model, allowed := range map[string]bool{
+		openai.GPT3Dot5TurboInstruct:           true,
+		openai.GPT3Davinci002:                  true,
+		openai.GPT3TextDavinci003:              true,
+		"gpt-35-turbo-instruct":                true,
+		"ft:davinci-002:acme::8Ab1cD2e":        true,
+		"curie:ft-acme-2023-01-01-00-00-00":    true,
+		openai.GPT4:                            false,
+		openai.GPT4TurboPreview:                false,
+		"gpt-4o":                               false,
+		openai.GPT3Dot5Turbo:                   false,
+		"ft:gpt-3.5-turbo-0613:acme::8Ab1cD2e": false,
+	} {
+		request := openai.CompletionRequest{Model: model, Prompt: "Lorem ipsum"}
+		_, err := client.CreateCompletion(ctx, request)
+		if errors.Is(err, openai.ErrCompletionInvalidModel) == allowed {
+			t.Errorf("%s: got error %v, allowed %t", model, err, allowed)
+		}
+		stream, err := client.CreateCompletionStream(ctx, request)
+		if errors.Is(err, openai.ErrCompletionInvalidModel) == allowed {
+			t.Errorf("%s: got stream error %v, allowed %t", model, err, allowed)
+		}
+		if err == nil {
+			stream.Close()
+		}
+	}
+
+	// Custom deployments can use any name once validation is disabled.
+	_, err := client.WithOptions(openai.WithModelValidation(false)).CreateCompletion(ctx, openai.CompletionRequest{
+		Model:  "my-gpt-4-deployment",
+		Prompt: "Lorem ipsum",
+	})
+	checks.NoError(t, err, "CreateCompletion error without model validation")
+	_, err = client.WithOptions(openai.WithModelValidation(false)).CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT3TextDavinci003,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	if errors.Is(err, openai.ErrChatCompletionInvalidModel) {
+		t.Errorf("CreateChatCompletion validated the model: %v", err)
+	}
+}
+
+func TestUnknownModelValidation(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var sent []string
+	server.RegisterHandler("/v1/completions", func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.URL.Path)
+		handleCompletionEndpoint(w, r)
+	})
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.URL.Path)
+		handleChatCompletionEndpoint(w, r)
+	})
+	ctx := context.Background()
+	const model = "my-gateway/gpt-5-preview"
+	call := func(client *openai.Client) (completionErr, chatErr error) {
+		_, completionErr = client.CreateCompletion(ctx, openai.CompletionRequest{Model: model, Prompt: "Lorem ipsum"})
+		_, chatErr = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:    model,
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		})
+		return completionErr, chatErr
+	}
+
+	completionErr, chatErr := call(client)
+	checks.NoError(t, completionErr, "CreateCompletion rejected an unknown model")
+	checks.NoError(t, chatErr, "CreateChatCompletion rejected an unknown model")
+	if len(sent) != 2 {
+		t.Fatalf("sent %v, want both requests", sent)
+	}
+
+	sent = nil
+	completionErr, chatErr = call(client.WithOptions(openai.WithStrictModelValidation(true)))
+	checks.ErrorIs(t, completionErr, openai.ErrCompletionInvalidModel, "strict CreateCompletion")
+	checks.ErrorIs(t, chatErr, openai.ErrChatCompletionInvalidModel, "strict CreateChatCompletion")
+	if len(sent) != 0 {
+		t.Errorf("strict validation sent %v", sent)
+	}
+
+	completionErr, chatErr = call(client.WithOptions(openai.WithStrictModelValidation(true),
+		openai.WithModelEndpoints(model, "/chat/completions")))
+	checks.ErrorIs(t, completionErr, openai.ErrCompletionInvalidModel, "CreateCompletion of a chat model")
+	checks.NoError(t, chatErr, "CreateChatCompletion rejected a registered model")
+
+	// The predicate replaces what the client knows of models.
+	completionErr, chatErr = call(client.WithOptions(openai.WithModelSupport(func(endpoint, _ string) bool {
+		return endpoint == "/completions"
+	})))
+	checks.NoError(t, completionErr, "CreateCompletion rejected by ModelSupport")
+	checks.ErrorIs(t, chatErr, openai.ErrChatCompletionInvalidModel, "CreateChatCompletion allowed by ModelSupport")
+	_, err := client.WithOptions(openai.WithModelSupport(func(string, string) bool { return true })).
+		CreateCompletion(ctx, openai.CompletionRequest{Model: openai.GPT4, Prompt: "Lorem ipsum"})
+	checks.NoError(t, err, "CreateCompletion rejected a model allowed by ModelSupport")
+}
+
 func TestCompletionWithStream(t *testing.T) {
 	config := openai.DefaultConfig("whatever")
 	client := openai.NewClientWithConfig(config)
@@ -0,0 +1,58 @@
+package openai
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WithCompression sets whether the client asks for gzip-compressed responses
+// with an Accept-Encoding header and decompresses them itself, which also works
+// with transports that do not decompress responses, such as an http.Transport
+// with DisableCompression set. It is enabled by default. Disabled, the client
+// leaves compression to the transport.
+func WithCompression(enabled bool) ClientOption {
+	return func(config *ClientConfig) {
+		config.DisableCompression = !enabled
+	}
+}
+
+// DecompressResponse replaces the body of a gzip-encoded response with its
+// decompressed content, like http.Transport does for the gzip requests it
+// sends. As clients ask for gzip themselves, transports passed to
+// WithTransportWrapper that read response bodies must call it first. It does
+// nothing for responses that are not compressed, or already decompressed.
+func DecompressResponse(res *http.Response) {
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+	res.Body = &gzipBody{body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// gzipBody decompresses a response body. It reads the gzip header on the first
+// Read rather than when the response arrives, which would block streams until
+// their first event.
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+	err    error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,186 @@
+package openai_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// countingWriter counts the bytes written to the connection.
+type countingWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return w.ResponseWriter.Write(p)
+}
+
+func (w countingWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// gzipWriter compresses what handlers write, flushing with them.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w gzipWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+func (w gzipWriter) Flush() {
+	_ = w.gz.Flush()
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// newGzipTestServer serves handler, compressing the responses of requests
+// accepting gzip, and counts the bytes sent in wireBytes.
+func newGzipTestServer(t testing.TB, handler http.HandlerFunc) (ts *httptest.Server, wireBytes *atomic.Int64) {
+	wireBytes = new(atomic.Int64)
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w = countingWriter{ResponseWriter: w, n: wireBytes}
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			handler(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		handler(gzipWriter{ResponseWriter: w, gz: gz}, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, wireBytes
+}
+
+// newGzipTestClient returns a client of ts whose transport does not decompress
+// responses itself.
+func newGzipTestClient(ts *httptest.Server, opts ...openai.ClientOption) *openai.Client {
+	config := openai.DefaultConfig("whatever")
+	config.BaseURL = ts.URL + "/v1"
+	config.HTTPClient = &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	return openai.NewClientWithConfig(config, opts...)
+}
+
+func TestGzipResponses(t *testing.T) {
+	ts, _ := newGzipTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.Contains(string(body), `"stream":true`):
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, content := range []string{"Hello", " world"} {
+				fmt.Fprintf(w, `data: {"id":"1","choices":[{"index":0,"delta":{"content":%q}}]}`+"\n\n", content)
+				w.(http.Flusher).Flush()
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		case strings.Contains(string(body), "fail"):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Invalid request","type":"invalid_request_error"}}`)
+		default:
+			fmt.Fprint(w, `{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+		}
+	})
+	client := newGzipTestClient(ts)
+	ctx := context.Background()
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	response, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if response.Choices[0].Message.Content != "Hi" {
+		t.Errorf("got response %+v", response)
+	}
+
+	stream, err := client.CreateChatCompletionStream(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	var content string
+	for {
+		chunk, recvErr := stream.Recv()
+		if errors.Is(recvErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, recvErr, "Recv error")
+		content += chunk.Choices[0].Delta.Content
+	}
+	if content != "Hello world" {
+		t.Errorf("got streamed content %q", content)
+	}
+
+	request.Messages[0].Content = "fail"
+	_, err = client.CreateChatCompletion(ctx, request)
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Invalid request" {
+		t.Errorf("got error %v, want the decompressed API error", err)
+	}
+}
+
+func TestWithCompressionDisabled(t *testing.T) {
+	var acceptEncoding string
+	ts, _ := newGzipTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		fmt.Fprint(w, `{"object":"list","data":[]}`)
+	})
+	_, err := newGzipTestClient(ts, openai.WithCompression(false)).ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+	if acceptEncoding != "" {
+		t.Errorf("got Accept-Encoding %q, want none", acceptEncoding)
+	}
+}
+
+// BenchmarkEmbeddingsCompression compares the bytes sent for 2048 embeddings
+// with and without gzip.
+func BenchmarkEmbeddingsCompression(b *testing.B) {
+	const inputs, dimensions = 2048, 256
+	random := rand.New(rand.NewSource(1))
+	var response strings.Builder
+	response.WriteString(`{"object":"list","model":"text-embedding-ada-002","data":[`)
+	for i := 0; i < inputs; i++ {
+		if i > 0 {
+			response.WriteString(",")
+		}
+		fmt.Fprintf(&response, `{"object":"embedding","index":%d,"embedding":[`, i)
+		for j := 0; j < dimensions; j++ {
+			if j > 0 {
+				response.WriteString(",")
+			}
+			fmt.Fprintf(&response, "%.9f", random.Float32()-0.5)
+		}
+		response.WriteString("]}")
+	}
+	response.WriteString(`],"usage":{"prompt_tokens":2048,"total_tokens":2048}}`)
+	body := response.String()
+	request := openai.EmbeddingRequest{Input: make([]string, inputs), Model: openai.AdaEmbeddingV2}
+
+	for _, compression := range []bool{true, false} {
+		b.Run(fmt.Sprintf("gzip=%t", compression), func(b *testing.B) {
+			ts, wireBytes := newGzipTestServer(b, func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, body)
+			})
+			client := newGzipTestClient(ts, openai.WithCompression(compression))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := client.CreateEmbeddings(context.Background(), request)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(wireBytes.Load())/float64(b.N), "wire-bytes/op")
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package openai
 
 import (
+	"io"
 	"net/http"
-	"regexp"
+	"net/url"
+	"time"
 )
 
 const (
 	openaiAPIURLv1                 = "https://api.openai.com/v1"
+	ollamaAPIURLv1                 = "http://localhost:11434/v1"
+	ollamaAPIKey                   = "ollama"
 	defaultEmptyMessagesLimit uint = 300
 
 	azureAPIPrefix         = "openai"
@@ -19,6 +23,7 @@ const (
 	APITypeOpenAI  APIType = "OPEN_AI"
 	APITypeAzure   APIType = "AZURE"
 	APITypeAzureAD APIType = "AZURE_AD"
+	APITypeOllama  APIType = "OLLAMA"
 )
 
 const AzureAPIKeyHeader = "api-key"
@@ -29,12 +34,97 @@ type ClientConfig struct {
 
 	BaseURL              string
 	OrgID                string
+	ProjectID            string // sent as OpenAI-Project, except to Azure
 	APIType              APIType
-	APIVersion           string                    // required when APIType is APITypeAzure or APITypeAzureAD
+	APIVersion           string                    // api-version of Azure requests, see WithRequestAPIVersion
 	AzureModelMapperFunc func(model string) string // replace model to azure deployment name func
-	HTTPClient           *http.Client
+	// AzureDeployments maps models to Azure deployment names. Models it does not
+	// contain are passed to AzureModelMapperFunc.
+	AzureDeployments map[string]string
+	// QueryParams are added to the URL of every request, see WithQueryParams.
+	QueryParams url.Values
+	// TokenProvider, if set, supplies the bearer token of each request instead
+	// of the token the config was created with, e.g. to refresh Microsoft Entra
+	// ID tokens. It is also used for APITypeAzure, replacing the api-key header.
+	TokenProvider TokenProvider
+	// HTTPClient sends the requests, by default an *http.Client with a
+	// transport from NewTransport shared by the default configs. Any HTTPDoer
+	// works, such as the clients of retry or tracing libraries.
+	HTTPClient HTTPDoer
+	// ModelRouter, if set, picks the backend of each request by its model, see
+	// WithModelRouting.
+	ModelRouter ModelRouter
+	// RequestTimeout bounds each request until its response has been read. For
+	// streams it only bounds the wait for the response headers, unlike
+	// http.Client.Timeout which also cuts off long streams. Zero means no limit.
+	RequestTimeout time.Duration
+	// StreamIdleTimeout closes streams that receive no event, keep-alive
+	// comments included, for that long, see ErrStreamIdleTimeout. Only the
+	// time spent waiting in Recv counts, not the time the caller takes to
+	// process the events between the calls. Streams
+	// resumed with StreamResumeLimit are re-issued instead. The context
+	// deadline still applies. Zero means no limit.
+	StreamIdleTimeout time.Duration
+	// FirstTokenTimeout cancels streams that receive no chunk within that long
+	// of sending the request, the wait for the response headers included, with
+	// a FirstTokenTimeoutError. Keep-alive comments do not count. Zero means no
+	// limit.
+	FirstTokenTimeout time.Duration
+	// ConcurrencyLimit bounds the requests CreateChatCompletionConcurrent sends
+	// at once, DefaultConcurrencyLimit if zero.
+	ConcurrencyLimit int
+	// Hooks observe every request of the client, see Hook.
+	Hooks []Hook
+	// UsageRecorder, if set, receives the token usage of the client's calls.
+	UsageRecorder UsageRecorder
+	// Cache, if set, stores the responses of deterministic requests for
+	// CacheTTL, see WithCache.
+	Cache    Cache
+	CacheTTL time.Duration
+	// Debug, if set, receives a dump of every request and response, see
+	// WithDebug.
+	Debug io.Writer
+	// MaxResponseBodySize limits the size of the response bodies the client
+	// reads, DefaultMaxResponseBodySize if zero. Negative values disable the
+	// limit. Streams and file contents, which are read by the caller, are not
+	// limited.
+	MaxResponseBodySize int64
+
+	// DisableCompression stops the client from asking for gzip-compressed
+	// responses, see WithCompression.
+	DisableCompression bool
+	// DisableModelValidation sends requests without checking that the API
+	// method supports their model, see WithModelValidation.
+	DisableModelValidation bool
+	// StrictModelValidation also rejects the models the client does not know,
+	// see WithStrictModelValidation.
+	StrictModelValidation bool
+	// ModelEndpoints lists the endpoints model validation accepts models on,
+	// overriding what the client knows of them, see WithModelEndpoints.
+	ModelEndpoints map[string][]string
+	// ModelSupport, if set, replaces model validation, see WithModelSupport.
+	ModelSupport func(endpoint, model string) bool
+
+	// MaxRetries is the number of times a request failing with one of the
+	// RetryableStatusCodes, or before reaching the server, is sent again, with
+	// an exponential backoff, which is shorter for the errors of overloaded
+	// models. The attempts share RequestTimeout. Streams are
+	// not retried once their response arrived. Zero disables retries, leaving
+	// them to the HTTP client, see WithRetries.
+	MaxRetries int
+	// RetryableStatusCodes are the statuses retried with MaxRetries,
+	// DefaultRetryableStatusCodes if empty.
+	RetryableStatusCodes []int
+	// RetryAfterHeader makes the retries wait as long as the Retry-After and
+	// retry-after-ms headers of the response ask instead of backing off, and
+	// return the response when they ask to wait more than 30 seconds.
+	RetryAfterHeader bool
 
 	EmptyMessagesLimit uint
+
+	// StreamResumeLimit is the number of times a chat completion stream is
+	// re-issued after the connection drops mid-stream. Zero disables resuming.
+	StreamResumeLimit uint
 }
 
 func DefaultConfig(authToken string) ClientConfig {
@@ -44,7 +134,7 @@ func DefaultConfig(authToken string) ClientConfig {
 		APIType:   APITypeOpenAI,
 		OrgID:     "",
 
-		HTTPClient: &http.Client{},
+		HTTPClient: &http.Client{Transport: defaultTransport},
 
 		EmptyMessagesLimit: defaultEmptyMessagesLimit,
 	}
@@ -52,16 +142,32 @@ func DefaultConfig(authToken string) ClientConfig {
 
 func DefaultAzureConfig(apiKey, baseURL string) ClientConfig {
 	return ClientConfig{
-		authToken:  apiKey,
-		BaseURL:    baseURL,
-		OrgID:      "",
-		APIType:    APITypeAzure,
-		APIVersion: "2023-05-15",
-		AzureModelMapperFunc: func(model string) string {
-			return regexp.MustCompile(`[.:]`).ReplaceAllString(model, "")
-		},
+		authToken:            apiKey,
+		BaseURL:              baseURL,
+		OrgID:                "",
+		APIType:              APITypeAzure,
+		APIVersion:           defaultAzureAPIVersion,
+		AzureModelMapperFunc: defaultAzureModelMapper,
 
-		HTTPClient: &http.Client{},
+		HTTPClient: &http.Client{Transport: defaultTransport},
+
+		EmptyMessagesLimit: defaultEmptyMessagesLimit,
+	}
+}
+
+// DefaultOllamaConfig returns a config for the OpenAI-compatible API of a local
+// Ollama server. An empty baseURL defaults to http://localhost:11434/v1.
+func DefaultOllamaConfig(baseURL string) ClientConfig {
+	if baseURL == "" {
+		baseURL = ollamaAPIURLv1
+	}
+	return ClientConfig{
+		authToken: ollamaAPIKey,
+		BaseURL:   baseURL,
+		APIType:   APITypeOllama,
+		OrgID:     "",
+
+		HTTPClient: &http.Client{Transport: defaultTransport},
 
 		EmptyMessagesLimit: defaultEmptyMessagesLimit,
 	}
@@ -72,6 +178,9 @@ func (ClientConfig) String() string {
 }
 
 func (c ClientConfig) GetAzureDeploymentByModel(model string) string {
+	if deployment, ok := c.AzureDeployments[model]; ok {
+		return deployment
+	}
 	if c.AzureModelMapperFunc != nil {
 		return c.AzureModelMapperFunc(model)
 	}
@@ -60,3 +60,18 @@ func TestGetAzureDeploymentByModel(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAzureDeploymentByModelDeployments(t *testing.T) {
+	conf := openai.DefaultAzureConfig("", "https://test.openai.azure.com/")
+	conf.AzureDeployments = map[string]string{"gpt-4o": "prod-gpt4o", "gpt-4o-mini": "cheap-mini"}
+
+	for model, expect := range map[string]string{
+		"gpt-4o":        "prod-gpt4o",
+		"gpt-4o-mini":   "cheap-mini",
+		"gpt-3.5-turbo": "gpt-35-turbo",
+	} {
+		if actual := conf.GetAzureDeploymentByModel(model); actual != expect {
+			t.Errorf("Expected %s for %s, got %s", expect, model, actual)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// summaryMaxTokens is the length limit of the summaries.
+	summaryMaxTokens = 512
+	// summaryPrompt instructs the summary model.
+	summaryPrompt = "You summarize conversations between a user and an assistant. " +
+		"Write a concise summary of the conversation you are given, keeping the facts, " +
+		"decisions, names, numbers and open questions the assistant needs to carry on with it. " +
+		"Answer with the summary only."
+	// summaryPrefix starts the synthetic system messages holding the summaries.
+	summaryPrefix = "Summary of the earlier conversation:\n"
+)
+
+// ConversationSummarizer compresses the older messages of conversations
+// outgrowing the context window into a summary written by a model.
+type ConversationSummarizer struct {
+	client       *Client
+	summaryModel string
+	maxAge       int
+}
+
+// NewConversationSummarizer creates a ConversationSummarizer writing the
+// summaries with summaryModel through client. Conversations of at most maxAge
+// messages are not summarized; zero summarizes any conversation longer than
+// the messages it keeps.
+func NewConversationSummarizer(client *Client, summaryModel string, maxAge int) *ConversationSummarizer {
+	return &ConversationSummarizer{client: client, summaryModel: summaryModel, maxAge: maxAge}
+}
+
+// Summarize returns messages with the ones before the last keepLast replaced
+// by a system message summarizing them, which follows the system messages
+// starting the conversation, kept as they are. Tool results are kept along
+// with the message calling them, so a few more than keepLast messages may be
+// kept. messages is returned as is when there is nothing to summarize.
+//
+// A summary kept from a previous call is summarized again with the messages
+// following it, so conversations can be summarized repeatedly as they grow.
+func (s *ConversationSummarizer) Summarize(
+	ctx context.Context,
+	messages []ChatCompletionMessage,
+	keepLast int,
+) ([]ChatCompletionMessage, error) {
+	if len(messages) <= s.maxAge {
+		return messages, nil
+	}
+	head := 0
+	for head < len(messages) && messages[head].Role == ChatMessageRoleSystem &&
+		!strings.HasPrefix(messages[head].Content, summaryPrefix) {
+		head++
+	}
+	split := max(len(messages)-max(keepLast, 0), head)
+	// The API rejects tool results without the tool call they answer.
+	for split > head && split < len(messages) && messages[split].Role == ChatMessageRoleTool {
+		split--
+	}
+	if split == head {
+		return messages, nil
+	}
+
+	response, err := s.client.CreateChatCompletion(ctx, ChatCompletionRequest{
+		Model: s.summaryModel,
+		Messages: []ChatCompletionMessage{
+			SystemMessage(summaryPrompt),
+			UserMessage(conversationTranscript(messages[head:split])),
+		},
+		MaxTokens: summaryMaxTokens,
+		// A zero temperature would be omitted, leaving the default of 1.
+		Temperature: math.SmallestNonzeroFloat32,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summarizing %d messages: %w", split-head, err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, ErrNoChoices
+	}
+
+	summarized := make([]ChatCompletionMessage, 0, head+1+len(messages)-split)
+	summarized = append(summarized, messages[:head]...)
+	summarized = append(summarized, SystemMessage(summaryPrefix+strings.TrimSpace(response.Choices[0].Message.Content)))
+	return append(summarized, messages[split:]...), nil
+}
+
+// conversationTranscript writes messages as the lines of a transcript, e.g.
+// "user: Hello".
+func conversationTranscript(messages []ChatCompletionMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role)
+		if m.Name != "" {
+			fmt.Fprintf(&b, " (%s)", m.Name)
+		}
+		b.WriteString(": ")
+		b.WriteString(m.Content)
+		if m.Content == "" {
+			for i, part := range m.Parts {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				if part.Type == ContentTypeText {
+					b.WriteString(part.Text)
+				} else {
+					b.WriteString("[image]")
+				}
+			}
+		}
+		if m.FunctionCall != nil {
+			fmt.Fprintf(&b, "[called %s(%s)]", m.FunctionCall.Name, m.FunctionCall.Arguments)
+		}
+		for _, call := range m.ToolCalls {
+			fmt.Fprintf(&b, "[called %s(%s)]", call.Function.Name, call.Function.Arguments)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
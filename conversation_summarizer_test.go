@@ -0,0 +1,140 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+func summaryResponse(summary string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+		Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: summary},
+	}}}
+}
+
+func TestConversationSummarizer(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(summaryResponse(" The user is planning a trip to Lisbon. "))
+	summarizer := openai.NewConversationSummarizer(server.Client(), openai.GPT3Dot5Turbo, 0)
+	ctx := context.Background()
+
+	system := openai.SystemMessage("You are a travel agent.")
+	call := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+		ID:       "call_1",
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: "weather", Arguments: `{"city":"Lisbon"}`},
+	}}}
+	result := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: "Sunny"}
+	messages := []openai.ChatCompletionMessage{
+		system,
+		openai.UserMessage("I want to visit Lisbon."),
+		{Role: openai.ChatMessageRoleAssistant, Content: "When?"},
+		call,
+		result,
+		openai.UserMessage("Book a hotel."),
+	}
+	// Keeping the last two messages would separate the tool result from its
+	// call, which is kept too.
+	summarized, err := summarizer.Summarize(ctx, messages, 2)
+	checks.NoError(t, err, "Summarize error")
+	want := []openai.ChatCompletionMessage{
+		system,
+		openai.SystemMessage("Summary of the earlier conversation:\nThe user is planning a trip to Lisbon."),
+		call,
+		result,
+		openai.UserMessage("Book a hotel."),
+	}
+	if !reflect.DeepEqual(summarized, want) {
+		t.Errorf("got messages %+v, want %+v", summarized, want)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(requests))
+	}
+	var request openai.ChatCompletionRequest
+	checks.NoError(t, json.Unmarshal(requests[0].Body, &request), "Unmarshal error")
+	if request.Model != openai.GPT3Dot5Turbo || request.MaxTokens != 512 ||
+		request.Temperature != math.SmallestNonzeroFloat32 {
+		t.Errorf("got request of model %s, max tokens %d and temperature %g",
+			request.Model, request.MaxTokens, request.Temperature)
+	}
+	if len(request.Messages) != 2 || request.Messages[0].Role != openai.ChatMessageRoleSystem {
+		t.Fatalf("got summary messages %+v", request.Messages)
+	}
+	if transcript := request.Messages[1].Content; transcript != "user: I want to visit Lisbon.\nassistant: When?\n" {
+		t.Errorf("got transcript %q", transcript)
+	}
+
+	// The summary is summarized again with the messages following it.
+	messages = append(summarized, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Done."})
+	_, err = summarizer.Summarize(ctx, messages, 1)
+	checks.NoError(t, err, "Summarize error")
+	transcript := sentMessages(t, server)[1].Content
+	if !strings.HasPrefix(transcript, "system: Summary of the earlier conversation:\n") ||
+		!strings.Contains(transcript, `assistant: [called weather({"city":"Lisbon"})]`) ||
+		!strings.HasSuffix(transcript, "user: Book a hotel.\n") {
+		t.Errorf("got transcript %q", transcript)
+	}
+}
+
+func TestConversationSummarizerNothingToSummarize(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(summaryResponse("unused"))
+	ctx := context.Background()
+	messages := []openai.ChatCompletionMessage{
+		openai.SystemMessage("You are helpful."),
+		openai.UserMessage("Hello"),
+		{Role: openai.ChatMessageRoleAssistant, Content: "Hi!"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		maxAge   int
+		keepLast int
+	}{
+		{"within max age", 3, 0},
+		{"all kept", 0, 2},
+		{"more kept than sent", 0, 10},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			summarizer := openai.NewConversationSummarizer(server.Client(), openai.GPT3Dot5Turbo, tc.maxAge)
+			summarized, err := summarizer.Summarize(ctx, messages, tc.keepLast)
+			checks.NoError(t, err, "Summarize error")
+			if !reflect.DeepEqual(summarized, messages) {
+				t.Errorf("got messages %+v, want them unchanged", summarized)
+			}
+		})
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("sent %d requests, want none", len(server.Requests()))
+	}
+}
+
+func TestConversationSummarizerErrors(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{}, openaitest.MatchModel(openai.GPT3Dot5Turbo))
+	server.Handle("/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"message":"no such model","type":"invalid_request_error",` +
+			`"code":"model_not_found"}}`))
+	}, openaitest.MatchModel("missing"))
+	ctx := context.Background()
+	messages := []openai.ChatCompletionMessage{openai.UserMessage("Hello"), openai.UserMessage("Again")}
+
+	summarizer := openai.NewConversationSummarizer(server.Client(), openai.GPT3Dot5Turbo, 0)
+	_, err := summarizer.Summarize(ctx, messages, 1)
+	checks.ErrorIs(t, err, openai.ErrNoChoices, "Summarize should fail without a summary")
+
+	summarizer = openai.NewConversationSummarizer(server.Client(), "missing", 0)
+	_, err = summarizer.Summarize(ctx, messages, 1)
+	checks.ErrorIs(t, err, openai.ErrModelNotFound, "Summarize should fail with the error of the request")
+}
@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debugMaxBodyBytes truncates the bodies dumped by WithDebug, which may hold
+// base64 images or audio.
+const debugMaxBodyBytes = 8192
+
+// WithDebug dumps every request of the client and its response to w: the
+// method, URL, headers and pretty-printed JSON body of the request, and the
+// status, headers and body of the response. Authorization and api-key headers
+// only show their last 4 characters, and bodies are truncated after 8 KiB.
+// Streamed responses are written as the caller reads them. Writes to w are
+// serialized, so w need not be safe for concurrent use.
+func WithDebug(w io.Writer) ClientOption {
+	return func(config *ClientConfig) {
+		config.Debug = &debugWriter{w: w}
+	}
+}
+
+// debugWriter serializes the writes of concurrent requests.
+type debugWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (d *debugWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.w.Write(p)
+}
+
+// doAttempt sends req with the HTTP client of the config, decompressing its
+// response and dumping both to ClientConfig.Debug if set.
+func (c *Client) doAttempt(req *http.Request) (*http.Response, error) {
+	if c.config.Debug == nil {
+		res, err := c.config.HTTPClient.Do(req)
+		if err == nil {
+			DecompressResponse(res)
+		}
+		return res, err
+	}
+	debug := c.config.Debug
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL)
+	writeDebugHeaders(&b, req.Header)
+	var data []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+	writeDebugBody(&b, data, int64(len(data)))
+	_, _ = debug.Write(b.Bytes())
+
+	start := time.Now()
+	res, err := c.config.HTTPClient.Do(req)
+	b.Reset()
+	if err != nil {
+		fmt.Fprintf(&b, "<-- %s %s error: %v (%s)\n\n", req.Method, req.URL, err, time.Since(start))
+		_, _ = debug.Write(b.Bytes())
+		return res, err
+	}
+
+	DecompressResponse(res)
+	fmt.Fprintf(&b, "<-- %s %s %s (%s)\n", res.Status, req.Method, req.URL, time.Since(start))
+	writeDebugHeaders(&b, res.Header)
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		b.WriteString("\n")
+		res.Body = &debugTee{ReadCloser: res.Body, w: debug}
+	} else {
+		var prefix []byte
+		prefix, res.Body = peekBody(res.Body, debugMaxBodyBytes)
+		writeDebugBody(&b, prefix, res.ContentLength)
+	}
+	_, _ = debug.Write(b.Bytes())
+	return res, nil
+}
+
+// debugTee writes what is read from a stream to w, ignoring write errors so
+// that they do not break the stream.
+type debugTee struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (t *debugTee) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = t.w.Write(p[:n])
+	}
+	return n, err
+}
+
+func writeDebugHeaders(b *bytes.Buffer, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			if k == "Authorization" || k == http.CanonicalHeaderKey(AzureAPIKeyHeader) {
+				v = redactSecret(v)
+			}
+			fmt.Fprintf(b, "%s: %s\n", k, v)
+		}
+	}
+}
+
+// redactSecret keeps the scheme of an Authorization header and the last 4
+// characters of its credentials.
+func redactSecret(value string) string {
+	scheme, secret, found := strings.Cut(value, " ")
+	if !found {
+		scheme, secret = "", value
+	} else {
+		scheme += " "
+	}
+	if len(secret) <= 4 {
+		return scheme + "****"
+	}
+	return scheme + "****" + secret[len(secret)-4:]
+}
+
+// writeDebugBody writes body, indented if it is JSON, or its first
+// debugMaxBodyBytes with a note of its size, which is -1 if unknown.
+func writeDebugBody(b *bytes.Buffer, body []byte, size int64) {
+	b.WriteString("\n")
+	if len(body) == 0 {
+		return
+	}
+	if len(body) > debugMaxBodyBytes {
+		b.Write(body[:debugMaxBodyBytes])
+		if size >= 0 {
+			fmt.Fprintf(b, "\n... (truncated, %d bytes total)\n\n", size)
+		} else {
+			fmt.Fprintf(b, "\n... (truncated after %d bytes)\n\n", debugMaxBodyBytes)
+		}
+		return
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	b.Write(body)
+	b.WriteString("\n\n")
+}
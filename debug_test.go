@@ -0,0 +1,129 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func setupDebugTestServer(t *testing.T) (client *openai.Client, server *test.ServerTest, debug *bytes.Buffer) {
+	server = test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+	debug = &bytes.Buffer{}
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	client = openai.NewClientWithConfig(config, openai.WithDebug(debug))
+	return
+}
+
+func TestDebug(t *testing.T) {
+	client, server, debug := setupDebugTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-request-id", "req_123")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"Invalid value for 'temperature'","type":"invalid_request_error"}}`))
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Invalid value for 'temperature'" {
+		t.Fatalf("CreateChatCompletion returned %v, want the API error to still be decoded", err)
+	}
+
+	dump := debug.String()
+	token := test.GetTestToken()
+	for _, want := range []string{
+		"--> POST " + "http://",
+		"/v1/chat/completions\n",
+		"Authorization: Bearer ****" + token[len(token)-4:] + "\n",
+		"Content-Type: application/json; charset=utf-8\n",
+		"{\n  \"model\": \"gpt-3.5-turbo\",\n  \"messages\": [\n",
+		"<-- 400 Bad Request POST ",
+		"X-Request-Id: req_123\n",
+		"{\n  \"error\": {\n    \"message\": \"Invalid value for 'temperature'\",\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("debug output does not contain %q:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, token) {
+		t.Errorf("debug output contains the API key:\n%s", dump)
+	}
+}
+
+func TestDebugTruncatesLargeBodies(t *testing.T) {
+	client, server, debug := setupDebugTestServer(t)
+	image := strings.Repeat("A", 20000)
+	body := `{"created":1,"data":[{"b64_json":"` + image + `"}]}`
+	server.RegisterHandler("/v1/images/generations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = w.Write([]byte(body))
+	})
+
+	resp, err := client.CreateImage(context.Background(), openai.ImageRequest{
+		Prompt:         "a cat",
+		ResponseFormat: openai.CreateImageResponseFormatB64JSON,
+	})
+	checks.NoError(t, err, "CreateImage error")
+	if resp.Data[0].B64JSON != image {
+		t.Error("the response body was not passed on intact")
+	}
+
+	dump := debug.String()
+	if !strings.Contains(dump, fmt.Sprintf("... (truncated, %d bytes total)", len(body))) {
+		t.Errorf("debug output does not note the truncation:\n%.200s", dump)
+	}
+	if len(dump) > 10000 {
+		t.Errorf("debug output is %d bytes long, want the body truncated", len(dump))
+	}
+}
+
+func TestDebugStream(t *testing.T) {
+	client, server, debug := setupDebugTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo",` +
+			`"choices":[{"index":0,"delta":{"content":"streamed"}}]}` + "\n\ndata: [DONE]\n\n"))
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	if strings.Contains(debug.String(), "streamed") {
+		t.Error("the stream was dumped before it was read")
+	}
+
+	resp, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv error")
+	if resp.Choices[0].Delta.Content != "streamed" {
+		t.Error("the stream was not passed on intact")
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream.Recv should end the stream")
+
+	dump := debug.String()
+	if !strings.Contains(dump, "Accept: text/event-stream\n") ||
+		!strings.Contains(dump, `"delta":{"content":"streamed"}}]}`+"\n\ndata: [DONE]") {
+		t.Errorf("the stream was not dumped as read:\n%s", dump)
+	}
+}
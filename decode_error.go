@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// decodeErrorSnippetSize is the number of bytes of the body kept by
+// DecodeError.
+const decodeErrorSnippetSize = 256
+
+// DecodeError is returned when a response body, or a chunk of a stream, is not
+// the JSON the client expects, e.g. the HTML error page of a proxy. It carries
+// what is needed to tell what came back instead.
+type DecodeError struct {
+	HTTPStatusCode int
+	ContentType    string
+	// URL is the URL of the request, with the credentials it may hold
+	// removed.
+	URL       string
+	RequestID string
+	// Snippet is the beginning of the body, or of the chunk, made printable,
+	// with its whitespace collapsed and "..." appended if it was truncated.
+	Snippet string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	b.WriteString("error decoding response")
+	if e.URL != "" {
+		fmt.Fprintf(&b, " from %s", e.URL)
+	}
+	fmt.Fprintf(&b, ": %v (status code: %d", e.Err, e.HTTPStatusCode)
+	if e.ContentType != "" {
+		fmt.Fprintf(&b, ", content type: %s", e.ContentType)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, ", request id: %s", e.RequestID)
+	}
+	if e.Snippet == "" {
+		b.WriteString("), body is empty")
+	} else {
+		fmt.Fprintf(&b, "), body: %s", e.Snippet)
+	}
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// newDecodeError returns a DecodeError for the failure err to decode body, the
+// body or a chunk of resp.
+func newDecodeError(resp *http.Response, body []byte, err error) *DecodeError {
+	decodeErr := &DecodeError{
+		HTTPStatusCode: resp.StatusCode,
+		ContentType:    resp.Header.Get("Content-Type"),
+		RequestID:      requestID(resp.Header),
+		Snippet:        bodySnippet(body),
+		Err:            err,
+	}
+	if resp.Request != nil {
+		decodeErr.URL = redactedURL(resp.Request.URL)
+	}
+	return decodeErr
+}
+
+// isDecodeFailure reports whether err shows that a body is not the JSON
+// expected, rather than failing to be read.
+func isDecodeFailure(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// bodySnippet returns the first decodeErrorSnippetSize bytes of body, made
+// printable.
+func bodySnippet(body []byte) string {
+	truncated := len(body) > decodeErrorSnippetSize
+	if truncated {
+		body = body[:decodeErrorSnippetSize]
+	}
+	// A rune cut by the truncation is dropped as invalid.
+	snippet := strings.ToValidUTF8(string(body), "")
+	snippet = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPrint(r) {
+			return r
+		}
+		return '?'
+	}, snippet)
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	if truncated {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// prefixReader keeps the first bytes read from r, one more than a snippet so
+// that bodySnippet can tell whether it truncated them.
+type prefixReader struct {
+	r      io.Reader
+	prefix []byte
+}
+
+func (p *prefixReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if room := decodeErrorSnippetSize + 1 - len(p.prefix); room > 0 {
+		p.prefix = append(p.prefix, b[:min(n, room)]...)
+	}
+	return n, err
+}
+
+// snippet returns the snippet of the body, reading the rest of it from r as
+// decoders stop reading at the first error.
+func (p *prefixReader) snippet() []byte {
+	if room := decodeErrorSnippetSize + 1 - len(p.prefix); room > 0 {
+		rest, _ := io.ReadAll(io.LimitReader(p.r, int64(room)))
+		p.prefix = append(p.prefix, rest...)
+	}
+	return p.prefix
+}
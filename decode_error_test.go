@@ -0,0 +1,119 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+func TestDecodeError(t *testing.T) {
+	htmlPage := "<html>\n  <head><title>Access denied</title></head>\n  <body>Blocked by proxy policy.</body>\n</html>"
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		snippet     string
+		cause       error
+	}{
+		{
+			name:        "html",
+			contentType: "text/html",
+			body:        htmlPage,
+			snippet: "body: <html> <head><title>Access denied</title></head> <body>Blocked by proxy policy.</body> " +
+				"</html>",
+		},
+		{
+			name:        "empty",
+			contentType: "application/json",
+			snippet:     "body is empty",
+			cause:       io.EOF,
+		},
+		{
+			name:        "truncated json",
+			contentType: "application/json",
+			body:        `{"id":"chatcmpl-1","object":"chat.comp`,
+			snippet:     `body: {"id":"chatcmpl-1","object":"chat.comp`,
+			cause:       io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "long body",
+			contentType: "text/plain",
+			body:        strings.Repeat("x", 1000),
+			snippet:     "body: " + strings.Repeat("x", 256) + "...",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := openaitest.NewServer(t)
+			server.Handle("/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Header().Set("x-request-id", "req_123")
+				io.WriteString(w, tc.body) //nolint:errcheck // the test fails on a short body
+			})
+			client := server.Client(openai.WithQueryParams(map[string][]string{"key": {"secret"}}))
+
+			_, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+			var decodeErr *openai.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected DecodeError, got %v", err)
+			}
+			if decodeErr.HTTPStatusCode != http.StatusOK || decodeErr.ContentType != tc.contentType ||
+				decodeErr.RequestID != "req_123" || decodeErr.URL != server.URL+"/chat/completions" {
+				t.Errorf("got DecodeError %+v", decodeErr)
+			}
+			if tc.cause != nil {
+				checks.ErrorIs(t, err, tc.cause, "DecodeError does not wrap the decoding error")
+			}
+
+			msg := err.Error()
+			for _, want := range []string{tc.snippet, "status code: 200", "content type: " + tc.contentType,
+				"request id: req_123", server.URL + "/chat/completions"} {
+				if !strings.Contains(msg, want) {
+					t.Errorf("error %q does not contain %q", msg, want)
+				}
+			}
+			if strings.Contains(msg, "secret") {
+				t.Errorf("error %q contains the query of the URL", msg)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorStream(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.Handle("/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("x-request-id", "req_456")
+		fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":\"zero\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stream, err := server.Client().CreateChatCompletionStream(context.Background(), retryTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	_, err = stream.Recv()
+	var decodeErr *openai.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{`body: {"id":"chatcmpl-1","choices":[{"index":"zero"}]}`,
+		"content type: text/event-stream", "request id: req_456"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+}
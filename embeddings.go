@@ -4,12 +4,19 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"net/http"
+	"slices"
 )
 
-var ErrVectorLengthMismatch = errors.New("vector length mismatch")
+var (
+	ErrVectorLengthMismatch = errors.New("vector length mismatch")
+	ErrEmbeddingInputEmpty  = errors.New("embedding input has no field set")
+	ErrEmbeddingInputMixed  = errors.New("embedding input has several fields set")
+)
 
 // EmbeddingModel enumerates the models which can be used
 // to generate Embedding vectors.
@@ -73,6 +80,8 @@ const (
 	// Deprecated: Will be shut down on January 04, 2024. Use text-embedding-ada-002 instead.
 	BabbageCodeSearchText
 	AdaEmbeddingV2
+	SmallEmbedding3
+	LargeEmbedding3
 )
 
 var enumToString = map[EmbeddingModel]string{
@@ -93,6 +102,8 @@ var enumToString = map[EmbeddingModel]string{
 	BabbageCodeSearchCode: "code-search-babbage-code-001",
 	BabbageCodeSearchText: "code-search-babbage-text-001",
 	AdaEmbeddingV2:        "text-embedding-ada-002",
+	SmallEmbedding3:       "text-embedding-3-small",
+	LargeEmbedding3:       "text-embedding-3-large",
 }
 
 var stringToEnum = map[string]EmbeddingModel{
@@ -113,6 +124,8 @@ var stringToEnum = map[string]EmbeddingModel{
 	"code-search-babbage-code-001":  BabbageCodeSearchCode,
 	"code-search-babbage-text-001":  BabbageCodeSearchText,
 	"text-embedding-ada-002":        AdaEmbeddingV2,
+	"text-embedding-3-small":        SmallEmbedding3,
+	"text-embedding-3-large":        LargeEmbedding3,
 }
 
 // Embedding is a special format of data representation that can be easily utilized by machine
@@ -130,18 +143,48 @@ type Embedding struct {
 // DotProduct calculates the dot product of the embedding vector with another
 // embedding vector. Both vectors must have the same length; otherwise, an
 // ErrVectorLengthMismatch is returned. The method returns the calculated dot
-// product as a float32 value.
+// product as a float32 value, accumulated in float64 for accuracy.
 func (e *Embedding) DotProduct(other *Embedding) (float32, error) {
 	if len(e.Embedding) != len(other.Embedding) {
 		return 0, ErrVectorLengthMismatch
 	}
+	return float32(dotProduct(e.Embedding, other.Embedding)), nil
+}
 
-	var dotProduct float32
-	for i := range e.Embedding {
-		dotProduct += e.Embedding[i] * other.Embedding[i]
+// CosineSimilarity calculates the cosine of the angle between the embedding
+// vector and another embedding vector, from -1 to 1. Both vectors must have
+// the same length; otherwise, an ErrVectorLengthMismatch is returned. The
+// similarity with a zero vector is 0.
+func (e *Embedding) CosineSimilarity(other *Embedding) (float32, error) {
+	if len(e.Embedding) != len(other.Embedding) {
+		return 0, ErrVectorLengthMismatch
+	}
+	var dot, norm1, norm2 float64
+	for i, x := range e.Embedding {
+		x, y := float64(x), float64(other.Embedding[i])
+		dot += x * y
+		norm1 += x * x
+		norm2 += y * y
 	}
+	if norm1 == 0 || norm2 == 0 {
+		return 0, nil
+	}
+	// Rounding may take the similarity of parallel vectors slightly past 1.
+	similarity := max(-1, min(1, dot/math.Sqrt(norm1*norm2)))
+	return float32(similarity), nil
+}
 
-	return dotProduct, nil
+// Norm calculates the Euclidean length of the embedding vector.
+func (e *Embedding) Norm() float32 {
+	return float32(math.Sqrt(dotProduct(e.Embedding, e.Embedding)))
+}
+
+func dotProduct(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
 }
 
 // EmbeddingResponse is the response from a Create embeddings request.
@@ -154,6 +197,40 @@ type EmbeddingResponse struct {
 	httpHeader
 }
 
+// ToMatrix returns the embedding vectors of r in the order of their Index,
+// which is the order of the inputs. The vectors are not copied.
+func (r EmbeddingResponse) ToMatrix() [][]float32 {
+	if len(r.Data) == 0 {
+		return nil
+	}
+	matrix := make([][]float32, len(r.Data))
+	for i, embedding := range r.sortedData() {
+		matrix[i] = embedding.Embedding
+	}
+	return matrix
+}
+
+// ToDenseMatrix returns the embedding vectors of r, in the order of their
+// Index, as one row-major slice with a stride of len(r.Data[0].Embedding),
+// e.g. for BLAS libraries. The vectors must all have the same length.
+func (r EmbeddingResponse) ToDenseMatrix() []float32 {
+	if len(r.Data) == 0 {
+		return nil
+	}
+	matrix := make([]float32, 0, len(r.Data)*len(r.Data[0].Embedding))
+	for _, embedding := range r.sortedData() {
+		matrix = append(matrix, embedding.Embedding...)
+	}
+	return matrix
+}
+
+// sortedData returns a copy of r.Data sorted by Index.
+func (r EmbeddingResponse) sortedData() []Embedding {
+	data := slices.Clone(r.Data)
+	slices.SortStableFunc(data, func(a, b Embedding) int { return a.Index - b.Index })
+	return data
+}
+
 type base64String string
 
 func (b base64String) Decode() ([]float32, error) {
@@ -228,17 +305,97 @@ const (
 	EmbeddingEncodingFormatBase64 EmbeddingEncodingFormat = "base64"
 )
 
+// EmbeddingInput is the input of an EmbeddingRequest in one of the forms the
+// API accepts: a string, strings, an array of token IDs, or arrays of token
+// IDs, e.g. the output of a tokenizer, which spares tokenizing the text again.
+// Exactly one of its fields must be set.
+type EmbeddingInput struct {
+	Text        string
+	Texts       []string
+	Tokens      []int
+	TokenArrays [][]int
+}
+
+// value returns the set field of the input, or an error if it has none or
+// several.
+func (in EmbeddingInput) value() (any, error) {
+	var (
+		value any
+		set   int
+	)
+	if in.Text != "" {
+		value, set = in.Text, set+1
+	}
+	if in.Texts != nil {
+		value, set = in.Texts, set+1
+	}
+	if in.Tokens != nil {
+		value, set = in.Tokens, set+1
+	}
+	if in.TokenArrays != nil {
+		value, set = in.TokenArrays, set+1
+	}
+	switch set {
+	case 0:
+		return nil, ErrEmbeddingInputEmpty
+	case 1:
+		return value, nil
+	default:
+		return nil, ErrEmbeddingInputMixed
+	}
+}
+
+func (in EmbeddingInput) MarshalJSON() ([]byte, error) {
+	value, err := in.value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
 type EmbeddingRequest struct {
+	// Input is an EmbeddingInput, or any of the types of its fields.
 	Input          any                     `json:"input"`
 	Model          EmbeddingModel          `json:"model"`
 	User           string                  `json:"user"`
 	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	// Dimensions shortens the embeddings to that many dimensions, for models
+	// such as text-embedding-3-small. Zero returns the full embeddings.
+	Dimensions int `json:"dimensions,omitempty"`
+
+	// ExtraFields are added to the JSON body. See
+	// ChatCompletionRequest.ExtraFields.
+	ExtraFields map[string]any `json:"-"`
+}
+
+func (r EmbeddingRequest) MarshalJSON() ([]byte, error) {
+	type embeddingRequest EmbeddingRequest
+	return marshalWithExtraFields(embeddingRequest(r), r.ExtraFields)
 }
 
 func (r EmbeddingRequest) Convert() EmbeddingRequest {
 	return r
 }
 
+// Validate checks the request against the documented constraints of the
+// embeddings API. It returns ValidationErrors listing all violations, or nil.
+func (r EmbeddingRequest) Validate() error {
+	var errs ValidationErrors
+	if input, ok := r.Input.(EmbeddingInput); ok {
+		if _, err := input.value(); err != nil {
+			errs.add("input", "must set exactly one of Text, Texts, Tokens and TokenArrays: %v", err)
+		}
+	}
+	if r.Dimensions < 0 {
+		errs.add("dimensions", "must be greater than 0 when set, got %d", r.Dimensions)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
 // EmbeddingRequestStrings is the input to a create embeddings request with a slice of strings.
 type EmbeddingRequestStrings struct {
 	// Input is a slice of strings for which you want to generate an Embedding vector.
@@ -257,6 +414,12 @@ type EmbeddingRequestStrings struct {
 	// Currently, only "float" and "base64" are supported, however, "base64" is not officially documented.
 	// If not specified OpenAI will use "float".
 	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	// Dimensions shortens the embeddings to that many dimensions, see
+	// EmbeddingRequest.Dimensions.
+	Dimensions int `json:"dimensions,omitempty"`
+	// ExtraFields are added to the JSON body. See
+	// ChatCompletionRequest.ExtraFields.
+	ExtraFields map[string]any `json:"-"`
 }
 
 func (r EmbeddingRequestStrings) Convert() EmbeddingRequest {
@@ -265,6 +428,8 @@ func (r EmbeddingRequestStrings) Convert() EmbeddingRequest {
 		Model:          r.Model,
 		User:           r.User,
 		EncodingFormat: r.EncodingFormat,
+		Dimensions:     r.Dimensions,
+		ExtraFields:    r.ExtraFields,
 	}
 }
 
@@ -285,6 +450,12 @@ type EmbeddingRequestTokens struct {
 	// Currently, only "float" and "base64" are supported, however, "base64" is not officially documented.
 	// If not specified OpenAI will use "float".
 	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	// Dimensions shortens the embeddings to that many dimensions, see
+	// EmbeddingRequest.Dimensions.
+	Dimensions int `json:"dimensions,omitempty"`
+	// ExtraFields are added to the JSON body. See
+	// ChatCompletionRequest.ExtraFields.
+	ExtraFields map[string]any `json:"-"`
 }
 
 func (r EmbeddingRequestTokens) Convert() EmbeddingRequest {
@@ -293,6 +464,8 @@ func (r EmbeddingRequestTokens) Convert() EmbeddingRequest {
 		Model:          r.Model,
 		User:           r.User,
 		EncodingFormat: r.EncodingFormat,
+		Dimensions:     r.Dimensions,
+		ExtraFields:    r.ExtraFields,
 	}
 }
 
@@ -306,12 +479,29 @@ func (c *Client) CreateEmbeddings(
 	conv EmbeddingRequestConverter,
 ) (res EmbeddingResponse, err error) {
 	baseReq := conv.Convert()
+	if err = baseReq.Validate(); err != nil {
+		return
+	}
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/embeddings", baseReq.Model.String()), withBody(baseReq))
 	if err != nil {
 		return
 	}
 
-	if baseReq.EncodingFormat != EmbeddingEncodingFormatBase64 {
+	if c.config.Cache != nil {
+		if key, keyErr := EmbeddingCacheKey(baseReq); keyErr == nil {
+			return cachedSend(ctx, c.config, key, func() (EmbeddingResponse, error) {
+				return c.sendEmbeddingsRequest(req, baseReq.EncodingFormat)
+			})
+		}
+	}
+	return c.sendEmbeddingsRequest(req, baseReq.EncodingFormat)
+}
+
+func (c *Client) sendEmbeddingsRequest(
+	req *http.Request,
+	encodingFormat EmbeddingEncodingFormat,
+) (res EmbeddingResponse, err error) {
+	if encodingFormat != EmbeddingEncodingFormatBase64 {
 		err = c.sendRequest(req, &res)
 		return
 	}
@@ -325,3 +515,72 @@ func (c *Client) CreateEmbeddings(
 	res, err = base64Response.ToEmbeddingResponse()
 	return
 }
+
+// nativeEmbeddingDimensions are the dimensions of the embeddings of the
+// models that accept EmbeddingRequest.Dimensions.
+var nativeEmbeddingDimensions = map[EmbeddingModel]int{
+	SmallEmbedding3: 1536,
+	LargeEmbedding3: 3072,
+}
+
+// CreateEmbeddingWithDimensions returns the embedding of input shortened to
+// dimensions, which must be positive and smaller than the native dimensions
+// of model, a text-embedding-3 model.
+func (c *Client) CreateEmbeddingWithDimensions(
+	ctx context.Context,
+	input string,
+	model string,
+	dimensions int,
+) ([]float32, error) {
+	embeddings, err := c.CreateEmbeddingsWithDimensions(ctx, []string{input}, model, dimensions)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// CreateEmbeddingsWithDimensions is like CreateEmbeddingWithDimensions for
+// several inputs, returning their embeddings in the same order.
+func (c *Client) CreateEmbeddingsWithDimensions(
+	ctx context.Context,
+	inputs []string,
+	model string,
+	dimensions int,
+) ([][]float32, error) {
+	embeddingModel := stringToEnum[model]
+	var errs ValidationErrors
+	if native, ok := nativeEmbeddingDimensions[embeddingModel]; !ok {
+		errs.add("model", "must support dimensions, got %q", model)
+	} else if dimensions <= 0 || dimensions >= native {
+		errs.add("dimensions", "must be between 1 and %d for %s, got %d", native-1, model, dimensions)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	res, err := c.CreateEmbeddings(ctx, EmbeddingRequestStrings{
+		Input:      inputs,
+		Model:      embeddingModel,
+		Dimensions: dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return embeddingVectors(res, len(inputs))
+}
+
+// embeddingVectors returns the vectors of the n embeddings of res in the
+// order of their Index, which must each be set once.
+func embeddingVectors(res EmbeddingResponse, n int) ([][]float32, error) {
+	if len(res.Data) != n {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(res.Data), n)
+	}
+	vectors := make([][]float32, n)
+	for _, embedding := range res.Data {
+		if embedding.Index < 0 || embedding.Index >= n || vectors[embedding.Index] != nil {
+			return nil, fmt.Errorf("got embedding of invalid or repeated index %d for %d inputs", embedding.Index, n)
+		}
+		vectors[embedding.Index] = embedding.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,224 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	// DefaultEmbeddingBatchInputs is the number of inputs the embeddings API
+	// accepts per request.
+	DefaultEmbeddingBatchInputs = 2048
+	// DefaultEmbeddingBatchTokens is the number of tokens, summed over its
+	// inputs, the embeddings API accepts per request.
+	DefaultEmbeddingBatchTokens = 300_000
+)
+
+// EmbeddingBatchOptions configures CreateEmbeddingsBatched.
+type EmbeddingBatchOptions struct {
+	// MaxInputs is the number of inputs per request,
+	// DefaultEmbeddingBatchInputs if zero.
+	MaxInputs int
+	// MaxTokens is the number of tokens per request,
+	// DefaultEmbeddingBatchTokens if zero. Inputs longer than that are sent
+	// alone.
+	MaxTokens int
+	// CountTokens counts the tokens of a string input, e.g. with a tokenizer
+	// library. If nil, the tokens are estimated like NewTokenBudgetMiddleware
+	// does. Token inputs count their length.
+	CountTokens func(input string) int
+	// Concurrency is the number of requests sent at once, 1 if zero.
+	Concurrency int
+	// AllowPartial returns the embeddings of the batches that succeeded along
+	// with an EmbeddingBatchError when others failed. Otherwise the first
+	// failure cancels the other batches and is returned alone.
+	AllowPartial bool
+}
+
+// EmbeddingBatchFailure is a batch of CreateEmbeddingsBatched that failed.
+type EmbeddingBatchFailure struct {
+	// Start and End delimit the inputs of the batch, End excluded.
+	Start, End int
+	Err        error
+}
+
+// EmbeddingBatchError lists the batches that failed in a
+// CreateEmbeddingsBatched call with EmbeddingBatchOptions.AllowPartial. It
+// wraps Errors, so errors.Is, errors.As and IsMultiError see the errors of
+// every failed batch.
+type EmbeddingBatchError struct {
+	// Batches is the number of batches of the call.
+	Batches  int
+	Failures []EmbeddingBatchFailure
+	// Errors holds the Err of each of Failures, in the same order.
+	Errors MultiError
+}
+
+func (e *EmbeddingBatchError) Error() string {
+	first := e.Failures[0]
+	return fmt.Sprintf("%d of %d embedding batches failed, first inputs %d to %d: %v",
+		len(e.Failures), e.Batches, first.Start, first.End-1, first.Err)
+}
+
+func (e *EmbeddingBatchError) Unwrap() error {
+	return e.Errors
+}
+
+// embeddingBatch is the request of the inputs from start to end, excluded,
+// which count tokens tokens.
+type embeddingBatch struct {
+	start, end int
+	tokens     int
+	request    EmbeddingRequest
+}
+
+// CreateEmbeddingsBatched creates the embeddings of any number of inputs by
+// splitting them into requests the API accepts, see EmbeddingBatchOptions.
+// The embeddings of the response are in the order of the inputs, with their
+// Index in the input, and its Usage adds up the ones of all the requests.
+//
+// Requests whose input is not a []string or [][]int, or an EmbeddingInput of
+// Texts or TokenArrays, are sent unchanged with CreateEmbeddings.
+func (c *Client) CreateEmbeddingsBatched(
+	ctx context.Context,
+	conv EmbeddingRequestConverter,
+	opts EmbeddingBatchOptions,
+) (EmbeddingResponse, error) {
+	request := conv.Convert()
+	batches := opts.split(request)
+	if batches == nil {
+		return c.CreateEmbeddings(ctx, request)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		responses = make([]EmbeddingResponse, len(batches))
+		errs      = make([]error, len(batches))
+		failOnce  sync.Once
+		failed    error
+	)
+	semaphore := make(chan struct{}, max(opts.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i := range batches {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			batch := batches[i]
+			responses[i], errs[i] = c.CreateEmbeddings(ctx, batch.request)
+			if errs[i] != nil && !opts.AllowPartial {
+				failOnce.Do(func() {
+					failed = fmt.Errorf("embedding inputs %d to %d: %w", batch.start, batch.end-1, errs[i])
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if !opts.AllowPartial {
+		// Batches that were not sent fail with the error of ctx.
+		for i, batch := range batches {
+			if failed == nil && errs[i] != nil {
+				failed = fmt.Errorf("embedding inputs %d to %d: %w", batch.start, batch.end-1, errs[i])
+			}
+		}
+		if failed != nil {
+			return EmbeddingResponse{}, failed
+		}
+	}
+
+	response := EmbeddingResponse{Object: "list", Model: request.Model}
+	batchErr := &EmbeddingBatchError{Batches: len(batches)}
+	for i, batch := range batches {
+		if errs[i] != nil {
+			batchErr.Failures = append(batchErr.Failures, EmbeddingBatchFailure{batch.start, batch.end, errs[i]})
+			batchErr.Errors = append(batchErr.Errors, errs[i])
+			continue
+		}
+		batchResponse := responses[i]
+		if response.httpHeader == nil {
+			response.Object, response.Model = batchResponse.Object, batchResponse.Model
+			response.httpHeader = batchResponse.httpHeader
+		}
+		response.Usage = response.Usage.Add(batchResponse.Usage)
+		slices.SortStableFunc(batchResponse.Data, func(a, b Embedding) int { return a.Index - b.Index })
+		for _, embedding := range batchResponse.Data {
+			embedding.Index += batch.start
+			response.Data = append(response.Data, embedding)
+		}
+	}
+	if len(batchErr.Failures) > 0 {
+		return response, batchErr
+	}
+	return response, nil
+}
+
+// split returns the batches of the inputs of request, or nil if its input is
+// empty or cannot be split.
+func (opts EmbeddingBatchOptions) split(request EmbeddingRequest) []embeddingBatch {
+	var (
+		counts []int
+		slice  func(start, end int) any
+	)
+	input := request.Input
+	if in, ok := input.(EmbeddingInput); ok {
+		input, _ = in.value()
+	}
+	switch input := input.(type) {
+	case []string:
+		count := opts.CountTokens
+		if count == nil {
+			count = estimateTokens
+		}
+		counts = make([]int, len(input))
+		for i, text := range input {
+			counts[i] = count(text)
+		}
+		slice = func(start, end int) any { return input[start:end:end] }
+	case [][]int:
+		counts = make([]int, len(input))
+		for i, tokens := range input {
+			counts[i] = len(tokens)
+		}
+		slice = func(start, end int) any { return input[start:end:end] }
+	default:
+		return nil
+	}
+
+	maxInputs, maxTokens := opts.MaxInputs, opts.MaxTokens
+	if maxInputs <= 0 {
+		maxInputs = DefaultEmbeddingBatchInputs
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultEmbeddingBatchTokens
+	}
+	var batches []embeddingBatch
+	add := func(start, end, tokens int) {
+		batch := request
+		batch.Input = slice(start, end)
+		batches = append(batches, embeddingBatch{start: start, end: end, tokens: tokens, request: batch})
+	}
+	start, tokens := 0, 0
+	for i, n := range counts {
+		if i > start && (i-start == maxInputs || tokens+n > maxTokens) {
+			add(start, i, tokens)
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(counts) {
+		add(start, len(counts), tokens)
+	}
+	return batches
+}
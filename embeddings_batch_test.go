@@ -0,0 +1,194 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+// batchEmbeddingsHandler embeds inputs "N" as the vector {N}, answering later
+// inputs faster and listing the embeddings in reverse, so that batches finish
+// out of order. Inputs "fail" get a 400. It records the inputs of each request
+// and the highest number of requests served at once.
+type batchEmbeddingsHandler struct {
+	mu      sync.Mutex
+	batches [][]string
+
+	active, maxActive atomic.Int32
+}
+
+func (h *batchEmbeddingsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	active := h.active.Add(1)
+	defer h.active.Add(-1)
+	for {
+		maxActive := h.maxActive.Load()
+		if active <= maxActive || h.maxActive.CompareAndSwap(maxActive, active) {
+			break
+		}
+	}
+
+	var request struct {
+		Input []string `json:"input"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+	h.mu.Lock()
+	h.batches = append(h.batches, request.Input)
+	h.mu.Unlock()
+
+	response := openai.EmbeddingResponse{Object: "list", Model: openai.SmallEmbedding3}
+	for i := len(request.Input) - 1; i >= 0; i-- {
+		n, err := strconv.Atoi(request.Input[i])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid input","type":"invalid_request_error"}}`))
+			return
+		}
+		response.Data = append(response.Data, openai.Embedding{
+			Object:    "embedding",
+			Embedding: []float32{float32(n)},
+			Index:     i,
+		})
+		response.Usage.PromptTokens++
+		response.Usage.TotalTokens++
+	}
+	if len(request.Input) > 0 {
+		first, _ := strconv.Atoi(request.Input[0])
+		time.Sleep(time.Duration(20-first) * 3 * time.Millisecond)
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func numberedInputs(n int) []string {
+	inputs := make([]string, n)
+	for i := range inputs {
+		inputs[i] = strconv.Itoa(i)
+	}
+	return inputs
+}
+
+func checkBatchedEmbeddings(t *testing.T, data []openai.Embedding, want []int) {
+	t.Helper()
+	if len(data) != len(want) {
+		t.Fatalf("got %d embeddings, want %d", len(data), len(want))
+	}
+	for i, embedding := range data {
+		if embedding.Index != want[i] || len(embedding.Embedding) != 1 || embedding.Embedding[0] != float32(want[i]) {
+			t.Errorf("embedding %d = %+v, want index and value %d", i, embedding, want[i])
+		}
+	}
+}
+
+func TestCreateEmbeddingsBatched(t *testing.T) {
+	server := openaitest.NewServer(t)
+	handler := &batchEmbeddingsHandler{}
+	server.Handle("/embeddings", handler.ServeHTTP)
+	client := server.Client()
+
+	response, err := client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequestStrings{
+		Input: numberedInputs(20),
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxInputs: 3, Concurrency: 4})
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+
+	want := make([]int, 20)
+	for i := range want {
+		want[i] = i
+	}
+	checkBatchedEmbeddings(t, response.Data, want)
+	if response.Usage != (openai.Usage{PromptTokens: 20, TotalTokens: 20}) {
+		t.Errorf("got usage %+v, want the sum of the batches", response.Usage)
+	}
+	if response.Model != openai.SmallEmbedding3 || response.Object != "list" {
+		t.Errorf("got model %v and object %q", response.Model, response.Object)
+	}
+	if len(handler.batches) != 7 {
+		t.Errorf("sent %d requests, want 7", len(handler.batches))
+	}
+	for _, batch := range handler.batches {
+		if len(batch) > 3 {
+			t.Errorf("sent batch of %d inputs, want at most 3", len(batch))
+		}
+	}
+	if maxActive := handler.maxActive.Load(); maxActive < 2 || maxActive > 4 {
+		t.Errorf("sent up to %d requests at once, want 2 to 4", maxActive)
+	}
+}
+
+func TestCreateEmbeddingsBatchedTokens(t *testing.T) {
+	server := openaitest.NewServer(t)
+	handler := &batchEmbeddingsHandler{}
+	server.Handle("/embeddings", handler.ServeHTTP)
+	client := server.Client()
+
+	// Inputs of n digits count 10^(n-1) tokens: 1 for 0 to 9, 10 for 10 to 19.
+	countTokens := func(input string) int {
+		return []int{1, 10}[len(input)-1]
+	}
+	inputs := []string{"1", "2", "10", "3", "11", "12", "4"}
+	response, err := client.CreateEmbeddingsBatched(context.Background(), openai.EmbeddingRequest{
+		Input: inputs,
+		Model: openai.SmallEmbedding3,
+	}, openai.EmbeddingBatchOptions{MaxTokens: 12, CountTokens: countTokens})
+	checks.NoError(t, err, "CreateEmbeddingsBatched error")
+	for i, embedding := range response.Data {
+		if embedding.Index != i || strconv.Itoa(int(embedding.Embedding[0])) != inputs[i] {
+			t.Errorf("embedding %d = %+v, want the one of input %q", i, embedding, inputs[i])
+		}
+	}
+	got := make([]string, len(handler.batches))
+	for i, batch := range handler.batches {
+		got[i] = strings.Join(batch, ",")
+	}
+	if want := []string{"1,2,10", "3,11", "12,4"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("sent batches %q, want %q", got, want)
+	}
+}
+
+func TestCreateEmbeddingsBatchedFailure(t *testing.T) {
+	server := openaitest.NewServer(t)
+	handler := &batchEmbeddingsHandler{}
+	server.Handle("/embeddings", handler.ServeHTTP)
+	client := server.Client()
+	inputs := numberedInputs(9)
+	inputs[4] = "fail"
+	request := openai.EmbeddingRequestStrings{Input: inputs, Model: openai.SmallEmbedding3}
+
+	response, err := client.CreateEmbeddingsBatched(context.Background(), request,
+		openai.EmbeddingBatchOptions{MaxInputs: 3, Concurrency: 3})
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		t.Fatalf("got error %v, want the API error of the failed batch", err)
+	}
+	if !strings.Contains(err.Error(), "embedding inputs 3 to 5") || len(response.Data) != 0 {
+		t.Errorf("got error %v and %d embeddings", err, len(response.Data))
+	}
+
+	response, err = client.CreateEmbeddingsBatched(context.Background(), request,
+		openai.EmbeddingBatchOptions{MaxInputs: 3, Concurrency: 3, AllowPartial: true})
+	var batchErr *openai.EmbeddingBatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("got error %v, want EmbeddingBatchError", err)
+	}
+	if batchErr.Batches != 3 || len(batchErr.Failures) != 1 ||
+		batchErr.Failures[0].Start != 3 || batchErr.Failures[0].End != 6 {
+		t.Errorf("got batch error %+v", batchErr)
+	}
+	if !errors.As(err, &apiErr) || !openai.IsMultiError(err) || len(batchErr.Errors) != 1 {
+		t.Errorf("EmbeddingBatchError does not wrap the API error in a MultiError: %v", err)
+	}
+	checkBatchedEmbeddings(t, response.Data, []int{0, 1, 2, 6, 7, 8})
+	if response.Usage.TotalTokens != 6 {
+		t.Errorf("got usage %+v, want the one of the batches that succeeded", response.Usage)
+	}
+}
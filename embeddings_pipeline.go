@@ -0,0 +1,247 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// EmbeddingPipelineOptions configures CreateEmbeddingsPipeline.
+type EmbeddingPipelineOptions struct {
+	// Request is the template of the requests, e.g. with their Model and
+	// Dimensions. Its Input is ignored.
+	Request EmbeddingRequest
+	// Batch splits the inputs into requests, see EmbeddingBatchOptions. Its
+	// Concurrency is the number of workers sending them; AllowPartial is
+	// ignored.
+	Batch EmbeddingBatchOptions
+	// RequestsPerMinute and TokensPerMinute space out the requests so that no
+	// more are sent per minute, unlimited if zero. Tokens are counted like
+	// Batch.CountTokens does.
+	RequestsPerMinute int
+	TokensPerMinute   int
+	// AdaptToRateLimitHeaders lowers the budgets to the x-ratelimit limits of
+	// the responses, and pauses the requests until the limits reset when the
+	// responses report nothing remains.
+	AdaptToRateLimitHeaders bool
+	// MaxRetries is the number of times a batch failing with an error for
+	// which IsRetryable reports true is sent again.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry of a batch, doubling
+	// with each retry. It defaults to 500ms.
+	RetryBackoff time.Duration
+	// OnProgress is called, never concurrently, each time a batch completed,
+	// with the number of inputs that have a result.
+	OnProgress func(completed, total int)
+}
+
+// EmbeddingResult is the embedding of the input at Index, or the error of its
+// batch.
+type EmbeddingResult struct {
+	Index     int
+	Embedding []float32
+	Err       error
+}
+
+// CreateEmbeddingsPipeline embeds a large number of inputs by sending batches
+// of them with a pool of workers, within the rate limits of opts, and retrying
+// the batches that fail. It sends one result per input on the returned
+// channel as the batches complete, so in any order, and closes it once all
+// were sent. The channel must be read until then, or until ctx is done: the
+// batches that were not sent yet then fail with the context's error, and the
+// results that nobody reads are dropped.
+func (c *Client) CreateEmbeddingsPipeline(
+	ctx context.Context,
+	inputs []string,
+	opts EmbeddingPipelineOptions,
+) <-chan EmbeddingResult {
+	results := make(chan EmbeddingResult)
+	request := opts.Request
+	request.Input = inputs
+	batches := opts.Batch.split(request)
+
+	limiter := newEmbeddingRateLimiter(opts.RequestsPerMinute, opts.TokensPerMinute)
+	var (
+		progressMu sync.Mutex
+		completed  int
+	)
+	progress := func(batch embeddingBatch) {
+		if opts.OnProgress == nil {
+			return
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		completed += batch.end - batch.start
+		opts.OnProgress(completed, len(inputs))
+	}
+
+	queue := make(chan embeddingBatch)
+	go func() {
+		defer close(queue)
+		for _, batch := range batches {
+			select {
+			case queue <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for workers := max(opts.Batch.Concurrency, 1); workers > 0; workers-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range queue {
+				vectors, err := c.sendEmbeddingBatch(ctx, batch, limiter, opts)
+				for i := batch.start; i < batch.end; i++ {
+					result := EmbeddingResult{Index: i, Err: err}
+					if err == nil {
+						result.Embedding = vectors[i-batch.start]
+					}
+					// Prefer sending to dropping the result when both are
+					// possible, so readers get what they keep reading.
+					select {
+					case results <- result:
+						continue
+					default:
+					}
+					select {
+					case results <- result:
+					case <-ctx.Done():
+						return
+					}
+				}
+				progress(batch)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// sendEmbeddingBatch creates the embeddings of batch, retrying it as
+// configured by opts.
+func (c *Client) sendEmbeddingBatch(
+	ctx context.Context,
+	batch embeddingBatch,
+	limiter *embeddingRateLimiter,
+	opts EmbeddingPipelineOptions,
+) ([][]float32, error) {
+	minBackoff := opts.RetryBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultRetryMinBackoff
+	}
+	for attempt := 1; ; attempt++ {
+		if err := limiter.wait(ctx, batch.tokens); err != nil {
+			return nil, err
+		}
+		res, err := c.CreateEmbeddings(ctx, batch.request)
+		if opts.AdaptToRateLimitHeaders && res.Header() != nil {
+			limiter.adapt(res.GetRateLimitHeaders())
+		}
+		if err == nil {
+			return embeddingVectors(res, batch.end-batch.start)
+		}
+		if attempt > opts.MaxRetries || !IsRetryable(err) {
+			return nil, err
+		}
+		delay := exponentialBackoff(minBackoff, defaultRetryMaxBackoff, attempt)
+		if after := retryAfterOf(err); after > 0 {
+			delay = after
+			// The other workers would be rate limited too.
+			if opts.AdaptToRateLimitHeaders {
+				limiter.pause(after)
+			}
+		}
+		if err = sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// embeddingRateLimiter spaces out requests so that each takes up the time
+// its share of the requests and tokens per minute allows.
+type embeddingRateLimiter struct {
+	mu              sync.Mutex
+	requestInterval time.Duration
+	tokenInterval   time.Duration
+	// next is when the next request may be sent.
+	next time.Time
+}
+
+func newEmbeddingRateLimiter(requestsPerMinute, tokensPerMinute int) *embeddingRateLimiter {
+	l := &embeddingRateLimiter{}
+	if requestsPerMinute > 0 {
+		l.requestInterval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	if tokensPerMinute > 0 {
+		l.tokenInterval = time.Minute / time.Duration(tokensPerMinute)
+	}
+	return l
+}
+
+// wait waits until a request of tokens tokens may be sent.
+func (l *embeddingRateLimiter) wait(ctx context.Context, tokens int) error {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(max(l.requestInterval, time.Duration(tokens)*l.tokenInterval))
+	l.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return sleepContext(ctx, time.Until(start))
+}
+
+// adapt lowers the budgets to the limits of the headers, and delays the next
+// request until a limit resets when nothing of it remains.
+func (l *embeddingRateLimiter) adapt(headers RateLimitHeaders) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if headers.LimitRequests > 0 {
+		l.requestInterval = max(l.requestInterval, time.Minute/time.Duration(headers.LimitRequests))
+		if headers.RemainingRequests == 0 {
+			l.delay(headers.ResetRequestsAfter)
+		}
+	}
+	if headers.LimitTokens > 0 {
+		l.tokenInterval = max(l.tokenInterval, time.Minute/time.Duration(headers.LimitTokens))
+		if headers.RemainingTokens == 0 {
+			l.delay(headers.ResetTokensAfter)
+		}
+	}
+}
+
+// pause delays the next request by d.
+func (l *embeddingRateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delay(d)
+}
+
+func (l *embeddingRateLimiter) delay(d time.Duration) {
+	if until := time.Now().Add(d); l.next.Before(until) {
+		l.next = until
+	}
+}
+
+// retryAfterOf returns how long the API error err asks to wait before
+// retrying, zero if it does not.
+func retryAfterOf(err error) time.Duration {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.RetryAfter
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,260 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+// throttlingHandler answers like next, unless the request arrives less than
+// interval after the previous one, which gets a 429. It records when the
+// requests it answered arrived.
+type throttlingHandler struct {
+	next     http.HandlerFunc
+	interval time.Duration
+
+	mu        sync.Mutex
+	last      time.Time
+	throttled int
+	arrivals  []time.Time
+}
+
+func (h *throttlingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	now := time.Now()
+	if !h.last.IsZero() && now.Sub(h.last) < h.interval {
+		h.throttled++
+		h.mu.Unlock()
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`))
+		return
+	}
+	h.last = now
+	h.arrivals = append(h.arrivals, now)
+	h.mu.Unlock()
+	h.next(w, r)
+}
+
+func collectEmbeddingResults(t *testing.T, results <-chan openai.EmbeddingResult, n int) []openai.EmbeddingResult {
+	t.Helper()
+	collected := make([]openai.EmbeddingResult, n)
+	seen := make([]bool, n)
+	for result := range results {
+		if result.Index < 0 || result.Index >= n || seen[result.Index] {
+			t.Fatalf("got result of invalid or repeated index %d", result.Index)
+		}
+		seen[result.Index] = true
+		collected[result.Index] = result
+	}
+	if i := slices.Index(seen, false); i >= 0 {
+		t.Fatalf("got no result for input %d", i)
+	}
+	return collected
+}
+
+func TestCreateEmbeddingsPipeline(t *testing.T) {
+	server := openaitest.NewServer(t)
+	batches := &batchEmbeddingsHandler{}
+	handler := &throttlingHandler{next: batches.ServeHTTP, interval: 30 * time.Millisecond}
+	server.Handle("/embeddings", handler.ServeHTTP)
+	client := server.Client()
+
+	var progress []int
+	inputs := numberedInputs(12)
+	results := client.CreateEmbeddingsPipeline(context.Background(), inputs, openai.EmbeddingPipelineOptions{
+		Request:           openai.EmbeddingRequest{Model: openai.SmallEmbedding3},
+		Batch:             openai.EmbeddingBatchOptions{MaxInputs: 2, Concurrency: 4},
+		RequestsPerMinute: 1000, // One request every 60ms.
+		OnProgress: func(completed, total int) {
+			if total != len(inputs) {
+				t.Errorf("got progress total %d, want %d", total, len(inputs))
+			}
+			progress = append(progress, completed)
+		},
+	})
+	for i, result := range collectEmbeddingResults(t, results, len(inputs)) {
+		if result.Err != nil || len(result.Embedding) != 1 || result.Embedding[0] != float32(i) {
+			t.Errorf("result %d = %+v, want the embedding of its input", i, result)
+		}
+	}
+
+	if handler.throttled != 0 {
+		t.Errorf("got throttled %d times, want requests spaced out", handler.throttled)
+	}
+	if len(handler.arrivals) != 6 {
+		t.Errorf("sent %d requests, want 6", len(handler.arrivals))
+	}
+	if !slices.Equal(progress, []int{2, 4, 6, 8, 10, 12}) {
+		t.Errorf("got progress %v", progress)
+	}
+}
+
+func TestCreateEmbeddingsPipelineRetries(t *testing.T) {
+	server := openaitest.NewServer(t)
+	batches := &batchEmbeddingsHandler{}
+	handler := &throttlingHandler{next: batches.ServeHTTP, interval: 20 * time.Millisecond}
+	server.Handle("/embeddings", handler.ServeHTTP)
+	client := server.Client()
+
+	inputs := numberedInputs(8)
+	inputs[5] = "fail"
+	results := client.CreateEmbeddingsPipeline(context.Background(), inputs, openai.EmbeddingPipelineOptions{
+		Request:      openai.EmbeddingRequest{Model: openai.SmallEmbedding3},
+		Batch:        openai.EmbeddingBatchOptions{MaxInputs: 2, Concurrency: 4},
+		MaxRetries:   10,
+		RetryBackoff: 10 * time.Millisecond,
+	})
+	for i, result := range collectEmbeddingResults(t, results, len(inputs)) {
+		if i == 4 || i == 5 {
+			// Errors other than rate limits are not retried.
+			if !errors.Is(result.Err, openai.ErrBadRequest) {
+				t.Errorf("result %d = %+v, want the error of its batch", i, result)
+			}
+			continue
+		}
+		if result.Err != nil || result.Embedding[0] != float32(i) {
+			t.Errorf("result %d = %+v, want the embedding of its input", i, result)
+		}
+	}
+	if handler.throttled == 0 {
+		t.Error("the requests were not throttled")
+	}
+	if len(handler.arrivals) != 4 {
+		t.Errorf("answered %d requests, want 4", len(handler.arrivals))
+	}
+}
+
+func TestCreateEmbeddingsPipelineRateLimitHeaders(t *testing.T) {
+	server := openaitest.NewServer(t)
+	batches := &batchEmbeddingsHandler{}
+	var (
+		requests int
+		arrivals []time.Time
+	)
+	server.Handle("/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		arrivals = append(arrivals, time.Now())
+		if requests == 1 {
+			w.Header().Set("x-ratelimit-limit-requests", "100000")
+			w.Header().Set("x-ratelimit-remaining-requests", "0")
+			w.Header().Set("x-ratelimit-reset-requests", "100ms")
+		}
+		batches.ServeHTTP(w, r)
+	})
+	client := server.Client()
+
+	inputs := numberedInputs(3)
+	results := client.CreateEmbeddingsPipeline(context.Background(), inputs, openai.EmbeddingPipelineOptions{
+		Request:                 openai.EmbeddingRequest{Model: openai.SmallEmbedding3},
+		Batch:                   openai.EmbeddingBatchOptions{MaxInputs: 1},
+		AdaptToRateLimitHeaders: true,
+	})
+	for i, result := range collectEmbeddingResults(t, results, len(inputs)) {
+		if result.Err != nil || result.Embedding[0] != float32(i) {
+			t.Errorf("result %d = %+v, want the embedding of its input", i, result)
+		}
+	}
+	if len(arrivals) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(arrivals))
+	}
+	if gap := arrivals[1].Sub(arrivals[0]); gap < 90*time.Millisecond {
+		t.Errorf("sent the second request %v after the first, want after the reset", gap)
+	}
+	if gap := arrivals[2].Sub(arrivals[1]); gap > 90*time.Millisecond {
+		t.Errorf("sent the third request %v after the second, want no wait", gap)
+	}
+}
+
+func TestCreateEmbeddingsPipelineRetryAfter(t *testing.T) {
+	server := openaitest.NewServer(t)
+	batches := &batchEmbeddingsHandler{}
+	var arrivals []time.Time
+	server.Handle("/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		arrivals = append(arrivals, time.Now())
+		if len(arrivals) == 1 {
+			w.Header().Set("retry-after-ms", "100")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`))
+			return
+		}
+		batches.ServeHTTP(w, r)
+	})
+	client := server.Client()
+
+	inputs := numberedInputs(1)
+	results := client.CreateEmbeddingsPipeline(context.Background(), inputs, openai.EmbeddingPipelineOptions{
+		Request:      openai.EmbeddingRequest{Model: openai.SmallEmbedding3},
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+	})
+	if result := collectEmbeddingResults(t, results, len(inputs))[0]; result.Err != nil {
+		t.Fatalf("got error %v", result.Err)
+	}
+	if len(arrivals) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(arrivals))
+	}
+	if gap := arrivals[1].Sub(arrivals[0]); gap < 90*time.Millisecond {
+		t.Errorf("retried %v after the rate limit, want after its Retry-After", gap)
+	}
+}
+
+func TestCreateEmbeddingsPipelineCanceled(t *testing.T) {
+	server := openaitest.NewServer(t)
+	batches := &batchEmbeddingsHandler{}
+	server.Handle("/embeddings", batches.ServeHTTP)
+	client := server.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inputs := numberedInputs(6)
+	results := client.CreateEmbeddingsPipeline(ctx, inputs, openai.EmbeddingPipelineOptions{
+		Request: openai.EmbeddingRequest{Model: openai.SmallEmbedding3},
+		Batch:   openai.EmbeddingBatchOptions{MaxInputs: 2},
+		OnProgress: func(int, int) {
+			cancel()
+		},
+	})
+	// The results of the first batch were sent before canceling, the others
+	// fail or are dropped.
+	var collected []openai.EmbeddingResult
+	for result := range results {
+		collected = append(collected, result)
+	}
+	if len(collected) < 2 || collected[0].Err != nil || collected[1].Err != nil {
+		t.Fatalf("the first batch failed: %+v", collected)
+	}
+	for _, result := range collected[2:] {
+		if result.Err == nil {
+			t.Errorf("result %d of a canceled pipeline succeeded", result.Index)
+		}
+	}
+
+	// Without inputs, the channel is closed at once.
+	collectEmbeddingResults(t, client.CreateEmbeddingsPipeline(ctx, nil, openai.EmbeddingPipelineOptions{}), 0)
+}
+
+func TestCreateEmbeddingsPipelineAbandoned(t *testing.T) {
+	server := openaitest.NewServer(t)
+	batches := &batchEmbeddingsHandler{}
+	server.Handle("/embeddings", batches.ServeHTTP)
+	client := server.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := client.CreateEmbeddingsPipeline(ctx, numberedInputs(20), openai.EmbeddingPipelineOptions{
+		Request: openai.EmbeddingRequest{Model: openai.SmallEmbedding3},
+		Batch:   openai.EmbeddingBatchOptions{MaxInputs: 1, Concurrency: 4},
+	})
+	<-results
+	cancel()
+	// The workers stop instead of waiting for the results to be read.
+	time.Sleep(50 * time.Millisecond)
+	if result, ok := <-results; ok {
+		t.Errorf("got result %+v after canceling, want the channel closed", result)
+	}
+}
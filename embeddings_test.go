@@ -174,6 +174,222 @@ func TestEmbeddingEndpoint(t *testing.T) {
 	checks.HasError(t, err, "CreateEmbeddings error")
 }
 
+func TestEmbeddingDimensions(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+
+	const dimensions = 256
+	vector := make([]float32, dimensions)
+	for i := range vector {
+		vector[i] = float32(i) / dimensions
+	}
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model      string `json:"model"`
+			Dimensions *int   `json:"dimensions"`
+		}
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&req), "Decode error")
+		if req.Model != "text-embedding-3-small" || req.Dimensions == nil || *req.Dimensions != dimensions {
+			t.Errorf("got model %q and dimensions %v, want text-embedding-3-small and %d", req.Model, req.Dimensions, dimensions)
+		}
+		resBytes, _ := json.Marshal(openai.EmbeddingResponse{
+			Object: "list",
+			Model:  openai.SmallEmbedding3,
+			Data: []openai.Embedding{
+				{Object: "embedding", Embedding: vector},
+				{Object: "embedding", Embedding: vector, Index: 1},
+			},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	for _, request := range []openai.EmbeddingRequestConverter{
+		openai.EmbeddingRequest{Input: []string{"a", "b"}, Model: openai.SmallEmbedding3, Dimensions: dimensions},
+		openai.EmbeddingRequestStrings{Input: []string{"a", "b"}, Model: openai.SmallEmbedding3, Dimensions: dimensions},
+		openai.EmbeddingRequestTokens{Input: [][]int{{64}, {65}}, Model: openai.SmallEmbedding3, Dimensions: dimensions},
+	} {
+		res, err := client.CreateEmbeddings(context.Background(), request)
+		checks.NoError(t, err, "CreateEmbeddings error")
+		if res.Model != openai.SmallEmbedding3 || len(res.Data) != 2 {
+			t.Fatalf("got response %+v", res)
+		}
+		for _, embedding := range res.Data {
+			if !reflect.DeepEqual(embedding.Embedding, vector) {
+				t.Errorf("got embedding of %d dimensions, want %d", len(embedding.Embedding), dimensions)
+			}
+		}
+	}
+
+	// Dimensions are omitted when zero.
+	marshaled, err := json.Marshal(openai.EmbeddingRequest{Model: openai.LargeEmbedding3})
+	checks.NoError(t, err, "Marshal error")
+	if bytes.Contains(marshaled, []byte("dimensions")) {
+		t.Errorf("got %s, want no dimensions", marshaled)
+	}
+
+	_, err = client.CreateEmbeddings(context.Background(), openai.EmbeddingRequestStrings{
+		Input: []string{"a"}, Model: openai.SmallEmbedding3, Dimensions: -1,
+	})
+	var validationErrs openai.ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 1 || validationErrs[0].Field != "dimensions" {
+		t.Errorf("got error %v for negative dimensions, want a dimensions ValidationError", err)
+	}
+}
+
+func TestCreateEmbeddingsWithDimensions(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input      []string `json:"input"`
+			Model      string   `json:"model"`
+			Dimensions int      `json:"dimensions"`
+		}
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&req), "Decode error")
+		if req.Model != "text-embedding-3-large" || req.Dimensions != 2 {
+			t.Errorf("got model %q and dimensions %d", req.Model, req.Dimensions)
+		}
+		// List the embeddings in reverse to check they are returned in order.
+		var res openai.EmbeddingResponse
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			res.Data = append(res.Data, openai.Embedding{Embedding: []float32{float32(i), 1}, Index: i})
+		}
+		resBytes, _ := json.Marshal(res)
+		fmt.Fprintln(w, string(resBytes))
+	})
+	ctx := context.Background()
+
+	embedding, err := client.CreateEmbeddingWithDimensions(ctx, "a", "text-embedding-3-large", 2)
+	checks.NoError(t, err, "CreateEmbeddingWithDimensions error")
+	if !reflect.DeepEqual(embedding, []float32{0, 1}) {
+		t.Errorf("got embedding %v", embedding)
+	}
+	embeddings, err := client.CreateEmbeddingsWithDimensions(ctx, []string{"a", "b", "c"}, "text-embedding-3-large", 2)
+	checks.NoError(t, err, "CreateEmbeddingsWithDimensions error")
+	if !reflect.DeepEqual(embeddings, [][]float32{{0, 1}, {1, 1}, {2, 1}}) {
+		t.Errorf("got embeddings %v", embeddings)
+	}
+
+	for _, tc := range []struct {
+		model      string
+		dimensions int
+		field      string
+	}{
+		{"text-embedding-3-small", 0, "dimensions"},
+		{"text-embedding-3-small", 1536, "dimensions"},
+		{"text-embedding-3-large", -1, "dimensions"},
+		{"text-embedding-ada-002", 256, "model"},
+		{"unknown", 256, "model"},
+	} {
+		_, err = client.CreateEmbeddingWithDimensions(ctx, "a", tc.model, tc.dimensions)
+		var validationErrs openai.ValidationErrors
+		if !errors.As(err, &validationErrs) || validationErrs[0].Field != tc.field {
+			t.Errorf("%s with %d dimensions: got error %v, want a %s ValidationError",
+				tc.model, tc.dimensions, err, tc.field)
+		}
+	}
+}
+
+func TestEmbeddingResponseToMatrix(t *testing.T) {
+	res := openai.EmbeddingResponse{Data: []openai.Embedding{
+		{Embedding: []float32{2, 2.5}, Index: 2},
+		{Embedding: []float32{0, 0.5}, Index: 0},
+		{Embedding: []float32{1, 1.5}, Index: 1},
+	}}
+	if got := res.ToMatrix(); !reflect.DeepEqual(got, [][]float32{{0, 0.5}, {1, 1.5}, {2, 2.5}}) {
+		t.Errorf("ToMatrix = %v", got)
+	}
+	if got := res.ToDenseMatrix(); !reflect.DeepEqual(got, []float32{0, 0.5, 1, 1.5, 2, 2.5}) {
+		t.Errorf("ToDenseMatrix = %v", got)
+	}
+	if res.Data[0].Index != 2 {
+		t.Error("ToMatrix sorted the data of the response")
+	}
+
+	var empty openai.EmbeddingResponse
+	if empty.ToMatrix() != nil || empty.ToDenseMatrix() != nil {
+		t.Error("got matrices of an empty response")
+	}
+}
+
+func TestEmbeddingInput(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input openai.EmbeddingInput
+		want  string
+	}{
+		{"text", openai.EmbeddingInput{Text: "hello"}, `"hello"`},
+		{"texts", openai.EmbeddingInput{Texts: []string{"hello", "world"}}, `["hello","world"]`},
+		{"tokens", openai.EmbeddingInput{Tokens: []int{15339, 1917}}, `[15339,1917]`},
+		{"token arrays", openai.EmbeddingInput{TokenArrays: [][]int{{15339}, {1917, 0}}}, `[[15339],[1917,0]]`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := openai.EmbeddingRequest{Input: tc.input, Model: openai.SmallEmbedding3}
+			checks.NoError(t, request.Validate(), "Validate error")
+			marshaled, err := json.Marshal(request)
+			checks.NoError(t, err, "Marshal error")
+			want := `{"input":` + tc.want + `,"model":"text-embedding-3-small","user":""}`
+			if string(marshaled) != want {
+				t.Errorf("got %s, want %s", marshaled, want)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		input openai.EmbeddingInput
+		err   error
+	}{
+		{openai.EmbeddingInput{}, openai.ErrEmbeddingInputEmpty},
+		{openai.EmbeddingInput{Text: "hello", Tokens: []int{15339}}, openai.ErrEmbeddingInputMixed},
+		{openai.EmbeddingInput{Texts: []string{"hello"}, TokenArrays: [][]int{{15339}}}, openai.ErrEmbeddingInputMixed},
+	} {
+		_, err := json.Marshal(openai.EmbeddingRequest{Input: tc.input})
+		checks.ErrorIs(t, err, tc.err, "Marshal should fail")
+		err = openai.EmbeddingRequest{Input: tc.input}.Validate()
+		var validationErrs openai.ValidationErrors
+		if !errors.As(err, &validationErrs) || validationErrs[0].Field != "input" {
+			t.Errorf("Validate returned %v, want an input ValidationError", err)
+		}
+	}
+}
+
+func TestEmbeddingInputEndpoint(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input json.RawMessage `json:"input"`
+		}
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&req), "Decode error")
+		if string(req.Input) != `[[1,2],[3]]` {
+			t.Errorf("got input %s", req.Input)
+		}
+		resBytes, _ := json.Marshal(openai.EmbeddingResponse{Data: []openai.Embedding{
+			{Embedding: []float32{0.1}}, {Embedding: []float32{0.2}, Index: 1},
+		}})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	res, err := client.CreateEmbeddings(context.Background(), openai.EmbeddingRequest{
+		Input: openai.EmbeddingInput{TokenArrays: [][]int{{1, 2}, {3}}},
+		Model: openai.SmallEmbedding3,
+	})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if len(res.Data) != 2 {
+		t.Errorf("got %d embeddings, want 2", len(res.Data))
+	}
+
+	_, err = client.CreateEmbeddings(context.Background(), openai.EmbeddingRequest{
+		Input: openai.EmbeddingInput{Text: "hello", Tokens: []int{1}},
+		Model: openai.SmallEmbedding3,
+	})
+	var validationErrs openai.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Errorf("got error %v for mixed input, want ValidationErrors", err)
+	}
+}
+
 func TestEmbeddingResponseBase64_ToEmbeddingResponse(t *testing.T) {
 	type fields struct {
 		Object string
@@ -271,3 +487,70 @@ func TestDotProduct(t *testing.T) {
 		t.Errorf("Expected Vector Length Mismatch Error, but got: %v", err)
 	}
 }
+
+func TestCosineSimilarity(t *testing.T) {
+	vectors := []*openai.Embedding{
+		{Embedding: []float32{1, 2, 3}},
+		{Embedding: []float32{-0.5, 0.25, 4}},
+		{Embedding: []float32{1e-3, 7, -2}},
+		{Embedding: []float32{0.1, 0.1, 0.1}},
+	}
+	for i, v1 := range vectors {
+		self, err := v1.CosineSimilarity(v1)
+		checks.NoError(t, err, "CosineSimilarity error")
+		if math.Abs(float64(self-1)) > 1e-6 {
+			t.Errorf("similarity of vector %d with itself = %v, want 1", i, self)
+		}
+		for j, v2 := range vectors {
+			s12, _ := v1.CosineSimilarity(v2)
+			s21, _ := v2.CosineSimilarity(v1)
+			if s12 != s21 || s12 < -1 || s12 > 1 {
+				t.Errorf("similarities of vectors %d and %d = %v and %v", i, j, s12, s21)
+			}
+		}
+	}
+
+	orthogonal, _ := (&openai.Embedding{Embedding: []float32{1, 0}}).CosineSimilarity(
+		&openai.Embedding{Embedding: []float32{0, 3}})
+	opposite, _ := (&openai.Embedding{Embedding: []float32{1, 2}}).CosineSimilarity(
+		&openai.Embedding{Embedding: []float32{-2, -4}})
+	if orthogonal != 0 || math.Abs(float64(opposite+1)) > 1e-6 {
+		t.Errorf("got similarities %v and %v, want 0 and -1", orthogonal, opposite)
+	}
+
+	zero := &openai.Embedding{Embedding: []float32{0, 0, 0}}
+	similarity, err := zero.CosineSimilarity(vectors[0])
+	checks.NoError(t, err, "CosineSimilarity error")
+	if similarity != 0 {
+		t.Errorf("similarity with a zero vector = %v, want 0", similarity)
+	}
+
+	_, err = vectors[0].CosineSimilarity(&openai.Embedding{Embedding: []float32{1}})
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "CosineSimilarity should fail on mismatched lengths")
+}
+
+func TestNorm(t *testing.T) {
+	if norm := (&openai.Embedding{Embedding: []float32{3, 4}}).Norm(); norm != 5 {
+		t.Errorf("Norm = %v, want 5", norm)
+	}
+	if norm := (&openai.Embedding{}).Norm(); norm != 0 {
+		t.Errorf("Norm of an empty vector = %v, want 0", norm)
+	}
+}
+
+// BenchmarkCosineSimilarity compares vectors of the size of text-embedding-3-small.
+func BenchmarkCosineSimilarity(b *testing.B) {
+	v1 := &openai.Embedding{Embedding: make([]float32, 1536)}
+	v2 := &openai.Embedding{Embedding: make([]float32, 1536)}
+	for i := range v1.Embedding {
+		v1.Embedding[i] = float32(math.Sin(float64(i)))
+		v2.Embedding[i] = float32(math.Cos(float64(i)))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := v1.CosineSimilarity(v2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvAPIKey        = "OPENAI_API_KEY"
+	EnvOrgID         = "OPENAI_ORG_ID"
+	EnvProjectID     = "OPENAI_PROJECT_ID"
+	EnvBaseURL       = "OPENAI_BASE_URL"
+	EnvAPIVersion    = "OPENAI_API_VERSION"
+	EnvAzureEndpoint = "AZURE_OPENAI_ENDPOINT"
+	EnvAzureAPIKey   = "AZURE_OPENAI_API_KEY"
+)
+
+var (
+	ErrMissingAPIKey  = errors.New("API key is not set")
+	ErrMissingBaseURL = errors.New("base URL is not set")
+)
+
+// ConfigFromEnv returns a config read from the environment. When
+// AZURE_OPENAI_ENDPOINT is set, it is a config for that Azure endpoint,
+// authenticated with AZURE_OPENAI_API_KEY or else OPENAI_API_KEY. Otherwise it
+// is a config for OPENAI_BASE_URL, which defaults to the OpenAI API,
+// authenticated with OPENAI_API_KEY. OPENAI_ORG_ID, OPENAI_PROJECT_ID and
+// OPENAI_API_VERSION are optional.
+func ConfigFromEnv() (ClientConfig, error) {
+	var config ClientConfig
+	if endpoint := os.Getenv(EnvAzureEndpoint); endpoint != "" {
+		apiKey := os.Getenv(EnvAzureAPIKey)
+		if apiKey == "" {
+			apiKey = os.Getenv(EnvAPIKey)
+		}
+		if apiKey == "" {
+			return ClientConfig{}, fmt.Errorf("%w: set %s or %s", ErrMissingAPIKey, EnvAzureAPIKey, EnvAPIKey)
+		}
+		config = DefaultAzureConfig(apiKey, endpoint)
+	} else {
+		apiKey := os.Getenv(EnvAPIKey)
+		if apiKey == "" {
+			return ClientConfig{}, fmt.Errorf("%w: set %s", ErrMissingAPIKey, EnvAPIKey)
+		}
+		config = DefaultConfig(apiKey)
+		if baseURL := os.Getenv(EnvBaseURL); baseURL != "" {
+			config.BaseURL = baseURL
+		}
+	}
+
+	config.OrgID = os.Getenv(EnvOrgID)
+	config.ProjectID = os.Getenv(EnvProjectID)
+	if version := os.Getenv(EnvAPIVersion); version != "" {
+		config.APIVersion = version
+	}
+	return config, nil
+}
+
+// NewClientFromEnv creates a client configured by ConfigFromEnv.
+func NewClientFromEnv(opts ...ClientOption) (*Client, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithConfig(config, opts...), nil
+}
+
+// MustNewClientFromEnv is like NewClientFromEnv but panics on error. It is
+// meant for scripts and package initialization.
+func MustNewClientFromEnv(opts ...ClientOption) *Client {
+	client, err := NewClientFromEnv(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// WithAPIKeyFromEnv returns an option authenticating with the API key in the
+// environment variable envVar. It returns an error wrapping ErrMissingAPIKey
+// if the variable is empty or unset, so that a missing key fails at setup
+// rather than at the first call.
+func WithAPIKeyFromEnv(envVar string) (ClientOption, error) {
+	apiKey := os.Getenv(envVar)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: set %s", ErrMissingAPIKey, envVar)
+	}
+	return func(config *ClientConfig) {
+		config.authToken = apiKey
+	}, nil
+}
+
+// MustWithAPIKeyFromEnv is like WithAPIKeyFromEnv but panics on error. It is
+// meant for main functions.
+func MustWithAPIKeyFromEnv(envVar string) ClientOption {
+	opt, err := WithAPIKeyFromEnv(envVar)
+	if err != nil {
+		panic(err)
+	}
+	return opt
+}
+
+// WithBaseURLFromEnv returns an option setting ClientConfig.BaseURL to the
+// environment variable envVar. It returns an error wrapping ErrMissingBaseURL
+// if the variable is empty or unset, or ErrInvalidBaseURL if it is not an
+// absolute http(s) URL.
+func WithBaseURLFromEnv(envVar string) (ClientOption, error) {
+	baseURL := os.Getenv(envVar)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%w: set %s", ErrMissingBaseURL, envVar)
+	}
+	if err := validateBaseURL(baseURL); err != nil {
+		return nil, fmt.Errorf("%s: %w", envVar, err)
+	}
+	return WithBaseURL(baseURL), nil
+}
@@ -0,0 +1,134 @@
+package openai //nolint:testpackage // testing private field
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func clearOpenAIEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		EnvAPIKey, EnvOrgID, EnvProjectID, EnvBaseURL, EnvAPIVersion, EnvAzureEndpoint, EnvAzureAPIKey,
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	clearOpenAIEnv(t)
+	t.Setenv(EnvAPIKey, "sk-test")
+	t.Setenv(EnvOrgID, "org-test")
+	t.Setenv(EnvProjectID, "proj-test")
+	t.Setenv(EnvBaseURL, "https://proxy.example.com/v1")
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned error: %v", err)
+	}
+	config := client.config
+	if config.APIType != APITypeOpenAI || config.BaseURL != "https://proxy.example.com/v1" ||
+		config.authToken != "sk-test" || config.OrgID != "org-test" || config.ProjectID != "proj-test" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, config.BaseURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.setCommonHeaders(req)
+	if req.Header.Get("Authorization") != "Bearer sk-test" || req.Header.Get("OpenAI-Project") != "proj-test" {
+		t.Errorf("unexpected headers %v", req.Header)
+	}
+}
+
+func TestNewClientFromEnvAzure(t *testing.T) {
+	clearOpenAIEnv(t)
+	t.Setenv(EnvAPIKey, "sk-test")
+	t.Setenv(EnvAzureEndpoint, "https://test.openai.azure.com/")
+	t.Setenv(EnvAzureAPIKey, "azure-key")
+	t.Setenv(EnvAPIVersion, "2024-02-01")
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned error: %v", err)
+	}
+	config := client.config
+	if config.APIType != APITypeAzure || config.BaseURL != "https://test.openai.azure.com/" ||
+		config.authToken != "azure-key" || config.APIVersion != "2024-02-01" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	t.Setenv(EnvAzureAPIKey, "")
+	client, err = NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv returned error: %v", err)
+	}
+	if client.config.authToken != "sk-test" {
+		t.Errorf("Azure config should fall back to %s", EnvAPIKey)
+	}
+}
+
+func TestNewClientFromEnvMissingKey(t *testing.T) {
+	clearOpenAIEnv(t)
+	if _, err := NewClientFromEnv(); !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("NewClientFromEnv returned %v, want ErrMissingAPIKey", err)
+	}
+
+	t.Setenv(EnvAzureEndpoint, "https://test.openai.azure.com/")
+	if _, err := NewClientFromEnv(); !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("NewClientFromEnv returned %v, want ErrMissingAPIKey", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustNewClientFromEnv should panic without an API key")
+		}
+	}()
+	MustNewClientFromEnv()
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-env")
+	t.Setenv("TEST_OPENAI_URL", "https://proxy.example.com/v1")
+	t.Setenv("TEST_OPENAI_EMPTY", "")
+	t.Setenv("TEST_OPENAI_BAD_URL", "proxy.example.com")
+
+	withKey, err := WithAPIKeyFromEnv("TEST_OPENAI_KEY")
+	if err != nil {
+		t.Fatalf("WithAPIKeyFromEnv returned error: %v", err)
+	}
+	withURL, err := WithBaseURLFromEnv("TEST_OPENAI_URL")
+	if err != nil {
+		t.Fatalf("WithBaseURLFromEnv returned error: %v", err)
+	}
+	client, err := NewClientWithOptions("", withKey, withURL)
+	if err != nil {
+		t.Fatalf("NewClientWithOptions returned error: %v", err)
+	}
+	if client.config.authToken != "sk-env" || client.config.BaseURL != "https://proxy.example.com/v1" {
+		t.Errorf("unexpected config %+v", client.config)
+	}
+	if MustWithAPIKeyFromEnv("TEST_OPENAI_KEY") == nil {
+		t.Error("MustWithAPIKeyFromEnv returned nil")
+	}
+
+	for _, name := range []string{"TEST_OPENAI_EMPTY", "TEST_OPENAI_UNSET"} {
+		if _, err = WithAPIKeyFromEnv(name); !errors.Is(err, ErrMissingAPIKey) {
+			t.Errorf("WithAPIKeyFromEnv(%q) returned %v, want ErrMissingAPIKey", name, err)
+		}
+		if _, err = WithBaseURLFromEnv(name); !errors.Is(err, ErrMissingBaseURL) {
+			t.Errorf("WithBaseURLFromEnv(%q) returned %v, want ErrMissingBaseURL", name, err)
+		}
+	}
+	if _, err = WithBaseURLFromEnv("TEST_OPENAI_BAD_URL"); !errors.Is(err, ErrInvalidBaseURL) {
+		t.Errorf("WithBaseURLFromEnv returned %v, want ErrInvalidBaseURL", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustWithAPIKeyFromEnv should panic without an API key")
+		}
+	}()
+	MustWithAPIKeyFromEnv("TEST_OPENAI_UNSET")
+}
@@ -2,8 +2,72 @@ package openai
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+)
+
+// Errors matching the codes of the most common API errors, for use with errors.Is.
+var (
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	ErrInvalidAPIKey         = errors.New("invalid API key")
+	ErrModelNotFound         = errors.New("model not found")
+	// ErrModelOverloaded matches the errors of overloaded models, see
+	// APIError.IsOverloaded.
+	ErrModelOverloaded = errors.New("model overloaded")
+)
+
+// Errors matching the classes of API errors, for use with errors.Is. They are
+// matched by the HTTP status of the error, or for errors sent within a stream
+// by their type and code.
+var (
+	ErrBadRequest     = errors.New("bad request")           // 400 and 422
+	ErrAuthentication = errors.New("authentication failed") // 401
+	ErrPermission     = errors.New("permission denied")     // 403
+	ErrNotFound       = errors.New("not found")             // 404
+	ErrRateLimited    = errors.New("rate limited")          // 429
+	ErrServer         = errors.New("server error")          // 5xx
+	// ErrInsufficientQuota matches the rate limit errors of accounts out of
+	// quota, which unlike other rate limits are not worth retrying.
+	ErrInsufficientQuota = errors.New("insufficient quota")
+)
+
+var apiErrorCodes = map[APIErrorCode]error{
+	ErrorCodeContextLengthExceeded: ErrContextLengthExceeded,
+	ErrorCodeInvalidAPIKey:         ErrInvalidAPIKey,
+	ErrorCodeModelNotFound:         ErrModelNotFound,
+}
+
+// APIErrorCode is the code of an API error, see APIError.ErrorCode.
+type APIErrorCode string
+
+// Codes of common API errors.
+const (
+	ErrorCodeContextLengthExceeded APIErrorCode = "context_length_exceeded"
+	ErrorCodeInvalidAPIKey         APIErrorCode = "invalid_api_key"
+	ErrorCodeModelNotFound         APIErrorCode = "model_not_found"
+	ErrorCodeRateLimitExceeded     APIErrorCode = "rate_limit_exceeded"
+	ErrorCodeInsufficientQuota     APIErrorCode = "insufficient_quota"
+	ErrorCodeModelOverloaded       APIErrorCode = "model_overloaded"
+	ErrorCodeContentFilter         APIErrorCode = "content_filter"
+	ErrorCodeInvalidValue          APIErrorCode = "invalid_value"
+	ErrorCodeUnsupportedParameter  APIErrorCode = "unsupported_parameter"
+)
+
+// Types of common API errors. They are untyped, like APIError.Type.
+const (
+	ErrorTypeInvalidRequest = "invalid_request_error"
+	ErrorTypeAuthentication = "authentication_error"
+	ErrorTypePermission     = "permission_error"
+	ErrorTypeNotFound       = "not_found_error"
+	ErrorTypeRateLimit      = "rate_limit_error"
+	ErrorTypeServer         = "server_error"
+	ErrorTypeOverloaded     = "overloaded_error"
 )
 
 // APIError provides error information returned by the OpenAI API.
@@ -15,6 +79,17 @@ type APIError struct {
 	Type           string      `json:"type"`
 	HTTPStatusCode int         `json:"-"`
 	InnerError     *InnerError `json:"innererror,omitempty"`
+	// RequestID is the x-request-id (or Azure apim-request-id) of the failed
+	// request, which OpenAI support asks for.
+	RequestID string `json:"-"`
+	// RetryAfter is how long the Retry-After or retry-after-ms header of the
+	// response asks to wait before retrying, zero if it has neither.
+	RetryAfter time.Duration `json:"-"`
+	// Method and URL are those of the failed request, with the credentials the
+	// URL may hold removed, and Stream whether it asked for a stream.
+	Method string `json:"-"`
+	URL    string `json:"-"`
+	Stream bool   `json:"-"`
 }
 
 // InnerError Azure Content filtering. Only valid for Azure OpenAI Service.
@@ -24,9 +99,19 @@ type InnerError struct {
 }
 
 // RequestError provides informations about generic request errors.
+// The embedded APIError is populated when the response body carries the error
+// fields without the usual {"error": ...} envelope.
 type RequestError struct {
-	HTTPStatusCode int
-	Err            error
+	// APIError holds the status of the response as well, in HTTPStatusCode.
+	APIError
+	Err error
+	// ContentType and Body are the Content-Type and the beginning of the body,
+	// made printable, of responses whose body is not a JSON error, such as the
+	// HTML pages of proxies and load balancers. Body is empty for empty bodies.
+	ContentType string
+	Body        string
+	// hasBody is set when the body was kept in Body.
+	hasBody bool
 }
 
 type ErrorResponse struct {
@@ -35,12 +120,187 @@ type ErrorResponse struct {
 
 func (e *APIError) Error() string {
 	if e.HTTPStatusCode > 0 {
-		return fmt.Sprintf("error, status code: %d, message: %s", e.HTTPStatusCode, e.Message)
+		return fmt.Sprintf("error, status code: %d, %s%s%smessage: %s", e.HTTPStatusCode,
+			e.requestField(), requestIDField(e.RequestID), paramField(e.Param), e.Message)
 	}
 
 	return e.Message
 }
 
+func (e *APIError) requestField() string {
+	if e.Method == "" && e.URL == "" {
+		return ""
+	}
+	field := fmt.Sprintf("method: %s, url: %s, ", e.Method, e.URL)
+	if e.Stream {
+		field += "stream: true, "
+	}
+	return field
+}
+
+// setRequest records the method, URL and streaming of the failed request req.
+func (e *APIError) setRequest(req *http.Request) {
+	if req == nil {
+		return
+	}
+	e.Method = req.Method
+	e.URL = redactedURL(req.URL)
+	e.Stream = req.Header.Get("Accept") == "text/event-stream"
+}
+
+// credentialQueryParams are the query parameters of URLs removed from errors
+// as they may hold credentials, compared ignoring case.
+var credentialQueryParams = []string{"api-key", "api_key", "apikey", "key", "access_token", "token"}
+
+// redactedURL returns u without its user info and the query parameters that
+// may hold credentials.
+func redactedURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.User = nil
+	if redacted.RawQuery != "" {
+		query := redacted.Query()
+		for key := range query {
+			if slices.Contains(credentialQueryParams, strings.ToLower(key)) {
+				query.Del(key)
+			}
+		}
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+func paramField(param *string) string {
+	if param == nil || *param == "" {
+		return ""
+	}
+	return fmt.Sprintf("param: %s, ", *param)
+}
+
+func requestIDField(requestID string) string {
+	if requestID == "" {
+		return ""
+	}
+	return fmt.Sprintf("request id: %s, ", requestID)
+}
+
+// Is reports whether the error code matches one of the sentinel errors, such as
+// ErrContextLengthExceeded, or the error is of the class of target, such as
+// ErrRateLimited, or is ErrModelOverloaded.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrModelOverloaded:
+		return e.IsOverloaded()
+	case ErrInsufficientQuota:
+		return e.IsInsufficientQuota() || e.Type == string(ErrorCodeInsufficientQuota)
+	}
+	if statusClassIs(e.statusCode(), target) {
+		return true
+	}
+	code := e.ErrorCode()
+	return code != "" && apiErrorCodes[code] == target
+}
+
+// statusCode returns the HTTP status of the error, or for errors without one,
+// such as those sent within a stream, the status matching its type and code.
+// Numeric codes, as sent by Azure, are statuses.
+func (e *APIError) statusCode() int {
+	if e.HTTPStatusCode != 0 || (e.Type == "" && e.Code == nil) {
+		return e.HTTPStatusCode
+	}
+	status, err := strconv.Atoi(string(e.ErrorCode()))
+	if err == nil && status >= http.StatusBadRequest && status < 600 {
+		return status
+	}
+	return streamErrorStatusCode(e)
+}
+
+// statusClassIs reports whether target is the sentinel error of the class of
+// the HTTP status.
+func statusClassIs(status int, target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+	case ErrAuthentication:
+		return status == http.StatusUnauthorized
+	case ErrPermission:
+		return status == http.StatusForbidden
+	case ErrNotFound:
+		return status == http.StatusNotFound
+	case ErrRateLimited:
+		return status == http.StatusTooManyRequests
+	case ErrServer:
+		return status >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// ErrorCode returns the code of the error as a string, formatting the numeric
+// codes some responses, notably of Azure, have instead of a string. It is
+// empty if the error has no code.
+func (e *APIError) ErrorCode() APIErrorCode {
+	switch code := e.Code.(type) {
+	case nil:
+		return ""
+	case string:
+		return APIErrorCode(code)
+	case int:
+		return APIErrorCode(strconv.Itoa(code))
+	default:
+		return APIErrorCode(fmt.Sprint(code))
+	}
+}
+
+// IsContextLengthExceeded reports whether the request had more tokens than the
+// context of the model accepts.
+func (e *APIError) IsContextLengthExceeded() bool {
+	return e.ErrorCode() == ErrorCodeContextLengthExceeded
+}
+
+// IsInvalidAPIKey reports whether the API key of the request was rejected.
+func (e *APIError) IsInvalidAPIKey() bool {
+	return e.ErrorCode() == ErrorCodeInvalidAPIKey
+}
+
+// IsModelNotFound reports whether the model of the request does not exist or
+// is not available to the API key.
+func (e *APIError) IsModelNotFound() bool {
+	return e.ErrorCode() == ErrorCodeModelNotFound
+}
+
+// IsRateLimitExceeded reports whether the request was rate limited. Unlike
+// insufficient quota errors, which share their status code, these are worth
+// retrying.
+func (e *APIError) IsRateLimitExceeded() bool {
+	return e.ErrorCode() == ErrorCodeRateLimitExceeded
+}
+
+// IsInsufficientQuota reports whether the account has exceeded its quota.
+func (e *APIError) IsInsufficientQuota() bool {
+	return e.ErrorCode() == ErrorCodeInsufficientQuota
+}
+
+// IsOverloaded reports whether the model was too busy to answer, which the API
+// reports with the model_overloaded code or, in older responses and on Azure,
+// only in messages such as "The engine is currently overloaded". Unlike other
+// server errors, these are worth retrying quickly.
+func (e *APIError) IsOverloaded() bool {
+	if e.ErrorCode() == ErrorCodeModelOverloaded || e.Type == ErrorTypeOverloaded {
+		return true
+	}
+	return strings.Contains(strings.ToLower(e.Message), "currently overloaded")
+}
+
+// Temporary reports whether retrying the request may succeed: the model was
+// overloaded, or the request was rate limited or failed with a server error.
+func (e *APIError) Temporary() bool {
+	return e.IsOverloaded() || e.HTTPStatusCode == http.StatusTooManyRequests ||
+		e.HTTPStatusCode >= http.StatusInternalServerError
+}
+
 func (e *APIError) UnmarshalJSON(data []byte) (err error) {
 	var rawMap map[string]json.RawMessage
 	err = json.Unmarshal(data, &rawMap)
@@ -101,9 +361,129 @@ func (e *APIError) UnmarshalJSON(data []byte) (err error) {
 }
 
 func (e *RequestError) Error() string {
-	return fmt.Sprintf("error, status code: %d, message: %s", e.HTTPStatusCode, e.Err)
+	msg := fmt.Sprintf("error, status code: %d, %s%smessage: %s",
+		e.HTTPStatusCode, e.requestField(), requestIDField(e.RequestID), e.Err)
+	if !e.hasBody {
+		return msg
+	}
+	if e.ContentType != "" {
+		msg += ", content type: " + e.ContentType
+	}
+	if e.Body == "" {
+		return msg + ", body is empty"
+	}
+	return msg + ", body: " + e.Body
 }
 
 func (e *RequestError) Unwrap() error {
 	return e.Err
 }
+
+// MultiError collects the errors of an operation made of several requests,
+// such as a batch. It supports errors.Is and errors.As on every sub-error.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	seen := make(map[string]bool, len(m))
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		if err == nil || seen[err.Error()] {
+			continue
+		}
+		seen[err.Error()] = true
+		msgs = append(msgs, err.Error())
+	}
+
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// Any reports whether any sub-error matches target, as defined by errors.As.
+func (m MultiError) Any(target any) bool {
+	for _, err := range m {
+		if err != nil && errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorOrNil returns the non-nil sub-errors as an error, or nil if there are none.
+func (m MultiError) ErrorOrNil() error {
+	var errs MultiError
+	for _, err := range m {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// IsMultiError reports whether err is or wraps a MultiError.
+func IsMultiError(err error) bool {
+	var multiErr MultiError
+	return errors.As(err, &multiErr)
+}
+
+// httpStatusCode returns the HTTP status code of the first APIError or
+// RequestError in the chain of err.
+func httpStatusCode(err error) (int, bool) {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode, true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return apiErr.HTTPStatusCode, true
+	}
+	return 0, false
+}
+
+// IsRetryable reports whether err is an API error that may succeed when
+// retried, i.e. a rate limit (429), a server error (5xx) or an overloaded
+// model.
+func IsRetryable(err error) bool {
+	if IsOverloadedError(err) {
+		return true
+	}
+	code, ok := httpStatusCode(err)
+	return ok && (code == http.StatusTooManyRequests || code >= http.StatusInternalServerError)
+}
+
+// IsOverloadedError reports whether err is an API error of an overloaded
+// model, like errors.Is(err, ErrModelOverloaded).
+func IsOverloadedError(err error) bool {
+	return errors.Is(err, ErrModelOverloaded)
+}
+
+// IsRateLimitError reports whether err is an API error with status 429.
+func IsRateLimitError(err error) bool {
+	code, ok := httpStatusCode(err)
+	return ok && code == http.StatusTooManyRequests
+}
+
+// IsAuthError reports whether err is an API error with status 401, or an
+// AuthError of a failing TokenProvider.
+func IsAuthError(err error) bool {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return true
+	}
+	code, ok := httpStatusCode(err)
+	return ok && code == http.StatusUnauthorized
+}
+
+// IsNotFoundError reports whether err is an API error with status 404.
+func IsNotFoundError(err error) bool {
+	code, ok := httpStatusCode(err)
+	return ok && code == http.StatusNotFound
+}
@@ -1,10 +1,14 @@
 package openai_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/zquestz/go-openai"
 )
@@ -260,8 +264,8 @@ func assertAPIErrorType(t *testing.T, apiErr openai.APIError, typ string) {
 
 func TestRequestError(t *testing.T) {
 	var err error = &openai.RequestError{
-		HTTPStatusCode: http.StatusTeapot,
-		Err:            errors.New("i am a teapot"),
+		APIError: openai.APIError{HTTPStatusCode: http.StatusTeapot},
+		Err:      errors.New("i am a teapot"),
 	}
 
 	var reqErr *openai.RequestError
@@ -277,3 +281,464 @@ func TestRequestError(t *testing.T) {
 		t.Fatalf("Empty request error occurred")
 	}
 }
+
+func TestMultiError(t *testing.T) {
+	errFoo := errors.New("foo")
+	apiErr := &openai.APIError{Message: "bar", HTTPStatusCode: http.StatusTooManyRequests}
+	multiErr := openai.MultiError{errFoo, apiErr, errors.New("foo"), nil}
+
+	want := "2 errors occurred: foo; error, status code: 429, message: bar"
+	if multiErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", multiErr.Error(), want)
+	}
+	if (openai.MultiError{errFoo, errFoo}).Error() != "foo" {
+		t.Errorf("duplicate errors should be reported once")
+	}
+
+	var err error = fmt.Errorf("batch failed: %w", multiErr)
+	if !errors.Is(err, errFoo) {
+		t.Errorf("errors.Is did not find a sub-error")
+	}
+	var gotAPIErr *openai.APIError
+	if !errors.As(err, &gotAPIErr) || gotAPIErr != apiErr {
+		t.Errorf("errors.As did not find the APIError")
+	}
+	if !openai.IsMultiError(err) || openai.IsMultiError(errFoo) {
+		t.Errorf("IsMultiError returned a wrong result")
+	}
+
+	if !multiErr.Any(&gotAPIErr) {
+		t.Errorf("Any did not match the APIError")
+	}
+	var reqErr *openai.RequestError
+	if multiErr.Any(&reqErr) {
+		t.Errorf("Any matched a type none of the errors has")
+	}
+}
+
+func TestMultiErrorErrorOrNil(t *testing.T) {
+	if err := (openai.MultiError{nil, nil}).ErrorOrNil(); err != nil {
+		t.Errorf("ErrorOrNil() = %v, want nil", err)
+	}
+
+	errFoo := errors.New("foo")
+	err := (openai.MultiError{nil, errFoo}).ErrorOrNil()
+	var multiErr openai.MultiError
+	if !errors.As(err, &multiErr) || len(multiErr) != 1 || multiErr[0] != errFoo {
+		t.Errorf("ErrorOrNil() = %#v, want only the non-nil error", err)
+	}
+}
+
+func TestAPIErrorIsSentinel(t *testing.T) {
+	testCases := []struct {
+		code   any
+		target error
+	}{
+		{"context_length_exceeded", openai.ErrContextLengthExceeded},
+		{"invalid_api_key", openai.ErrInvalidAPIKey},
+		{"model_not_found", openai.ErrModelNotFound},
+	}
+
+	for _, tc := range testCases {
+		var err error = &openai.APIError{Code: tc.code, Message: "foo"}
+		if !errors.Is(err, tc.target) {
+			t.Errorf("errors.Is(%v, %v) = false, want true", tc.code, tc.target)
+		}
+		if errors.Is(err, openai.ErrTooManyEmptyStreamMessages) {
+			t.Errorf("code %v matched an unrelated error", tc.code)
+		}
+	}
+
+	if errors.Is(&openai.APIError{Code: 404}, openai.ErrModelNotFound) {
+		t.Errorf("integer code matched a sentinel error")
+	}
+
+	reqErr := &openai.RequestError{
+		APIError: openai.APIError{
+			Code:           "invalid_api_key",
+			Message:        "Incorrect API key provided",
+			HTTPStatusCode: http.StatusUnauthorized,
+		},
+	}
+	if !errors.Is(reqErr, openai.ErrInvalidAPIKey) {
+		t.Errorf("RequestError does not match the code of its APIError")
+	}
+}
+
+// requestError returns a RequestError for a response of status.
+func requestError(status int, err error) *openai.RequestError {
+	return &openai.RequestError{APIError: openai.APIError{HTTPStatusCode: status}, Err: err}
+}
+
+func TestErrorStatusHelpers(t *testing.T) {
+	testCases := []struct {
+		name        string
+		err         error
+		retryable   bool
+		rateLimited bool
+		auth        bool
+		notFound    bool
+	}{
+		{"rate limit", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, true, true, false, false},
+		{"server error", requestError(http.StatusBadGateway, nil), true, false, false, false},
+		{"auth", &openai.APIError{HTTPStatusCode: http.StatusUnauthorized}, false, false, true, false},
+		{"not found", requestError(http.StatusNotFound, nil), false, false, false, true},
+		{"bad request", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false, false, false, false},
+		{
+			"wrapped",
+			fmt.Errorf("wrapped: %w", &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}),
+			true, false, false, false,
+		},
+		{"stream error without status", &openai.APIError{Message: "foo"}, false, false, false, false},
+		{"other error", errors.New("foo"), false, false, false, false},
+		{"nil", nil, false, false, false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := openai.IsRetryable(tc.err); got != tc.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tc.retryable)
+			}
+			if got := openai.IsRateLimitError(tc.err); got != tc.rateLimited {
+				t.Errorf("IsRateLimitError() = %v, want %v", got, tc.rateLimited)
+			}
+			if got := openai.IsAuthError(tc.err); got != tc.auth {
+				t.Errorf("IsAuthError() = %v, want %v", got, tc.auth)
+			}
+			if got := openai.IsNotFoundError(tc.err); got != tc.notFound {
+				t.Errorf("IsNotFoundError() = %v, want %v", got, tc.notFound)
+			}
+		})
+	}
+}
+
+func TestOverloadedErrors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		azure      bool
+		status     int
+		header     map[string]string
+		body       string
+		overloaded bool
+		retryAfter time.Duration
+	}{
+		{
+			name:   "model_overloaded code",
+			status: http.StatusServiceUnavailable,
+			body: `{"error":{"message":"The model is overloaded, please retry.",` +
+				`"type":"server_error","param":null,"code":"model_overloaded"}}`,
+			overloaded: true,
+		},
+		{
+			name:   "legacy message",
+			status: http.StatusServiceUnavailable,
+			header: map[string]string{"retry-after-ms": "1500"},
+			body: `{"error":{"message":"That model is currently overloaded with other requests. ` +
+				`You can retry your request.","type":"server_error","param":null,"code":null}}`,
+			overloaded: true,
+			retryAfter: 1500 * time.Millisecond,
+		},
+		{
+			name:       "azure",
+			azure:      true,
+			status:     http.StatusServiceUnavailable,
+			header:     map[string]string{"Retry-After": "2"},
+			body:       `{"error":{"code":"503","message":"The engine is currently overloaded, please try again later"}}`,
+			overloaded: true,
+			retryAfter: 2 * time.Second,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			body:   `{"error":{"message":"The server had an error while processing your request.","type":"server_error"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup, path := setupOpenAITestServer, "/v1/chat/completions"
+			if tc.azure {
+				setup, path = setupAzureTestServer, "/openai/deployments/gpt-4/chat/completions"
+			}
+			client, server, teardown := setup()
+			defer teardown()
+			server.RegisterHandler(path, func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tc.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			})
+
+			_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    openai.GPT4,
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+			})
+			var apiErr *openai.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, got %v", err)
+			}
+			if errors.Is(err, openai.ErrModelOverloaded) != tc.overloaded || apiErr.IsOverloaded() != tc.overloaded ||
+				openai.IsOverloadedError(err) != tc.overloaded {
+				t.Errorf("overloaded = %v, want %v", apiErr.IsOverloaded(), tc.overloaded)
+			}
+			if !apiErr.Temporary() || !openai.IsRetryable(err) {
+				t.Error("error should be temporary")
+			}
+			if apiErr.RetryAfter != tc.retryAfter {
+				t.Errorf("RetryAfter = %s, want %s", apiErr.RetryAfter, tc.retryAfter)
+			}
+		})
+	}
+
+	// Errors sent within streams have no status.
+	streamErr := &openai.APIError{Code: "model_overloaded", Message: "overloaded"}
+	if !openai.IsRetryable(streamErr) || !streamErr.Temporary() {
+		t.Error("overloaded errors without a status should be retryable")
+	}
+	if errors.Is(&openai.APIError{HTTPStatusCode: http.StatusBadRequest}, openai.ErrModelOverloaded) {
+		t.Error("bad request matched ErrModelOverloaded")
+	}
+}
+
+func TestAPIErrorCodes(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		body          string
+		code          openai.APIErrorCode
+		typ           string
+		contextLength bool
+		invalidKey    bool
+		modelNotFound bool
+		rateLimited   bool
+		quota         bool
+		message       string
+	}{
+		{
+			name:   "context length exceeded",
+			status: http.StatusBadRequest,
+			body: `{"error":{"message":"This model's maximum context length is 8192 tokens. ` +
+				`However, your messages resulted in 9010 tokens. Please reduce the length of the messages.",` +
+				`"type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`,
+			code:          openai.ErrorCodeContextLengthExceeded,
+			typ:           openai.ErrorTypeInvalidRequest,
+			contextLength: true,
+			message: "error, status code: 400, param: messages, message: This model's maximum context length " +
+				"is 8192 tokens. However, your messages resulted in 9010 tokens. Please reduce the length of the messages.",
+		},
+		{
+			name:   "invalid api key",
+			status: http.StatusUnauthorized,
+			body: `{"error":{"message":"Incorrect API key provided: sk-abc. You can find your API key at ` +
+				`https://platform.openai.com/account/api-keys.","type":"invalid_request_error","param":null,` +
+				`"code":"invalid_api_key"}}`,
+			code:       openai.ErrorCodeInvalidAPIKey,
+			typ:        openai.ErrorTypeInvalidRequest,
+			invalidKey: true,
+			message: "error, status code: 401, message: Incorrect API key provided: sk-abc. " +
+				"You can find your API key at https://platform.openai.com/account/api-keys.",
+		},
+		{
+			name:   "model not found",
+			status: http.StatusNotFound,
+			body: `{"error":{"message":"The model ` + "`gpt-5-turbo`" + ` does not exist or you do not have ` +
+				`access to it.","type":"invalid_request_error","param":null,"code":"model_not_found"}}`,
+			code:          openai.ErrorCodeModelNotFound,
+			typ:           openai.ErrorTypeInvalidRequest,
+			modelNotFound: true,
+			message: "error, status code: 404, message: The model `gpt-5-turbo` does not exist " +
+				"or you do not have access to it.",
+		},
+		{
+			name:   "rate limit exceeded",
+			status: http.StatusTooManyRequests,
+			body: `{"error":{"message":"Rate limit reached for gpt-4o in organization org-abc on tokens per ` +
+				`min (TPM): Limit 30000, Used 29874, Requested 1179.","type":"tokens","param":null,` +
+				`"code":"rate_limit_exceeded"}}`,
+			code:        openai.ErrorCodeRateLimitExceeded,
+			typ:         "tokens",
+			rateLimited: true,
+			message: "error, status code: 429, message: Rate limit reached for gpt-4o in organization " +
+				"org-abc on tokens per min (TPM): Limit 30000, Used 29874, Requested 1179.",
+		},
+		{
+			name:   "insufficient quota",
+			status: http.StatusTooManyRequests,
+			body: `{"error":{"message":"You exceeded your current quota, please check your plan and billing ` +
+				`details.","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`,
+			code:  openai.ErrorCodeInsufficientQuota,
+			typ:   "insufficient_quota",
+			quota: true,
+			message: "error, status code: 429, message: You exceeded your current quota, " +
+				"please check your plan and billing details.",
+		},
+		{
+			name:   "numeric code",
+			status: http.StatusTooManyRequests,
+			body: `{"error":{"code":429,"message":"Requests to the ChatCompletions_Create Operation have ` +
+				`exceeded call rate limit. Please retry after 6 seconds."}}`,
+			code: "429",
+			message: "error, status code: 429, message: Requests to the ChatCompletions_Create Operation " +
+				"have exceeded call rate limit. Please retry after 6 seconds.",
+		},
+		{
+			name:   "unsupported parameter",
+			status: http.StatusBadRequest,
+			body: `{"error":{"message":"Unsupported parameter: 'max_tokens' is not supported with this model.",` +
+				`"type":"invalid_request_error","param":"max_tokens","code":"unsupported_parameter"}}`,
+			code: openai.ErrorCodeUnsupportedParameter,
+			typ:  openai.ErrorTypeInvalidRequest,
+			message: "error, status code: 400, param: max_tokens, message: Unsupported parameter: " +
+				"'max_tokens' is not supported with this model.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp openai.ErrorResponse
+			if err := json.Unmarshal([]byte(tc.body), &resp); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			apiErr := resp.Error
+			apiErr.HTTPStatusCode = tc.status
+
+			if got := apiErr.ErrorCode(); got != tc.code {
+				t.Errorf("ErrorCode() = %q, want %q", got, tc.code)
+			}
+			if apiErr.Type != tc.typ {
+				t.Errorf("Type = %q, want %q", apiErr.Type, tc.typ)
+			}
+			if got := apiErr.IsContextLengthExceeded(); got != tc.contextLength {
+				t.Errorf("IsContextLengthExceeded() = %v, want %v", got, tc.contextLength)
+			}
+			if got := apiErr.IsInvalidAPIKey(); got != tc.invalidKey {
+				t.Errorf("IsInvalidAPIKey() = %v, want %v", got, tc.invalidKey)
+			}
+			if got := apiErr.IsModelNotFound(); got != tc.modelNotFound {
+				t.Errorf("IsModelNotFound() = %v, want %v", got, tc.modelNotFound)
+			}
+			if got := apiErr.IsRateLimitExceeded(); got != tc.rateLimited {
+				t.Errorf("IsRateLimitExceeded() = %v, want %v", got, tc.rateLimited)
+			}
+			if got := apiErr.IsInsufficientQuota(); got != tc.quota {
+				t.Errorf("IsInsufficientQuota() = %v, want %v", got, tc.quota)
+			}
+			if got := apiErr.Error(); got != tc.message {
+				t.Errorf("Error() = %q, want %q", got, tc.message)
+			}
+		})
+	}
+}
+
+func TestAPIErrorClasses(t *testing.T) {
+	sentinels := []error{
+		openai.ErrBadRequest,
+		openai.ErrAuthentication,
+		openai.ErrPermission,
+		openai.ErrNotFound,
+		openai.ErrRateLimited,
+		openai.ErrServer,
+		openai.ErrInsufficientQuota,
+	}
+	testCases := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{
+			"bad request",
+			&openai.APIError{HTTPStatusCode: http.StatusBadRequest, Type: openai.ErrorTypeInvalidRequest},
+			[]error{openai.ErrBadRequest},
+		},
+		{
+			"unprocessable entity",
+			&openai.APIError{HTTPStatusCode: http.StatusUnprocessableEntity},
+			[]error{openai.ErrBadRequest},
+		},
+		{
+			"invalid api key",
+			&openai.APIError{
+				HTTPStatusCode: http.StatusUnauthorized,
+				Type:           openai.ErrorTypeInvalidRequest,
+				Code:           "invalid_api_key",
+			},
+			[]error{openai.ErrAuthentication, openai.ErrInvalidAPIKey},
+		},
+		{
+			"permission denied",
+			&openai.APIError{HTTPStatusCode: http.StatusForbidden},
+			[]error{openai.ErrPermission},
+		},
+		{
+			"model not found",
+			&openai.APIError{HTTPStatusCode: http.StatusNotFound, Code: "model_not_found"},
+			[]error{openai.ErrNotFound, openai.ErrModelNotFound},
+		},
+		{
+			"rate limited",
+			&openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Type: "requests", Code: "rate_limit_exceeded"},
+			[]error{openai.ErrRateLimited},
+		},
+		{
+			"insufficient quota",
+			&openai.APIError{
+				HTTPStatusCode: http.StatusTooManyRequests,
+				Type:           "insufficient_quota",
+				Code:           "insufficient_quota",
+			},
+			[]error{openai.ErrRateLimited, openai.ErrInsufficientQuota},
+		},
+		{
+			"server error",
+			&openai.APIError{HTTPStatusCode: http.StatusInternalServerError, Type: openai.ErrorTypeServer},
+			[]error{openai.ErrServer},
+		},
+		{
+			"bad gateway without body",
+			requestError(http.StatusBadGateway, errors.New("invalid character '<'")),
+			[]error{openai.ErrServer},
+		},
+		{
+			"wrapped request error",
+			fmt.Errorf("listing models: %w", requestError(http.StatusUnauthorized, nil)),
+			[]error{openai.ErrAuthentication},
+		},
+		{
+			"stream error by type",
+			&openai.APIError{Type: openai.ErrorTypeAuthentication, Message: "foo"},
+			[]error{openai.ErrAuthentication},
+		},
+		{
+			"stream error by code",
+			&openai.APIError{Code: "insufficient_quota", Message: "foo"},
+			[]error{openai.ErrRateLimited, openai.ErrInsufficientQuota},
+		},
+		{
+			"azure numeric code",
+			&openai.APIError{Code: 429, Message: "Requests have exceeded call rate limit."},
+			[]error{openai.ErrRateLimited},
+		},
+		{"no status", &openai.APIError{Message: "foo"}, nil},
+		{"other error", errors.New("foo"), nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, sentinel := range sentinels {
+				want := false
+				for _, w := range tc.want {
+					want = want || w == sentinel
+				}
+				if got := errors.Is(tc.err, sentinel); got != want {
+					t.Errorf("errors.Is(err, %q) = %v, want %v", sentinel, got, want)
+				}
+			}
+			for _, w := range tc.want {
+				if !errors.Is(tc.err, w) {
+					t.Errorf("errors.Is(err, %q) = false, want true", w)
+				}
+			}
+		})
+	}
+}
@@ -144,6 +144,25 @@ func ExampleClient_CreateTranscription() {
 	fmt.Println(resp.Text)
 }
 
+func ExampleClient_CreateTranscription_language() {
+	client := openai.NewClient(os.Getenv("OPENAI_API_KEY"))
+	// Telling the language of the audio improves the accuracy of the
+	// transcription.
+	resp, err := client.CreateTranscription(
+		context.Background(),
+		openai.AudioRequest{
+			Model:    openai.Whisper1,
+			FilePath: "grabacion.mp3",
+			Language: openai.AudioLanguageSpanish,
+		},
+	)
+	if err != nil {
+		fmt.Printf("Transcription error: %v\n", err)
+		return
+	}
+	fmt.Println(resp.Text)
+}
+
 func ExampleClient_CreateTranscription_captions() {
 	client := openai.NewClient(os.Getenv("OPENAI_API_KEY"))
 
@@ -0,0 +1,78 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrExtraFieldConflict is returned when marshaling a request whose
+// ExtraFields has a field of the request itself.
+var ErrExtraFieldConflict = errors.New("extra field conflicts with a request field")
+
+// jsonFieldNames caches the JSON field names of the request types, by type.
+var jsonFieldNames sync.Map
+
+// marshalWithExtraFields marshals the struct v, then appends the fields of
+// extra, sorted by name, to its JSON object. Fields of extra named like a field
+// of v return ErrExtraFieldConflict, even if that field is omitted as empty.
+func marshalWithExtraFields(v any, extra map[string]any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	names := fieldNames(reflect.TypeOf(v))
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if names[key] {
+			return nil, fmt.Errorf("%w: %s", ErrExtraFieldConflict, key)
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	buf := bytes.NewBuffer(bytes.TrimSuffix(data, []byte("}")))
+	for i, key := range keys {
+		value, err := json.Marshal(extra[key])
+		if err != nil {
+			return nil, fmt.Errorf("extra field %s: %w", key, err)
+		}
+		if i > 0 || len(data) > len("{}") {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// fieldNames returns the JSON field names of the struct type t.
+func fieldNames(t reflect.Type) map[string]bool {
+	if names, ok := jsonFieldNames.Load(t); ok {
+		return names.(map[string]bool)
+	}
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	jsonFieldNames.Store(t, names)
+	return names
+}
@@ -0,0 +1,74 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestExtraFieldsMarshal(t *testing.T) {
+	request := openai.ChatCompletionRequest{
+		Model:    "meta-llama/llama-3-70b",
+		Messages: []openai.ChatCompletionMessage{openai.UserMessage("Hello!")},
+		ExtraFields: map[string]any{
+			"top_k":    40,
+			"min_p":    0.05,
+			"provider": map[string]any{"order": []string{"together"}},
+		},
+	}
+	data, err := json.Marshal(request)
+	checks.NoError(t, err, "Marshal error")
+	want := `{"model":"meta-llama/llama-3-70b","messages":[{"role":"user","content":"Hello!"}],` +
+		`"min_p":0.05,"provider":{"order":["together"]},"top_k":40}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	// Typed fields conflict even when empty and omitted.
+	_, err = json.Marshal(request.WithExtraField("temperature", 0.2))
+	checks.ErrorIs(t, err, openai.ErrExtraFieldConflict, "conflict with an omitted field")
+	if _, ok := request.ExtraFields["temperature"]; ok {
+		t.Error("WithExtraField changed the original request")
+	}
+
+	data, err = json.Marshal(openai.CompletionRequest{Model: "gpt-3.5-turbo-instruct", ExtraFields: map[string]any{"top_k": 1}})
+	checks.NoError(t, err, "Marshal error")
+	if want = `{"model":"gpt-3.5-turbo-instruct","top_k":1}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+	_, err = json.Marshal(openai.CompletionRequest{ExtraFields: map[string]any{"model": "other"}})
+	checks.ErrorIs(t, err, openai.ErrExtraFieldConflict, "conflict with model")
+}
+
+func TestExtraFieldsSent(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var body map[string]any
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	})
+
+	_, err := client.CreateEmbeddings(context.Background(), openai.EmbeddingRequestStrings{
+		Input:       []string{"Hello"},
+		Model:       openai.AdaEmbeddingV2,
+		ExtraFields: map[string]any{"truncate": "END"},
+	})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if body["truncate"] != "END" || body["model"] != openai.AdaEmbeddingV2.String() {
+		t.Errorf("unexpected body %v", body)
+	}
+
+	_, err = client.CreateEmbeddings(context.Background(), openai.EmbeddingRequestStrings{
+		Input:       []string{"Hello"},
+		Model:       openai.AdaEmbeddingV2,
+		ExtraFields: map[string]any{"input": "other"},
+	})
+	checks.ErrorIs(t, err, openai.ErrExtraFieldConflict, "CreateEmbeddings sent a conflicting field")
+}
@@ -0,0 +1,158 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrFunctionNotRegistered  = errors.New("function is not registered")
+	ErrFunctionAlreadyExists  = errors.New("function is already registered")
+	ErrFunctionInvalidHandler = errors.New("handler must be a func(T) (any, error) or func(context.Context, T) (any, error)") //nolint:lll
+)
+
+var (
+	errorInterfaceType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextInterfaceType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	anyInterfaceType     = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// FunctionCallError is the error of a single tool call dispatched by a
+// FunctionRouter.
+type FunctionCallError struct {
+	ToolCallID string
+	Name       string
+	Err        error
+}
+
+func (e *FunctionCallError) Error() string {
+	return fmt.Sprintf("tool call %s to %s: %s", e.ToolCallID, e.Name, e.Err)
+}
+
+func (e *FunctionCallError) Unwrap() error {
+	return e.Err
+}
+
+// FunctionRouter dispatches the tool calls returned by the model to registered
+// Go functions and builds the tool messages to send back. It is safe for
+// concurrent use.
+type FunctionRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]reflect.Value
+}
+
+// NewFunctionRouter creates a FunctionRouter without any handlers.
+func NewFunctionRouter() *FunctionRouter {
+	return &FunctionRouter{handlers: make(map[string]reflect.Value)}
+}
+
+// Register registers fn as the handler of the function name. fn must be a
+// func(T) (any, error) or func(context.Context, T) (any, error), where T is
+// the type the JSON arguments of the call are unmarshaled into, and not nil.
+func (r *FunctionRouter) Register(name string, fn any) error {
+	v := reflect.ValueOf(fn)
+	if !v.IsValid() || v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("%w: %s", ErrFunctionInvalidHandler, name)
+	}
+	t := v.Type()
+	if t.NumOut() != 2 ||
+		t.Out(0) != anyInterfaceType || t.Out(1) != errorInterfaceType {
+		return fmt.Errorf("%w: %s", ErrFunctionInvalidHandler, name)
+	}
+	switch {
+	case t.NumIn() == 1:
+	case t.NumIn() == 2 && t.In(0) == contextInterfaceType:
+	default:
+		return fmt.Errorf("%w: %s", ErrFunctionInvalidHandler, name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.handlers == nil {
+		r.handlers = make(map[string]reflect.Value)
+	}
+	if _, ok := r.handlers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrFunctionAlreadyExists, name)
+	}
+	r.handlers[name] = v
+	return nil
+}
+
+// Dispatch calls the handlers of calls in parallel and returns one tool message
+// per call, in the order of calls. Results are sent as is if they are strings
+// and as JSON otherwise. A failed call still gets a message, carrying
+// {"error": "..."} so the model can react, and its FunctionCallError is
+// returned as part of a MultiError.
+func (r *FunctionRouter) Dispatch(ctx context.Context, calls []ToolCall) ([]ChatCompletionMessage, error) {
+	messages := make([]ChatCompletionMessage, len(calls))
+	errs := make(MultiError, len(calls))
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call ToolCall) {
+			defer wg.Done()
+			content, err := r.call(ctx, call)
+			if err != nil {
+				errs[i] = &FunctionCallError{ToolCallID: call.ID, Name: call.Function.Name, Err: err}
+				b, _ := json.Marshal(map[string]string{"error": err.Error()})
+				content = string(b)
+			}
+			messages[i] = ChatCompletionMessage{
+				Role:       ChatMessageRoleTool,
+				Content:    content,
+				Name:       call.Function.Name,
+				ToolCallID: call.ID,
+			}
+		}(i, call)
+	}
+	wg.Wait()
+
+	return messages, errs.ErrorOrNil()
+}
+
+func (r *FunctionRouter) call(ctx context.Context, call ToolCall) (content string, err error) {
+	r.mu.RLock()
+	fn, ok := r.handlers[call.Function.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", ErrFunctionNotRegistered
+	}
+
+	t := fn.Type()
+	arg := reflect.New(t.In(t.NumIn() - 1))
+	if call.Function.Arguments != "" {
+		err = json.Unmarshal([]byte(call.Function.Arguments), arg.Interface())
+		if err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	args := []reflect.Value{arg.Elem()}
+	if t.NumIn() == 2 { //nolint:gomnd // context and arguments
+		args = []reflect.Value{reflect.ValueOf(ctx), arg.Elem()}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+	out := fn.Call(args)
+	if errOut, _ := out[1].Interface().(error); errOut != nil {
+		return "", errOut
+	}
+
+	result := out[0].Interface()
+	if s, isString := result.(string); isString {
+		return s, nil
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("invalid result: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,91 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+type weatherArgs struct {
+	City string `json:"city"`
+}
+
+func TestFunctionRouter(t *testing.T) {
+	router := openai.NewFunctionRouter()
+	errUnknownCity := errors.New("unknown city")
+	err := router.Register("get_weather", func(args weatherArgs) (any, error) {
+		if args.City != "Paris" {
+			return nil, errUnknownCity
+		}
+		return map[string]any{"city": args.City, "celsius": 21}, nil
+	})
+	checks.NoError(t, err, "Register error")
+	err = router.Register("echo", func(_ context.Context, args struct{ Text string }) (any, error) {
+		return args.Text, nil
+	})
+	checks.NoError(t, err, "Register error")
+
+	calls := []openai.ToolCall{
+		{ID: "call_1", Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		{ID: "call_2", Function: openai.FunctionCall{Name: "echo", Arguments: `{"Text":"hi"}`}},
+		{ID: "call_3", Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Atlantis"}`}},
+		{ID: "call_4", Function: openai.FunctionCall{Name: "missing"}},
+		{ID: "call_5", Function: openai.FunctionCall{Name: "echo", Arguments: `not json`}},
+	}
+	messages, err := router.Dispatch(context.Background(), calls)
+
+	want := []string{
+		`{"celsius":21,"city":"Paris"}`,
+		"hi",
+		`{"error":"unknown city"}`,
+		`{"error":"function is not registered"}`,
+	}
+	if len(messages) != len(calls) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(calls))
+	}
+	for i, msg := range messages {
+		if msg.Role != openai.ChatMessageRoleTool || msg.ToolCallID != calls[i].ID {
+			t.Errorf("message %d = %+v, want a tool message for %s", i, msg, calls[i].ID)
+		}
+		if i < len(want) && msg.Content != want[i] {
+			t.Errorf("message %d content = %s, want %s", i, msg.Content, want[i])
+		}
+	}
+
+	var callErr *openai.FunctionCallError
+	if !errors.As(err, &callErr) || callErr.ToolCallID != "call_3" {
+		t.Fatalf("expected the FunctionCallError of call_3, got %v", err)
+	}
+	checks.ErrorIs(t, err, errUnknownCity, "Dispatch error does not wrap the handler error")
+	checks.ErrorIs(t, err, openai.ErrFunctionNotRegistered, "Dispatch error does not report the missing function")
+	if errs := err.(openai.MultiError); len(errs) != 3 { //nolint:errorlint // Dispatch returns a MultiError
+		t.Errorf("got %d errors, want 3: %v", len(errs), err)
+	}
+}
+
+func TestFunctionRouterRegister(t *testing.T) {
+	router := openai.NewFunctionRouter()
+	invalid := []any{
+		"not a function",
+		func(weatherArgs) error { return nil },
+		func(weatherArgs) (string, error) { return "", nil },
+		func(a, b weatherArgs) (any, error) { return nil, nil },
+		func() (any, error) { return nil, nil },
+		nil,
+		(func(weatherArgs) (any, error))(nil),
+	}
+	for i, fn := range invalid {
+		if err := router.Register("f", fn); !errors.Is(err, openai.ErrFunctionInvalidHandler) {
+			t.Errorf("handler %d: Register returned %v, want ErrFunctionInvalidHandler", i, err)
+		}
+	}
+
+	handler := func(weatherArgs) (any, error) { return nil, nil }
+	checks.NoError(t, router.Register("f", handler), "Register error")
+	if err := router.Register("f", handler); !errors.Is(err, openai.ErrFunctionAlreadyExists) {
+		t.Errorf("Register returned %v, want ErrFunctionAlreadyExists", err)
+	}
+}
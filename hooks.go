@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RequestMeta describes the API call a request belongs to.
+type RequestMeta struct {
+	// Endpoint is the path of the endpoint relative to the base URL, e.g.
+	// "/chat/completions", without the Azure deployment prefix.
+	Endpoint string
+	// Model is the model of the request, if it has one.
+	Model  string
+	Stream bool
+	// Start is when the request was sent, for measuring latency.
+	Start time.Time
+}
+
+// StreamStats summarizes a stream when it is closed.
+type StreamStats struct {
+	// Chunks is the number of data events received.
+	Chunks int
+	// FirstChunk is the time from sending the request to the first data event,
+	// zero if none was received.
+	FirstChunk time.Duration
+	// Duration is the time from sending the request to closing the stream.
+	Duration time.Duration
+}
+
+// Hook observes every request sent by a client. Any function may be nil.
+// OnRequest runs right before a request is sent and OnResponse once its
+// response, or for streams its headers, arrived; err is the error the call
+// would return, if any. Except for streams and file contents, resp.Body can
+// still be read by OnResponse. A hook returning an error aborts the call
+// with it. OnStreamClose runs when a stream is closed.
+type Hook struct {
+	OnRequest     func(ctx context.Context, req *http.Request, meta RequestMeta) error
+	OnResponse    func(ctx context.Context, resp *http.Response, meta RequestMeta, err error) error
+	OnStreamClose func(ctx context.Context, meta RequestMeta, stats StreamStats)
+}
+
+// WithHooks appends hooks to ClientConfig.Hooks. Hooks run in the order they
+// were registered.
+func WithHooks(hooks ...Hook) ClientOption {
+	return func(config *ClientConfig) {
+		config.Hooks = append(config.Hooks[:len(config.Hooks):len(config.Hooks)], hooks...)
+	}
+}
+
+type requestMetaKey struct{}
+
+func withModel(model string) requestOption {
+	return func(args *requestOptions) {
+		args.model = model
+	}
+}
+
+// withRequestMeta returns ctx carrying the RequestMeta of a request to rawURL.
+func (c *Client) withRequestMeta(ctx context.Context, rawURL string, args *requestOptions) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, RequestMeta{
+		Endpoint: c.endpoint(rawURL),
+		Model:    args.requestModel(),
+	})
+}
+
+// requestModel returns the model of the request.
+func (args *requestOptions) requestModel() string {
+	if args.model != "" {
+		return args.model
+	}
+	return requestModel(args.body)
+}
+
+// RequestMetaFromContext returns the RequestMeta of the API call a request
+// context belongs to, e.g. for http.RoundTripper implementations. Only
+// Endpoint and Model are set, since the request has not been sent yet when
+// its context is created.
+func RequestMetaFromContext(ctx context.Context) (RequestMeta, bool) {
+	meta, ok := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta, ok
+}
+
+func requestMetaOf(req *http.Request) RequestMeta {
+	meta, _ := req.Context().Value(requestMetaKey{}).(RequestMeta)
+	return meta
+}
+
+// endpoint returns the path of rawURL relative to the base URL.
+func (c *Client) endpoint(rawURL string) string {
+	path, _, _ := strings.Cut(rawURL, "?")
+	base := strings.TrimRight(c.config.BaseURL, "/")
+	if !strings.HasPrefix(path, base) {
+		if u, err := url.Parse(rawURL); err == nil {
+			return u.Path
+		}
+		return path
+	}
+	path = strings.TrimPrefix(path, base)
+	if c.config.APIType == APITypeAzure || c.config.APIType == APITypeAzureAD {
+		path = strings.TrimPrefix(path, "/"+azureAPIPrefix)
+		if rest, ok := strings.CutPrefix(path, "/"+azureDeploymentsPrefix+"/"); ok {
+			_, path, _ = strings.Cut(rest, "/")
+			path = "/" + path
+		}
+	}
+	return path
+}
+
+// requestModel returns the Model field of a request body, if it has one.
+func requestModel(body any) string {
+	v := reflect.ValueOf(body)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName("Model")
+	switch {
+	case !field.IsValid():
+		return ""
+	case field.Kind() == reflect.String:
+		return field.String()
+	}
+	// e.g. EmbeddingModel, which is not a string type.
+	if stringer, ok := field.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return ""
+}
+
+func (c *Client) runRequestHooks(req *http.Request, meta RequestMeta) error {
+	for _, hook := range c.config.Hooks {
+		if hook.OnRequest == nil {
+			continue
+		}
+		if err := hook.OnRequest(req.Context(), req, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runResponseHooks returns the error the call should return: err, unless a
+// hook fails first.
+func (c *Client) runResponseHooks(req *http.Request, resp *http.Response, meta RequestMeta, err error) error {
+	for _, hook := range c.config.Hooks {
+		if hook.OnResponse == nil {
+			continue
+		}
+		if hookErr := hook.OnResponse(req.Context(), resp, meta, err); hookErr != nil {
+			return hookErr
+		}
+	}
+	return err
+}
+
+func (c *Client) runStreamCloseHooks(ctx context.Context, meta RequestMeta, stats StreamStats) {
+	for _, hook := range c.config.Hooks {
+		if hook.OnStreamClose != nil {
+			hook.OnStreamClose(ctx, meta, stats)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+type hookCall struct {
+	hook   string
+	meta   openai.RequestMeta
+	status int
+	err    error
+}
+
+func recordingHook(name string, calls *[]hookCall) openai.Hook {
+	return openai.Hook{
+		OnRequest: func(_ context.Context, _ *http.Request, meta openai.RequestMeta) error {
+			meta.Start = time.Time{}
+			*calls = append(*calls, hookCall{hook: name + ".request", meta: meta})
+			return nil
+		},
+		OnResponse: func(_ context.Context, resp *http.Response, meta openai.RequestMeta, err error) error {
+			meta.Start = time.Time{}
+			call := hookCall{hook: name + ".response", meta: meta, err: err}
+			if resp != nil {
+				call.status = resp.StatusCode
+			}
+			*calls = append(*calls, call)
+			return nil
+		},
+	}
+}
+
+func setupHooksTestServer(hooks ...openai.Hook) (client *openai.Client, server *test.ServerTest, teardown func()) {
+	server = test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	teardown = ts.Close
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	client = openai.NewClientWithConfig(config, openai.WithHooks(hooks...))
+	return
+}
+
+func TestHooks(t *testing.T) {
+	var calls []hookCall
+	client, server, teardown := setupHooksTestServer(recordingHook("first", &calls), recordingHook("second", &calls))
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleChatCompletionEndpoint)
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	})
+	server.RegisterHandler("/v1/images/generations", handleImageEndpoint)
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"message":"boom","type":"server_error"}}`))
+	})
+
+	ctx := context.Background()
+	chatRequest := openai.ChatCompletionRequest{
+		Model:     openai.GPT3Dot5Turbo,
+		MaxTokens: 5,
+		Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	_, err := client.CreateChatCompletion(ctx, chatRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	_, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequest{Input: []string{"hi"}, Model: openai.AdaEmbeddingV2})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	_, err = client.CreateImage(ctx, openai.ImageRequest{Prompt: "a cat", Model: openai.CreateImageModelDallE3, N: 1})
+	checks.NoError(t, err, "CreateImage error")
+	_, err = client.ListFiles(ctx)
+	checks.HasError(t, err, "ListFiles should fail")
+
+	want := []openai.RequestMeta{
+		{Endpoint: "/chat/completions", Model: openai.GPT3Dot5Turbo},
+		{Endpoint: "/embeddings", Model: openai.AdaEmbeddingV2.String()},
+		{Endpoint: "/images/generations", Model: openai.CreateImageModelDallE3},
+		{Endpoint: "/files"},
+	}
+	if len(calls) != 4*len(want) {
+		t.Fatalf("hooks were called %d times, want %d: %+v", len(calls), 4*len(want), calls)
+	}
+	order := []string{"first.request", "second.request", "first.response", "second.response"}
+	for i, call := range calls {
+		if call.hook != order[i%4] || call.meta != want[i/4] {
+			t.Errorf("call %d = %s %+v, want %s %+v", i, call.hook, call.meta, order[i%4], want[i/4])
+		}
+	}
+	last := calls[len(calls)-1]
+	if last.status != http.StatusInternalServerError || !openai.IsRetryable(last.err) {
+		t.Errorf("OnResponse did not receive the API error: %+v", last)
+	}
+}
+
+func TestHooksStream(t *testing.T) {
+	var calls []hookCall
+	client, server, teardown := setupHooksTestServer(recordingHook("hook", &calls))
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	stream.Close()
+
+	want := openai.RequestMeta{Endpoint: "/chat/completions", Model: openai.GPT4, Stream: true}
+	if len(calls) != 2 || calls[0].meta != want || calls[1].meta != want || calls[1].status != http.StatusOK {
+		t.Errorf("unexpected hook calls: %+v", calls)
+	}
+}
+
+func TestHooksAbort(t *testing.T) {
+	errAbort := errors.New("denied by policy")
+	sent := false
+	var calls []hookCall
+	client, server, teardown := setupHooksTestServer(
+		openai.Hook{OnRequest: func(context.Context, *http.Request, openai.RequestMeta) error {
+			return errAbort
+		}},
+		recordingHook("later", &calls),
+	)
+	defer teardown()
+	server.RegisterHandler("/v1/models", func(http.ResponseWriter, *http.Request) {
+		sent = true
+	})
+
+	_, err := client.ListModels(context.Background())
+	checks.ErrorIs(t, err, errAbort, "ListModels should return the hook error")
+	if sent || len(calls) != 0 {
+		t.Errorf("request should not be sent after a hook failed")
+	}
+}
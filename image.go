@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 )
 
@@ -16,6 +17,10 @@ const (
 	// dall-e-3 supported only.
 	CreateImageSize1792x1024 = "1792x1024"
 	CreateImageSize1024x1792 = "1024x1792"
+	// gpt-image-1 supported only.
+	CreateImageSize1536x1024 = "1536x1024"
+	CreateImageSize1024x1536 = "1024x1536"
+	CreateImageSizeAuto      = "auto"
 )
 
 const (
@@ -24,13 +29,19 @@ const (
 )
 
 const (
-	CreateImageModelDallE2 = "dall-e-2"
-	CreateImageModelDallE3 = "dall-e-3"
+	CreateImageModelDallE2    = "dall-e-2"
+	CreateImageModelDallE3    = "dall-e-3"
+	CreateImageModelGptImage1 = "gpt-image-1"
 )
 
 const (
 	CreateImageQualityHD       = "hd"
 	CreateImageQualityStandard = "standard"
+	// gpt-image-1 supported only.
+	CreateImageQualityHigh   = "high"
+	CreateImageQualityMedium = "medium"
+	CreateImageQualityLow    = "low"
+	CreateImageQualityAuto   = "auto"
 )
 
 const (
@@ -38,6 +49,20 @@ const (
 	CreateImageStyleNatural = "natural"
 )
 
+// Output formats, backgrounds and moderation levels of gpt-image-1.
+const (
+	CreateImageOutputFormatPNG  = "png"
+	CreateImageOutputFormatJPEG = "jpeg"
+	CreateImageOutputFormatWEBP = "webp"
+
+	CreateImageBackgroundTransparent = "transparent"
+	CreateImageBackgroundOpaque      = "opaque"
+	CreateImageBackgroundAuto        = "auto"
+
+	CreateImageModerationLow  = "low"
+	CreateImageModerationAuto = "auto"
+)
+
 // ImageRequest represents the request structure for the image API.
 type ImageRequest struct {
 	Prompt         string `json:"prompt,omitempty"`
@@ -48,27 +73,201 @@ type ImageRequest struct {
 	Style          string `json:"style,omitempty"`
 	ResponseFormat string `json:"response_format,omitempty"`
 	User           string `json:"user,omitempty"`
+	// OutputFormat, OutputCompression, from 0 to 100 for jpeg and webp,
+	// Background and Moderation are only supported by gpt-image-1, which always
+	// returns base64 images.
+	OutputFormat      string `json:"output_format,omitempty"`
+	OutputCompression *int   `json:"output_compression,omitempty"`
+	Background        string `json:"background,omitempty"`
+	Moderation        string `json:"moderation,omitempty"`
 }
 
 // ImageResponse represents a response structure for image API.
 type ImageResponse struct {
 	Created int64                    `json:"created,omitempty"`
 	Data    []ImageResponseDataInner `json:"data,omitempty"`
+	// Background, OutputFormat, Quality and Size are the parameters the images
+	// of gpt-image-1 were generated with.
+	Background   string `json:"background,omitempty"`
+	OutputFormat string `json:"output_format,omitempty"`
+	Quality      string `json:"quality,omitempty"`
+	Size         string `json:"size,omitempty"`
+	// Usage is only sent for gpt-image-1.
+	Usage *ImageUsage `json:"usage,omitempty"`
 
 	httpHeader
 }
 
+// ImageUsage represents the tokens used to generate images.
+type ImageUsage struct {
+	InputTokens        int                     `json:"input_tokens"`
+	OutputTokens       int                     `json:"output_tokens"`
+	TotalTokens        int                     `json:"total_tokens"`
+	InputTokensDetails ImageInputTokensDetails `json:"input_tokens_details"`
+}
+
+// ImageInputTokensDetails breaks down the input tokens of an image request.
+type ImageInputTokensDetails struct {
+	TextTokens  int `json:"text_tokens"`
+	ImageTokens int `json:"image_tokens"`
+}
+
 // ImageResponseDataInner represents a response data structure for image API.
+// gpt-image-1 only sets B64JSON.
 type ImageResponseDataInner struct {
 	URL           string `json:"url,omitempty"`
 	B64JSON       string `json:"b64_json,omitempty"`
 	RevisedPrompt string `json:"revised_prompt,omitempty"`
 }
 
+const (
+	dallE2MaxImages    = 10
+	dallE3MaxImages    = 1
+	gptImage1MaxImages = 10
+
+	maxImageOutputCompression = 100
+)
+
+var (
+	dallE2ImageSizes    = []string{CreateImageSize256x256, CreateImageSize512x512, CreateImageSize1024x1024}
+	dallE3ImageSizes    = []string{CreateImageSize1024x1024, CreateImageSize1792x1024, CreateImageSize1024x1792}
+	gptImage1ImageSizes = []string{
+		CreateImageSize1024x1024, CreateImageSize1536x1024, CreateImageSize1024x1536, CreateImageSizeAuto,
+	}
+	gptImage1Qualities = []string{
+		CreateImageQualityHigh, CreateImageQualityMedium, CreateImageQualityLow, CreateImageQualityAuto,
+	}
+	imageOutputFormats = []string{CreateImageOutputFormatPNG, CreateImageOutputFormatJPEG, CreateImageOutputFormatWEBP}
+	imageBackgrounds   = []string{
+		CreateImageBackgroundTransparent, CreateImageBackgroundOpaque, CreateImageBackgroundAuto,
+	}
+	imageModerations = []string{CreateImageModerationLow, CreateImageModerationAuto}
+)
+
+// Validate checks the request against the constraints the image API documents
+// for dall-e-2, the default model, dall-e-3 and gpt-image-1, such as dall-e-3
+// generating a single image per request. Other models are only checked for a
+// negative N. It returns ValidationErrors listing all violations, or nil.
+func (r ImageRequest) Validate() error {
+	var errs ValidationErrors
+	// N is omitted when zero, which the API treats as 1.
+	if r.N < 0 {
+		errs.add("n", "must be at least 1, got %d", r.N)
+	}
+	if r.ResponseFormat != "" && r.ResponseFormat != CreateImageResponseFormatURL &&
+		r.ResponseFormat != CreateImageResponseFormatB64JSON {
+		errs.add("response_format", "must be %q or %q, got %q",
+			CreateImageResponseFormatURL, CreateImageResponseFormatB64JSON, r.ResponseFormat)
+	}
+
+	switch r.Model {
+	case "", CreateImageModelDallE2:
+		if r.N > dallE2MaxImages {
+			errs.add("n", "must be at most %d for %s, got %d", dallE2MaxImages, CreateImageModelDallE2, r.N)
+		}
+		if r.Size != "" && !slices.Contains(dallE2ImageSizes, r.Size) {
+			errs.add("size", "must be one of %v for %s, got %q", dallE2ImageSizes, CreateImageModelDallE2, r.Size)
+		}
+		if r.Quality != "" && r.Quality != CreateImageQualityStandard {
+			errs.add("quality", "must be %q for %s, got %q", CreateImageQualityStandard, CreateImageModelDallE2, r.Quality)
+		}
+		if r.Style != "" {
+			errs.add("style", "is only supported by %s", CreateImageModelDallE3)
+		}
+		r.validateGptImage1Only(&errs)
+	case CreateImageModelDallE3:
+		if r.N > dallE3MaxImages {
+			errs.add("n", "must be %d for %s, got %d", dallE3MaxImages, CreateImageModelDallE3, r.N)
+		}
+		if r.Size != "" && !slices.Contains(dallE3ImageSizes, r.Size) {
+			errs.add("size", "must be one of %v for %s, got %q", dallE3ImageSizes, CreateImageModelDallE3, r.Size)
+		}
+		if r.Quality != "" && r.Quality != CreateImageQualityStandard && r.Quality != CreateImageQualityHD {
+			errs.add("quality", "must be %q or %q for %s, got %q",
+				CreateImageQualityStandard, CreateImageQualityHD, CreateImageModelDallE3, r.Quality)
+		}
+		if r.Style != "" && r.Style != CreateImageStyleVivid && r.Style != CreateImageStyleNatural {
+			errs.add("style", "must be %q or %q, got %q", CreateImageStyleVivid, CreateImageStyleNatural, r.Style)
+		}
+		r.validateGptImage1Only(&errs)
+	case CreateImageModelGptImage1:
+		r.validateGptImage1(&errs)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (r ImageRequest) validateGptImage1(errs *ValidationErrors) {
+	if r.N > gptImage1MaxImages {
+		errs.add("n", "must be at most %d for %s, got %d", gptImage1MaxImages, CreateImageModelGptImage1, r.N)
+	}
+	if r.Size != "" && !slices.Contains(gptImage1ImageSizes, r.Size) {
+		errs.add("size", "must be one of %v for %s, got %q", gptImage1ImageSizes, CreateImageModelGptImage1, r.Size)
+	}
+	if r.Quality != "" && !slices.Contains(gptImage1Qualities, r.Quality) {
+		errs.add("quality", "must be one of %v for %s, got %q", gptImage1Qualities, CreateImageModelGptImage1, r.Quality)
+	}
+	if r.Style != "" {
+		errs.add("style", "is only supported by %s", CreateImageModelDallE3)
+	}
+	if r.ResponseFormat != "" {
+		errs.add("response_format", "is not supported by %s, which always returns base64 images",
+			CreateImageModelGptImage1)
+	}
+	if r.OutputFormat != "" && !slices.Contains(imageOutputFormats, r.OutputFormat) {
+		errs.add("output_format", "must be one of %v, got %q", imageOutputFormats, r.OutputFormat)
+	}
+	if r.OutputCompression != nil {
+		if *r.OutputCompression < 0 || *r.OutputCompression > maxImageOutputCompression {
+			errs.add("output_compression", "must be between 0 and %d, got %d",
+				maxImageOutputCompression, *r.OutputCompression)
+		}
+		if r.OutputFormat != CreateImageOutputFormatJPEG && r.OutputFormat != CreateImageOutputFormatWEBP {
+			errs.add("output_compression", "requires output_format %q or %q",
+				CreateImageOutputFormatJPEG, CreateImageOutputFormatWEBP)
+		}
+	}
+	if r.Background != "" && !slices.Contains(imageBackgrounds, r.Background) {
+		errs.add("background", "must be one of %v, got %q", imageBackgrounds, r.Background)
+	}
+	// The default output format is png.
+	if r.Background == CreateImageBackgroundTransparent && r.OutputFormat == CreateImageOutputFormatJPEG {
+		errs.add("background", "cannot be transparent with output_format %q", CreateImageOutputFormatJPEG)
+	}
+	if r.Moderation != "" && !slices.Contains(imageModerations, r.Moderation) {
+		errs.add("moderation", "must be one of %v, got %q", imageModerations, r.Moderation)
+	}
+}
+
+// validateGptImage1Only reports the parameters set that only gpt-image-1
+// supports.
+func (r ImageRequest) validateGptImage1Only(errs *ValidationErrors) {
+	for _, param := range []struct {
+		field string
+		set   bool
+	}{
+		{"output_format", r.OutputFormat != ""},
+		{"output_compression", r.OutputCompression != nil},
+		{"background", r.Background != ""},
+		{"moderation", r.Moderation != ""},
+	} {
+		if param.set {
+			errs.add(param.field, "is only supported by %s", CreateImageModelGptImage1)
+		}
+	}
+}
+
 // CreateImage - API call to create an image. This is the main endpoint of the DALL-E API.
+// The request is checked with Validate first.
 func (c *Client) CreateImage(ctx context.Context, request ImageRequest) (response ImageResponse, err error) {
+	if err = request.Validate(); err != nil {
+		return
+	}
 	urlSuffix := "/images/generations"
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
 	if err != nil {
 		return
 	}
@@ -82,6 +281,7 @@ type ImageEditRequest struct {
 	Image          *os.File `json:"image,omitempty"`
 	Mask           *os.File `json:"mask,omitempty"`
 	Prompt         string   `json:"prompt,omitempty"`
+	Model          string   `json:"model,omitempty"`
 	N              int      `json:"n,omitempty"`
 	Size           string   `json:"size,omitempty"`
 	ResponseFormat string   `json:"response_format,omitempty"`
@@ -111,6 +311,13 @@ func (c *Client) CreateEditImage(ctx context.Context, request ImageEditRequest)
 		return
 	}
 
+	if request.Model != "" {
+		err = builder.WriteField("model", request.Model)
+		if err != nil {
+			return
+		}
+	}
+
 	err = builder.WriteField("n", strconv.Itoa(request.N))
 	if err != nil {
 		return
@@ -131,8 +338,8 @@ func (c *Client) CreateEditImage(ctx context.Context, request ImageEditRequest)
 		return
 	}
 
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/edits"),
-		withBody(body), withContentType(builder.FormDataContentType()))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/edits", request.Model),
+		withBody(body), withContentType(builder.FormDataContentType()), withModel(request.Model))
 	if err != nil {
 		return
 	}
@@ -144,6 +351,7 @@ func (c *Client) CreateEditImage(ctx context.Context, request ImageEditRequest)
 // ImageVariRequest represents the request structure for the image API.
 type ImageVariRequest struct {
 	Image          *os.File `json:"image,omitempty"`
+	Model          string   `json:"model,omitempty"`
 	N              int      `json:"n,omitempty"`
 	Size           string   `json:"size,omitempty"`
 	ResponseFormat string   `json:"response_format,omitempty"`
@@ -151,42 +359,17 @@ type ImageVariRequest struct {
 
 // CreateVariImage - API call to create an image variation. This is the main endpoint of the DALL-E API.
 // Use abbreviations(vari for variation) because ci-lint has a single-line length limit ...
+// The image is uploaded with CreateVariImageReader, and must meet its constraints.
 func (c *Client) CreateVariImage(ctx context.Context, request ImageVariRequest) (response ImageResponse, err error) {
-	body := &bytes.Buffer{}
-	builder := c.createFormBuilder(body)
-
-	// image
-	err = builder.CreateFormFile("image", request.Image)
-	if err != nil {
-		return
-	}
-
-	err = builder.WriteField("n", strconv.Itoa(request.N))
-	if err != nil {
-		return
-	}
-
-	err = builder.WriteField("size", request.Size)
-	if err != nil {
-		return
+	var image ImageFile
+	if request.Image != nil {
+		image = ImageFile{Reader: request.Image, Name: request.Image.Name()}
 	}
-
-	err = builder.WriteField("response_format", request.ResponseFormat)
-	if err != nil {
-		return
-	}
-
-	err = builder.Close()
-	if err != nil {
-		return
-	}
-
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/variations"),
-		withBody(body), withContentType(builder.FormDataContentType()))
-	if err != nil {
-		return
-	}
-
-	err = c.sendRequest(req, &response)
-	return
+	return c.CreateVariImageReader(ctx, ImageVariReaderRequest{
+		Image:          image,
+		Model:          request.Model,
+		N:              request.N,
+		Size:           request.Size,
+		ResponseFormat: request.ResponseFormat,
+	})
 }
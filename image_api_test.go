@@ -1,16 +1,22 @@
 package openai_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
 	"github.com/zquestz/go-openai/internal/test/checks"
 )
 
@@ -31,6 +37,186 @@ func TestImages(t *testing.T) {
 	checks.NoError(t, err, "CreateImage error")
 }
 
+func TestImageRequestMarshal(t *testing.T) {
+	marshaled, err := json.Marshal(openai.ImageRequest{
+		Prompt:  "Lorem ipsum",
+		Model:   openai.CreateImageModelDallE3,
+		Quality: openai.CreateImageQualityHD,
+		Size:    openai.CreateImageSize1792x1024,
+		Style:   openai.CreateImageStyleNatural,
+	})
+	checks.NoError(t, err, "Marshal error")
+	want := `{"prompt":"Lorem ipsum","model":"dall-e-3","quality":"hd","size":"1792x1024","style":"natural"}`
+	if string(marshaled) != want {
+		t.Errorf("got %s, want %s", marshaled, want)
+	}
+
+	// Unset parameters are left to the API defaults.
+	marshaled, err = json.Marshal(openai.ImageRequest{Prompt: "Lorem ipsum"})
+	checks.NoError(t, err, "Marshal error")
+	if string(marshaled) != `{"prompt":"Lorem ipsum"}` {
+		t.Errorf("got %s", marshaled)
+	}
+}
+
+func TestImageGptImage1(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	compression := 80
+	request := openai.ImageRequest{
+		Prompt:            "Lorem ipsum",
+		Model:             openai.CreateImageModelGptImage1,
+		N:                 2,
+		Quality:           openai.CreateImageQualityMedium,
+		Size:              openai.CreateImageSizeAuto,
+		OutputFormat:      openai.CreateImageOutputFormatJPEG,
+		OutputCompression: &compression,
+		Background:        openai.CreateImageBackgroundOpaque,
+		Moderation:        openai.CreateImageModerationLow,
+	}
+	server.RegisterHandler("/v1/images/generations", func(w http.ResponseWriter, r *http.Request) {
+		got, err := getImageBody(r)
+		checks.NoError(t, err, "getImageBody error")
+		if !reflect.DeepEqual(got, request) {
+			t.Errorf("got request %+v, want %+v", got, request)
+		}
+		fmt.Fprintln(w, `{
+			"created": 1713833628,
+			"background": "opaque",
+			"output_format": "jpeg",
+			"quality": "medium",
+			"size": "1024x1024",
+			"data": [{"b64_json": "e30K"}, {"b64_json": "e30L"}],
+			"usage": {
+				"total_tokens": 100,
+				"input_tokens": 50,
+				"output_tokens": 50,
+				"input_tokens_details": {"text_tokens": 10, "image_tokens": 40}
+			}
+		}`)
+	})
+
+	response, err := client.CreateImage(context.Background(), request)
+	checks.NoError(t, err, "CreateImage error")
+	if len(response.Data) != 2 || response.Data[0].URL != "" || response.Data[1].B64JSON != "e30L" {
+		t.Errorf("got data %+v", response.Data)
+	}
+	if response.OutputFormat != openai.CreateImageOutputFormatJPEG || response.Size != openai.CreateImageSize1024x1024 ||
+		response.Background != openai.CreateImageBackgroundOpaque || response.Quality != openai.CreateImageQualityMedium {
+		t.Errorf("got parameters %+v", response)
+	}
+	wantUsage := &openai.ImageUsage{
+		InputTokens:        50,
+		OutputTokens:       50,
+		TotalTokens:        100,
+		InputTokensDetails: openai.ImageInputTokensDetails{TextTokens: 10, ImageTokens: 40},
+	}
+	if !reflect.DeepEqual(response.Usage, wantUsage) {
+		t.Errorf("got usage %+v, want %+v", response.Usage, wantUsage)
+	}
+
+	marshaled, err := json.Marshal(request)
+	checks.NoError(t, err, "Marshal error")
+	want := `{"prompt":"Lorem ipsum","model":"gpt-image-1","n":2,"quality":"medium","size":"auto",` +
+		`"output_format":"jpeg","output_compression":80,"background":"opaque","moderation":"low"}`
+	if string(marshaled) != want {
+		t.Errorf("got %s, want %s", marshaled, want)
+	}
+}
+
+func TestImageRequestValidate(t *testing.T) {
+	compression, overCompression := 50, 101
+	testCases := []struct {
+		name    string
+		request openai.ImageRequest
+		fields  []string
+	}{
+		{"defaults", openai.ImageRequest{Prompt: "a cat"}, nil},
+		{"dall-e-2", openai.ImageRequest{
+			Model: openai.CreateImageModelDallE2, N: 10, Size: openai.CreateImageSize256x256,
+			Quality: openai.CreateImageQualityStandard, ResponseFormat: openai.CreateImageResponseFormatB64JSON,
+		}, nil},
+		{"dall-e-3", openai.ImageRequest{
+			Model: openai.CreateImageModelDallE3, N: 1, Size: openai.CreateImageSize1024x1792,
+			Quality: openai.CreateImageQualityHD, Style: openai.CreateImageStyleVivid,
+		}, nil},
+		{"gpt-image-1", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, N: 4, Size: openai.CreateImageSize1536x1024,
+			Quality: openai.CreateImageQualityHigh, OutputFormat: openai.CreateImageOutputFormatWEBP,
+			OutputCompression: &compression, Background: openai.CreateImageBackgroundTransparent,
+			Moderation: openai.CreateImageModerationLow,
+		}, nil},
+		{"other model", openai.ImageRequest{Model: "gpt-image-2", N: 20, Size: "4096x4096", Quality: "max"}, nil},
+		{"negative n", openai.ImageRequest{Model: "gpt-image-2", N: -1}, []string{"n"}},
+		{"response format", openai.ImageRequest{ResponseFormat: "png"}, []string{"response_format"}},
+		{"dall-e-2 n", openai.ImageRequest{N: 11}, []string{"n"}},
+		{"dall-e-2 size", openai.ImageRequest{Size: openai.CreateImageSize1792x1024}, []string{"size"}},
+		{"dall-e-2 quality and style", openai.ImageRequest{
+			Model: openai.CreateImageModelDallE2, Quality: openai.CreateImageQualityHD, Style: openai.CreateImageStyleVivid,
+		}, []string{"quality", "style"}},
+		{"dall-e-3 n", openai.ImageRequest{Model: openai.CreateImageModelDallE3, N: 2}, []string{"n"}},
+		{"dall-e-3 size", openai.ImageRequest{
+			Model: openai.CreateImageModelDallE3, Size: openai.CreateImageSize512x512,
+		}, []string{"size"}},
+		{"dall-e-3 quality and style", openai.ImageRequest{
+			Model: openai.CreateImageModelDallE3, Quality: "ultra", Style: "pastel",
+		}, []string{"quality", "style"}},
+		{"dall-e-3 gpt-image-1 parameters", openai.ImageRequest{
+			Model: openai.CreateImageModelDallE3, OutputFormat: openai.CreateImageOutputFormatPNG,
+			OutputCompression: &compression, Background: openai.CreateImageBackgroundOpaque,
+			Moderation: openai.CreateImageModerationAuto,
+		}, []string{"output_format", "output_compression", "background", "moderation"}},
+		{"gpt-image-1 n, size, quality and style", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, N: 11, Size: openai.CreateImageSize1792x1024,
+			Quality: openai.CreateImageQualityHD, Style: openai.CreateImageStyleVivid,
+		}, []string{"n", "size", "quality", "style"}},
+		{"gpt-image-1 response format", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, ResponseFormat: openai.CreateImageResponseFormatURL,
+		}, []string{"response_format"}},
+		{"gpt-image-1 output", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, OutputFormat: "gif", Background: "checkered", Moderation: "high",
+		}, []string{"output_format", "background", "moderation"}},
+		{"gpt-image-1 png compression", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, OutputCompression: &compression,
+		}, []string{"output_compression"}},
+		{"gpt-image-1 compression range", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, OutputFormat: openai.CreateImageOutputFormatJPEG,
+			OutputCompression: &overCompression,
+		}, []string{"output_compression"}},
+		{"gpt-image-1 transparent jpeg", openai.ImageRequest{
+			Model: openai.CreateImageModelGptImage1, OutputFormat: openai.CreateImageOutputFormatJPEG,
+			Background: openai.CreateImageBackgroundTransparent,
+		}, []string{"background"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request.Validate()
+			var fields []string
+			var validationErrs openai.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				for _, validationErr := range validationErrs {
+					fields = append(fields, validationErr.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("got error %v, want ValidationErrors", err)
+			}
+			if !reflect.DeepEqual(fields, tc.fields) {
+				t.Errorf("got errors %v, want errors of %v", err, tc.fields)
+			}
+		})
+	}
+
+	client, _, teardown := setupOpenAITestServer()
+	defer teardown()
+	_, err := client.CreateImage(context.Background(), openai.ImageRequest{
+		Prompt: "a cat", Model: openai.CreateImageModelDallE3, N: 2,
+	})
+	var validationErrs openai.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Errorf("CreateImage returned %v, want ValidationErrors", err)
+	}
+}
+
 // handleImageEndpoint Handles the images endpoint by the test server.
 func handleImageEndpoint(w http.ResponseWriter, r *http.Request) {
 	var err error
@@ -178,7 +364,8 @@ func TestImageVariation(t *testing.T) {
 	defer teardown()
 	server.RegisterHandler("/v1/images/variations", handleVariateImageEndpoint)
 
-	origin, err := os.Create("image.png")
+	checks.NoError(t, os.WriteFile("image.png", test.CreateTestPNG(t, 8, 8), 0o600), "write origin file error")
+	origin, err := os.Open("image.png")
 	if err != nil {
 		t.Error("open origin file error")
 		return
@@ -228,3 +415,188 @@ func handleVariateImageEndpoint(w http.ResponseWriter, r *http.Request) {
 	resBytes, _ = json.Marshal(responses)
 	fmt.Fprintln(w, string(resBytes))
 }
+
+// imageEditPart is a file of an image edit form.
+type imageEditPart struct {
+	field, filename, contentType, content string
+}
+
+func TestImageEditReader(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var (
+		parts  []imageEditPart
+		fields map[string][]string
+		length int64
+	)
+	server.RegisterHandler("/v1/images/edits", func(w http.ResponseWriter, r *http.Request) {
+		length = r.ContentLength
+		checks.NoError(t, r.ParseMultipartForm(1<<20), "ParseMultipartForm error")
+		parts = nil
+		for _, field := range []string{"image", "image[]", "mask"} {
+			for _, header := range r.MultipartForm.File[field] {
+				file, err := header.Open()
+				checks.NoError(t, err, "Open error")
+				content, _ := io.ReadAll(file)
+				file.Close()
+				parts = append(parts, imageEditPart{field, header.Filename, header.Header.Get("Content-Type"), string(content)})
+			}
+		}
+		fields = r.MultipartForm.Value
+		fmt.Fprintln(w, `{"created": 1713833628, "data": [{"b64_json": "e30K"}]}`)
+	})
+	ctx := context.Background()
+
+	response, err := client.CreateEditImageReader(ctx, openai.ImageEditReaderRequest{
+		Images: []openai.ImageFile{{Reader: strings.NewReader("png image"), Name: "bucket/photo.png"}},
+		Mask:   &openai.ImageFile{Reader: strings.NewReader("png mask"), Name: "mask.png"},
+		Prompt: "Add a hat",
+		Model:  openai.CreateImageModelDallE2,
+		N:      2,
+		Size:   openai.CreateImageSize512x512,
+	})
+	checks.NoError(t, err, "CreateEditImageReader error")
+	if len(response.Data) != 1 || response.Data[0].B64JSON != "e30K" {
+		t.Errorf("got response %+v", response)
+	}
+	if length != -1 {
+		t.Errorf("got content length %d, want a streamed body", length)
+	}
+	wantParts := []imageEditPart{
+		{"image", "photo.png", "image/png", "png image"},
+		{"mask", "mask.png", "image/png", "png mask"},
+	}
+	if !reflect.DeepEqual(parts, wantParts) {
+		t.Errorf("got parts %+v, want %+v", parts, wantParts)
+	}
+	wantFields := map[string][]string{
+		"prompt": {"Add a hat"}, "model": {"dall-e-2"}, "n": {"2"}, "size": {"512x512"},
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("got fields %v, want %v", fields, wantFields)
+	}
+
+	compression := 60
+	_, err = client.CreateEditImageReader(ctx, openai.ImageEditReaderRequest{
+		Images: []openai.ImageFile{
+			{Reader: strings.NewReader("jpeg image"), Name: "first.jpg"},
+			{Reader: strings.NewReader("webp image"), Name: "second", ContentType: "image/webp"},
+		},
+		Prompt:            "Combine them",
+		Model:             openai.CreateImageModelGptImage1,
+		Quality:           openai.CreateImageQualityLow,
+		Background:        openai.CreateImageBackgroundOpaque,
+		OutputFormat:      openai.CreateImageOutputFormatWEBP,
+		OutputCompression: &compression,
+	})
+	checks.NoError(t, err, "CreateEditImageReader error")
+	wantParts = []imageEditPart{
+		{"image[]", "first.jpg", "image/jpeg", "jpeg image"},
+		{"image[]", "second", "image/webp", "webp image"},
+	}
+	if !reflect.DeepEqual(parts, wantParts) {
+		t.Errorf("got parts %+v, want %+v", parts, wantParts)
+	}
+	wantFields = map[string][]string{
+		"prompt": {"Combine them"}, "model": {"gpt-image-1"}, "quality": {"low"}, "background": {"opaque"},
+		"output_format": {"webp"}, "output_compression": {"60"},
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("got fields %v, want %v", fields, wantFields)
+	}
+
+	_, err = client.CreateEditImageReader(ctx, openai.ImageEditReaderRequest{Prompt: "Add a hat"})
+	checks.ErrorIs(t, err, openai.ErrImageEditNoImage, "CreateEditImageReader should fail without images")
+
+	readErr := errors.New("bucket unavailable")
+	_, err = client.CreateEditImageReader(ctx, openai.ImageEditReaderRequest{
+		Images: []openai.ImageFile{{Reader: iotest.ErrReader(readErr), Name: "photo.png"}},
+		Prompt: "Add a hat",
+	})
+	checks.ErrorIs(t, err, readErr, "CreateEditImageReader should fail when an image cannot be read")
+}
+
+// unsizedReader hides the size of its reader.
+type unsizedReader struct {
+	io.Reader
+}
+
+func TestImageVariationReader(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var (
+		parts  []imageEditPart
+		fields map[string][]string
+	)
+	server.RegisterHandler("/v1/images/variations", func(w http.ResponseWriter, r *http.Request) {
+		// Too large images are sent partly.
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		parts = nil
+		for _, header := range r.MultipartForm.File["image"] {
+			file, err := header.Open()
+			checks.NoError(t, err, "Open error")
+			content, _ := io.ReadAll(file)
+			file.Close()
+			parts = append(parts, imageEditPart{"image", header.Filename, header.Header.Get("Content-Type"), string(content)})
+		}
+		fields = r.MultipartForm.Value
+		fmt.Fprintln(w, `{"created": 1713833628, "data": [{"url": "test-url1"}]}`)
+	})
+	ctx := context.Background()
+
+	image := test.CreateTestPNG(t, 16, 16)
+	response, err := client.CreateVariImageReader(ctx, openai.ImageVariReaderRequest{
+		Image:          openai.ImageFile{Reader: bytes.NewReader(image), Name: "photo.png"},
+		Model:          openai.CreateImageModelDallE2,
+		N:              2,
+		Size:           openai.CreateImageSize256x256,
+		ResponseFormat: openai.CreateImageResponseFormatURL,
+	})
+	checks.NoError(t, err, "CreateVariImageReader error")
+	if len(response.Data) != 1 || response.Data[0].URL != "test-url1" {
+		t.Errorf("got response %+v", response)
+	}
+	wantParts := []imageEditPart{{"image", "photo.png", "image/png", string(image)}}
+	if !reflect.DeepEqual(parts, wantParts) {
+		t.Errorf("got parts %+v, want %+v", parts, wantParts)
+	}
+	wantFields := map[string][]string{
+		"model": {"dall-e-2"}, "n": {"2"}, "size": {"256x256"}, "response_format": {"url"},
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("got fields %v, want %v", fields, wantFields)
+	}
+
+	// Too large images are caught before sending them if the size of their
+	// reader is known, or else as they are sent.
+	large := append(test.CreateTestPNG(t, 16, 16), make([]byte, 4<<20)...)
+	for _, tc := range []struct {
+		name   string
+		reader io.Reader
+		want   string
+	}{
+		{"unset", nil, "image must be set"},
+		{"not png", strings.NewReader("GIF89a"), "image must be a PNG"},
+		{"not square", bytes.NewReader(test.CreateTestPNG(t, 16, 8)), "image must be square, got 16x8"},
+		{"too large", bytes.NewReader(large), "image must be less than 4 MB, got 4194"},
+		{"too large unsized", unsizedReader{bytes.NewReader(large)}, "image must be less than 4 MB"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var file openai.ImageFile
+			if tc.reader != nil {
+				file = openai.ImageFile{Reader: tc.reader, Name: "photo.png"}
+			}
+			_, err := client.CreateVariImageReader(ctx, openai.ImageVariReaderRequest{Image: file})
+			var validationErrs openai.ValidationErrors
+			if !errors.As(err, &validationErrs) {
+				t.Fatalf("got error %v, want ValidationErrors", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got error %q, want %q", err, tc.want)
+			}
+		})
+	}
+}
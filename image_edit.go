@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	utils "github.com/zquestz/go-openai/internal"
+)
+
+// ErrImageEditNoImage is returned by CreateEditImageReader for requests
+// without images.
+var ErrImageEditNoImage = errors.New("image edit request has no image")
+
+// ImageFile is an image uploaded from a reader, e.g. an object of a storage
+// bucket.
+type ImageFile struct {
+	Reader io.Reader
+	// Name is the filename of the image, e.g. "photo.png".
+	Name string
+	// ContentType is the media type of the image, the one of the extension of
+	// Name if empty, e.g. image/png.
+	ContentType string
+}
+
+func (f ImageFile) contentType() string {
+	if f.ContentType != "" {
+		return f.ContentType
+	}
+	if contentType := mime.TypeByExtension(path.Ext(f.Name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// ImageEditReaderRequest is an ImageEditRequest reading its images from
+// readers rather than files.
+type ImageEditReaderRequest struct {
+	// Images are the images to edit. dall-e-2 takes a single one, gpt-image-1
+	// up to 16, which are sent as image[] parts.
+	Images []ImageFile
+	// Mask, if set, marks with its transparent areas where to edit the first
+	// image.
+	Mask *ImageFile
+
+	Prompt         string
+	Model          string
+	N              int
+	Size           string
+	ResponseFormat string
+	User           string
+	// Quality, Background, OutputFormat and OutputCompression are only
+	// supported by gpt-image-1, see ImageRequest.
+	Quality           string
+	Background        string
+	OutputFormat      string
+	OutputCompression *int
+}
+
+// CreateEditImageReader - API call to edit images read from readers. The
+// multipart body is streamed as it is sent rather than buffered, except by
+// clients retrying requests, which must be able to send it again.
+func (c *Client) CreateEditImageReader(
+	ctx context.Context,
+	request ImageEditReaderRequest,
+) (response ImageResponse, err error) {
+	if len(request.Images) == 0 {
+		err = ErrImageEditNoImage
+		return
+	}
+
+	body, writer := io.Pipe()
+	// Stops writing the form if the request ends before reading all of it.
+	defer body.Close()
+	builder := c.createFormBuilder(writer)
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/edits", request.Model),
+		withBody(body), withContentType(builder.FormDataContentType()), withModel(request.Model))
+	if err != nil {
+		return
+	}
+
+	go func() {
+		writer.CloseWithError(imageEditMultipartForm(request, builder))
+	}()
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// imageEditMultipartForm writes the form of request to b, closing it.
+func imageEditMultipartForm(request ImageEditReaderRequest, b utils.FormBuilder) error {
+	field := "image"
+	if len(request.Images) > 1 {
+		field = "image[]"
+	}
+	for _, image := range request.Images {
+		if err := b.CreateFormFileReaderWithType(field, image.Reader, image.Name, image.contentType()); err != nil {
+			return fmt.Errorf("writing image %s: %w", image.Name, err)
+		}
+	}
+	if mask := request.Mask; mask != nil {
+		if err := b.CreateFormFileReaderWithType("mask", mask.Reader, mask.Name, mask.contentType()); err != nil {
+			return fmt.Errorf("writing mask: %w", err)
+		}
+	}
+
+	fields := []struct{ name, value string }{
+		{"prompt", request.Prompt},
+		{"model", request.Model},
+		{"size", request.Size},
+		{"response_format", request.ResponseFormat},
+		{"user", request.User},
+		{"quality", request.Quality},
+		{"background", request.Background},
+		{"output_format", request.OutputFormat},
+	}
+	if request.N != 0 {
+		fields = append(fields, struct{ name, value string }{"n", strconv.Itoa(request.N)})
+	}
+	if request.OutputCompression != nil {
+		fields = append(fields, struct{ name, value string }{
+			"output_compression", strconv.Itoa(*request.OutputCompression),
+		})
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if err := b.WriteField(field.name, field.value); err != nil {
+			return fmt.Errorf("writing %s: %w", field.name, err)
+		}
+	}
+	return b.Close()
+}
@@ -2,11 +2,15 @@ package openai //nolint:testpackage // testing private field
 
 import (
 	utils "github.com/zquestz/go-openai/internal"
+	"github.com/zquestz/go-openai/internal/test"
 	"github.com/zquestz/go-openai/internal/test/checks"
 
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 )
@@ -26,6 +30,10 @@ func (fb *mockFormBuilder) CreateFormFileReader(fieldname string, r io.Reader, f
 	return fb.mockCreateFormFileReader(fieldname, r, filename)
 }
 
+func (fb *mockFormBuilder) CreateFormFileReaderWithType(fieldname string, r io.Reader, filename, _ string) error {
+	return fb.mockCreateFormFileReader(fieldname, r, filename)
+}
+
 func (fb *mockFormBuilder) WriteField(fieldname, value string) error {
 	return fb.mockWriteField(fieldname, value)
 }
@@ -106,8 +114,13 @@ func TestImageFormBuilderFailures(t *testing.T) {
 }
 
 func TestVariImageFormBuilderFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		fmt.Fprintln(w, "{}")
+	}))
+	defer server.Close()
 	config := DefaultConfig("")
-	config.BaseURL = ""
+	config.BaseURL = server.URL
 	client := NewClientWithConfig(config)
 
 	mockBuilder := &mockFormBuilder{}
@@ -116,16 +129,26 @@ func TestVariImageFormBuilderFailures(t *testing.T) {
 	}
 	ctx := context.Background()
 
-	req := ImageVariRequest{}
+	image := test.CreateTestPNG(t, 8, 8)
+	req := ImageVariReaderRequest{
+		Image:          ImageFile{Name: "image.png"},
+		N:              2,
+		Size:           CreateImageSize256x256,
+		ResponseFormat: CreateImageResponseFormatURL,
+	}
+	send := func() error {
+		req.Image.Reader = bytes.NewReader(image)
+		_, err := client.CreateVariImageReader(ctx, req)
+		return err
+	}
 
 	mockFailedErr := fmt.Errorf("mock form builder fail")
-	mockBuilder.mockCreateFormFile = func(string, *os.File) error {
+	mockBuilder.mockCreateFormFileReader = func(string, io.Reader, string) error {
 		return mockFailedErr
 	}
-	_, err := client.CreateVariImage(ctx, req)
-	checks.ErrorIs(t, err, mockFailedErr, "CreateVariImage should return error if form builder fails")
+	checks.ErrorIs(t, send(), mockFailedErr, "CreateVariImageReader should return error if form builder fails")
 
-	mockBuilder.mockCreateFormFile = func(name string, file *os.File) error {
+	mockBuilder.mockCreateFormFileReader = func(string, io.Reader, string) error {
 		return nil
 	}
 
@@ -138,21 +161,17 @@ func TestVariImageFormBuilderFailures(t *testing.T) {
 	}
 
 	failForField = "n"
-	_, err = client.CreateVariImage(ctx, req)
-	checks.ErrorIs(t, err, mockFailedErr, "CreateVariImage should return error if form builder fails")
+	checks.ErrorIs(t, send(), mockFailedErr, "CreateVariImageReader should return error if form builder fails")
 
 	failForField = "size"
-	_, err = client.CreateVariImage(ctx, req)
-	checks.ErrorIs(t, err, mockFailedErr, "CreateVariImage should return error if form builder fails")
+	checks.ErrorIs(t, send(), mockFailedErr, "CreateVariImageReader should return error if form builder fails")
 
 	failForField = "response_format"
-	_, err = client.CreateVariImage(ctx, req)
-	checks.ErrorIs(t, err, mockFailedErr, "CreateVariImage should return error if form builder fails")
+	checks.ErrorIs(t, send(), mockFailedErr, "CreateVariImageReader should return error if form builder fails")
 
 	failForField = ""
 	mockBuilder.mockClose = func() error {
 		return mockFailedErr
 	}
-	_, err = client.CreateVariImage(ctx, req)
-	checks.ErrorIs(t, err, mockFailedErr, "CreateImage should return error if form builder fails")
+	checks.ErrorIs(t, send(), mockFailedErr, "CreateVariImageReader should return error if form builder fails")
 }
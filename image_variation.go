@@ -0,0 +1,147 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	utils "github.com/zquestz/go-openai/internal"
+)
+
+// maxImageVariationBytes is the size images of variations must stay below.
+const maxImageVariationBytes = 4 << 20
+
+// ImageVariReaderRequest is an ImageVariRequest reading its image from a
+// reader rather than a file.
+type ImageVariReaderRequest struct {
+	// Image must be a square PNG of less than 4 MB.
+	Image          ImageFile
+	Model          string
+	N              int
+	Size           string
+	ResponseFormat string
+	User           string
+}
+
+// CreateVariImageReader - API call to create variations of an image read from
+// a reader. The image is checked to be a square PNG before uploading it, and
+// to be less than 4 MB if the size of its reader is known, e.g. a bytes.Reader
+// or an os.File, or else as it is sent. The multipart body is streamed as it
+// is sent rather than buffered, except by clients retrying requests.
+func (c *Client) CreateVariImageReader(
+	ctx context.Context,
+	request ImageVariReaderRequest,
+) (response ImageResponse, err error) {
+	image, err := checkVariationImage(request.Image)
+	if err != nil {
+		return
+	}
+
+	body, writer := io.Pipe()
+	// Stops writing the form if the request ends before reading all of it.
+	defer body.Close()
+	builder := c.createFormBuilder(writer)
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/images/variations", request.Model),
+		withBody(body), withContentType(builder.FormDataContentType()), withModel(request.Model))
+	if err != nil {
+		return
+	}
+
+	go func() {
+		writer.CloseWithError(imageVariMultipartForm(request, image, builder))
+	}()
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// checkVariationImage checks what it can of file before uploading it, and
+// returns the reader of its content, which fails if it turns out too large.
+func checkVariationImage(file ImageFile) (io.Reader, error) {
+	var errs ValidationErrors
+	if file.Reader == nil {
+		errs.add("image", "must be set")
+		return nil, errs
+	}
+	if size, ok := readerSize(file.Reader); ok && size >= maxImageVariationBytes {
+		errs.add("image", "must be less than 4 MB, got %d bytes", size)
+	}
+	// DecodeConfig reads the header of the image, which is then sent again.
+	var header bytes.Buffer
+	config, err := png.DecodeConfig(io.TeeReader(file.Reader, &header))
+	if err != nil {
+		errs.add("image", "must be a PNG: %v", err)
+	} else if config.Width != config.Height {
+		errs.add("image", "must be square, got %dx%d", config.Width, config.Height)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &maxSizeReader{reader: io.MultiReader(&header, file.Reader), limit: maxImageVariationBytes}, nil
+}
+
+// readerSize returns the size of the readers that tell it.
+func readerSize(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		return info.Size(), true
+	default:
+		return 0, false
+	}
+}
+
+// maxSizeReader fails with a ValidationError once limit bytes were read.
+type maxSizeReader struct {
+	reader io.Reader
+	read   int64
+	limit  int64
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read >= r.limit {
+		var errs ValidationErrors
+		errs.add("image", "must be less than 4 MB")
+		return n, errs
+	}
+	return n, err
+}
+
+// imageVariMultipartForm writes the form of request, with the content of its
+// image read from image, to b, closing it.
+func imageVariMultipartForm(request ImageVariReaderRequest, image io.Reader, b utils.FormBuilder) error {
+	// The image was checked to be a PNG, whatever its name.
+	if err := b.CreateFormFileReaderWithType("image", image, request.Image.Name, "image/png"); err != nil {
+		return fmt.Errorf("writing image %s: %w", request.Image.Name, err)
+	}
+
+	fields := []struct{ name, value string }{
+		{"model", request.Model},
+		{"size", request.Size},
+		{"response_format", request.ResponseFormat},
+		{"user", request.User},
+	}
+	if request.N != 0 {
+		fields = append(fields, struct{ name, value string }{"n", strconv.Itoa(request.N)})
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if err := b.WriteField(field.name, field.value); err != nil {
+			return fmt.Errorf("writing %s: %w", field.name, err)
+		}
+	}
+	return b.Close()
+}
@@ -0,0 +1,126 @@
+package openai
+
+import (
+	"context"
+	"io"
+)
+
+// The interfaces below group the API methods of Client by area, so that code
+// can depend on the smallest one it needs and be tested with a fake such as
+// the ones of the openaitest package. Streams are their concrete types, which
+// fakes create with NewChatCompletionStream and its siblings.
+
+// ChatCompleter creates chat completions.
+type ChatCompleter interface {
+	CreateChatCompletion(ctx context.Context, request ChatCompletionRequest) (ChatCompletionResponse, error)
+	CreateChatCompletionStream(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionStream, error)
+}
+
+// Completer creates legacy completions.
+type Completer interface {
+	CreateCompletion(ctx context.Context, request CompletionRequest) (CompletionResponse, error)
+	CreateCompletionStream(ctx context.Context, request CompletionRequest) (*CompletionStream, error)
+}
+
+// Responder creates responses of the responses API.
+type Responder interface {
+	CreateResponse(ctx context.Context, request ResponseRequest) (Response, error)
+	CreateResponseStream(ctx context.Context, request ResponseRequest) (*ResponseStream, error)
+}
+
+// Embedder creates embeddings.
+type Embedder interface {
+	CreateEmbeddings(ctx context.Context, conv EmbeddingRequestConverter) (EmbeddingResponse, error)
+}
+
+// Transcriber transcribes and translates audio.
+type Transcriber interface {
+	CreateTranscription(ctx context.Context, request AudioRequest) (AudioResponse, error)
+	CreateTranslation(ctx context.Context, request AudioRequest) (AudioResponse, error)
+}
+
+// Speaker generates audio from text.
+type Speaker interface {
+	CreateSpeech(ctx context.Context, request SpeechRequest) (io.ReadCloser, error)
+}
+
+// ImageCreator creates, edits and varies images.
+type ImageCreator interface {
+	CreateImage(ctx context.Context, request ImageRequest) (ImageResponse, error)
+	CreateEditImage(ctx context.Context, request ImageEditRequest) (ImageResponse, error)
+	CreateEditImageReader(ctx context.Context, request ImageEditReaderRequest) (ImageResponse, error)
+	CreateVariImage(ctx context.Context, request ImageVariRequest) (ImageResponse, error)
+	CreateVariImageReader(ctx context.Context, request ImageVariReaderRequest) (ImageResponse, error)
+}
+
+// Moderator classifies content against the usage policies.
+type Moderator interface {
+	Moderations(ctx context.Context, request ModerationRequest) (ModerationResponse, error)
+}
+
+// FileManager uploads, lists and deletes files.
+type FileManager interface {
+	CreateFile(ctx context.Context, request FileRequest) (File, error)
+	DeleteFile(ctx context.Context, fileID string) error
+	ListFiles(ctx context.Context) (FilesList, error)
+	GetFile(ctx context.Context, fileID string) (File, error)
+	GetFileContent(ctx context.Context, fileID string) (io.ReadCloser, error)
+}
+
+// ModelManager lists models and deletes fine-tuned ones.
+type ModelManager interface {
+	ListModels(ctx context.Context) (ModelsList, error)
+	GetModel(ctx context.Context, modelID string) (Model, error)
+	DeleteFineTuneModel(ctx context.Context, modelID string) (FineTuneModelDeleteResponse, error)
+}
+
+// FineTuner manages fine-tuning jobs.
+type FineTuner interface {
+	CreateFineTuningJob(ctx context.Context, request FineTuningJobRequest) (FineTuningJob, error)
+	CancelFineTuningJob(ctx context.Context, fineTuningJobID string) (FineTuningJob, error)
+	RetrieveFineTuningJob(ctx context.Context, fineTuningJobID string) (FineTuningJob, error)
+	ListFineTuningJobEvents(
+		ctx context.Context,
+		fineTuningJobID string,
+		setters ...ListFineTuningJobEventsParameter,
+	) (FineTuningJobEventList, error)
+}
+
+// AssistantManager manages assistants and their files.
+type AssistantManager interface {
+	CreateAssistant(ctx context.Context, request AssistantRequest) (Assistant, error)
+	RetrieveAssistant(ctx context.Context, assistantID string) (Assistant, error)
+	ModifyAssistant(ctx context.Context, assistantID string, request AssistantRequest) (Assistant, error)
+	DeleteAssistant(ctx context.Context, assistantID string) (AssistantDeleteResponse, error)
+	ListAssistants(ctx context.Context, limit *int, order *string, after *string, before *string) (AssistantsList, error)
+	CreateAssistantFile(ctx context.Context, assistantID string, request AssistantFileRequest) (AssistantFile, error)
+	RetrieveAssistantFile(ctx context.Context, assistantID string, fileID string) (AssistantFile, error)
+	DeleteAssistantFile(ctx context.Context, assistantID string, fileID string) error
+	ListAssistantFiles(
+		ctx context.Context,
+		assistantID string,
+		limit *int,
+		order *string,
+		after *string,
+		before *string,
+	) (AssistantFilesList, error)
+}
+
+// API is implemented by Client. It leaves out the deprecated edits, engines
+// and fine-tunes endpoints.
+type API interface {
+	ChatCompleter
+	Completer
+	Responder
+	Embedder
+	Transcriber
+	Speaker
+	ImageCreator
+	Moderator
+	FileManager
+	ModelManager
+	FineTuner
+	AssistantManager
+}
+
+var _ API = (*Client)(nil)
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/textproto"
 	"os"
 	"path"
+	"strings"
 )
 
 type FormBuilder interface {
 	CreateFormFile(fieldname string, file *os.File) error
 	CreateFormFileReader(fieldname string, r io.Reader, filename string) error
+	CreateFormFileReaderWithType(fieldname string, r io.Reader, filename, contentType string) error
 	WriteField(fieldname, value string) error
 	Close() error
 	FormDataContentType() string
@@ -34,6 +37,36 @@ func (fb *DefaultFormBuilder) CreateFormFileReader(fieldname string, r io.Reader
 	return fb.createFormFile(fieldname, r, path.Base(filename))
 }
 
+// CreateFormFileReaderWithType is like CreateFormFileReader, but sends the
+// file with contentType rather than application/octet-stream.
+func (fb *DefaultFormBuilder) CreateFormFileReaderWithType(
+	fieldname string,
+	r io.Reader,
+	filename string,
+	contentType string,
+) error {
+	filename = path.Base(filename)
+	if filename == "" || filename == "." {
+		return fmt.Errorf("filename cannot be empty")
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(fieldname), quoteEscaper.Replace(filename)))
+	header.Set("Content-Type", contentType)
+	fieldWriter, err := fb.writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fieldWriter, r)
+	return err
+}
+
+// quoteEscaper escapes the names of the Content-Disposition header of parts,
+// like mime/multipart does.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
 func (fb *DefaultFormBuilder) createFormFile(fieldname string, r io.Reader, filename string) error {
 	if filename == "" {
 		return fmt.Errorf("filename cannot be empty")
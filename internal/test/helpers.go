@@ -3,6 +3,9 @@ package test
 import (
 	"github.com/zquestz/go-openai/internal/test/checks"
 
+	"bytes"
+	"image"
+	"image/png"
 	"net/http"
 	"os"
 	"testing"
@@ -19,6 +22,16 @@ func CreateTestFile(t *testing.T, path string) {
 	file.Close()
 }
 
+// CreateTestPNG encodes a blank PNG image of the given size.
+func CreateTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)))
+	checks.NoError(t, err, "failed to encode PNG")
+	return buf.Bytes()
+}
+
 // CreateTestDirectory creates a temporary folder which will be deleted when cleanup is called.
 func CreateTestDirectory(t *testing.T) (path string, cleanup func()) {
 	t.Helper()
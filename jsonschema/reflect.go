@@ -0,0 +1,143 @@
+package jsonschema
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GenerateSchemaForType returns the schema of the JSON encoding of v's type.
+//
+// Struct fields are named by their json tag and are required unless it has the
+// omitempty option. A description tag sets their Description, and an enum tag
+// their Enum, as a comma-separated list. Embedded structs are flattened, as
+// encoding/json does. Types marshaled as text, such as time.Time, are strings.
+// Channels, functions, complex numbers and recursive types return an error.
+func GenerateSchemaForType(v any) (*Definition, error) {
+	def, err := reflectSchema(reflect.TypeOf(v), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// SchemaForType returns the schema of the JSON encoding of a T, see
+// GenerateSchemaForType. As it needs no value, it also describes interface
+// types.
+func SchemaForType[T any]() (*Definition, error) {
+	def, err := reflectSchema(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// reflectSchema describes t. visiting holds the structs being described, which
+// would otherwise recurse forever for recursive types.
+func reflectSchema(t reflect.Type, visiting map[reflect.Type]bool) (Definition, error) {
+	if t == nil {
+		return Definition{}, fmt.Errorf("jsonschema: cannot describe a nil type")
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return Definition{Type: String}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Definition{Type: String}, nil
+	case reflect.Bool:
+		return Definition{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Definition{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return Definition{Type: Number}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json marshals []byte as a base64 string.
+			return Definition{Type: String}, nil
+		}
+		items, err := reflectSchema(t.Elem(), visiting)
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Array, Items: &items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return Definition{}, fmt.Errorf("jsonschema: unsupported map key type %s", t.Key())
+		}
+		values, err := reflectSchema(t.Elem(), visiting)
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Object, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return Definition{}, fmt.Errorf("jsonschema: unsupported recursive type %s", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		def := Definition{Type: Object, Properties: map[string]Definition{}}
+		if err := reflectFields(t, &def, visiting); err != nil {
+			return Definition{}, err
+		}
+		return def, nil
+	case reflect.Interface:
+		// Any value, which no type keyword describes.
+		return Definition{}, nil
+	default:
+		return Definition{}, fmt.Errorf("jsonschema: unsupported type %s", t)
+	}
+}
+
+func reflectFields(t reflect.Type, def *Definition, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, options, _ := strings.Cut(tag, ",")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if err := reflectFields(fieldType, def, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		property, err := reflectSchema(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("%w in field %s", err, field.Name)
+		}
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property.Enum = strings.Split(enum, ",")
+		}
+		if name == "" {
+			name = field.Name
+		}
+		def.Properties[name] = property
+		if !strings.Contains(options, "omitempty") {
+			def.Required = append(def.Required, name)
+		}
+	}
+	return nil
+}
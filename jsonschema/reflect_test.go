@@ -0,0 +1,93 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai/jsonschema"
+)
+
+type schemaTestBase struct {
+	ID string `json:"id"`
+}
+
+type schemaTestItem struct {
+	schemaTestBase
+	Name     string            `json:"name" description:"The name of the item"`
+	Unit     string            `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	Count    *int              `json:"count"`
+	Price    float64           `json:"price"`
+	Tags     []string          `json:"tags"`
+	Data     []byte            `json:"data,omitempty"`
+	Created  time.Time         `json:"created"`
+	Labels   map[string]bool   `json:"labels,omitempty"`
+	Children []schemaTestChild `json:"children"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+type schemaTestChild struct {
+	Done bool
+}
+
+type schemaTestTree struct {
+	Children []schemaTestTree `json:"children"`
+}
+
+func TestGenerateSchemaForType(t *testing.T) {
+	def, err := jsonschema.GenerateSchemaForType(schemaTestItem{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	got, err := json.Marshal(def)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	want := `{"type":"object","properties":{
+		"id":{"type":"string","properties":{}},
+		"name":{"type":"string","description":"The name of the item","properties":{}},
+		"unit":{"type":"string","enum":["celsius","fahrenheit"],"properties":{}},
+		"count":{"type":"integer","properties":{}},
+		"price":{"type":"number","properties":{}},
+		"tags":{"type":"array","properties":{},"items":{"type":"string","properties":{}}},
+		"data":{"type":"string","properties":{}},
+		"created":{"type":"string","properties":{}},
+		"labels":{"type":"object","properties":{},"additionalProperties":{"type":"boolean","properties":{}}},
+		"children":{"type":"array","properties":{},"items":{"type":"object","properties":{
+			"Done":{"type":"boolean","properties":{}}},"required":["Done"]}}},
+		"required":["id","name","count","price","tags","created","children"]}`
+	var gotValue, wantValue any
+	_ = json.Unmarshal(got, &gotValue)
+	if err = json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("GenerateSchemaForType() = %s", got)
+	}
+
+	for _, v := range []any{make(chan int), schemaTestTree{}, nil} {
+		if _, err = jsonschema.GenerateSchemaForType(v); err == nil {
+			t.Errorf("GenerateSchemaForType(%T) returned no error", v)
+		}
+	}
+}
+
+func TestSchemaForType(t *testing.T) {
+	def, err := jsonschema.SchemaForType[*schemaTestItem]()
+	if err != nil {
+		t.Fatalf("SchemaForType error: %v", err)
+	}
+	want, err := jsonschema.GenerateSchemaForType(schemaTestItem{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+	if !reflect.DeepEqual(def, want) {
+		t.Errorf("SchemaForType() = %+v, want %+v", def, want)
+	}
+
+	if _, err = jsonschema.SchemaForType[schemaTestTree](); err == nil {
+		t.Error("SchemaForType of a recursive type returned no error")
+	}
+}
@@ -0,0 +1,59 @@
+package openai
+
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultMaxResponseBodySize is the limit on the size of response bodies read
+// by the client when ClientConfig.MaxResponseBodySize is zero.
+const DefaultMaxResponseBodySize int64 = 100 << 20 // 100 MB
+
+// ResponseTooLargeError is returned when a response body exceeds the
+// MaxResponseBodySize of the client.
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds the limit of %d bytes", e.Limit)
+}
+
+// WithMaxResponseBodySize sets ClientConfig.MaxResponseBodySize.
+func WithMaxResponseBodySize(bytes int64) ClientOption {
+	return func(config *ClientConfig) {
+		config.MaxResponseBodySize = bytes
+	}
+}
+
+// limitedBody fails with a ResponseTooLargeError once more than limit bytes
+// were read.
+type limitedBody struct {
+	io.Closer
+	reader io.LimitedReader
+	limit  int64
+}
+
+// limitBody returns body limited to the MaxResponseBodySize of the client.
+func (c *Client) limitBody(body io.ReadCloser) io.ReadCloser {
+	limit := c.config.MaxResponseBodySize
+	if limit == 0 {
+		limit = DefaultMaxResponseBodySize
+	}
+	if limit < 0 {
+		return body
+	}
+	return &limitedBody{
+		Closer: body,
+		reader: io.LimitedReader{R: body, N: limit + 1},
+		limit:  limit,
+	}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if b.reader.N <= 0 {
+		return n, &ResponseTooLargeError{Limit: b.limit}
+	}
+	return n, err
+}
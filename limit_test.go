@@ -0,0 +1,72 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestMaxResponseBodySize(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	padding := strings.Repeat("x", 2048)
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"` + padding + `"}]}`))
+	})
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"message":"` + padding + `"}}`))
+	})
+	ctx := context.Background()
+
+	_, err := client.ListModels(ctx)
+	checks.NoError(t, err, "ListModels should succeed within the default limit")
+
+	limited := client.WithOptions(openai.WithMaxResponseBodySize(1024))
+	_, err = limited.ListModels(ctx)
+	var tooLarge *openai.ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 1024 {
+		t.Fatalf("ListModels returned %v, want ResponseTooLargeError", err)
+	}
+	if !strings.Contains(err.Error(), "1024 bytes") {
+		t.Errorf("error should mention the limit: %v", err)
+	}
+
+	_, err = limited.ListFiles(ctx)
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("ListFiles returned %v, want ResponseTooLargeError", err)
+	}
+
+	_, err = client.WithOptions(openai.WithMaxResponseBodySize(-1)).ListModels(ctx)
+	checks.NoError(t, err, "negative limits should disable the limit")
+}
+
+func TestMaxResponseBodySizeSkipsStreams(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	content := strings.Repeat("x", 2048)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4",` +
+			`"choices":[{"index":0,"delta":{"content":"` + content + `"}}]}` + "\n\ndata: [DONE]\n\n"))
+	})
+
+	client = client.WithOptions(openai.WithMaxResponseBodySize(1024))
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	resp, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv error")
+	if resp.Choices[0].Delta.Content != content {
+		t.Error("stream content was truncated")
+	}
+}
@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultLogMaxBodyBytes = 4096
+	redacted               = "[REDACTED]"
+)
+
+// LogOptions configures the logging of WithLogger.
+type LogOptions struct {
+	// Level is used for successful calls, slog.LevelInfo if zero.
+	Level slog.Level
+	// ErrorLevel is used for failed calls. slog.LevelError if zero.
+	ErrorLevel slog.Level
+	// LogHeaders logs the request headers, with Authorization and api-key redacted.
+	LogHeaders bool
+	// LogBodies logs the request and response bodies, which contain the
+	// messages exchanged with the model, truncated to MaxBodyBytes.
+	LogBodies bool
+	// MaxBodyBytes defaults to 4096.
+	MaxBodyBytes int
+}
+
+// WithLogger logs every call of the client to logger: the request, its
+// response with status code, latency, token usage and request ID, and for
+// streams their first chunk latency and chunk count when closed. Message
+// contents are only logged with LogOptions.LogBodies.
+func WithLogger(logger *slog.Logger, opts LogOptions) ClientOption {
+	if opts.ErrorLevel == 0 {
+		opts.ErrorLevel = slog.LevelError
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultLogMaxBodyBytes
+	}
+	l := &requestLogger{logger: logger, opts: opts}
+	return WithHooks(Hook{
+		OnRequest:     l.onRequest,
+		OnResponse:    l.onResponse,
+		OnStreamClose: l.onStreamClose,
+	})
+}
+
+type requestLogger struct {
+	logger *slog.Logger
+	opts   LogOptions
+}
+
+func metaAttrs(meta RequestMeta) []slog.Attr {
+	attrs := []slog.Attr{slog.String("endpoint", meta.Endpoint)}
+	if meta.Model != "" {
+		attrs = append(attrs, slog.String("model", meta.Model))
+	}
+	if meta.Stream {
+		attrs = append(attrs, slog.Bool("stream", true))
+	}
+	return attrs
+}
+
+func (l *requestLogger) onRequest(ctx context.Context, req *http.Request, meta RequestMeta) error {
+	attrs := append(metaAttrs(meta), slog.String("method", req.Method))
+	if l.opts.LogHeaders {
+		attrs = append(attrs, slog.Any("headers", redactHeaders(req.Header)))
+	}
+	if l.opts.LogBodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			attrs = append(attrs, slog.String("body", l.readBody(body)))
+		}
+	}
+	l.logger.LogAttrs(ctx, l.opts.Level, "openai request", attrs...)
+	return nil
+}
+
+func (l *requestLogger) onResponse(ctx context.Context, resp *http.Response, meta RequestMeta, err error) error {
+	attrs := append(metaAttrs(meta), slog.Duration("latency", time.Since(meta.Start)))
+	var body []byte
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if id := requestID(resp.Header); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if !meta.Stream && resp.Body != nil {
+			// The body is left for the caller, e.g. of GetFileContent, and the
+			// next hooks.
+			body, _ = io.ReadAll(resp.Body)
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+	if usage := responseUsage(body); usage != nil {
+		attrs = append(attrs, slog.Any("usage", usage))
+	}
+	if l.opts.LogBodies && len(body) > 0 {
+		attrs = append(attrs, slog.String("body", l.truncate(body)))
+	}
+
+	level, msg := l.opts.Level, "openai response"
+	if meta.Stream {
+		msg = "openai stream open"
+	}
+	if err != nil {
+		level = l.opts.ErrorLevel
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+	return nil
+}
+
+func (l *requestLogger) onStreamClose(ctx context.Context, meta RequestMeta, stats StreamStats) {
+	attrs := append(metaAttrs(meta),
+		slog.Int("chunks", stats.Chunks),
+		slog.Duration("first_chunk_latency", stats.FirstChunk),
+		slog.Duration("duration", stats.Duration),
+	)
+	l.logger.LogAttrs(ctx, l.opts.Level, "openai stream close", attrs...)
+}
+
+func (l *requestLogger) readBody(body io.ReadCloser) string {
+	defer body.Close()
+	b, _ := io.ReadAll(io.LimitReader(body, int64(l.opts.MaxBodyBytes)+1))
+	return l.truncate(b)
+}
+
+func (l *requestLogger) truncate(body []byte) string {
+	if len(body) > l.opts.MaxBodyBytes {
+		return string(body[:l.opts.MaxBodyBytes]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func redactHeaders(header http.Header) http.Header {
+	h := header.Clone()
+	for _, key := range []string{"Authorization", AzureAPIKeyHeader} {
+		if h.Get(key) != "" {
+			h.Set(key, redacted)
+		}
+	}
+	return h
+}
+
+// responseUsage returns the token counts of the usage object of a JSON
+// response, if any.
+func responseUsage(body []byte) map[string]int {
+	if len(body) == 0 {
+		return nil
+	}
+	var resp struct {
+		Usage map[string]json.RawMessage `json:"usage"`
+	}
+	if json.Unmarshal(body, &resp) != nil || len(resp.Usage) == 0 {
+		return nil
+	}
+	usage := make(map[string]int, len(resp.Usage))
+	for k, v := range resp.Usage {
+		var n int
+		if json.Unmarshal(v, &n) == nil {
+			usage[k] = n
+		}
+	}
+	return usage
+}
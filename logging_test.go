@@ -0,0 +1,172 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+const loggingTestSecret = "do not log this message"
+
+func setupLoggingTestServer(opts openai.LogOptions) (*openai.Client, *bytes.Buffer, func()) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_123")
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			//nolint:lll
+			chunk := `data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n"
+			_, _ = w.Write([]byte(chunk + chunk + "data: [DONE]\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-3.5-turbo",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"` + loggingTestSecret + `"}}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":12,"total_tokens":21}}`))
+	})
+	server.RegisterHandler("/v1/audio/speech", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("speech audio"))
+	})
+	server.RegisterHandler("/v1/files/file-1/content", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("file content"))
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	return openai.NewClientWithConfig(config, openai.WithLogger(logger, opts)), &logs, ts.Close
+}
+
+func decodeLogs(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		checks.NoError(t, json.Unmarshal([]byte(line), &record), "invalid log line")
+		records = append(records, record)
+	}
+	return records
+}
+
+var loggingTestRequest = openai.ChatCompletionRequest{
+	Model:    openai.GPT3Dot5Turbo,
+	Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: loggingTestSecret}},
+}
+
+func TestWithLogger(t *testing.T) {
+	client, logs, teardown := setupLoggingTestServer(openai.LogOptions{LogHeaders: true})
+	defer teardown()
+
+	_, err := client.CreateChatCompletion(context.Background(), loggingTestRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if strings.Contains(logs.String(), loggingTestSecret) {
+		t.Errorf("message contents were logged by default: %s", logs)
+	}
+	if strings.Contains(logs.String(), test.GetTestToken()) {
+		t.Errorf("the API key was logged: %s", logs)
+	}
+
+	records := decodeLogs(t, logs)
+	if len(records) != 2 {
+		t.Fatalf("got %d log records, want 2: %s", len(records), logs)
+	}
+	request, response := records[0], records[1]
+	headers, _ := request["headers"].(map[string]any)
+	if request["msg"] != "openai request" || request["model"] != openai.GPT3Dot5Turbo ||
+		request["endpoint"] != "/chat/completions" || request["method"] != http.MethodPost {
+		t.Errorf("unexpected request record: %v", request)
+	}
+	if auth, _ := headers["Authorization"].([]any); len(auth) != 1 || auth[0] != "[REDACTED]" {
+		t.Errorf("Authorization header was not redacted: %v", headers)
+	}
+
+	usage, _ := response["usage"].(map[string]any)
+	if response["msg"] != "openai response" || response["status"] != float64(http.StatusOK) ||
+		response["request_id"] != "req_123" || response["latency"] == nil || usage["total_tokens"] != float64(21) {
+		t.Errorf("unexpected response record: %v", response)
+	}
+}
+
+func TestWithLoggerBodies(t *testing.T) {
+	client, logs, teardown := setupLoggingTestServer(openai.LogOptions{LogBodies: true, MaxBodyBytes: 1000})
+	defer teardown()
+
+	resp, err := client.CreateChatCompletion(context.Background(), loggingTestRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Choices[0].Message.Content != loggingTestSecret {
+		t.Errorf("logging changed the response: %+v", resp)
+	}
+
+	records := decodeLogs(t, logs)
+	for _, record := range records {
+		if body, _ := record["body"].(string); !strings.Contains(body, loggingTestSecret) {
+			t.Errorf("body was not logged: %v", record)
+		}
+	}
+}
+
+func TestWithLoggerStream(t *testing.T) {
+	client, logs, teardown := setupLoggingTestServer(openai.LogOptions{})
+	defer teardown()
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), loggingTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	stream.Close()
+
+	records := decodeLogs(t, logs)
+	if len(records) != 3 {
+		t.Fatalf("got %d log records, want 3: %s", len(records), logs)
+	}
+	if records[1]["msg"] != "openai stream open" || records[1]["stream"] != true {
+		t.Errorf("unexpected stream open record: %v", records[1])
+	}
+	closed := records[2]
+	if closed["msg"] != "openai stream close" || closed["chunks"] != float64(2) || closed["first_chunk_latency"] == nil {
+		t.Errorf("unexpected stream close record: %v", closed)
+	}
+}
+
+func TestWithLoggerRawBodies(t *testing.T) {
+	client, logs, teardown := setupLoggingTestServer(openai.LogOptions{LogBodies: true})
+	defer teardown()
+	ctx := context.Background()
+
+	audio, err := client.CreateSpeech(ctx, openai.SpeechRequest{
+		Model: openai.TTSModel1, Input: "Hello", Voice: openai.VoiceAlloy,
+	})
+	checks.NoError(t, err, "CreateSpeech error")
+	content, err := io.ReadAll(audio)
+	audio.Close()
+	checks.NoError(t, err, "ReadAll error")
+	if string(content) != "speech audio" {
+		t.Errorf("got audio %q after logging it", content)
+	}
+
+	file, err := client.GetFileContent(ctx, "file-1")
+	checks.NoError(t, err, "GetFileContent error")
+	content, err = io.ReadAll(file)
+	file.Close()
+	checks.NoError(t, err, "ReadAll error")
+	if string(content) != "file content" {
+		t.Errorf("got file content %q after logging it", content)
+	}
+
+	if !strings.Contains(logs.String(), "speech audio") || !strings.Contains(logs.String(), "file content") {
+		t.Errorf("bodies were not logged: %s", logs)
+	}
+}
@@ -0,0 +1,167 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogFunc receives the log entries of a logging transport.
+type LogFunc func(msg string, fields map[string]any)
+
+// LoggingOptions configures NewLoggingTransport.
+type LoggingOptions struct {
+	// RedactAuthHeader replaces the Authorization and api-key headers.
+	RedactAuthHeader bool
+	// RedactRequestBody and RedactResponseBody replace the bodies, which contain
+	// the messages exchanged with the model, so that they are only sized.
+	RedactRequestBody  bool
+	RedactResponseBody bool
+	// MaxBodyLogBytes truncates logged bodies, 4096 if zero.
+	MaxBodyLogBytes int
+	// IncludeHeaders logs the request and response headers.
+	IncludeHeaders bool
+}
+
+// NewLoggingTransport returns an http.RoundTripper logging each request sent
+// through inner, http.DefaultTransport if nil, and its response as
+// "openai http request" and "openai http response", or "openai http error"
+// when no response arrived. Streamed response bodies are not logged.
+//
+//	config.HTTPClient = &http.Client{Transport: openai.NewLoggingTransport(nil, logf, opts)}
+func NewLoggingTransport(inner http.RoundTripper, logger LogFunc, opts LoggingOptions) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	if opts.MaxBodyLogBytes <= 0 {
+		opts.MaxBodyLogBytes = defaultLogMaxBodyBytes
+	}
+	return &loggingTransport{inner: inner, log: logger, opts: opts}
+}
+
+// NewSlogLoggingTransport is NewLoggingTransport logging to logger, at error
+// level for failed requests and info level otherwise.
+func NewSlogLoggingTransport(inner http.RoundTripper, logger *slog.Logger, opts LoggingOptions) http.RoundTripper {
+	return NewLoggingTransport(inner, func(msg string, fields map[string]any) {
+		level := slog.LevelInfo
+		if _, failed := fields["error"]; failed {
+			level = slog.LevelError
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]slog.Attr, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, slog.Any(k, fields[k]))
+		}
+		logger.LogAttrs(context.Background(), level, msg, attrs...)
+	}, opts)
+}
+
+type loggingTransport struct {
+	inner http.RoundTripper
+	log   LogFunc
+	opts  LoggingOptions
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fields := map[string]any{
+		"method": req.Method,
+		"url":    req.URL.String(),
+	}
+	if t.opts.IncludeHeaders {
+		fields["headers"] = t.headers(req.Header)
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		var prefix []byte
+		if !t.opts.RedactRequestBody {
+			// RoundTrippers must not modify the request they were given.
+			req = req.Clone(req.Context())
+			prefix, req.Body = peekBody(req.Body, t.opts.MaxBodyLogBytes)
+		}
+		t.addBody(fields, prefix, req.ContentLength, t.opts.RedactRequestBody)
+	}
+	t.log("openai http request", fields)
+
+	start := time.Now()
+	resp, err := t.inner.RoundTrip(req)
+	fields = map[string]any{
+		"method":   req.Method,
+		"url":      req.URL.String(),
+		"duration": time.Since(start),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		t.log("openai http error", fields)
+		return resp, err
+	}
+
+	DecompressResponse(resp)
+	fields["status"] = resp.StatusCode
+	if id := requestID(resp.Header); id != "" {
+		fields["request_id"] = id
+	}
+	if t.opts.IncludeHeaders {
+		fields["headers"] = t.headers(resp.Header)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var prefix []byte
+		if !t.opts.RedactResponseBody {
+			prefix, resp.Body = peekBody(resp.Body, t.opts.MaxBodyLogBytes)
+		}
+		t.addBody(fields, prefix, resp.ContentLength, t.opts.RedactResponseBody)
+	}
+	t.log("openai http response", fields)
+	return resp, nil
+}
+
+func (t *loggingTransport) headers(header http.Header) http.Header {
+	if t.opts.RedactAuthHeader {
+		return redactHeaders(header)
+	}
+	return header.Clone()
+}
+
+// addBody logs a body of which prefix was read, size bytes long if known.
+func (t *loggingTransport) addBody(fields map[string]any, prefix []byte, size int64, redact bool) {
+	if size >= 0 {
+		fields["body_size"] = size
+	}
+	switch {
+	case redact:
+		fields["body"] = redacted
+	case len(prefix) > t.opts.MaxBodyLogBytes:
+		fields["body"] = string(prefix[:t.opts.MaxBodyLogBytes]) + "...(truncated)"
+	default:
+		fields["body"] = string(prefix)
+	}
+}
+
+// peekBody reads up to limit+1 bytes of body and returns them with a body that
+// still yields all of it.
+func peekBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	prefix, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	rest := io.MultiReader(bytes.NewReader(prefix), body)
+	if err != nil {
+		rest = io.MultiReader(bytes.NewReader(prefix), errReader{err})
+	}
+	return prefix, struct {
+		io.Reader
+		io.Closer
+	}{rest, body}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
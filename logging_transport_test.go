@@ -0,0 +1,177 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+type logEntry struct {
+	msg    string
+	fields map[string]any
+}
+
+func setupLoggingTransportTestServer(
+	t *testing.T,
+	opts openai.LoggingOptions,
+) (client *openai.Client, entries *[]logEntry) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_123")
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			//nolint:lll
+			_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"` + loggingTestSecret + `"}}]}` + "\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-3.5-turbo",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"` + loggingTestSecret + `"}}]}`))
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	entries = &[]logEntry{}
+	logf := func(msg string, fields map[string]any) {
+		*entries = append(*entries, logEntry{msg: msg, fields: fields})
+	}
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	config.HTTPClient = &http.Client{Transport: openai.NewLoggingTransport(nil, logf, opts)}
+	client = openai.NewClientWithConfig(config)
+	return
+}
+
+var loggingTransportRequest = openai.ChatCompletionRequest{
+	Model:    openai.GPT3Dot5Turbo,
+	Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: loggingTestSecret}},
+}
+
+func TestLoggingTransport(t *testing.T) {
+	client, entries := setupLoggingTransportTestServer(t, openai.LoggingOptions{
+		RedactAuthHeader: true,
+		IncludeHeaders:   true,
+		MaxBodyLogBytes:  20,
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), loggingTransportRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.Choices[0].Message.Content != loggingTestSecret {
+		t.Error("the response body was not passed on intact")
+	}
+
+	if len(*entries) != 2 || (*entries)[0].msg != "openai http request" || (*entries)[1].msg != "openai http response" {
+		t.Fatalf("unexpected log entries %+v", *entries)
+	}
+	request, response := (*entries)[0].fields, (*entries)[1].fields
+	if request["method"] != http.MethodPost || !strings.HasSuffix(request["url"].(string), "/v1/chat/completions") {
+		t.Errorf("unexpected request fields %v", request)
+	}
+	headers := request["headers"].(http.Header)
+	if headers.Get("Authorization") != "[REDACTED]" {
+		t.Errorf("Authorization was not redacted: %q", headers.Get("Authorization"))
+	}
+	if body := request["body"].(string); body != `{"model":"gpt-3.5-tu...(truncated)` {
+		t.Errorf("request body not truncated: %q", body)
+	}
+	if response["status"] != http.StatusOK || response["request_id"] != "req_123" {
+		t.Errorf("unexpected response fields %v", response)
+	}
+	if response["headers"].(http.Header).Get("x-request-id") != "req_123" {
+		t.Error("response headers were not logged")
+	}
+}
+
+func TestLoggingTransportRedactsBodies(t *testing.T) {
+	client, entries := setupLoggingTransportTestServer(t, openai.LoggingOptions{
+		RedactRequestBody:  true,
+		RedactResponseBody: true,
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), loggingTransportRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	for _, entry := range *entries {
+		if _, ok := entry.fields["headers"]; ok {
+			t.Errorf("%s: headers logged without IncludeHeaders", entry.msg)
+		}
+		if entry.fields["body"] != "[REDACTED]" {
+			t.Errorf("%s: body = %v, want it redacted", entry.msg, entry.fields["body"])
+		}
+	}
+}
+
+func TestLoggingTransportStream(t *testing.T) {
+	client, entries := setupLoggingTransportTestServer(t, openai.LoggingOptions{RedactRequestBody: true})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), loggingTransportRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	resp, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv error")
+	if resp.Choices[0].Delta.Content != loggingTestSecret {
+		t.Error("the stream was not passed on intact")
+	}
+
+	if len(*entries) != 2 {
+		t.Fatalf("unexpected log entries %+v", *entries)
+	}
+	if _, ok := (*entries)[1].fields["body"]; ok {
+		t.Error("stream bodies should not be logged")
+	}
+}
+
+func TestLoggingTransportError(t *testing.T) {
+	var entries []logEntry
+	errTransport := errors.New("connection refused")
+	transport := openai.NewLoggingTransport(failingTransport{errTransport}, func(msg string, fields map[string]any) {
+		entries = append(entries, logEntry{msg: msg, fields: fields})
+	}, openai.LoggingOptions{})
+	config := openai.DefaultConfig("token")
+	config.HTTPClient = &http.Client{Transport: transport}
+
+	_, err := openai.NewClientWithConfig(config).ListModels(context.Background())
+	if !errors.Is(err, errTransport) {
+		t.Fatalf("ListModels returned %v, want the transport error", err)
+	}
+	if len(entries) != 2 || entries[1].msg != "openai http error" || entries[1].fields["error"] != errTransport.Error() {
+		t.Errorf("unexpected log entries %+v", entries)
+	}
+}
+
+func TestSlogLoggingTransport(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	transport := openai.NewSlogLoggingTransport(failingTransport{errors.New("boom")}, logger, openai.LoggingOptions{})
+	config := openai.DefaultConfig("token")
+	config.HTTPClient = &http.Client{Transport: transport}
+	_, _ = openai.NewClientWithConfig(config).ListModels(context.Background())
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2:\n%s", len(lines), logs.String())
+	}
+	var entry map[string]any
+	checks.NoError(t, json.Unmarshal([]byte(lines[1]), &entry), "Unmarshal error")
+	if entry["msg"] != "openai http error" || entry["level"] != "ERROR" || entry["error"] != "boom" {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+}
+
+type failingTransport struct {
+	err error
+}
+
+func (f failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, f.err
+}
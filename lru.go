@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"container/list"
+	"time"
+)
+
+// lru is a least recently used cache of values that may expire. It is not safe
+// for concurrent use.
+type lru[K comparable, V any] struct {
+	list  *list.List // of *lruEntry[K, V], most recently used first
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	// expires is zero for values that do not expire.
+	expires time.Time
+}
+
+func newLRU[K comparable, V any]() *lru[K, V] {
+	return &lru[K, V]{list: list.New(), items: make(map[K]*list.Element)}
+}
+
+// get returns the value of key and marks it as the most recently used one.
+// Expired values are removed instead.
+func (c *lru[K, V]) get(key K) (value V, ok bool) {
+	element, ok := c.items[key]
+	if !ok {
+		return value, false
+	}
+	entry := element.Value.(*lruEntry[K, V])
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(element)
+		return value, false
+	}
+	c.list.MoveToFront(element)
+	return entry.value, true
+}
+
+// add sets the value of key, then evicts the least recently used values beyond
+// maxEntries if it is positive. It returns the number of evicted values.
+func (c *lru[K, V]) add(key K, value V, expires time.Time, maxEntries int) (evicted int) {
+	entry := &lruEntry[K, V]{key: key, value: value, expires: expires}
+	if element, ok := c.items[key]; ok {
+		element.Value = entry
+		c.list.MoveToFront(element)
+	} else {
+		c.items[key] = c.list.PushFront(entry)
+	}
+	for maxEntries > 0 && c.list.Len() > maxEntries {
+		c.remove(c.list.Back())
+		evicted++
+	}
+	return evicted
+}
+
+func (c *lru[K, V]) remove(element *list.Element) {
+	c.list.Remove(element)
+	delete(c.items, element.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,125 @@
+package openai
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrMessageBuilderUnknownRole = errors.New("unknown chat message role")
+	ErrMessageBuilderImageRole   = errors.New("image parts can only be added to user messages")
+	ErrMessageBuilderEmpty       = errors.New("message has no content parts")
+	ErrUnsupportedImageType      = errors.New("unsupported image type")
+)
+
+// inlineImageTypes are the MIME types of the images vision models accept.
+var inlineImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// MessageBuilder builds a ChatCompletionMessage from text and image parts:
+//
+//	msg, err := openai.NewMessage(openai.ChatMessageRoleUser).
+//		Text("What is in this image?").
+//		ImageURLWithDetail("https://example.com/cat.png", openai.ImageDetailHigh).
+//		Build()
+type MessageBuilder struct {
+	role  string
+	parts Parts
+	err   error
+}
+
+// NewMessage starts a message with the given role, one of the ChatMessageRole
+// constants.
+func NewMessage(role string) *MessageBuilder {
+	b := &MessageBuilder{role: role}
+	switch role {
+	case ChatMessageRoleSystem, ChatMessageRoleUser, ChatMessageRoleAssistant,
+		ChatMessageRoleFunction, ChatMessageRoleTool:
+	default:
+		b.err = fmt.Errorf("%w: %q", ErrMessageBuilderUnknownRole, role)
+	}
+	return b
+}
+
+// Text adds a text part.
+func (b *MessageBuilder) Text(text string) *MessageBuilder {
+	b.parts = append(b.parts, Part{Type: ContentTypeText, Text: text})
+	return b
+}
+
+// ImageURL adds an image part. The URL may also be a base64 data URL.
+func (b *MessageBuilder) ImageURL(url string) *MessageBuilder {
+	return b.ImageURLWithDetail(url, "")
+}
+
+// ImageURLWithDetail adds an image part seen at the given detail.
+func (b *MessageBuilder) ImageURLWithDetail(url string, detail ImageDetail) *MessageBuilder {
+	if b.role != ChatMessageRoleUser && b.err == nil {
+		b.err = ErrMessageBuilderImageRole
+	}
+	b.parts = append(b.parts, Part{Type: ContentTypeImage, ImageUrl: url, Detail: detail})
+	return b
+}
+
+// Build returns the message, or the first error found while building it.
+// Messages made of a single text part use Content rather than Parts.
+func (b *MessageBuilder) Build() (ChatCompletionMessage, error) {
+	if b.err != nil {
+		return ChatCompletionMessage{}, b.err
+	}
+	if len(b.parts) == 0 {
+		return ChatCompletionMessage{}, ErrMessageBuilderEmpty
+	}
+	if len(b.parts) == 1 && b.parts[0].Type == ContentTypeText {
+		return ChatCompletionMessage{Role: b.role, Content: b.parts[0].Text}, nil
+	}
+	return ChatCompletionMessage{Role: b.role, Parts: append(Parts(nil), b.parts...)}, nil
+}
+
+// NewInlineImagePart returns an image part sending data, an image of type
+// mimeType, inline as a base64 data URL instead of a URL the API downloads.
+// mimeType must be one of image/png, image/jpeg, image/gif and image/webp,
+// otherwise ErrUnsupportedImageType is returned.
+func NewInlineImagePart(data []byte, mimeType string) (Part, error) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil || !slices.Contains(inlineImageTypes, mediaType) {
+		return Part{}, fmt.Errorf("%w: %q", ErrUnsupportedImageType, mimeType)
+	}
+	return Part{
+		Type:     ContentTypeImage,
+		ImageUrl: "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// NewInlineImagePartFromFile returns an inline image part of the image file at
+// path, see NewInlineImagePart. The type of the image is detected from its
+// content, or else from the extension of path.
+func NewInlineImagePartFromFile(path string) (Part, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Part{}, err
+	}
+	mimeType := http.DetectContentType(data)
+	if !slices.Contains(inlineImageTypes, mimeType) {
+		if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(path))); byExtension != "" {
+			mimeType = byExtension
+		}
+	}
+	return NewInlineImagePart(data, mimeType)
+}
+
+// UserMessage returns a text-only user message.
+func UserMessage(text string) ChatCompletionMessage {
+	return ChatCompletionMessage{Role: ChatMessageRoleUser, Content: text}
+}
+
+// SystemMessage returns a text-only system message.
+func SystemMessage(text string) ChatCompletionMessage {
+	return ChatCompletionMessage{Role: ChatMessageRoleSystem, Content: text}
+}
@@ -0,0 +1,126 @@
+package openai_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestMessageBuilder(t *testing.T) {
+	msg, err := openai.NewMessage(openai.ChatMessageRoleUser).
+		Text("What is in these images?").
+		ImageURL("https://example.com/a.png").
+		ImageURLWithDetail("https://example.com/b.png", openai.ImageDetailHigh).
+		Build()
+	checks.NoError(t, err, "Build error")
+
+	bs, err := json.Marshal(msg)
+	checks.NoError(t, err, "Marshal error")
+	want := `{"role":"user","content":[{"type":"text","text":"What is in these images?"},` +
+		`{"type":"image_url","image_url":"https://example.com/a.png"},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/b.png","detail":"high"}}]}`
+	if string(bs) != want {
+		t.Errorf("unexpected JSON:\n%s\nwant\n%s", bs, want)
+	}
+
+	var decoded openai.ChatCompletionMessage
+	err = json.Unmarshal(bs, &decoded)
+	checks.NoError(t, err, "Unmarshal error")
+	if len(decoded.Parts) != 3 || decoded.Parts[2].ImageUrl != "https://example.com/b.png" ||
+		decoded.Parts[2].Detail != openai.ImageDetailHigh {
+		t.Errorf("parts did not round-trip: %+v", decoded.Parts)
+	}
+
+	msg, err = openai.NewMessage(openai.ChatMessageRoleSystem).Text("Be brief.").Build()
+	checks.NoError(t, err, "Build error")
+	if msg.Role != openai.ChatMessageRoleSystem || msg.Content != "Be brief." || msg.Parts != nil {
+		t.Errorf("text-only message = %+v, want %+v", msg, openai.SystemMessage("Be brief."))
+	}
+	if m := openai.UserMessage("Hi"); m.Role != openai.ChatMessageRoleUser || m.Content != "Hi" {
+		t.Errorf("UserMessage = %+v", m)
+	}
+}
+
+func TestMessageBuilderErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder *openai.MessageBuilder
+		wantErr error
+	}{
+		{"unknown role", openai.NewMessage("robot").Text("hi"), openai.ErrMessageBuilderUnknownRole},
+		{
+			"image on system message",
+			openai.NewMessage(openai.ChatMessageRoleSystem).ImageURL("https://example.com/a.png"),
+			openai.ErrMessageBuilderImageRole,
+		},
+		{"no parts", openai.NewMessage(openai.ChatMessageRoleUser), openai.ErrMessageBuilderEmpty},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.builder.Build()
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Build() returned %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewInlineImagePart(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	part, err := openai.NewInlineImagePart(png, "image/png")
+	checks.NoError(t, err, "NewInlineImagePart error")
+	wantURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+	if part.Type != openai.ContentTypeImage || part.ImageUrl != wantURL {
+		t.Errorf("NewInlineImagePart() = %+v, want image part with URL %s", part, wantURL)
+	}
+
+	for _, mimeType := range []string{"image/svg+xml", "text/plain", ""} {
+		if _, err = openai.NewInlineImagePart(png, mimeType); !errors.Is(err, openai.ErrUnsupportedImageType) {
+			t.Errorf("NewInlineImagePart(%q) returned %v, want ErrUnsupportedImageType", mimeType, err)
+		}
+	}
+}
+
+func TestNewInlineImagePartFromFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		checks.NoError(t, os.WriteFile(path, data, 0o600), "WriteFile error")
+		return path
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		wantType string
+		wantErr  error
+	}{
+		{"sniffed png", write("photo.bin", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")), "image/png", nil},
+		{"sniffed jpeg", write("photo", []byte("\xff\xd8\xff\xe0\x00\x10JFIF")), "image/jpeg", nil},
+		{"sniffed gif", write("anim.png", []byte("GIF89a\x01\x00\x01\x00")), "image/gif", nil},
+		{"sniffed webp", write("photo.webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 ")), "image/webp", nil},
+		{"by extension", write("photo.JPG", []byte("not sniffable")), "image/jpeg", nil},
+		{"unsupported", write("notes.txt", []byte("hello")), "", openai.ErrUnsupportedImageType},
+		{"missing", filepath.Join(dir, "missing.png"), "", os.ErrNotExist},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			part, err := openai.NewInlineImagePartFromFile(tc.path)
+			if tc.wantErr != nil {
+				checks.ErrorIs(t, err, tc.wantErr, "NewInlineImagePartFromFile error")
+				return
+			}
+			checks.NoError(t, err, "NewInlineImagePartFromFile error")
+			if !strings.HasPrefix(part.ImageUrl, "data:"+tc.wantType+";base64,") {
+				t.Errorf("got image URL %.40s..., want type %s", part.ImageUrl, tc.wantType)
+			}
+		})
+	}
+}
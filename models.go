@@ -1,9 +1,12 @@
 package openai
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 )
 
 // Model struct represents an OpenAPI model.
@@ -15,10 +18,21 @@ type Model struct {
 	Permission []Permission `json:"permission"`
 	Root       string       `json:"root"`
 	Parent     string       `json:"parent"`
+	// Capabilities is only returned by Azure OpenAI.
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
 
 	httpHeader
 }
 
+// ModelCapabilities lists what an Azure OpenAI model can be used for.
+type ModelCapabilities struct {
+	FineTune       bool `json:"fine_tune"`
+	Inference      bool `json:"inference"`
+	Completion     bool `json:"completion"`
+	ChatCompletion bool `json:"chat_completion"`
+	Embeddings     bool `json:"embeddings"`
+}
+
 // Permission struct represents an OpenAPI permission.
 type Permission struct {
 	CreatedAt          int64       `json:"created"`
@@ -63,6 +77,30 @@ func (c *Client) ListModels(ctx context.Context) (models ModelsList, err error)
 	return
 }
 
+// ListModelsOwnedBy lists the available models whose owner starts with prefix,
+// e.g. "system" or "organization-owner".
+func (c *Client) ListModelsOwnedBy(ctx context.Context, prefix string) ([]Model, error) {
+	list, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]Model, 0, len(list.Models))
+	for _, model := range list.Models {
+		if strings.HasPrefix(model.OwnedBy, prefix) {
+			models = append(models, model)
+		}
+	}
+	return models, nil
+}
+
+// SortByCreated sorts models from the oldest to the newest, keeping models
+// created at the same time in their order.
+func SortByCreated(models []Model) {
+	slices.SortStableFunc(models, func(a, b Model) int {
+		return cmp.Compare(a.CreatedAt, b.CreatedAt)
+	})
+}
+
 // GetModel Retrieves a model instance, providing basic information about
 // the model such as the owner and permissioning.
 func (c *Client) GetModel(ctx context.Context, modelID string) (model Model, err error) {
@@ -38,6 +38,43 @@ func handleListModelsEndpoint(w http.ResponseWriter, _ *http.Request) {
 	fmt.Fprintln(w, string(resBytes))
 }
 
+func TestListModelsOwnedBy(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"object":"list","data":[`+
+			`{"id":"gpt-4","object":"model","created":1687882411,"owned_by":"openai"},`+
+			`{"id":"ft:gpt-3.5-turbo:acme::1","object":"model","created":1700000000,"owned_by":"user-abc"},`+
+			`{"id":"gpt-3.5-turbo","object":"model","created":1677610602,"owned_by":"openai-internal"}]}`)
+	})
+	models, err := client.ListModelsOwnedBy(context.Background(), "openai")
+	checks.NoError(t, err, "ListModelsOwnedBy error")
+	if len(models) != 2 || models[0].ID != "gpt-4" || models[1].ID != "gpt-3.5-turbo" {
+		t.Fatalf("got models %+v", models)
+	}
+
+	openai.SortByCreated(models)
+	if models[0].ID != "gpt-3.5-turbo" || models[1].ID != "gpt-4" {
+		t.Errorf("got models sorted as %s, %s", models[0].ID, models[1].ID)
+	}
+}
+
+func TestAzureGetModelCapabilities(t *testing.T) {
+	client, server, teardown := setupAzureTestServer()
+	defer teardown()
+	server.RegisterHandler("/openai/models/text-embedding-ada-002", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"text-embedding-ada-002","object":"model","created_at":1677628800,`+
+			`"capabilities":{"fine_tune":false,"inference":true,"completion":false,"chat_completion":false,`+
+			`"embeddings":true}}`)
+	})
+	model, err := client.GetModel(context.Background(), "text-embedding-ada-002")
+	checks.NoError(t, err, "GetModel error")
+	want := openai.ModelCapabilities{Inference: true, Embeddings: true}
+	if model.Capabilities == nil || *model.Capabilities != want {
+		t.Errorf("got capabilities %+v, want %+v", model.Capabilities, want)
+	}
+}
+
 // TestGetModel Tests the retrieve model endpoint of the API using the mocked server.
 func TestGetModel(t *testing.T) {
 	client, server, teardown := setupOpenAITestServer()
@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrOllamaInvalidBaseURL = errors.New("ollama base URL must be an absolute http(s) URL")
+
+// OllamaModel represents a model available on a local Ollama server.
+type OllamaModel struct {
+	Name       string             `json:"name"`
+	Model      string             `json:"model"`
+	ModifiedAt time.Time          `json:"modified_at"`
+	Size       int64              `json:"size"`
+	Digest     string             `json:"digest"`
+	Details    OllamaModelDetails `json:"details"`
+}
+
+// OllamaModelDetails describes the format and size of an Ollama model.
+type OllamaModelDetails struct {
+	ParentModel       string   `json:"parent_model"`
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+type ollamaTagsResponse struct {
+	Models []OllamaModel `json:"models"`
+
+	httpHeader
+}
+
+// NewOllamaClient creates new API client for the OpenAI-compatible API of a
+// local Ollama server, e.g. http://localhost:11434/v1. An empty baseURL uses
+// the default Ollama address.
+func NewOllamaClient(baseURL string) (*Client, error) {
+	config := DefaultOllamaConfig(baseURL)
+	u, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOllamaInvalidBaseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrOllamaInvalidBaseURL
+	}
+	return NewClientWithConfig(config), nil
+}
+
+// ListLocalModels lists the models pulled on the Ollama server using its
+// native /api/tags endpoint.
+func (c *Client) ListLocalModels(ctx context.Context) (models []OllamaModel, err error) {
+	baseURL := strings.TrimSuffix(strings.TrimRight(c.config.BaseURL, "/"), "/v1")
+	req, err := c.newRequest(ctx, http.MethodGet, addQueryParams(baseURL+"/api/tags", c.config.QueryParams))
+	if err != nil {
+		return
+	}
+
+	var response ollamaTagsResponse
+	err = c.sendRequest(req, &response)
+	if err != nil {
+		return
+	}
+	models = response.Models
+	return
+}
@@ -0,0 +1,81 @@
+package openai //nolint:testpackage // testing private field
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestNewOllamaClient(t *testing.T) {
+	client, err := NewOllamaClient("")
+	checks.NoError(t, err, "NewOllamaClient error")
+	if client.config.BaseURL != ollamaAPIURLv1 {
+		t.Errorf("BaseURL = %s, want %s", client.config.BaseURL, ollamaAPIURLv1)
+	}
+	if client.config.authToken != ollamaAPIKey {
+		t.Errorf("Client does not contain the Ollama placeholder token")
+	}
+
+	for _, baseURL := range []string{"localhost:11434", "/v1", "ftp://localhost/v1", "http://%zz"} {
+		_, err = NewOllamaClient(baseURL)
+		if !errors.Is(err, ErrOllamaInvalidBaseURL) {
+			t.Errorf("NewOllamaClient(%q) did not return ErrOllamaInvalidBaseURL: %v", baseURL, err)
+		}
+	}
+}
+
+func setupOllamaTestServer() (client *Client, server *test.ServerTest, teardown func()) {
+	server = test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	teardown = ts.Close
+	config := DefaultOllamaConfig(ts.URL + "/v1")
+	config.authToken = test.GetTestToken()
+	client = NewClientWithConfig(config)
+	return
+}
+
+func TestOllamaSkipsModelValidation(t *testing.T) {
+	client, server, teardown := setupOllamaTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"id":"chatcmpl-1","model":"davinci"}`))
+		checks.NoError(t, err, "Write error")
+	})
+	server.RegisterHandler("/v1/completions", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"id":"cmpl-1","model":"gpt-4"}`))
+		checks.NoError(t, err, "Write error")
+	})
+
+	ctx := context.Background()
+	_, err := client.CreateChatCompletion(ctx, ChatCompletionRequest{Model: GPT3Davinci})
+	checks.NoError(t, err, "CreateChatCompletion should not validate models against Ollama")
+
+	_, err = client.CreateCompletion(ctx, CompletionRequest{Model: GPT4, Prompt: "Hello"})
+	checks.NoError(t, err, "CreateCompletion should not validate models against Ollama")
+}
+
+func TestListLocalModels(t *testing.T) {
+	client, server, teardown := setupOllamaTestServer()
+	defer teardown()
+	server.RegisterHandler("/api/tags", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"models":[{"name":"llama3:latest","model":"llama3:latest",` +
+			`"modified_at":"2024-05-01T10:00:00.000000000-07:00","size":4661224676,"digest":"365c0bd3c000",` +
+			`"details":{"parent_model":"","format":"gguf","family":"llama","families":["llama"],` +
+			`"parameter_size":"8.0B","quantization_level":"Q4_0"}}]}`))
+		checks.NoError(t, err, "Write error")
+	})
+
+	models, err := client.ListLocalModels(context.Background())
+	checks.NoError(t, err, "ListLocalModels error")
+	if len(models) != 1 {
+		t.Fatalf("ListLocalModels returned %d models, want 1", len(models))
+	}
+	if models[0].Name != "llama3:latest" || models[0].Details.ParameterSize != "8.0B" {
+		t.Errorf("unexpected model: %+v", models[0])
+	}
+}
@@ -0,0 +1,119 @@
+// Package openaitest provides fakes for testing code using the openai package:
+// fakes of its interfaces, which need no HTTP server, and Server, a fake
+// OpenAI API for code using an *openai.Client.
+package openaitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/zquestz/go-openai"
+)
+
+// ErrNoResponse is returned by fakes called more often than they have
+// scripted responses.
+var ErrNoResponse = errors.New("openaitest: no scripted response left")
+
+// ChatCompleter is a fake openai.ChatCompleter returning scripted responses in
+// order and recording the requests it receives. It is safe for concurrent use.
+type ChatCompleter struct {
+	// Responses are returned by successive CreateChatCompletion calls.
+	Responses []openai.ChatCompletionResponse
+	// Streams are the chunks of the streams returned by successive
+	// CreateChatCompletionStream calls.
+	Streams [][]openai.ChatCompletionStreamResponse
+	// Err, if set, is returned by every call instead.
+	Err error
+
+	mu        sync.Mutex
+	requests  []openai.ChatCompletionRequest
+	responses int
+	streams   int
+}
+
+var _ openai.ChatCompleter = (*ChatCompleter)(nil)
+
+func (c *ChatCompleter) CreateChatCompletion(
+	ctx context.Context,
+	request openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request.Clone())
+	if err := c.err(ctx); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	if c.responses == len(c.Responses) {
+		return openai.ChatCompletionResponse{}, ErrNoResponse
+	}
+	c.responses++
+	return c.Responses[c.responses-1], nil
+}
+
+func (c *ChatCompleter) CreateChatCompletionStream(
+	ctx context.Context,
+	request openai.ChatCompletionRequest,
+) (*openai.ChatCompletionStream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request.Clone())
+	if err := c.err(ctx); err != nil {
+		return nil, err
+	}
+	if c.streams == len(c.Streams) {
+		return nil, ErrNoResponse
+	}
+	c.streams++
+	return ChatCompletionStream(c.Streams[c.streams-1]...), nil
+}
+
+// Requests returns the requests received so far, streamed or not.
+func (c *ChatCompleter) Requests() []openai.ChatCompletionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.requests)
+}
+
+func (c *ChatCompleter) err(ctx context.Context) error {
+	if c.Err != nil {
+		return c.Err
+	}
+	return ctx.Err()
+}
+
+// ChatCompletionStream returns a stream sending chunks, then ending with
+// io.EOF.
+func ChatCompletionStream(chunks ...openai.ChatCompletionStreamResponse) *openai.ChatCompletionStream {
+	return openai.NewChatCompletionStream(eventStream(chunks))
+}
+
+// CompletionStream returns a stream sending chunks, then ending with io.EOF.
+func CompletionStream(chunks ...openai.CompletionResponse) *openai.CompletionStream {
+	return openai.NewCompletionStream(eventStream(chunks))
+}
+
+// ResponseStream returns a stream sending events, then ending with io.EOF.
+func ResponseStream(events ...openai.ResponseStreamEvent) *openai.ResponseStream {
+	return openai.NewResponseStream(eventStream(events))
+}
+
+// eventStream encodes chunks as the server-sent events of a stream.
+func eventStream[T any](chunks []T) io.ReadCloser {
+	var events bytes.Buffer
+	for _, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			panic(err)
+		}
+		events.WriteString("data: ")
+		events.Write(data)
+		events.WriteString("\n\n")
+	}
+	events.WriteString("data: [DONE]\n\n")
+	return io.NopCloser(&events)
+}
@@ -0,0 +1,104 @@
+package openaitest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+// summarize is the code under test, depending on an openai.ChatCompleter
+// rather than an *openai.Client.
+func summarize(ctx context.Context, client openai.ChatCompleter, text string) (string, error) {
+	stream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Summarize: " + text}},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	var summary strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return summary.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		summary.WriteString(chunk.Choices[0].Delta.Content)
+	}
+}
+
+func chunk(content string) openai.ChatCompletionStreamResponse {
+	return openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: content}}},
+	}
+}
+
+func ExampleChatCompleter() {
+	fake := &openaitest.ChatCompleter{
+		Streams: [][]openai.ChatCompletionStreamResponse{{chunk("Go is "), chunk("fun.")}},
+	}
+	summary, err := summarize(context.Background(), fake, "A long text about Go.")
+	fmt.Println(summary, err)
+	fmt.Println(fake.Requests()[0].Messages[0].Content)
+	// Output:
+	// Go is fun. <nil>
+	// Summarize: A long text about Go.
+}
+
+func TestChatCompleter(t *testing.T) {
+	fake := &openaitest.ChatCompleter{
+		Responses: []openai.ChatCompletionResponse{{ID: "1"}, {ID: "2"}},
+	}
+	ctx := context.Background()
+	for _, want := range []string{"1", "2"} {
+		response, err := fake.CreateChatCompletion(ctx, openai.ChatCompletionRequest{})
+		checks.NoError(t, err, "CreateChatCompletion error")
+		if response.ID != want {
+			t.Errorf("got response %q, want %q", response.ID, want)
+		}
+	}
+	_, err := fake.CreateChatCompletion(ctx, openai.ChatCompletionRequest{})
+	checks.ErrorIs(t, err, openaitest.ErrNoResponse, "CreateChatCompletion should run out of responses")
+	_, err = fake.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{})
+	checks.ErrorIs(t, err, openaitest.ErrNoResponse, "CreateChatCompletionStream should run out of streams")
+	if len(fake.Requests()) != 4 {
+		t.Errorf("got %d requests, want 4", len(fake.Requests()))
+	}
+
+	errFake := &openaitest.ChatCompleter{Err: &openai.APIError{HTTPStatusCode: 429}}
+	_, err = summarize(ctx, errFake, "text")
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 429 {
+		t.Errorf("got error %v, want the scripted one", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = fake.CreateChatCompletion(canceled, openai.ChatCompletionRequest{})
+	checks.ErrorIs(t, err, context.Canceled, "CreateChatCompletion should fail with a canceled context")
+}
+
+func TestCompletionStream(t *testing.T) {
+	stream := openaitest.CompletionStream(
+		openai.CompletionResponse{Choices: []openai.CompletionChoice{{Text: "Hello"}}},
+	)
+	defer stream.Close()
+	response, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if response.Choices[0].Text != "Hello" {
+		t.Errorf("got response %+v", response)
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should end after the scripted chunks")
+}
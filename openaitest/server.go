@@ -0,0 +1,244 @@
+package openaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+)
+
+// Token is the API key of the clients returned by Server.Client.
+const Token = "openaitest-token"
+
+// Request is a request received by a Server.
+type Request struct {
+	Method string
+	// Endpoint is the path of the request without the /v1 prefix, e.g.
+	// /chat/completions.
+	Endpoint string
+	Header   http.Header
+	Body     []byte
+	// Model and Stream are decoded from the JSON body, if any.
+	Model  string
+	Stream bool
+	// N is the position of the request among the ones sent to Endpoint,
+	// starting at 1.
+	N int
+}
+
+// Matcher selects the requests a reply is sent to.
+type Matcher func(r *Request) bool
+
+// MatchModel matches the requests for model.
+func MatchModel(model string) Matcher {
+	return func(r *Request) bool {
+		return r.Model == model
+	}
+}
+
+// MatchNth matches the nth request to the endpoint, starting at 1. Retried
+// requests count as separate requests.
+func MatchNth(n int) Matcher {
+	return func(r *Request) bool {
+		return r.N == n
+	}
+}
+
+type route struct {
+	endpoint string
+	matchers []Matcher
+	handler  http.HandlerFunc
+}
+
+func (rt *route) match(r *Request) bool {
+	if rt.endpoint != r.Endpoint {
+		return false
+	}
+	for _, matcher := range rt.matchers {
+		if !matcher(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Server is a fake OpenAI API replying to requests with scripted responses
+// and recording them. Each request gets the reply of the first registration
+// whose endpoint and matchers all match it, so specific replies must be
+// registered before general ones. Requests without a reply fail the test and
+// get a 404. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the API, ending with /v1.
+	URL string
+
+	t      testing.TB
+	server *httptest.Server
+
+	mu       sync.Mutex
+	routes   []*route
+	requests []Request
+	counts   map[string]int
+}
+
+// NewServer starts a Server, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{t: t, counts: make(map[string]int)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.server.Close)
+	s.URL = s.server.URL + "/v1"
+	return s
+}
+
+// Client returns a client of the server, configured with opts.
+func (s *Server) Client(opts ...openai.ClientOption) *openai.Client {
+	config := openai.DefaultConfig(Token)
+	config.BaseURL = s.URL
+	return openai.NewClientWithConfig(config, opts...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Handle replies to the matching requests to endpoint with handler, e.g. for
+// endpoints without a dedicated method.
+func (s *Server) Handle(endpoint string, handler http.HandlerFunc, matchers ...Matcher) {
+	s.handle(endpoint, matchers, handler)
+}
+
+// OnChatCompletion replies to the matching chat completions that are not
+// streamed with response.
+func (s *Server) OnChatCompletion(response openai.ChatCompletionResponse, matchers ...Matcher) {
+	matchers = append([]Matcher{notStreamed}, matchers...)
+	s.handle("/chat/completions", matchers, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, response)
+	})
+}
+
+// OnChatCompletionStream replies to the matching streamed chat completions with
+// chunks, waiting delay before each of them.
+func (s *Server) OnChatCompletionStream(
+	chunks []openai.ChatCompletionStreamResponse,
+	delay time.Duration,
+	matchers ...Matcher,
+) {
+	matchers = append([]Matcher{streamed}, matchers...)
+	s.handle("/chat/completions", matchers, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flush := func() {
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		flush()
+		for _, chunk := range chunks {
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return
+			}
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				s.t.Errorf("openaitest: marshaling chunk: %v", err)
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flush()
+		}
+		io.WriteString(w, "data: [DONE]\n\n") //nolint:errcheck // the client may be gone
+	})
+}
+
+// OnRateLimit replies to the matching requests to endpoint with a 429 asking
+// to retry after retryAfter, in the Retry-After and retry-after-ms headers.
+func (s *Server) OnRateLimit(endpoint string, retryAfter time.Duration, matchers ...Matcher) {
+	s.handle(endpoint, matchers, func(w http.ResponseWriter, _ *http.Request) {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		w.Header().Set("retry-after-ms", strconv.FormatInt(retryAfter.Milliseconds(), 10))
+		writeError(w, http.StatusTooManyRequests, "rate_limit_exceeded", "Rate limit reached")
+	})
+}
+
+func (s *Server) handle(endpoint string, matchers []Matcher, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, &route{endpoint: endpoint, matchers: matchers, handler: handler})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	r := Request{
+		Method:   req.Method,
+		Endpoint: strings.TrimPrefix(req.URL.Path, "/v1"),
+		Header:   req.Header.Clone(),
+		Body:     body,
+	}
+	var fields struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if json.Unmarshal(body, &fields) == nil {
+		r.Model, r.Stream = fields.Model, fields.Stream
+	}
+
+	s.mu.Lock()
+	s.counts[r.Endpoint]++
+	r.N = s.counts[r.Endpoint]
+	s.requests = append(s.requests, r)
+	var matched *route
+	for _, rt := range s.routes {
+		if rt.match(&r) {
+			matched = rt
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if matched == nil {
+		s.t.Errorf("openaitest: no reply registered for %s %s (model %q, request %d)", r.Method, r.Endpoint, r.Model, r.N)
+		writeError(w, http.StatusNotFound, "not_found", "no reply registered")
+		return
+	}
+	matched.handler(w, req)
+}
+
+func streamed(r *Request) bool {
+	return r.Stream
+}
+
+func notStreamed(r *Request) bool {
+	return !r.Stream
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck,errchkjson // the client may be gone
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{"message": message, "type": "openaitest", "code": code},
+	})
+}
@@ -0,0 +1,114 @@
+package openaitest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+func chatRequest(model string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+}
+
+func TestServerChatCompletion(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{ID: "gpt-4"}, openaitest.MatchModel(openai.GPT4))
+	server.OnChatCompletion(openai.ChatCompletionResponse{ID: "second"}, openaitest.MatchNth(2))
+	server.OnChatCompletion(openai.ChatCompletionResponse{ID: "default"})
+	client := server.Client()
+	ctx := context.Background()
+
+	for _, c := range []struct{ model, want string }{
+		{openai.GPT3Dot5Turbo, "default"},
+		{openai.GPT3Dot5Turbo, "second"},
+		{openai.GPT4, "gpt-4"},
+		{openai.GPT3Dot5Turbo, "default"},
+	} {
+		response, err := client.CreateChatCompletion(ctx, chatRequest(c.model))
+		checks.NoError(t, err, "CreateChatCompletion error")
+		if response.ID != c.want {
+			t.Errorf("got response %q, want %q", response.ID, c.want)
+		}
+	}
+
+	requests := server.Requests()
+	if len(requests) != 4 {
+		t.Fatalf("got %d requests, want 4", len(requests))
+	}
+	last := requests[3]
+	if last.Endpoint != "/chat/completions" || last.Model != openai.GPT3Dot5Turbo || last.N != 4 ||
+		last.Header.Get("Authorization") != "Bearer "+openaitest.Token {
+		t.Errorf("got request %+v", last)
+	}
+	var body openai.ChatCompletionRequest
+	checks.NoError(t, json.Unmarshal(last.Body, &body), "Unmarshal error")
+	if body.Messages[0].Content != "Hello!" {
+		t.Errorf("got request body %s", last.Body)
+	}
+}
+
+func TestServerChatCompletionStream(t *testing.T) {
+	server := openaitest.NewServer(t)
+	chunks := []openai.ChatCompletionStreamResponse{chunk("Hello"), chunk(" world")}
+	server.OnChatCompletionStream(chunks, 10*time.Millisecond)
+	stream, err := server.Client().CreateChatCompletionStream(context.Background(), chatRequest(openai.GPT4))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	var content string
+	start := time.Now()
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "Recv error")
+		content += response.Choices[0].Delta.Content
+	}
+	if content != "Hello world" {
+		t.Errorf("got content %q", content)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("stream took %s, want the chunks delayed", elapsed)
+	}
+	if !server.Requests()[0].Stream {
+		t.Error("request was not recorded as streamed")
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnRateLimit("/chat/completions", 5*time.Millisecond, openaitest.MatchNth(1))
+	server.OnChatCompletion(openai.ChatCompletionResponse{ID: "1"})
+	ctx := context.Background()
+
+	_, err := server.Client().CreateChatCompletion(ctx, chatRequest(openai.GPT4))
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got error %v, want a 429", err)
+	}
+
+	server.OnRateLimit("/embeddings", 5*time.Millisecond, openaitest.MatchNth(1))
+	server.Handle("/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.5],"index":0}]}`))
+	})
+	response, err := server.Client(openai.WithRetries(2)).CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{"Hello"},
+		Model: openai.AdaEmbeddingV2,
+	})
+	checks.NoError(t, err, "CreateEmbeddings should be retried")
+	if len(response.Data) != 1 || len(server.Requests()) != 3 {
+		t.Errorf("got response %+v after %d requests", response, len(server.Requests()))
+	}
+}
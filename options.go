@@ -0,0 +1,153 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+)
+
+var (
+	ErrInvalidBaseURL    = errors.New("base URL must be an absolute http(s) URL")
+	ErrInvalidAPIType    = errors.New("unknown API type")
+	ErrMissingHTTPClient = errors.New("HTTP client is not set")
+)
+
+const defaultAzureAPIVersion = "2024-10-21"
+
+// defaultAzureModelMapper strips the characters Azure does not allow in
+// deployment names, e.g. gpt-3.5-turbo becomes gpt-35-turbo.
+func defaultAzureModelMapper(model string) string {
+	return regexp.MustCompile(`[.:]`).ReplaceAllString(model, "")
+}
+
+// NewClientWithOptions creates a client for the OpenAI API authenticated with
+// authToken and customized by opts, which apply in order to DefaultConfig.
+// Clients of APITypeAzure and APITypeAzureAD get the API version and
+// deployment names of DefaultAzureConfig unless opts set them. Unlike
+// NewClientWithConfig, it checks the resulting config and returns an error
+// for an invalid base URL or API type.
+//
+//	client, err := openai.NewClientWithOptions(apiKey,
+//		openai.WithBaseURL("https://example.openai.azure.com"),
+//		openai.WithAPIType(openai.APITypeAzure),
+//		openai.WithAzureDeployment(openai.GPT4, "gpt4-prod"),
+//		openai.WithRetries(3),
+//	)
+func NewClientWithOptions(authToken string, opts ...ClientOption) (*Client, error) {
+	config := DefaultConfig(authToken)
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.APIType == APITypeAzure || config.APIType == APITypeAzureAD {
+		if config.APIVersion == "" {
+			config.APIVersion = defaultAzureAPIVersion
+		}
+		if config.AzureModelMapperFunc == nil {
+			config.AzureModelMapperFunc = defaultAzureModelMapper
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return NewClientWithConfig(config), nil
+}
+
+func (c ClientConfig) validate() error {
+	if err := validateBaseURL(c.BaseURL); err != nil {
+		return err
+	}
+	switch c.APIType {
+	case APITypeOpenAI, APITypeAzure, APITypeAzureAD, APITypeOllama:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidAPIType, c.APIType)
+	}
+	if c.HTTPClient == nil {
+		return ErrMissingHTTPClient
+	}
+	return nil
+}
+
+func validateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBaseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidBaseURL, baseURL)
+	}
+	return nil
+}
+
+// WithBaseURL sets ClientConfig.BaseURL, e.g. to the endpoint of an Azure
+// resource or of a proxy.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(config *ClientConfig) {
+		config.BaseURL = baseURL
+	}
+}
+
+// WithOrg sets ClientConfig.OrgID. WithOrganization overrides it per request.
+func WithOrg(orgID string) ClientOption {
+	return func(config *ClientConfig) {
+		config.OrgID = orgID
+	}
+}
+
+// WithProject sets ClientConfig.ProjectID. WithProjectID overrides it per
+// request.
+func WithProject(projectID string) ClientOption {
+	return func(config *ClientConfig) {
+		config.ProjectID = projectID
+	}
+}
+
+// WithAPIType sets ClientConfig.APIType.
+func WithAPIType(apiType APIType) ClientOption {
+	return func(config *ClientConfig) {
+		config.APIType = apiType
+	}
+}
+
+// WithAPIVersion sets ClientConfig.APIVersion, the api-version of Azure
+// requests. WithRequestAPIVersion overrides it per request.
+func WithAPIVersion(apiVersion string) ClientOption {
+	return func(config *ClientConfig) {
+		config.APIVersion = apiVersion
+	}
+}
+
+// WithQueryParams adds params to ClientConfig.QueryParams, which are added to
+// the URL of every request, e.g. the flags of Azure preview features. They
+// replace the parameters of the same keys set by earlier calls, but never
+// those of the client such as api-version. Use it with Client.WithOptions for
+// a single call.
+func WithQueryParams(params url.Values) ClientOption {
+	return func(config *ClientConfig) {
+		// Copy the values, which may be shared with other configs.
+		merged := make(url.Values, len(config.QueryParams)+len(params))
+		for key, values := range config.QueryParams {
+			merged[key] = values
+		}
+		for key, values := range params {
+			merged[key] = slices.Clone(values)
+		}
+		config.QueryParams = merged
+	}
+}
+
+// WithAzureDeployment sends the requests for model to the Azure deployment
+// named deployment, adding it to ClientConfig.AzureDeployments.
+func WithAzureDeployment(model, deployment string) ClientOption {
+	return func(config *ClientConfig) {
+		// Copy the map, which may be shared with other configs.
+		deployments := maps.Clone(config.AzureDeployments)
+		if deployments == nil {
+			deployments = make(map[string]string)
+		}
+		deployments[model] = deployment
+		config.AzureDeployments = deployments
+	}
+}
@@ -0,0 +1,418 @@
+// Package openaiotel instruments go-openai clients with OpenTelemetry. It lives
+// in its own module so that the openai package does not depend on otel.
+//
+//	client := openai.NewClientWithConfig(config, openaiotel.WithOTelTracing(tp, mp))
+//
+// Every API call gets a span following the gen_ai semantic conventions, which
+// ends once its response body was read or closed, for streams when the stream
+// is closed. Its duration and token usage are recorded as the
+// gen_ai.client.operation.duration and gen_ai.client.token.usage histograms.
+package openaiotel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ScopeName is the instrumentation scope of the tracer.
+	ScopeName = "github.com/zquestz/go-openai/otel"
+
+	// FirstTokenEvent is added to stream spans when the first chunk arrives.
+	FirstTokenEvent = "gen_ai.first_token"
+)
+
+// Attribute keys of the gen_ai semantic conventions.
+const (
+	AttributeSystem             = attribute.Key("gen_ai.system")
+	AttributeOperationName      = attribute.Key("gen_ai.operation.name")
+	AttributeRequestModel       = attribute.Key("gen_ai.request.model")
+	AttributeResponseModel      = attribute.Key("gen_ai.response.model")
+	AttributeResponseID         = attribute.Key("gen_ai.response.id")
+	AttributeResponseFinishes   = attribute.Key("gen_ai.response.finish_reasons")
+	AttributeUsageInputTokens   = attribute.Key("gen_ai.usage.input_tokens")
+	AttributeUsageOutputTokens  = attribute.Key("gen_ai.usage.output_tokens")
+	AttributeTokenType          = attribute.Key("gen_ai.token.type")
+	AttributeErrorType          = attribute.Key("error.type")
+	AttributeHTTPResponseStatus = attribute.Key("http.response.status_code")
+)
+
+// Metric names of the gen_ai semantic conventions.
+const (
+	MetricOperationDuration = "gen_ai.client.operation.duration"
+	MetricTokenUsage        = "gen_ai.client.token.usage"
+)
+
+const (
+	systemOpenAI            = "openai"
+	tokenTypeInput          = "input"
+	tokenTypeOutput         = "output"
+	operationChat           = "chat"
+	operationTextCompletion = "text_completion"
+	operationEmbeddings     = "embeddings"
+)
+
+type Option func(*Transport)
+
+// WithTracerProvider sets the provider of the tracer, the global one by
+// default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *Transport) {
+		t.tracer = provider.Tracer(ScopeName)
+	}
+}
+
+// WithMeterProvider sets the provider of the meter, the global one by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(t *Transport) {
+		t.meter = provider.Meter(ScopeName)
+	}
+}
+
+// Transport is an http.RoundTripper that traces the API calls sent through it.
+// It must be used by a go-openai client, since it reads the endpoint and model
+// from the request context.
+type Transport struct {
+	// Base is the transport used to send requests, http.DefaultTransport if nil.
+	Base     http.RoundTripper
+	tracer   trace.Tracer
+	meter    metric.Meter
+	duration metric.Float64Histogram
+	tokens   metric.Int64Histogram
+}
+
+// NewTransport creates a Transport sending requests with base.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	t := &Transport{Base: base}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.tracer == nil {
+		t.tracer = otel.GetTracerProvider().Tracer(ScopeName)
+	}
+	if t.meter == nil {
+		t.meter = otel.GetMeterProvider().Meter(ScopeName)
+	}
+	// Instrument errors are reported to the otel error handler and leave no-op
+	// instruments behind.
+	t.duration, _ = t.meter.Float64Histogram(MetricOperationDuration,
+		metric.WithDescription("Duration of GenAI operations."),
+		metric.WithUnit("s"),
+	)
+	t.tokens, _ = t.meter.Int64Histogram(MetricTokenUsage,
+		metric.WithDescription("Number of input and output tokens used."),
+		metric.WithUnit("{token}"),
+	)
+	return t
+}
+
+// WithTracing makes the client trace its API calls by wrapping the transport
+// of its HTTP client. Options applied afterwards wrap the traced transport, so
+// when combined with openai.WithRetries, passing WithTracing first gives every
+// attempt its own span.
+func WithTracing(opts ...Option) openai.ClientOption {
+	return openai.WithTransportWrapper(func(base http.RoundTripper) http.RoundTripper {
+		return NewTransport(base, opts...)
+	})
+}
+
+// WithOTelTracing is WithTracing recording spans with tp and metrics with mp.
+// Nil providers default to the global ones.
+func WithOTelTracing(tp trace.TracerProvider, mp metric.MeterProvider) openai.ClientOption {
+	var opts []Option
+	if tp != nil {
+		opts = append(opts, WithTracerProvider(tp))
+	}
+	if mp != nil {
+		opts = append(opts, WithMeterProvider(mp))
+	}
+	return WithTracing(opts...)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	meta, _ := openai.RequestMetaFromContext(req.Context())
+	endpoint := meta.Endpoint
+	if endpoint == "" {
+		endpoint = req.URL.Path
+	}
+	c := &call{transport: t, start: time.Now()}
+	c.attrs = []attribute.KeyValue{AttributeSystem.String(systemOpenAI)}
+	name := "openai " + endpoint
+	if operation := operationName(endpoint); operation != "" {
+		name = "gen_ai." + operation
+		c.attrs = append(c.attrs, AttributeOperationName.String(operation))
+	}
+	if meta.Model != "" {
+		c.attrs = append(c.attrs, AttributeRequestModel.String(meta.Model))
+	}
+	ctx, span := t.tracer.Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(c.attrs...),
+	)
+	c.ctx, c.span = ctx, span
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.end(AttributeErrorType.String(fmt.Sprintf("%T", err)))
+		return resp, err
+	}
+
+	span.SetAttributes(AttributeHTTPResponseStatus.Int(resp.StatusCode))
+	openai.DecompressResponse(resp)
+	contentType := resp.Header.Get("Content-Type")
+	resp.Body = &tracedBody{
+		ReadCloser: resp.Body,
+		call:       c,
+		status:     resp.StatusCode,
+		stream:     strings.HasPrefix(contentType, "text/event-stream"),
+		json:       strings.HasPrefix(contentType, "application/json"),
+	}
+	return resp, nil
+}
+
+// call is an API call being traced.
+type call struct {
+	transport *Transport
+	ctx       context.Context
+	span      trace.Span
+	start     time.Time
+	// attrs are the request attributes, shared by the span and the metrics.
+	attrs []attribute.KeyValue
+}
+
+// end ends the span and records the metrics of the call with the response
+// attributes attrs.
+func (c *call) end(attrs ...attribute.KeyValue) {
+	c.span.End()
+	attrs = append(attrs[:len(attrs):len(attrs)], c.attrs...)
+	c.transport.duration.Record(c.ctx, time.Since(c.start).Seconds(), metric.WithAttributes(attrs...))
+}
+
+// recordTokens records the token usage of the call.
+func (c *call) recordTokens(tokenType string, tokens int, attrs []attribute.KeyValue) {
+	attrs = append(attrs[:len(attrs):len(attrs)], c.attrs...)
+	attrs = append(attrs, AttributeTokenType.String(tokenType))
+	c.transport.tokens.Record(c.ctx, int64(tokens), metric.WithAttributes(attrs...))
+}
+
+// operationName returns the gen_ai operation of an endpoint, if it has one.
+func operationName(endpoint string) string {
+	switch endpoint {
+	case "/chat/completions":
+		return operationChat
+	case "/completions":
+		return operationTextCompletion
+	case "/embeddings":
+		return operationEmbeddings
+	}
+	return ""
+}
+
+// responseSummary holds the fields of responses and stream chunks that are
+// recorded on spans.
+type responseSummary struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	// Usage is reported as prompt and completion tokens by the chat and
+	// embeddings endpoints, and as input and output tokens by the Responses API.
+	Usage *struct {
+		PromptTokens     *int `json:"prompt_tokens"`
+		CompletionTokens *int `json:"completion_tokens"`
+		InputTokens      *int `json:"input_tokens"`
+		OutputTokens     *int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// tracedBody ends the span of a response once the body was read or closed, for
+// streams once they are closed. It keeps JSON bodies to record their summary
+// and parses streams line by line. Other bodies, e.g. file contents, are passed
+// through.
+type tracedBody struct {
+	io.ReadCloser
+	call    *call
+	status  int
+	stream  bool
+	json    bool
+	buf     bytes.Buffer
+	chunks  int
+	summary responseSummary
+	finish  []string
+	readErr error
+	once    sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	switch {
+	case b.stream:
+		b.buf.Write(p[:n])
+		b.readLines()
+	case b.json:
+		b.buf.Write(p[:n])
+	}
+	switch {
+	case err == nil:
+	case b.stream:
+		// Stream spans end when the stream is closed.
+		b.readErr = err
+	default:
+		b.end(err)
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.end(b.readErr)
+	return err
+}
+
+// readLines handles the complete lines of a stream in buf.
+func (b *tracedBody) readLines() {
+	for {
+		line, err := b.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial line for the next read.
+			rest := append([]byte(nil), line...)
+			b.buf.Reset()
+			b.buf.Write(rest)
+			return
+		}
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			continue
+		}
+		b.chunks++
+		if b.chunks == 1 {
+			b.call.span.AddEvent(FirstTokenEvent)
+		}
+		b.merge(data)
+	}
+}
+
+// merge adds a response or stream chunk to the summary.
+func (b *tracedBody) merge(data []byte) {
+	var chunk responseSummary
+	if json.Unmarshal(data, &chunk) != nil {
+		return
+	}
+	if chunk.ID != "" {
+		b.summary.ID = chunk.ID
+	}
+	if chunk.Model != "" {
+		b.summary.Model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		b.summary.Usage = chunk.Usage
+	}
+	if chunk.Error != nil {
+		b.summary.Error = chunk.Error
+	}
+	for _, choice := range chunk.Choices {
+		if choice.FinishReason != "" {
+			b.finish = append(b.finish, choice.FinishReason)
+		}
+	}
+}
+
+func (b *tracedBody) end(err error) {
+	b.once.Do(func() {
+		if b.json {
+			b.merge(b.buf.Bytes())
+		}
+		b.buf = bytes.Buffer{}
+
+		// metricAttrs are the response attributes recorded with the metrics.
+		var attrs, metricAttrs []attribute.KeyValue
+		if b.summary.ID != "" {
+			attrs = append(attrs, AttributeResponseID.String(b.summary.ID))
+		}
+		if b.summary.Model != "" {
+			model := AttributeResponseModel.String(b.summary.Model)
+			attrs = append(attrs, model)
+			metricAttrs = append(metricAttrs, model)
+		}
+		if len(b.finish) > 0 {
+			attrs = append(attrs, AttributeResponseFinishes.StringSlice(b.finish))
+		}
+		var input, output *int
+		if usage := b.summary.Usage; usage != nil {
+			input = firstOf(usage.PromptTokens, usage.InputTokens)
+			output = firstOf(usage.CompletionTokens, usage.OutputTokens)
+		}
+		if input != nil {
+			attrs = append(attrs, AttributeUsageInputTokens.Int(*input))
+		}
+		if output != nil {
+			attrs = append(attrs, AttributeUsageOutputTokens.Int(*output))
+		}
+
+		span := b.call.span
+		switch {
+		case b.status >= http.StatusBadRequest:
+			description := http.StatusText(b.status)
+			if b.summary.Error != nil && b.summary.Error.Message != "" {
+				description = b.summary.Error.Message
+			}
+			errorType := AttributeErrorType.String(strconv.Itoa(b.status))
+			attrs = append(attrs, errorType)
+			metricAttrs = append(metricAttrs, errorType)
+			span.SetStatus(codes.Error, description)
+		case b.summary.Error != nil:
+			span.SetStatus(codes.Error, b.summary.Error.Message)
+		case err != nil && !errors.Is(err, io.EOF):
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attrs...)
+
+		if input != nil {
+			b.call.recordTokens(tokenTypeInput, *input, metricAttrs)
+		}
+		if output != nil {
+			b.call.recordTokens(tokenTypeOutput, *output, metricAttrs)
+		}
+		b.call.end(metricAttrs...)
+	})
+}
+
+func firstOf(values ...*int) *int {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
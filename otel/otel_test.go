@@ -0,0 +1,282 @@
+package openaiotel_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	openaiotel "github.com/zquestz/go-openai/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupTracingTestServer(t *testing.T) (
+	client *openai.Client,
+	server *test.ServerTest,
+	exporter *tracetest.InMemoryExporter,
+	provider *sdktrace.TracerProvider,
+) {
+	server = test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	exporter = tracetest.NewInMemoryExporter()
+	provider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	client = openai.NewClientWithConfig(config, openaiotel.WithTracing(openaiotel.WithTracerProvider(provider)))
+	return
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func checkAttributes(t *testing.T, span tracetest.SpanStub, want map[attribute.Key]any) {
+	t.Helper()
+	attrs := spanAttributes(span)
+	for key, value := range want {
+		got, ok := attrs[key]
+		if !ok {
+			t.Errorf("span %q has no attribute %s", span.Name, key)
+			continue
+		}
+		gotValue := got.AsInterface()
+		if slice, isSlice := value.([]string); isSlice {
+			gotSlice, _ := gotValue.([]string)
+			if len(gotSlice) != len(slice) || (len(slice) > 0 && gotSlice[0] != slice[0]) {
+				t.Errorf("span %q attribute %s = %v, want %v", span.Name, key, gotValue, value)
+			}
+			continue
+		}
+		if gotValue != value {
+			t.Errorf("span %q attribute %s = %v, want %v", span.Name, key, gotValue, value)
+		}
+	}
+}
+
+func TestTracingChatCompletionAndEmbeddings(t *testing.T) {
+	client, server, exporter, provider := setupTracingTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4-0613",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`))
+	})
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-ada-002-v2",` +
+			`"data":[{"object":"embedding","index":0,"embedding":[0.1]}],` +
+			`"usage":{"prompt_tokens":4,"total_tokens":4}}`))
+	})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	_, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{"hello"},
+		Model: openai.AdaEmbeddingV2,
+	})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	chat, embeddings := spans[0], spans[1]
+	if chat.Name != "gen_ai.chat" || embeddings.Name != "gen_ai.embeddings" {
+		t.Errorf("unexpected span names %q and %q", chat.Name, embeddings.Name)
+	}
+	for _, span := range spans[:2] {
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the caller's span", span.Name)
+		}
+		if span.SpanKind != trace.SpanKindClient {
+			t.Errorf("span %q has kind %v, want client", span.Name, span.SpanKind)
+		}
+	}
+	checkAttributes(t, chat, map[attribute.Key]any{
+		openaiotel.AttributeSystem:             "openai",
+		openaiotel.AttributeOperationName:      "chat",
+		openaiotel.AttributeRequestModel:       openai.GPT4,
+		openaiotel.AttributeResponseModel:      "gpt-4-0613",
+		openaiotel.AttributeResponseID:         "chatcmpl-1",
+		openaiotel.AttributeResponseFinishes:   []string{"stop"},
+		openaiotel.AttributeUsageInputTokens:   int64(9),
+		openaiotel.AttributeUsageOutputTokens:  int64(3),
+		openaiotel.AttributeHTTPResponseStatus: int64(http.StatusOK),
+	})
+	checkAttributes(t, embeddings, map[attribute.Key]any{
+		openaiotel.AttributeSystem:           "openai",
+		openaiotel.AttributeOperationName:    "embeddings",
+		openaiotel.AttributeRequestModel:     openai.AdaEmbeddingV2.String(),
+		openaiotel.AttributeResponseModel:    "text-embedding-ada-002-v2",
+		openaiotel.AttributeUsageInputTokens: int64(4),
+	})
+	if _, ok := spanAttributes(embeddings)[openaiotel.AttributeUsageOutputTokens]; ok {
+		t.Error("embeddings span should not report output tokens")
+	}
+}
+
+func TestTracingStream(t *testing.T) {
+	client, server, exporter, _ := setupTracingTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4-0613","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}
+
+data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4-0613","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4-0613","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}
+
+data: [DONE]
+
+`))
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		Stream:   true,
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	_, err = stream.Recv()
+	checks.NoError(t, err, "stream.Recv error")
+	if len(exporter.GetSpans()) != 0 {
+		t.Fatal("stream span ended before the stream was closed")
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("stream.Recv returned %v, want io.EOF", err)
+	}
+	stream.Close()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if len(span.Events) != 1 || span.Events[0].Name != openaiotel.FirstTokenEvent {
+		t.Errorf("unexpected span events %+v", span.Events)
+	}
+	checkAttributes(t, span, map[attribute.Key]any{
+		openaiotel.AttributeResponseModel:     "gpt-4-0613",
+		openaiotel.AttributeResponseFinishes:  []string{"stop"},
+		openaiotel.AttributeUsageInputTokens:  int64(9),
+		openaiotel.AttributeUsageOutputTokens: int64(1),
+	})
+}
+
+func TestTracingError(t *testing.T) {
+	client, server, exporter, _ := setupTracingTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests"}}`))
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.HasError(t, err, "CreateChatCompletion should fail")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Status.Code != codes.Error || span.Status.Description != "Rate limit reached" {
+		t.Errorf("unexpected span status %+v", span.Status)
+	}
+	checkAttributes(t, span, map[attribute.Key]any{
+		openaiotel.AttributeErrorType:          "429",
+		openaiotel.AttributeHTTPResponseStatus: int64(http.StatusTooManyRequests),
+	})
+}
+
+func TestOTelTracingMetrics(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4-0613",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`))
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	client := openai.NewClientWithConfig(config, openaiotel.WithOTelTracing(tp, mp))
+
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Name != "gen_ai.chat" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+
+	var metrics metricdata.ResourceMetrics
+	checks.NoError(t, reader.Collect(context.Background(), &metrics), "Collect error")
+	histograms := make(map[string]metricdata.Aggregation)
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			histograms[m.Name] = m.Data
+		}
+	}
+
+	duration, ok := histograms[openaiotel.MetricOperationDuration].(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Fatalf("unexpected %s data %+v", openaiotel.MetricOperationDuration, histograms[openaiotel.MetricOperationDuration])
+	}
+	model, _ := duration.DataPoints[0].Attributes.Value(openaiotel.AttributeResponseModel)
+	if model.AsString() != "gpt-4-0613" {
+		t.Errorf("duration recorded without the response model: %v", duration.DataPoints[0].Attributes)
+	}
+
+	usage, ok := histograms[openaiotel.MetricTokenUsage].(metricdata.Histogram[int64])
+	if !ok || len(usage.DataPoints) != 2 {
+		t.Fatalf("unexpected %s data %+v", openaiotel.MetricTokenUsage, histograms[openaiotel.MetricTokenUsage])
+	}
+	tokens := make(map[string]int64)
+	for _, point := range usage.DataPoints {
+		tokenType, _ := point.Attributes.Value(openaiotel.AttributeTokenType)
+		operation, _ := point.Attributes.Value(openaiotel.AttributeOperationName)
+		if operation.AsString() != "chat" {
+			t.Errorf("token usage recorded without operation: %v", point.Attributes)
+		}
+		tokens[tokenType.AsString()] = point.Sum
+	}
+	if tokens["input"] != 9 || tokens["output"] != 3 {
+		t.Errorf("token usage = %v, want 9 input and 3 output tokens", tokens)
+	}
+}
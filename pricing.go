@@ -0,0 +1,151 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+var ErrUnknownModelPricing = errors.New("no pricing known for model")
+
+// MicroUSD is an amount of millionths of a US dollar, so that costs add up
+// exactly.
+type MicroUSD int64
+
+// String formats the amount in dollars, e.g. "$0.001250".
+func (m MicroUSD) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s$%d.%06d", sign, m/1_000_000, m%1_000_000)
+}
+
+// Cost is the estimated price of an API call, rounded to the nearest MicroUSD.
+type Cost struct {
+	Input  MicroUSD
+	Output MicroUSD
+	Total  MicroUSD
+}
+
+// ModelPricing is the price of a model per 1M tokens. Zero CachedInput,
+// AudioInput and AudioOutput rates bill those tokens at the Input or Output
+// rate.
+type ModelPricing struct {
+	Input       MicroUSD
+	CachedInput MicroUSD
+	Output      MicroUSD
+	AudioInput  MicroUSD
+	AudioOutput MicroUSD
+}
+
+// PricingTable maps models to their pricing. Dated snapshots such as
+// gpt-4o-2024-08-06 are priced as gpt-4o unless listed themselves.
+type PricingTable map[string]ModelPricing
+
+// defaultPricing lists the prices of openai.com/api/pricing in December 2024.
+var defaultPricing = PricingTable{
+	"gpt-4o":                  {Input: 2_500_000, CachedInput: 1_250_000, Output: 10_000_000},
+	"gpt-4o-mini":             {Input: 150_000, CachedInput: 75_000, Output: 600_000},
+	"gpt-4o-audio-preview":    {Input: 2_500_000, Output: 10_000_000, AudioInput: 40_000_000, AudioOutput: 80_000_000},
+	"gpt-4o-realtime-preview": {Input: 5_000_000, CachedInput: 2_500_000, Output: 20_000_000, AudioInput: 40_000_000, AudioOutput: 80_000_000}, //nolint:lll
+	"o1":                      {Input: 15_000_000, CachedInput: 7_500_000, Output: 60_000_000},
+	"o1-preview":              {Input: 15_000_000, CachedInput: 7_500_000, Output: 60_000_000},
+	"o1-mini":                 {Input: 3_000_000, CachedInput: 1_500_000, Output: 12_000_000},
+	GPT4TurboPreview:          {Input: 10_000_000, Output: 30_000_000},
+	"gpt-4-turbo":             {Input: 10_000_000, Output: 30_000_000},
+	GPT4:                      {Input: 30_000_000, Output: 60_000_000},
+	GPT432K:                   {Input: 60_000_000, Output: 120_000_000},
+	GPT3Dot5Turbo16K:          {Input: 3_000_000, Output: 4_000_000},
+	GPT3Dot5Turbo:             {Input: 500_000, Output: 1_500_000},
+	GPT3Dot5TurboInstruct:     {Input: 1_500_000, Output: 2_000_000},
+	"text-embedding-3-small":  {Input: 20_000},
+	"text-embedding-3-large":  {Input: 130_000},
+	"text-embedding-ada-002":  {Input: 100_000},
+}
+
+// DefaultPricingTable returns the prices of well-known OpenAI models as of
+// December 2024. The table is a copy, which can be extended or overridden with
+// negotiated or newer prices.
+func DefaultPricingTable() PricingTable {
+	return maps.Clone(defaultPricing)
+}
+
+// EstimateCost estimates the cost of a call with DefaultPricingTable.
+func EstimateCost(model string, usage Usage) (Cost, error) {
+	return defaultPricing.EstimateCost(model, usage)
+}
+
+// EstimateCost estimates the cost of a call of model that used usage, billing
+// cached and audio tokens at their rates. It returns an error wrapping
+// ErrUnknownModelPricing if the table has no pricing for model.
+func (t PricingTable) EstimateCost(model string, usage Usage) (Cost, error) {
+	pricing, ok := t.lookup(model)
+	if !ok {
+		return Cost{}, fmt.Errorf("%w: %s", ErrUnknownModelPricing, model)
+	}
+
+	var cached, audioIn, audioOut int
+	if details := usage.PromptTokensDetails; details != nil {
+		cached, audioIn = details.CachedTokens, details.AudioTokens
+	}
+	if details := usage.CompletionTokensDetails; details != nil {
+		audioOut = details.AudioTokens
+	}
+	cost := Cost{
+		Input: perMillion(
+			tokenRate{usage.PromptTokens - cached - audioIn, pricing.Input},
+			tokenRate{cached, rateOr(pricing.CachedInput, pricing.Input)},
+			tokenRate{audioIn, rateOr(pricing.AudioInput, pricing.Input)},
+		),
+		Output: perMillion(
+			tokenRate{usage.CompletionTokens - audioOut, pricing.Output},
+			tokenRate{audioOut, rateOr(pricing.AudioOutput, pricing.Output)},
+		),
+	}
+	cost.Total = cost.Input + cost.Output
+	return cost, nil
+}
+
+func (t PricingTable) lookup(model string) (ModelPricing, bool) {
+	return lookupModel(t, model)
+}
+
+// lookupModel returns the value of model in m, or of the longest model it is
+// a dated or otherwise suffixed version of.
+func lookupModel[V any](m map[string]V, model string) (V, bool) {
+	if v, ok := m[model]; ok {
+		return v, true
+	}
+	var best string
+	for name := range m {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	v, ok := m[best]
+	return v, ok && best != ""
+}
+
+type tokenRate struct {
+	tokens int
+	rate   MicroUSD // per 1M tokens
+}
+
+// perMillion adds up the cost of tokens at their rates, rounded once to the
+// nearest MicroUSD.
+func perMillion(rates ...tokenRate) MicroUSD {
+	var sum MicroUSD
+	for _, r := range rates {
+		sum += MicroUSD(r.tokens) * r.rate
+	}
+	return (sum + 500_000) / 1_000_000
+}
+
+func rateOr(rate, fallback MicroUSD) MicroUSD {
+	if rate == 0 {
+		return fallback
+	}
+	return rate
+}
@@ -0,0 +1,166 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cases := []struct {
+		name  string
+		model string
+		usage openai.Usage
+		want  openai.Cost
+	}{
+		{
+			name:  "text",
+			model: "gpt-4o",
+			usage: openai.Usage{PromptTokens: 10000, CompletionTokens: 1000, TotalTokens: 11000},
+			want:  openai.Cost{Input: 25000, Output: 10000, Total: 35000},
+		},
+		{
+			name:  "cached tokens",
+			model: "gpt-4o",
+			usage: openai.Usage{
+				PromptTokens:        10000,
+				CompletionTokens:    1000,
+				TotalTokens:         11000,
+				PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 4000},
+			},
+			want: openai.Cost{Input: 20000, Output: 10000, Total: 30000},
+		},
+		{
+			name:  "audio tokens",
+			model: "gpt-4o-audio-preview",
+			usage: openai.Usage{
+				PromptTokens:            1000,
+				CompletionTokens:        500,
+				TotalTokens:             1500,
+				PromptTokensDetails:     &openai.PromptTokensDetails{AudioTokens: 200},
+				CompletionTokensDetails: &openai.CompletionTokensDetails{AudioTokens: 100},
+			},
+			want: openai.Cost{Input: 10000, Output: 12000, Total: 22000},
+		},
+		{
+			name:  "dated snapshot",
+			model: "gpt-4o-mini-2024-07-18",
+			usage: openai.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100},
+			want:  openai.Cost{Input: 150, Output: 60, Total: 210},
+		},
+		{
+			name:  "rounding",
+			model: "text-embedding-3-small",
+			usage: openai.Usage{PromptTokens: 30, TotalTokens: 30},
+			want:  openai.Cost{Input: 1, Total: 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cost, err := openai.EstimateCost(c.model, c.usage)
+			checks.NoError(t, err, "EstimateCost error")
+			if cost != c.want {
+				t.Errorf("got cost %+v, want %+v", cost, c.want)
+			}
+		})
+	}
+}
+
+func TestEstimateCostUnknownModel(t *testing.T) {
+	_, err := openai.EstimateCost("my-fine-tuned-model", openai.Usage{PromptTokens: 1})
+	checks.ErrorIs(t, err, openai.ErrUnknownModelPricing, "EstimateCost should fail for unknown models")
+	// A model is not priced as another one it only starts with.
+	_, err = openai.EstimateCost("gpt-4omni", openai.Usage{PromptTokens: 1})
+	checks.ErrorIs(t, err, openai.ErrUnknownModelPricing, "EstimateCost should fail for unknown models")
+}
+
+func TestPricingTableOverride(t *testing.T) {
+	table := openai.DefaultPricingTable()
+	table["gpt-4o"] = openai.ModelPricing{Input: 2_000_000, Output: 8_000_000}
+	table["my-fine-tuned-model"] = openai.ModelPricing{Input: 1_000_000, Output: 1_000_000}
+	usage := openai.Usage{
+		PromptTokens:        1000,
+		CompletionTokens:    1000,
+		TotalTokens:         2000,
+		PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 500},
+	}
+
+	// Without a cached rate, cached tokens are billed at the input rate.
+	cost, err := table.EstimateCost("gpt-4o-2024-08-06", usage)
+	checks.NoError(t, err, "EstimateCost error")
+	if want := (openai.Cost{Input: 2000, Output: 8000, Total: 10000}); cost != want {
+		t.Errorf("got cost %+v, want %+v", cost, want)
+	}
+	_, err = table.EstimateCost("my-fine-tuned-model", usage)
+	checks.NoError(t, err, "EstimateCost error")
+
+	cost, err = openai.EstimateCost("gpt-4o-2024-08-06", usage)
+	checks.NoError(t, err, "EstimateCost error")
+	if want := (openai.Cost{Input: 1875, Output: 10000, Total: 11875}); cost != want {
+		t.Errorf("default table was modified: got cost %+v, want %+v", cost, want)
+	}
+}
+
+func TestMicroUSDString(t *testing.T) {
+	for amount, want := range map[openai.MicroUSD]string{
+		0:         "$0.000000",
+		1_250:     "$0.001250",
+		2_500_000: "$2.500000",
+		-5:        "-$0.000005",
+	} {
+		if got := amount.String(); got != want {
+			t.Errorf("%d: got %q, want %q", int64(amount), got, want)
+		}
+	}
+}
+
+func TestUsageTokensDetails(t *testing.T) {
+	var usage openai.Usage
+	err := json.Unmarshal([]byte(`{"prompt_tokens":2006,"completion_tokens":300,"total_tokens":2306,
+		"prompt_tokens_details":{"cached_tokens":1920,"audio_tokens":0},
+		"completion_tokens_details":{"audio_tokens":0}}`), &usage)
+	checks.NoError(t, err, "Unmarshal error")
+	if usage.PromptTokensDetails == nil || usage.PromptTokensDetails.CachedTokens != 1920 {
+		t.Fatalf("got prompt tokens details %+v", usage.PromptTokensDetails)
+	}
+
+	cost, err := openai.EstimateCost("gpt-4o-mini", usage)
+	checks.NoError(t, err, "EstimateCost error")
+	// 86 tokens at $0.15 and 1920 at $0.075 per 1M tokens.
+	if cost.Input != 157 {
+		t.Errorf("got input cost %s, want $0.000157", cost.Input)
+	}
+}
+
+func TestUsageTrackerCosts(t *testing.T) {
+	tracker := openai.NewUsageTracker()
+	tracker.Pricing = openai.DefaultPricingTable()
+	ctx := context.Background()
+	cached := openai.Usage{
+		PromptTokens:        10000,
+		CompletionTokens:    1000,
+		TotalTokens:         11000,
+		PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 4000},
+	}
+	tracker.Record(ctx, "/chat/completions", "gpt-4o", cached)
+	tracker.Record(ctx, "/chat/completions", "gpt-4o", openai.Usage{PromptTokens: 10000, TotalTokens: 10000})
+	tracker.Record(ctx, "/chat/completions", "my-fine-tuned-model", openai.Usage{PromptTokens: 5, TotalTokens: 5})
+
+	costs := tracker.Costs()
+	if want := (openai.Cost{Input: 45000, Output: 10000, Total: 55000}); costs["gpt-4o"] != want {
+		t.Errorf("got cost %+v, want %+v", costs["gpt-4o"], want)
+	}
+	if _, ok := costs["my-fine-tuned-model"]; ok || len(costs) != 1 {
+		t.Errorf("got costs %v, want only gpt-4o", costs)
+	}
+	if tracker.Snapshot()["my-fine-tuned-model"].TotalTokens != 5 {
+		t.Errorf("got usage %v, want usage of unpriced models too", tracker.Snapshot())
+	}
+
+	if costs := openai.NewUsageTracker().Costs(); len(costs) != 0 {
+		t.Errorf("got costs %v without pricing", costs)
+	}
+}
@@ -0,0 +1,229 @@
+// Package openaiprometheus records Prometheus metrics for go-openai clients. It
+// lives in its own module so that the openai package does not depend on the
+// Prometheus client.
+//
+//	transport := openaiprometheus.NewPrometheusTransport(nil, prometheus.DefaultRegisterer)
+//	defer transport.Close()
+//	config.HTTPClient = &http.Client{Transport: transport}
+package openaiprometheus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zquestz/go-openai"
+)
+
+const namespace = "openai"
+
+// Transport is an http.RoundTripper recording metrics of the API calls sent
+// through it. It must be used by a go-openai client, since it reads the
+// endpoint and model from the request context. It is safe for concurrent use.
+type Transport struct {
+	// Base is the transport used to send requests, http.DefaultTransport if nil.
+	Base http.RoundTripper
+
+	registerer prometheus.Registerer
+	duration   *prometheus.HistogramVec
+	errors     *prometheus.CounterVec
+	tokens     *prometheus.CounterVec
+	rateLimit  *prometheus.GaugeVec
+	closeOnce  sync.Once
+}
+
+// NewPrometheusTransport returns a Transport sending requests with inner and
+// registering its metrics with registerer:
+//
+//   - openai_request_duration_seconds, a histogram of the time until the
+//     response headers arrived, by model and endpoint
+//   - openai_request_errors_total, a counter of failed requests by HTTP status,
+//     "error" for requests that got no response
+//   - openai_tokens_used_total, a counter of tokens by model and type, prompt
+//     or completion
+//   - openai_rate_limit_remaining, a gauge of the remaining rate limit by
+//     resource, requests or tokens
+//
+// Metrics that are already registered, e.g. by another Transport, are shared.
+func NewPrometheusTransport(inner http.RoundTripper, registerer prometheus.Registerer) *Transport {
+	t := &Transport{Base: inner, registerer: registerer}
+	t.duration = register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time until the response headers of OpenAI API requests arrived.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	}, []string{"model", "endpoint"}))
+	t.errors = register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "request_errors_total",
+		Help:      "Failed OpenAI API requests.",
+	}, []string{"status"}))
+	t.tokens = register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_used_total",
+		Help:      "Tokens used by OpenAI API requests.",
+	}, []string{"model", "type"}))
+	t.rateLimit = register(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_remaining",
+		Help:      "Remaining OpenAI API rate limit.",
+	}, []string{"resource"}))
+	return t
+}
+
+// register registers collector, or returns the collector already registered
+// in its place.
+func register[C prometheus.Collector](registerer prometheus.Registerer, collector C) C {
+	err := registerer.Register(collector)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	return collector
+}
+
+// Close unregisters the metrics of the transport, including those shared with
+// other transports.
+func (t *Transport) Close() error {
+	t.closeOnce.Do(func() {
+		t.registerer.Unregister(t.duration)
+		t.registerer.Unregister(t.errors)
+		t.registerer.Unregister(t.tokens)
+		t.registerer.Unregister(t.rateLimit)
+	})
+	return nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	meta, _ := openai.RequestMetaFromContext(req.Context())
+	endpoint := meta.Endpoint
+	if endpoint == "" {
+		endpoint = req.URL.Path
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	t.duration.WithLabelValues(meta.Model, endpoint).Observe(time.Since(start).Seconds())
+	if err != nil {
+		t.errors.WithLabelValues("error").Inc()
+		return resp, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		t.errors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	t.recordRateLimits(resp.Header)
+
+	openai.DecompressResponse(resp)
+	contentType := resp.Header.Get("Content-Type")
+	stream := strings.HasPrefix(contentType, "text/event-stream")
+	if stream || strings.HasPrefix(contentType, "application/json") {
+		resp.Body = &usageBody{ReadCloser: resp.Body, transport: t, model: meta.Model, stream: stream}
+	}
+	return resp, nil
+}
+
+func (t *Transport) recordRateLimits(header http.Header) {
+	for _, resource := range []string{"requests", "tokens"} {
+		remaining, err := strconv.Atoi(header.Get("x-ratelimit-remaining-" + resource))
+		if err == nil {
+			t.rateLimit.WithLabelValues(resource).Set(float64(remaining))
+		}
+	}
+}
+
+// usageBody records the token usage of a response once its body was read,
+// parsing streams line by line.
+type usageBody struct {
+	io.ReadCloser
+	transport *Transport
+	model     string
+	stream    bool
+	buf       bytes.Buffer
+	usage     *usage
+	once      sync.Once
+}
+
+// usage is reported as prompt and completion tokens by the chat and embeddings
+// endpoints, and as input and output tokens by the Responses API.
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if b.stream {
+		b.readLines()
+	}
+	if err != nil {
+		b.record()
+	}
+	return n, err
+}
+
+func (b *usageBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.record()
+	return err
+}
+
+// readLines handles the complete lines of a stream in buf.
+func (b *usageBody) readLines() {
+	for {
+		line, err := b.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial line for the next read.
+			rest := append([]byte(nil), line...)
+			b.buf.Reset()
+			b.buf.Write(rest)
+			return
+		}
+		if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:")); ok {
+			b.parse(bytes.TrimSpace(data))
+		}
+	}
+}
+
+func (b *usageBody) parse(data []byte) {
+	var resp struct {
+		Usage *usage `json:"usage"`
+	}
+	if json.Unmarshal(data, &resp) == nil && resp.Usage != nil {
+		b.usage = resp.Usage
+	}
+}
+
+func (b *usageBody) record() {
+	b.once.Do(func() {
+		if !b.stream {
+			b.parse(b.buf.Bytes())
+		}
+		b.buf = bytes.Buffer{}
+		if b.usage == nil {
+			return
+		}
+		prompt := b.usage.PromptTokens + b.usage.InputTokens
+		completion := b.usage.CompletionTokens + b.usage.OutputTokens
+		b.transport.tokens.WithLabelValues(b.model, "prompt").Add(float64(prompt))
+		if completion > 0 {
+			b.transport.tokens.WithLabelValues(b.model, "completion").Add(float64(completion))
+		}
+	})
+}
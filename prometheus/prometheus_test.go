@@ -0,0 +1,206 @@
+package openaiprometheus_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	openaiprometheus "github.com/zquestz/go-openai/prometheus"
+)
+
+func setupMetricsTestServer(t *testing.T) (
+	client *openai.Client,
+	server *test.ServerTest,
+	registry *prometheus.Registry,
+	transport *openaiprometheus.Transport,
+) {
+	server = test.NewTestServer()
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	registry = prometheus.NewRegistry()
+	transport = openaiprometheus.NewPrometheusTransport(nil, registry)
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	config.HTTPClient = &http.Client{Transport: transport}
+	client = openai.NewClientWithConfig(config)
+	return
+}
+
+var metricsTestRequest = openai.ChatCompletionRequest{
+	Model:    openai.GPT4,
+	Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+}
+
+func checkMetrics(t *testing.T, registry *prometheus.Registry, want string, names ...string) {
+	t.Helper()
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), names...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrometheusTransport(t *testing.T) {
+	client, server, registry, _ := setupMetricsTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ratelimit-remaining-requests", "499")
+		w.Header().Set("x-ratelimit-remaining-tokens", "29000")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4-0613",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(context.Background(), metricsTestRequest)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+
+	checkMetrics(t, registry, `
+# HELP openai_tokens_used_total Tokens used by OpenAI API requests.
+# TYPE openai_tokens_used_total counter
+openai_tokens_used_total{model="gpt-4",type="completion"} 6
+openai_tokens_used_total{model="gpt-4",type="prompt"} 18
+# HELP openai_rate_limit_remaining Remaining OpenAI API rate limit.
+# TYPE openai_rate_limit_remaining gauge
+openai_rate_limit_remaining{resource="requests"} 499
+openai_rate_limit_remaining{resource="tokens"} 29000
+`, "openai_tokens_used_total", "openai_rate_limit_remaining")
+
+	families, err := registry.Gather()
+	checks.NoError(t, err, "Gather error")
+	var observed bool
+	for _, family := range families {
+		if family.GetName() != "openai_request_duration_seconds" {
+			continue
+		}
+		metric := family.GetMetric()[0]
+		labels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		observed = len(family.GetMetric()) == 1 && labels["model"] == openai.GPT4 &&
+			labels["endpoint"] == "/chat/completions" && metric.GetHistogram().GetSampleCount() == 2
+	}
+	if !observed {
+		t.Errorf("request durations were not observed by model and endpoint: %v", families)
+	}
+}
+
+func TestPrometheusTransportStream(t *testing.T) {
+	client, server, registry, _ := setupMetricsTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4",` +
+			`"choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n" +
+			`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4","choices":[],` +
+			`"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}` + "\n\n" +
+			"data: [DONE]\n\n"))
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), metricsTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("stream.Recv returned %v, want io.EOF", err)
+	}
+	stream.Close()
+
+	checkMetrics(t, registry, `
+# HELP openai_tokens_used_total Tokens used by OpenAI API requests.
+# TYPE openai_tokens_used_total counter
+openai_tokens_used_total{model="gpt-4",type="completion"} 1
+openai_tokens_used_total{model="gpt-4",type="prompt"} 5
+`, "openai_tokens_used_total")
+}
+
+func TestPrometheusTransportGzip(t *testing.T) {
+	client, server, registry, _ := setupMetricsTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4-0613",` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`))
+		_ = gz.Close()
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), metricsTestRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	checkMetrics(t, registry, `
+# HELP openai_tokens_used_total Tokens used by OpenAI API requests.
+# TYPE openai_tokens_used_total counter
+openai_tokens_used_total{model="gpt-4",type="completion"} 3
+openai_tokens_used_total{model="gpt-4",type="prompt"} 9
+`, "openai_tokens_used_total")
+}
+
+func TestPrometheusTransportErrors(t *testing.T) {
+	client, server, registry, _ := setupMetricsTestServer(t)
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests"}}`))
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), metricsTestRequest)
+	checks.HasError(t, err, "CreateChatCompletion should fail")
+
+	failing := openai.DefaultConfig("token")
+	failing.HTTPClient = &http.Client{Transport: openaiprometheus.NewPrometheusTransport(
+		roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("boom") }),
+		registry,
+	)}
+	_, err = openai.NewClientWithConfig(failing).ListModels(context.Background())
+	checks.HasError(t, err, "ListModels should fail")
+
+	checkMetrics(t, registry, `
+# HELP openai_request_errors_total Failed OpenAI API requests.
+# TYPE openai_request_errors_total counter
+openai_request_errors_total{status="429"} 1
+openai_request_errors_total{status="error"} 1
+`, "openai_request_errors_total")
+}
+
+func TestPrometheusTransportClose(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	transport := openaiprometheus.NewPrometheusTransport(nil, registry)
+	checks.NoError(t, transport.Close(), "Close error")
+	checks.NoError(t, transport.Close(), "second Close error")
+
+	if count := testutil.CollectAndCount(registry); count != 0 {
+		t.Errorf("got %d metrics after Close, want none", count)
+	}
+
+	// Once unregistered, the metrics can be registered again.
+	second := openaiprometheus.NewPrometheusTransport(nil, registry)
+	defer second.Close()
+	_, err := (&http.Client{Transport: second}).Get("http://127.0.0.1:0")
+	checks.HasError(t, err, "Get should fail")
+	checkMetrics(t, registry, `
+# HELP openai_request_errors_total Failed OpenAI API requests.
+# TYPE openai_request_errors_total counter
+openai_request_errors_total{status="error"} 1
+`, "openai_request_errors_total")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,126 @@
+package openai
+
+import (
+	"bytes"
+	"sync"
+	"text/template"
+)
+
+// PromptTemplate renders prompts from a text/template, e.g.
+// "Summarize this in {{.Language}}:". Referencing a field or key data lacks is
+// an error rather than rendering "<no value>". It is safe for concurrent use.
+type PromptTemplate struct {
+	tmpl *template.Template
+}
+
+var promptBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// NewPromptTemplate parses tmpl as the template name.
+func NewPromptTemplate(name, tmpl string) (*PromptTemplate, error) {
+	return parsePromptTemplate(template.New(name).Option("missingkey=error"), tmpl)
+}
+
+func parsePromptTemplate(t *template.Template, tmpl string) (*PromptTemplate, error) {
+	t, err := t.Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &PromptTemplate{tmpl: t}, nil
+}
+
+// Render executes the template with data.
+func (t *PromptTemplate) Render(data any) (string, error) {
+	buf, _ := promptBuffers.Get().(*bytes.Buffer)
+	defer promptBuffers.Put(buf)
+	buf.Reset()
+	if err := t.tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MustRender is like Render but panics if the template fails, e.g. to set up
+// tests.
+func (t *PromptTemplate) MustRender(data any) string {
+	text, err := t.Render(data)
+	if err != nil {
+		panic(err)
+	}
+	return text
+}
+
+// Message returns a function rendering a text-only message of the given role
+// with data.
+func (t *PromptTemplate) Message(role string) func(data any) (ChatCompletionMessage, error) {
+	return func(data any) (ChatCompletionMessage, error) {
+		text, err := t.Render(data)
+		if err != nil {
+			return ChatCompletionMessage{}, err
+		}
+		return ChatCompletionMessage{Role: role, Content: text}, nil
+	}
+}
+
+// NewSystemMessageTemplate parses tmpl, see NewPromptTemplate, and returns a
+// function rendering system messages from it.
+func NewSystemMessageTemplate(tmpl string) (func(data any) (ChatCompletionMessage, error), error) {
+	return newMessageTemplate(ChatMessageRoleSystem, tmpl)
+}
+
+// NewUserMessageTemplate parses tmpl, see NewPromptTemplate, and returns a
+// function rendering user messages from it.
+func NewUserMessageTemplate(tmpl string) (func(data any) (ChatCompletionMessage, error), error) {
+	return newMessageTemplate(ChatMessageRoleUser, tmpl)
+}
+
+func newMessageTemplate(role, tmpl string) (func(data any) (ChatCompletionMessage, error), error) {
+	t, err := NewPromptTemplate(role, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return t.Message(role), nil
+}
+
+// TemplateRegistry holds named templates, such as shared instructions, that
+// the prompt templates it creates include with {{template "name" .}}. It is
+// safe for concurrent use.
+type TemplateRegistry struct {
+	mu   sync.Mutex
+	root *template.Template
+}
+
+// NewTemplateRegistry returns an empty registry.
+func NewTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{root: template.New("").Option("missingkey=error")}
+}
+
+// Register parses tmpl as the template name, replacing any template of that
+// name. Prompt templates created before do not see it.
+func (r *TemplateRegistry) Register(name, tmpl string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	root, err := r.root.Clone()
+	if err != nil {
+		return err
+	}
+	if _, err = root.New(name).Parse(tmpl); err != nil {
+		return err
+	}
+	r.root = root
+	return nil
+}
+
+// NewPromptTemplate parses tmpl as the template name, which may include the
+// templates registered so far.
+func (r *TemplateRegistry) NewPromptTemplate(name, tmpl string) (*PromptTemplate, error) {
+	r.mu.Lock()
+	root := r.root
+	r.mu.Unlock()
+	// Templates are never parsed again once in r.root, so it can be cloned
+	// without the lock.
+	t, err := root.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return parsePromptTemplate(t.New(name), tmpl)
+}
@@ -0,0 +1,107 @@
+package openai_test
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestPromptTemplate(t *testing.T) {
+	tmpl, err := openai.NewPromptTemplate("summary", "Summarize in {{.Language}}:\n{{.Text}}")
+	checks.NoError(t, err, "NewPromptTemplate error")
+
+	got, err := tmpl.Render(map[string]string{"Language": "French", "Text": "Hello"})
+	checks.NoError(t, err, "Render error")
+	if got != "Summarize in French:\nHello" {
+		t.Errorf("Render = %q", got)
+	}
+	data := struct{ Language, Text string }{"German", "Hi"}
+	if got = tmpl.MustRender(data); got != "Summarize in German:\nHi" {
+		t.Errorf("MustRender = %q", got)
+	}
+
+	// Missing keys fail instead of rendering "<no value>".
+	_, err = tmpl.Render(map[string]string{"Language": "French"})
+	checks.HasError(t, err, "Render should fail without Text")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustRender did not panic")
+			}
+		}()
+		tmpl.MustRender(nil)
+	}()
+
+	_, err = openai.NewPromptTemplate("broken", "{{.Language")
+	checks.HasError(t, err, "NewPromptTemplate should fail on invalid templates")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := tmpl.MustRender(data); got != "Summarize in German:\nHi" {
+				t.Errorf("concurrent MustRender = %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMessageTemplates(t *testing.T) {
+	system, err := openai.NewSystemMessageTemplate("You are a {{.Role}}.")
+	checks.NoError(t, err, "NewSystemMessageTemplate error")
+	user, err := openai.NewUserMessageTemplate("Translate {{printf \"%q\" .Text}}.")
+	checks.NoError(t, err, "NewUserMessageTemplate error")
+
+	message, err := system(map[string]string{"Role": "translator"})
+	checks.NoError(t, err, "system message error")
+	if !reflect.DeepEqual(message, openai.SystemMessage("You are a translator.")) {
+		t.Errorf("got system message %+v", message)
+	}
+	message, err = user(map[string]string{"Text": "hello"})
+	checks.NoError(t, err, "user message error")
+	if !reflect.DeepEqual(message, openai.UserMessage(`Translate "hello".`)) {
+		t.Errorf("got user message %+v", message)
+	}
+	_, err = user(nil)
+	checks.HasError(t, err, "user message should fail without Text")
+
+	_, err = openai.NewUserMessageTemplate("{{")
+	checks.HasError(t, err, "NewUserMessageTemplate should fail on invalid templates")
+}
+
+func TestTemplateRegistry(t *testing.T) {
+	registry := openai.NewTemplateRegistry()
+	checks.NoError(t, registry.Register("tone", "Answer {{.Tone}}."), "Register error")
+	checks.HasError(t, registry.Register("broken", "{{end}}"), "Register should fail on invalid templates")
+
+	tmpl, err := registry.NewPromptTemplate("support", `You help with {{.Product}}. {{template "tone" .}}`)
+	checks.NoError(t, err, "NewPromptTemplate error")
+	data := map[string]string{"Product": "billing", "Tone": "briefly"}
+	if got := tmpl.MustRender(data); got != "You help with billing. Answer briefly." {
+		t.Errorf("Render = %q", got)
+	}
+
+	// Templates registered later are not seen by existing prompt templates.
+	checks.NoError(t, registry.Register("tone", "Answer {{.Tone}}, politely."), "Register error")
+	if got := tmpl.MustRender(data); got != "You help with billing. Answer briefly." {
+		t.Errorf("Render after Register = %q", got)
+	}
+	tmpl, err = registry.NewPromptTemplate("support", `{{template "tone" .}}`)
+	checks.NoError(t, err, "NewPromptTemplate error")
+	if got := tmpl.MustRender(data); got != "Answer briefly, politely." {
+		t.Errorf("Render of the new template = %q", got)
+	}
+
+	tmpl, err = registry.NewPromptTemplate("unknown", `{{template "missing" .}}`)
+	checks.NoError(t, err, "NewPromptTemplate error")
+	_, err = tmpl.Render(data)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Render of an unknown template returned %v", err)
+	}
+}
@@ -3,10 +3,13 @@ package openai
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // RateLimitHeaders struct represents Openai rate limits headers.
+// Headers that are absent, e.g. the limits and resets Azure does not send,
+// are left at their zero value.
 type RateLimitHeaders struct {
 	LimitRequests     int       `json:"x-ratelimit-limit-requests"`
 	LimitTokens       int       `json:"x-ratelimit-limit-tokens"`
@@ -14,6 +17,11 @@ type RateLimitHeaders struct {
 	RemainingTokens   int       `json:"x-ratelimit-remaining-tokens"`
 	ResetRequests     ResetTime `json:"x-ratelimit-reset-requests"`
 	ResetTokens       ResetTime `json:"x-ratelimit-reset-tokens"`
+
+	// ResetRequestsAfter and ResetTokensAfter are ResetRequests and ResetTokens
+	// parsed into the time left until the limit resets.
+	ResetRequestsAfter time.Duration `json:"-"`
+	ResetTokensAfter   time.Duration `json:"-"`
 }
 
 type ResetTime string
@@ -22,9 +30,26 @@ func (r ResetTime) String() string {
 	return string(r)
 }
 
+// Duration parses the reset time into the time left until the limit resets.
+// It accepts Go durations such as "6m0s", "1h30m" or "120ms", and bare numbers
+// as milliseconds. Invalid or empty values yield zero.
+func (r ResetTime) Duration() time.Duration {
+	value := strings.TrimSpace(string(r))
+	if value == "" {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
 func (r ResetTime) Time() time.Time {
-	d, _ := time.ParseDuration(string(r))
-	return time.Now().Add(d)
+	return time.Now().Add(r.Duration())
 }
 
 func newRateLimitHeaders(h http.Header) RateLimitHeaders {
@@ -32,12 +57,16 @@ func newRateLimitHeaders(h http.Header) RateLimitHeaders {
 	limitTokens, _ := strconv.Atoi(h.Get("x-ratelimit-limit-tokens"))
 	remainingReq, _ := strconv.Atoi(h.Get("x-ratelimit-remaining-requests"))
 	remainingTokens, _ := strconv.Atoi(h.Get("x-ratelimit-remaining-tokens"))
+	resetReq := ResetTime(h.Get("x-ratelimit-reset-requests"))
+	resetTokens := ResetTime(h.Get("x-ratelimit-reset-tokens"))
 	return RateLimitHeaders{
-		LimitRequests:     limitReq,
-		LimitTokens:       limitTokens,
-		RemainingRequests: remainingReq,
-		RemainingTokens:   remainingTokens,
-		ResetRequests:     ResetTime(h.Get("x-ratelimit-reset-requests")),
-		ResetTokens:       ResetTime(h.Get("x-ratelimit-reset-tokens")),
+		LimitRequests:      limitReq,
+		LimitTokens:        limitTokens,
+		RemainingRequests:  remainingReq,
+		RemainingTokens:    remainingTokens,
+		ResetRequests:      resetReq,
+		ResetTokens:        resetTokens,
+		ResetRequestsAfter: resetReq.Duration(),
+		ResetTokensAfter:   resetTokens.Duration(),
 	}
 }
@@ -0,0 +1,95 @@
+package openai_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestResetTimeDuration(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"6m0s", 6 * time.Minute},
+		{"120ms", 120 * time.Millisecond},
+		{"1h30m", 90 * time.Minute},
+		{"1.5s", 1500 * time.Millisecond},
+		{"250", 250 * time.Millisecond},
+		{"", 0},
+		{"soon", 0},
+	}
+	for _, tc := range testCases {
+		if got := openai.ResetTime(tc.value).Duration(); got != tc.want {
+			t.Errorf("ResetTime(%q).Duration() = %s, want %s", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestGetRateLimitHeaders(t *testing.T) {
+	testCases := []struct {
+		name    string
+		headers map[string]string
+		want    openai.RateLimitHeaders
+	}{
+		{
+			name: "openai",
+			headers: map[string]string{
+				"x-ratelimit-limit-requests":     "5000",
+				"x-ratelimit-limit-tokens":       "160000",
+				"x-ratelimit-remaining-requests": "4999",
+				"x-ratelimit-remaining-tokens":   "159976",
+				"x-ratelimit-reset-requests":     "12ms",
+				"x-ratelimit-reset-tokens":       "1h30m",
+			},
+			want: openai.RateLimitHeaders{
+				LimitRequests:      5000,
+				LimitTokens:        160000,
+				RemainingRequests:  4999,
+				RemainingTokens:    159976,
+				ResetRequests:      "12ms",
+				ResetTokens:        "1h30m",
+				ResetRequestsAfter: 12 * time.Millisecond,
+				ResetTokensAfter:   90 * time.Minute,
+			},
+		},
+		{
+			name: "azure",
+			headers: map[string]string{
+				"x-ratelimit-remaining-requests": "119",
+				"x-ratelimit-remaining-tokens":   "119968",
+			},
+			want: openai.RateLimitHeaders{
+				RemainingRequests: 119,
+				RemainingTokens:   119968,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server, teardown := setupOpenAITestServer()
+			defer teardown()
+			server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tc.headers {
+					w.Header().Set(k, v)
+				}
+				_, err := w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-3.5-turbo"}`))
+				checks.NoError(t, err, "Write error")
+			})
+
+			resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    openai.GPT3Dot5Turbo,
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+			})
+			checks.NoError(t, err, "CreateChatCompletion error")
+			if got := resp.GetRateLimitHeaders(); got != tc.want {
+				t.Errorf("GetRateLimitHeaders() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ReproducibilityCheck detects changes of the system fingerprint of chat
+// completions, after which requests with the same Seed may no longer get the
+// same responses. It is safe for concurrent use.
+type ReproducibilityCheck struct {
+	// ExpectedFingerprint is the fingerprint responses are compared to. If
+	// empty, the first fingerprint checked is recorded without reporting a
+	// change. Once the check is in use, read it with Fingerprint.
+	ExpectedFingerprint string
+
+	mu sync.Mutex
+}
+
+// Check compares the system fingerprint of resp to the expected one and
+// records it as the new expected fingerprint. It returns whether it changed,
+// along with the previous and the current fingerprint. Responses without
+// fingerprint are ignored.
+func (c *ReproducibilityCheck) Check(resp ChatCompletionResponse) (changed bool, prev, curr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev, curr = c.ExpectedFingerprint, resp.SystemFingerprint
+	if curr == "" {
+		return false, prev, prev
+	}
+	c.ExpectedFingerprint = curr
+	return prev != "" && prev != curr, prev, curr
+}
+
+// Fingerprint returns the expected fingerprint.
+func (c *ReproducibilityCheck) Fingerprint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ExpectedFingerprint
+}
+
+// WithReproducibilityCheck checks the chat completions of the client with
+// check, calling onChanged, if not nil, when the fingerprint changed. Streams
+// are not checked, since their fingerprint comes with the chunks; pass the
+// response of AccumulateStream to Check instead.
+func WithReproducibilityCheck(check *ReproducibilityCheck, onChanged func(prev, curr string)) ClientOption {
+	return WithHooks(Hook{
+		OnResponse: func(_ context.Context, resp *http.Response, meta RequestMeta, err error) error {
+			if err != nil || resp == nil || resp.Body == nil || meta.Stream || meta.Endpoint != chatCompletionsSuffix {
+				return nil
+			}
+			// The body was read by the client already, and is left for the
+			// next hooks.
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			var response ChatCompletionResponse
+			if json.Unmarshal(body, &response) != nil {
+				return nil
+			}
+			if changed, prev, curr := check.Check(response); changed && onChanged != nil {
+				onChanged(prev, curr)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,100 @@
+package openai_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+func TestReproducibilityCheck(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expected    string
+		fingerprint string
+		changed     bool
+		prev, curr  string
+	}{
+		{"first", "", "fp_1", false, "", "fp_1"},
+		{"same", "fp_1", "fp_1", false, "fp_1", "fp_1"},
+		{"changed", "fp_1", "fp_2", true, "fp_1", "fp_2"},
+		{"missing", "fp_1", "", false, "fp_1", "fp_1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := &openai.ReproducibilityCheck{ExpectedFingerprint: tc.expected}
+			changed, prev, curr := check.Check(openai.ChatCompletionResponse{SystemFingerprint: tc.fingerprint})
+			if changed != tc.changed || prev != tc.prev || curr != tc.curr {
+				t.Errorf("Check = %t, %q, %q, want %t, %q, %q", changed, prev, curr, tc.changed, tc.prev, tc.curr)
+			}
+			if got := check.Fingerprint(); got != tc.curr {
+				t.Errorf("Fingerprint = %q, want %q", got, tc.curr)
+			}
+		})
+	}
+}
+
+func TestWithReproducibilityCheck(t *testing.T) {
+	server := openaitest.NewServer(t)
+	for n, fingerprint := range []string{"fp_1", "fp_1", "fp_2"} {
+		server.OnChatCompletion(openai.ChatCompletionResponse{
+			ID:                "chatcmpl-1",
+			SystemFingerprint: fingerprint,
+		}, openaitest.MatchNth(n+1))
+	}
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{{SystemFingerprint: "fp_3"}}, 0)
+	check := &openai.ReproducibilityCheck{}
+	var changes [][2]string
+	client := server.Client(
+		openai.WithReproducibilityCheck(check, func(prev, curr string) {
+			changes = append(changes, [2]string{prev, curr})
+		}),
+		// Later hooks still read the body.
+		openai.WithHooks(openai.Hook{
+			OnResponse: func(_ context.Context, resp *http.Response, meta openai.RequestMeta, _ error) error {
+				if !meta.Stream {
+					body, _ := io.ReadAll(resp.Body)
+					if !strings.Contains(string(body), `"system_fingerprint"`) {
+						t.Errorf("next hook read body %q", body)
+					}
+				}
+				return nil
+			},
+		}),
+	)
+	ctx := context.Background()
+	seed := 42
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Seed:     &seed,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	for i := 0; i < 3; i++ {
+		response, err := client.CreateChatCompletion(ctx, request)
+		checks.NoError(t, err, "CreateChatCompletion error")
+		if response.ID != "chatcmpl-1" {
+			t.Errorf("got response %+v", response)
+		}
+	}
+	if len(changes) != 1 || changes[0] != [2]string{"fp_1", "fp_2"} {
+		t.Errorf("got changes %v, want fp_1 to fp_2", changes)
+	}
+
+	stream, err := client.CreateChatCompletionStream(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	response, err := openai.AccumulateStream(stream)
+	stream.Close()
+	checks.NoError(t, err, "AccumulateStream error")
+	if check.Fingerprint() != "fp_2" {
+		t.Errorf("streams changed the fingerprint to %q", check.Fingerprint())
+	}
+	if changed, _, _ := check.Check(response); !changed || check.Fingerprint() != "fp_3" {
+		t.Errorf("the fingerprint of the accumulated stream was not checked: %q", check.Fingerprint())
+	}
+}
@@ -0,0 +1,163 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses of a client configured with WithCache, by keys
+// such as the ones of ChatCompletionCacheKey. Implementations must be safe for
+// concurrent use, and report their failures as misses.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool)
+	// Set stores value for ttl, or without expiry if ttl is zero or less.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// WithCache sets ClientConfig.Cache and ClientConfig.CacheTTL.
+//
+// CreateChatCompletion and CreateEmbeddings then look up their requests in
+// cache before sending them, and store their successful responses for ttl.
+// Embeddings are always cached. As chat completions are only reproducible with
+// a seed, those without a Seed or with a Temperature are sent unless the
+// context comes from WithForcedCache. Streams are never cached.
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(config *ClientConfig) {
+		config.Cache = cache
+		config.CacheTTL = ttl
+	}
+}
+
+type forcedCacheKey struct{}
+
+// WithForcedCache returns a context making a client configured with WithCache
+// cache chat completions that are not deterministic, e.g. to replay a recorded
+// conversation in tests.
+func WithForcedCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcedCacheKey{}, true)
+}
+
+// ChatCompletionCacheKey returns the key under which a client configured with
+// WithCache caches the response of request. Requests differing only by fields
+// set to their default, e.g. N of 1, by User or by streaming have the same key.
+func ChatCompletionCacheKey(request ChatCompletionRequest) (string, error) {
+	request.User = ""
+	request.Stream = false
+	request.StreamOptions = nil
+	if request.N == 1 {
+		request.N = 0
+	}
+	if request.TopP == 1 {
+		request.TopP = 0
+	}
+	if request.ResponseFormat != nil && request.ResponseFormat.Type == ChatCompletionResponseFormatTypeText {
+		request.ResponseFormat = nil
+	}
+	return cacheKey(chatCompletionsSuffix, request)
+}
+
+// EmbeddingCacheKey returns the key under which a client configured with
+// WithCache caches the response of request, ignoring User and EncodingFormat.
+func EmbeddingCacheKey(request EmbeddingRequest) (string, error) {
+	request.User = ""
+	request.EncodingFormat = ""
+	return cacheKey("/embeddings", request)
+}
+
+// cacheKey hashes the canonical JSON of request: compact, with the keys of all
+// objects sorted, so that json.RawMessage fields or maps in fields of type any
+// hash the same however they are formatted.
+func cacheKey(endpoint string, request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var canonical any
+	if err = decoder.Decode(&canonical); err != nil {
+		return "", err
+	}
+	if data, err = json.Marshal(canonical); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return endpoint + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// chatCompletionCacheKey returns the cache key of request, or false if it must
+// not be cached.
+func (c *Client) chatCompletionCacheKey(ctx context.Context, request ChatCompletionRequest) (string, bool) {
+	if c.config.Cache == nil {
+		return "", false
+	}
+	forced, _ := ctx.Value(forcedCacheKey{}).(bool)
+	if !forced && (request.Seed == nil || request.Temperature > 0) {
+		return "", false
+	}
+	key, err := ChatCompletionCacheKey(request)
+	return key, err == nil
+}
+
+// cachedSend returns the response cached under key, or calls send and caches
+// the response it returns.
+func cachedSend[T any](ctx context.Context, config ClientConfig, key string, send func() (T, error)) (T, error) {
+	if data, ok := config.Cache.Get(ctx, key); ok {
+		var response T
+		if json.Unmarshal(data, &response) == nil {
+			return response, nil
+		}
+	}
+	response, err := send()
+	if err != nil {
+		return response, err
+	}
+	if data, err := json.Marshal(response); err == nil {
+		config.Cache.Set(ctx, key, data, config.CacheTTL)
+	}
+	return response, nil
+}
+
+// LRUCache is an in-memory Cache evicting the least recently used values. It
+// is safe for concurrent use.
+type LRUCache struct {
+	maxEntries int
+	mu         sync.Mutex
+	entries    *lru[string, []byte]
+}
+
+// NewLRUCache creates an LRUCache keeping up to maxEntries values, or any
+// number if maxEntries is zero.
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{maxEntries: maxEntries, entries: newLRU[string, []byte]()}
+}
+
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.get(key)
+}
+
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.add(key, slices.Clone(value), expires, c.maxEntries)
+}
+
+// Len returns the number of values in the cache, including expired ones that
+// were not looked up since.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.list.Len()
+}
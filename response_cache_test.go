@@ -0,0 +1,136 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+func TestWithCache(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{
+		ID:      "chatcmpl-1",
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "Hi"}}},
+		Usage:   openai.Usage{TotalTokens: 5},
+	})
+	server.Handle("/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.25,-0.5],"index":0}]}`))
+	})
+	client := server.Client(openai.WithCache(openai.NewLRUCache(0), time.Minute))
+	ctx := context.Background()
+	seed := 42
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		Seed:     &seed,
+	}
+
+	first, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	request.User = "user-1"
+	request.N = 1
+	cached, err := client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if sent := len(server.Requests()); sent != 1 {
+		t.Errorf("sent %d requests, want the second one cached", sent)
+	}
+	if cached.ID != first.ID || cached.Choices[0].Message.Content != "Hi" || cached.Usage.TotalTokens != 5 {
+		t.Errorf("got cached response %+v, want %+v", cached, first)
+	}
+
+	// Requests that are not reproducible are sent every time, unless forced.
+	noSeed := request
+	noSeed.Seed = nil
+	hot := request
+	hot.Temperature = 0.7
+	for _, r := range []openai.ChatCompletionRequest{noSeed, noSeed, hot, hot} {
+		_, err = client.CreateChatCompletion(ctx, r)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	if sent := len(server.Requests()); sent != 5 {
+		t.Errorf("sent %d requests, want 5", sent)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = client.CreateChatCompletion(openai.WithForcedCache(ctx), hot)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	if sent := len(server.Requests()); sent != 6 {
+		t.Errorf("sent %d requests, want forced requests cached", sent)
+	}
+
+	embeddings := openai.EmbeddingRequest{Input: []string{"Hello"}, Model: openai.AdaEmbeddingV2}
+	for i := 0; i < 2; i++ {
+		response, err := client.CreateEmbeddings(ctx, embeddings)
+		checks.NoError(t, err, "CreateEmbeddings error")
+		if len(response.Data) != 1 || response.Data[0].Embedding[1] != -0.5 {
+			t.Errorf("got embeddings %+v", response.Data)
+		}
+	}
+	if sent := len(server.Requests()); sent != 7 {
+		t.Errorf("sent %d requests, want the second embeddings cached", sent)
+	}
+}
+
+func TestChatCompletionCacheKey(t *testing.T) {
+	request := func(parameters string) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:    openai.GPT4,
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+			Tools: []openai.Tool{{
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionDefinition{Name: "lookup", Parameters: json.RawMessage(parameters)},
+			}},
+		}
+	}
+	key, err := openai.ChatCompletionCacheKey(request(`{"type":"object","properties":{}}`))
+	checks.NoError(t, err, "ChatCompletionCacheKey error")
+
+	same := request(`{ "properties": {}, "type": "object" }`)
+	same.N = 1
+	same.TopP = 1
+	same.Stream = true
+	same.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeText}
+	if sameKey, _ := openai.ChatCompletionCacheKey(same); sameKey != key {
+		t.Errorf("got key %s for an equivalent request, want %s", sameKey, key)
+	}
+
+	different := request(`{"type":"object","properties":{}}`)
+	different.N = 2
+	if differentKey, _ := openai.ChatCompletionCacheKey(different); differentKey == key {
+		t.Error("got the same key for a different request")
+	}
+	embeddingKey, err := openai.EmbeddingCacheKey(openai.EmbeddingRequest{Input: []string{"Hello!"}})
+	checks.NoError(t, err, "EmbeddingCacheKey error")
+	if embeddingKey == key {
+		t.Error("got the same key for an embeddings request")
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := openai.NewLRUCache(2)
+	ctx := context.Background()
+	cache.Set(ctx, "a", []byte("1"), 0)
+	cache.Set(ctx, "b", []byte("2"), 0)
+	if _, ok := cache.Get(ctx, "a"); !ok {
+		t.Fatal("a missing")
+	}
+	cache.Set(ctx, "c", []byte("3"), 0)
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Error("b was not evicted as the least recently used value")
+	}
+	if value, ok := cache.Get(ctx, "a"); !ok || string(value) != "1" || cache.Len() != 2 {
+		t.Errorf("got a = %q, %t with %d values", value, ok, cache.Len())
+	}
+
+	cache.Set(ctx, "d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get(ctx, "d"); ok {
+		t.Error("d did not expire")
+	}
+}
@@ -0,0 +1,68 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/zquestz/go-openai/jsonschema"
+)
+
+// NewJSONSchemaResponseFormat returns a response format making the model answer
+// with the JSON encoding of a T, described by the schema of
+// jsonschema.GenerateSchemaForType. T should be a struct, as schemas must be
+// objects. With strict, every object of the schema requires all its
+// properties, omitempty or not, and forbids others, as structured outputs
+// require.
+//
+// It panics if T cannot be described by a schema, e.g. for recursive types,
+// as this is a programming error.
+func NewJSONSchemaResponseFormat[T any](name string, strict bool) ChatCompletionResponseFormat {
+	format, err := jsonSchemaResponseFormat[T](name, strict)
+	if err != nil {
+		panic(fmt.Sprintf("openai: NewJSONSchemaResponseFormat: %v", err))
+	}
+	return format
+}
+
+func jsonSchemaResponseFormat[T any](name string, strict bool) (ChatCompletionResponseFormat, error) {
+	def, err := jsonschema.SchemaForType[T]()
+	if err != nil {
+		return ChatCompletionResponseFormat{}, err
+	}
+	if strict {
+		strictDefinition(def)
+	}
+	schema, err := json.Marshal(def)
+	if err != nil {
+		return ChatCompletionResponseFormat{}, err
+	}
+	return ChatCompletionResponseFormat{
+		Type: ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &ResponseFormatJSONSchema{
+			Name:   name,
+			Schema: schema,
+			Strict: &strict,
+		},
+	}, nil
+}
+
+// strictDefinition makes the objects of def require all their properties and
+// forbid others.
+func strictDefinition(def *jsonschema.Definition) {
+	if def.Type == jsonschema.Object && def.AdditionalProperties == nil {
+		def.AdditionalProperties = false
+		def.Required = make([]string, 0, len(def.Properties))
+		for name := range def.Properties {
+			def.Required = append(def.Required, name)
+		}
+		slices.Sort(def.Required)
+	}
+	for name, property := range def.Properties {
+		strictDefinition(&property)
+		def.Properties[name] = property
+	}
+	if def.Items != nil {
+		strictDefinition(def.Items)
+	}
+}
@@ -0,0 +1,52 @@
+package openai_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+type weatherReport struct {
+	Location string   `json:"location" description:"The city"`
+	Unit     string   `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	Days     []string `json:"days"`
+	Wind     struct {
+		Speed float64 `json:"speed"`
+	} `json:"wind"`
+}
+
+func TestNewJSONSchemaResponseFormat(t *testing.T) {
+	format := openai.NewJSONSchemaResponseFormat[weatherReport]("weather_report", true)
+	if format.Type != openai.ChatCompletionResponseFormatTypeJSONSchema || format.JSONSchema == nil ||
+		format.JSONSchema.Name != "weather_report" || !*format.JSONSchema.Strict {
+		t.Fatalf("NewJSONSchemaResponseFormat() = %+v", format)
+	}
+	// Strict schemas require every property, omitempty or not.
+	def := openai.FunctionDefinition{Name: "weather_report", Parameters: format.JSONSchema.Schema}
+	checks.NoError(t, openai.ValidateStrictSchema(def), "strict schema is invalid")
+
+	data, err := json.Marshal(format)
+	checks.NoError(t, err, "Marshal error")
+	var decoded struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Name   string `json:"name"`
+			Strict bool   `json:"strict"`
+			Schema struct {
+				Required []string `json:"required"`
+			} `json:"schema"`
+		} `json:"json_schema"`
+	}
+	checks.NoError(t, json.Unmarshal(data, &decoded), "Unmarshal error")
+	if decoded.Type != "json_schema" || decoded.JSONSchema.Name != "weather_report" || !decoded.JSONSchema.Strict ||
+		len(decoded.JSONSchema.Schema.Required) != 4 {
+		t.Errorf("format marshaled as %s", data)
+	}
+
+	lax := openai.NewJSONSchemaResponseFormat[weatherReport]("weather_report", false)
+	if err = openai.ValidateStrictSchema(openai.FunctionDefinition{Parameters: lax.JSONSchema.Schema}); err == nil {
+		t.Error("schema that is not strict passed ValidateStrictSchema")
+	}
+}
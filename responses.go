@@ -0,0 +1,206 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const responsesSuffix = "/responses"
+
+var (
+	ErrResponseStreamNotSupported = errors.New("streaming is not supported with this method, please use CreateResponseStream") //nolint:lll
+)
+
+type ResponseToolType string
+
+const (
+	ResponseToolTypeFunction   ResponseToolType = "function"
+	ResponseToolTypeFileSearch ResponseToolType = "file_search"
+	ResponseToolTypeWebSearch  ResponseToolType = "web_search_preview"
+)
+
+// ResponseTool is a tool the model may call while generating a response.
+// Unlike Tool, function tools are not nested under a "function" key.
+type ResponseTool struct {
+	Type        ResponseToolType `json:"type"`
+	Name        string           `json:"name,omitempty"`
+	Description string           `json:"description,omitempty"`
+	// Parameters is an object describing the function, see FunctionDefinition.Parameters.
+	Parameters any  `json:"parameters,omitempty"`
+	Strict     bool `json:"strict,omitempty"`
+	// VectorStoreIDs is only used by file_search tools.
+	VectorStoreIDs []string `json:"vector_store_ids,omitempty"`
+}
+
+// ResponseInputMessage is a message passed as part of ResponseRequest.Input.
+type ResponseInputMessage struct {
+	Type    ResponseOutputItemType `json:"type,omitempty"`
+	Role    string                 `json:"role"`
+	Content string                 `json:"content"`
+}
+
+// ResponseRequest represents a request structure for the responses API.
+type ResponseRequest struct {
+	Model string `json:"model"`
+	// Input can be either a string or a slice of items, e.g. ResponseInputMessage
+	// values or ResponseOutputItem values returned by a previous response.
+	Input           any            `json:"input"`
+	Instructions    string         `json:"instructions,omitempty"`
+	MaxOutputTokens int            `json:"max_output_tokens,omitempty"`
+	Tools           []ResponseTool `json:"tools,omitempty"`
+	// This can be either a string or a ToolChoice object.
+	ToolChoice any  `json:"tool_choice,omitempty"`
+	Stream     bool `json:"stream,omitempty"`
+}
+
+type ResponseStatus string
+
+const (
+	ResponseStatusCompleted  ResponseStatus = "completed"
+	ResponseStatusFailed     ResponseStatus = "failed"
+	ResponseStatusInProgress ResponseStatus = "in_progress"
+	ResponseStatusIncomplete ResponseStatus = "incomplete"
+)
+
+type ResponseOutputItemType string
+
+const (
+	ResponseOutputItemTypeMessage            ResponseOutputItemType = "message"
+	ResponseOutputItemTypeFunctionCall       ResponseOutputItemType = "function_call"
+	ResponseOutputItemTypeFunctionCallOutput ResponseOutputItemType = "function_call_output"
+	ResponseOutputItemTypeReasoning          ResponseOutputItemType = "reasoning"
+	ResponseOutputItemTypeFileSearchCall     ResponseOutputItemType = "file_search_call"
+)
+
+// ResponseOutputItem is one item of Response.Output. Type tells which of the
+// optional fields are set.
+type ResponseOutputItem struct {
+	Type   ResponseOutputItemType `json:"type"`
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+
+	// message
+	Role    string                  `json:"role,omitempty"`
+	Content []ResponseOutputContent `json:"content,omitempty"`
+
+	// function_call and function_call_output
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+
+	// reasoning
+	Summary []ResponseReasoningSummary `json:"summary,omitempty"`
+
+	// file_search_call
+	Queries []string                   `json:"queries,omitempty"`
+	Results []ResponseFileSearchResult `json:"results,omitempty"`
+}
+
+type ResponseOutputContentType string
+
+const (
+	ResponseOutputContentTypeText    ResponseOutputContentType = "output_text"
+	ResponseOutputContentTypeRefusal ResponseOutputContentType = "refusal"
+)
+
+// ResponseOutputContent is a part of a message output item.
+type ResponseOutputContent struct {
+	Type        ResponseOutputContentType `json:"type"`
+	Text        string                    `json:"text,omitempty"`
+	Refusal     string                    `json:"refusal,omitempty"`
+	Annotations []ResponseAnnotation      `json:"annotations,omitempty"`
+}
+
+// ResponseAnnotation is a citation attached to output text.
+type ResponseAnnotation struct {
+	Type       string `json:"type"`
+	Index      int    `json:"index,omitempty"`
+	FileID     string `json:"file_id,omitempty"`
+	Filename   string `json:"filename,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Title      string `json:"title,omitempty"`
+	StartIndex int    `json:"start_index,omitempty"`
+	EndIndex   int    `json:"end_index,omitempty"`
+}
+
+// ResponseReasoningSummary is a summary of the model's reasoning.
+type ResponseReasoningSummary struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ResponseFileSearchResult is a file found by a file_search tool call.
+type ResponseFileSearchResult struct {
+	FileID   string  `json:"file_id"`
+	Filename string  `json:"filename"`
+	Score    float64 `json:"score"`
+	Text     string  `json:"text"`
+}
+
+// ResponseUsage represents the token usage of a response.
+type ResponseUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+	// InputTokensDetails and OutputTokensDetails break the token counts down
+	// further, like their Usage counterparts. They are nil if the API does not
+	// send them.
+	InputTokensDetails  *PromptTokensDetails     `json:"input_tokens_details,omitempty"`
+	OutputTokensDetails *CompletionTokensDetails `json:"output_tokens_details,omitempty"`
+}
+
+// ResponseError describes why a response failed.
+type ResponseError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Response represents a response structure for the responses API.
+type Response struct {
+	ID           string               `json:"id"`
+	Object       string               `json:"object"`
+	CreatedAt    int64                `json:"created_at"`
+	Status       ResponseStatus       `json:"status"`
+	Model        string               `json:"model"`
+	Instructions string               `json:"instructions,omitempty"`
+	Output       []ResponseOutputItem `json:"output"`
+	Usage        ResponseUsage        `json:"usage"`
+	Error        *ResponseError       `json:"error,omitempty"`
+
+	httpHeader
+}
+
+// OutputText returns the concatenated text of all message output items.
+func (r *Response) OutputText() string {
+	var sb strings.Builder
+	for _, item := range r.Output {
+		if item.Type != ResponseOutputItemTypeMessage {
+			continue
+		}
+		for _, content := range item.Content {
+			if content.Type == ResponseOutputContentTypeText {
+				sb.WriteString(content.Text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// CreateResponse — API call to create a model response.
+func (c *Client) CreateResponse(ctx context.Context, request ResponseRequest) (response Response, err error) {
+	if request.Stream {
+		err = ErrResponseStreamNotSupported
+		return
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(responsesSuffix, request.Model), withBody(request))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
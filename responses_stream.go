@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Stream event types of the responses API.
+const (
+	ResponseStreamEventCreated               = "response.created"
+	ResponseStreamEventInProgress            = "response.in_progress"
+	ResponseStreamEventCompleted             = "response.completed"
+	ResponseStreamEventFailed                = "response.failed"
+	ResponseStreamEventIncomplete            = "response.incomplete"
+	ResponseStreamEventOutputItemAdded       = "response.output_item.added"
+	ResponseStreamEventOutputItemDone        = "response.output_item.done"
+	ResponseStreamEventOutputTextDelta       = "response.output_text.delta"
+	ResponseStreamEventOutputTextDone        = "response.output_text.done"
+	ResponseStreamEventFunctionCallArgsDelta = "response.function_call_arguments.delta"
+	ResponseStreamEventFunctionCallArgsDone  = "response.function_call_arguments.done"
+	ResponseStreamEventReasoningSummaryDelta = "response.reasoning_summary_text.delta"
+	ResponseStreamEventError                 = "error"
+)
+
+// ResponseStreamEvent is a server-sent event of a response stream. Type tells
+// which of the optional fields are set.
+type ResponseStreamEvent struct {
+	Type           string `json:"type"`
+	SequenceNumber int    `json:"sequence_number"`
+
+	// Response is set for response.created, response.in_progress and the
+	// terminal response.completed, response.failed and response.incomplete events.
+	Response *Response `json:"response,omitempty"`
+
+	OutputIndex  int                 `json:"output_index"`
+	ContentIndex int                 `json:"content_index"`
+	ItemID       string              `json:"item_id,omitempty"`
+	Item         *ResponseOutputItem `json:"item,omitempty"`
+
+	// Delta is set for *.delta events, Text and Arguments for the matching *.done events.
+	Delta     string `json:"delta,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+
+	// Code and Message are set for error events.
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type ResponseStream struct {
+	*streamReader[ResponseStreamEvent]
+}
+
+// NewResponseStream returns a stream reading the server-sent events of body,
+// see NewChatCompletionStream.
+func NewResponseStream(body io.ReadCloser) *ResponseStream {
+	return &ResponseStream{streamReader: newStreamReader[ResponseStreamEvent](body)}
+}
+
+// CreateResponseStream — API call to create a model response w/ streaming
+// support. Events are sent as server-sent events until the response
+// reaches a terminal state.
+func (c *Client) CreateResponseStream(
+	ctx context.Context,
+	request ResponseRequest,
+) (stream *ResponseStream, err error) {
+	request.Stream = true
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(responsesSuffix, request.Model), withBody(request))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := sendRequestStream[ResponseStreamEvent](c, req)
+	if err != nil {
+		return
+	}
+	stream = &ResponseStream{
+		streamReader: resp,
+	}
+	return
+}
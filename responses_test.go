@@ -0,0 +1,155 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestCreateResponseWithStream(t *testing.T) {
+	config := openai.DefaultConfig("whatever")
+	config.BaseURL = "http://localhost/v1"
+	client := openai.NewClientWithConfig(config)
+
+	_, err := client.CreateResponse(context.Background(), openai.ResponseRequest{Stream: true})
+	checks.ErrorIs(t, err, openai.ErrResponseStreamNotSupported, "unexpected error")
+}
+
+func TestCreateResponse(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/responses", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ResponseRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		if request.Instructions != "Be brief." || request.MaxOutputTokens != 64 || request.Tools[0].Name != "get_weather" {
+			t.Errorf("unexpected request: %+v", request)
+		}
+
+		_, err = w.Write([]byte(`{
+			"id": "resp_1",
+			"object": "response",
+			"created_at": 1741476542,
+			"status": "completed",
+			"model": "gpt-4o",
+			"output": [
+				{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "Thinking."}]},
+				{"type": "file_search_call", "id": "fs_1", "status": "completed", "queries": ["weather"],
+					"results": [{"file_id": "file-1", "filename": "a.txt", "score": 0.9, "text": "sunny"}]},
+				{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "get_weather",
+					"arguments": "{\"city\":\"Paris\"}", "status": "completed"},
+				{"type": "function_call_output", "call_id": "call_1", "output": "sunny"},
+				{"type": "message", "id": "msg_1", "status": "completed", "role": "assistant",
+					"content": [{"type": "output_text", "text": "It is ", "annotations": []},
+						{"type": "output_text", "text": "sunny.", "annotations": []}]}
+			],
+			"usage": {"input_tokens": 36, "output_tokens": 87, "total_tokens": 123}
+		}`))
+		checks.NoError(t, err, "Write error")
+	})
+
+	resp, err := client.CreateResponse(context.Background(), openai.ResponseRequest{
+		Model:           "gpt-4o",
+		Input:           "What is the weather in Paris?",
+		Instructions:    "Be brief.",
+		MaxOutputTokens: 64,
+		Tools: []openai.ResponseTool{{
+			Type:       openai.ResponseToolTypeFunction,
+			Name:       "get_weather",
+			Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		}},
+		ToolChoice: "auto",
+	})
+	checks.NoError(t, err, "CreateResponse error")
+
+	if resp.ID != "resp_1" || resp.Status != openai.ResponseStatusCompleted || resp.Usage.TotalTokens != 123 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	wantTypes := []openai.ResponseOutputItemType{
+		openai.ResponseOutputItemTypeReasoning,
+		openai.ResponseOutputItemTypeFileSearchCall,
+		openai.ResponseOutputItemTypeFunctionCall,
+		openai.ResponseOutputItemTypeFunctionCallOutput,
+		openai.ResponseOutputItemTypeMessage,
+	}
+	if len(resp.Output) != len(wantTypes) {
+		t.Fatalf("got %d output items, want %d", len(resp.Output), len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if resp.Output[i].Type != want {
+			t.Errorf("output[%d].Type = %s, want %s", i, resp.Output[i].Type, want)
+		}
+	}
+	if resp.Output[0].Summary[0].Text != "Thinking." ||
+		resp.Output[1].Results[0].FileID != "file-1" ||
+		resp.Output[2].Arguments != `{"city":"Paris"}` ||
+		resp.Output[3].Output != "sunny" {
+		t.Errorf("output items were not decoded: %+v", resp.Output)
+	}
+	if resp.OutputText() != "It is sunny." {
+		t.Errorf("OutputText() = %q", resp.OutputText())
+	}
+}
+
+func TestCreateResponseStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/responses", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ResponseRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		if !request.Stream {
+			t.Errorf("stream was not requested")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1","status":"in_progress"}}`,
+			`{"type":"response.output_text.delta","sequence_number":1,"item_id":"msg_1","delta":"Hi"}`,
+			`{"type":"response.output_text.delta","sequence_number":2,"item_id":"msg_1","delta":" there"}`,
+			`{"type":"response.completed","sequence_number":3,"response":{"id":"resp_1","status":"completed",` +
+				`"usage":{"input_tokens":1,"output_tokens":2,"total_tokens":3}}}`,
+		}
+		for _, data := range events {
+			var event openai.ResponseStreamEvent
+			checks.NoError(t, json.Unmarshal([]byte(data), &event), "fixture error")
+			_, err = w.Write([]byte("event: " + event.Type + "\ndata: " + data + "\n\n"))
+			checks.NoError(t, err, "Write error")
+		}
+	})
+
+	stream, err := client.CreateResponseStream(context.Background(), openai.ResponseRequest{
+		Model: "gpt-4o",
+		Input: []openai.ResponseInputMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateResponseStream error")
+	defer stream.Close()
+
+	var text string
+	var last openai.ResponseStreamEvent
+	for {
+		event, streamErr := stream.Recv()
+		if errors.Is(streamErr, io.EOF) {
+			break
+		}
+		checks.NoError(t, streamErr, "stream.Recv() failed")
+		if event.Type == openai.ResponseStreamEventOutputTextDelta {
+			text += event.Delta
+		}
+		last = event
+	}
+
+	if text != "Hi there" {
+		t.Errorf("streamed text = %q", text)
+	}
+	if last.Type != openai.ResponseStreamEventCompleted || last.Response.Status != openai.ResponseStatusCompleted ||
+		last.Response.Usage.TotalTokens != 3 {
+		t.Errorf("unexpected terminal event: %+v", last)
+	}
+}
@@ -0,0 +1,364 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// RetryAttemptsHeader is set by RetryTransport, and by clients with
+	// ClientConfig.MaxRetries, on the returned response to the number of
+	// attempts that were made.
+	RetryAttemptsHeader = "X-Retry-Attempts"
+
+	defaultRetryMinBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 30 * time.Second
+	// overloadedRetryMinBackoff is the shorter backoff of overloaded models,
+	// whose capacity usually frees up quickly.
+	overloadedRetryMinBackoff = 100 * time.Millisecond
+	// maxRetryErrorBodyBytes is the size of the error bodies read to tell
+	// whether the model was overloaded.
+	maxRetryErrorBodyBytes = 64 << 10
+)
+
+// DefaultRetryableStatusCodes are retried by RetryTransport, and when
+// ClientConfig.MaxRetries is set without RetryableStatusCodes.
+var DefaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryError is returned by RetryTransport when the last of several attempts
+// failed without a response.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("giving up after %d attempts: %s", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryTransport is an http.RoundTripper that retries requests failing with one
+// of the DefaultRetryableStatusCodes, or whose connection was refused or reset
+// before a response arrived. It waits as long as the Retry-After or
+// retry-after-ms headers ask, or backs off exponentially with jitter, starting
+// from at most 100ms for the errors of overloaded models, see
+// APIError.IsOverloaded. Responses are returned as soon as their headers
+// arrive, so streams are never retried once their body is being read.
+//
+// Enable it with the WithRetries client option:
+//
+//	client := openai.NewClientWithConfig(config, openai.WithRetries(3))
+type RetryTransport struct {
+	// Base is the transport used for each attempt, http.DefaultTransport if nil.
+	Base http.RoundTripper
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the exponential backoff. When the server
+	// asks to wait longer than MaxBackoff, its response is returned instead.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type RetryOption func(*RetryTransport)
+
+// WithRetryBase sets the transport used for each attempt.
+func WithRetryBase(base http.RoundTripper) RetryOption {
+	return func(t *RetryTransport) {
+		t.Base = base
+	}
+}
+
+// WithRetryBackoff sets the bounds of the exponential backoff.
+func WithRetryBackoff(minBackoff, maxBackoff time.Duration) RetryOption {
+	return func(t *RetryTransport) {
+		t.MinBackoff = minBackoff
+		t.MaxBackoff = maxBackoff
+	}
+}
+
+// NewRetryTransport creates a RetryTransport making up to maxAttempts attempts.
+func NewRetryTransport(maxAttempts int, opts ...RetryOption) *RetryTransport {
+	t := &RetryTransport{
+		MaxAttempts: maxAttempts,
+		MinBackoff:  defaultRetryMinBackoff,
+		MaxBackoff:  defaultRetryMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// WithRetries makes the client retry failed requests up to maxAttempts attempts
+// in total, by wrapping the transport of ClientConfig.HTTPClient in a
+// RetryTransport.
+func WithRetries(maxAttempts int, opts ...RetryOption) ClientOption {
+	return WithTransportWrapper(func(base http.RoundTripper) http.RoundTripper {
+		return NewRetryTransport(maxAttempts, append([]RetryOption{WithRetryBase(base)}, opts...)...)
+	})
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return retryRequest(req, retryPolicy{
+		maxAttempts: t.MaxAttempts,
+		statusCodes: DefaultRetryableStatusCodes,
+		send:        base.RoundTrip,
+		minBackoff:  t.MinBackoff,
+		maxBackoff:  t.MaxBackoff,
+		retryAfter:  true,
+	})
+}
+
+// retryPolicy configures retryRequest.
+type retryPolicy struct {
+	// maxAttempts is the total number of attempts, including the first one.
+	maxAttempts int
+	statusCodes []int
+	send        func(*http.Request) (*http.Response, error)
+	// minBackoff and maxBackoff bound the exponential backoff.
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	// retryAfter waits as long as the Retry-After headers ask, giving up when
+	// they ask longer than maxBackoff.
+	retryAfter bool
+}
+
+// retryRequest sends req with policy.send, retrying it while it fails with one
+// of policy.statusCodes or before reaching the server. It returns responses as
+// soon as their headers arrive, gives up when the context deadline would pass
+// during the backoff, and sets RetryAttemptsHeader on the response.
+func retryRequest(req *http.Request, policy retryPolicy) (*http.Response, error) {
+	getBody, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		attemptReq.Body, err = getBody()
+		if err != nil {
+			return nil, err
+		}
+
+		var resp *http.Response
+		resp, err = policy.send(attemptReq)
+		if attempt >= policy.maxAttempts || !isRetryableAttempt(ctx, resp, err, policy.statusCodes) {
+			return annotateAttempts(resp, err, attempt)
+		}
+
+		delay, ok := policy.backoff(resp, attempt)
+		if !ok {
+			return annotateAttempts(resp, err, attempt)
+		}
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < delay {
+			return annotateAttempts(resp, err, attempt)
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err = sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// replayableBody returns a function creating a fresh copy of the request body
+// for each attempt, buffering the body if the request cannot do it itself.
+// The original body is closed, as required from a RoundTripper.
+func replayableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return req.Body, nil }, nil
+	}
+	defer req.Body.Close()
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}, nil
+}
+
+func isRetryableAttempt(ctx context.Context, resp *http.Response, err error, statusCodes []int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isConnectionFailure(err)
+	}
+	return slices.Contains(statusCodes, resp.StatusCode)
+}
+
+// isConnectionFailure reports whether err shows that the request cannot have
+// been processed: the connection was refused or failed to dial, or was reset.
+// Other errors, e.g. a timeout waiting for the response headers, may come
+// after the server already acted on the request and are not retried.
+func isConnectionFailure(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// backoff returns how long to wait before the attempt following attempt. It
+// reports false if the server asks to wait longer than maxBackoff.
+func (p retryPolicy) backoff(resp *http.Response, attempt int) (time.Duration, bool) {
+	if p.retryAfter && resp != nil {
+		if delay, ok := retryAfter(resp.Header); ok {
+			return delay, delay <= p.maxBackoff
+		}
+	}
+	minBackoff := p.minBackoff
+	if resp != nil && isOverloadedResponse(resp) {
+		minBackoff = min(minBackoff, overloadedRetryMinBackoff)
+	}
+	return exponentialBackoff(minBackoff, p.maxBackoff, attempt), true
+}
+
+// isOverloadedResponse reports whether resp is the error of an overloaded
+// model. Its body is read, and replaced so that it can still be returned.
+func isOverloadedResponse(resp *http.Response) bool {
+	if resp.Body == nil {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRetryErrorBodyBytes))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+	var errRes ErrorResponse
+	if json.Unmarshal(body, &errRes) != nil || errRes.Error == nil {
+		return false
+	}
+	return errRes.Error.IsOverloaded()
+}
+
+// exponentialBackoff returns the delay before the attempt following attempt,
+// doubling from minBackoff up to maxBackoff.
+func exponentialBackoff(minBackoff, maxBackoff time.Duration, attempt int) time.Duration {
+	delay := minBackoff << (attempt - 1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	// Equal jitter in [delay/2, delay) spreads out clients failing together.
+	half := int64(delay / 2) //nolint:gomnd // half of the delay
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half)) //nolint:gosec // jitter does not need a secure source
+}
+
+// retryAfter parses the retry-after-ms header sent by OpenAI and the standard
+// Retry-After header, in seconds or as an HTTP date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func annotateAttempts(resp *http.Response, err error, attempts int) (*http.Response, error) {
+	if err != nil {
+		if attempts > 1 {
+			err = &RetryError{Attempts: attempts, Err: err}
+		}
+		return resp, err
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set(RetryAttemptsHeader, strconv.Itoa(attempts))
+	return resp, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// do sends req, retrying it up to ClientConfig.MaxRetries times like
+// RetryTransport does, while it fails with one of the RetryableStatusCodes or
+// before reaching the server.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.config.MaxRetries <= 0 {
+		return c.doAttempt(req)
+	}
+	statusCodes := c.config.RetryableStatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = DefaultRetryableStatusCodes
+	}
+	return retryRequest(req, retryPolicy{
+		maxAttempts: c.config.MaxRetries + 1,
+		statusCodes: statusCodes,
+		send:        c.doAttempt,
+		minBackoff:  defaultRetryMinBackoff,
+		maxBackoff:  defaultRetryMaxBackoff,
+		retryAfter:  c.config.RetryAfterHeader,
+	})
+}
+
+// RetryAttempts returns the number of attempts RetryTransport, or a client with
+// ClientConfig.MaxRetries, made to get the response, or zero if it was not
+// retried by either.
+func (h *httpHeader) RetryAttempts() int {
+	attempts, err := strconv.Atoi(h.Header().Get(RetryAttemptsHeader))
+	if err != nil {
+		return 0
+	}
+	return attempts
+}
@@ -0,0 +1,558 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+type fakeAttempt struct {
+	status int
+	header http.Header
+	body   string
+	err    error
+}
+
+// fakeRetryTransport replays the scripted attempts and records the bodies
+// it was sent. The last attempt is repeated once the script runs out.
+type fakeRetryTransport struct {
+	attempts []fakeAttempt
+	bodies   []string
+}
+
+func (f *fakeRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		body = string(b)
+	}
+	f.bodies = append(f.bodies, body)
+
+	attempt := f.attempts[min(len(f.bodies), len(f.attempts))-1]
+	if attempt.err != nil {
+		return nil, attempt.err
+	}
+	header := attempt.header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: attempt.status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(attempt.body)),
+		Request:    req,
+	}, nil
+}
+
+const (
+	retryTestOK        = `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-3.5-turbo"}`
+	retryTestRateLimit = `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`
+	retryTestServerErr = `{"error":{"message":"The server had an error","type":"server_error"}}`
+)
+
+func newRetryTestClient(fake *fakeRetryTransport, maxAttempts int, opts ...openai.RetryOption) *openai.Client {
+	config := openai.DefaultConfig("whatever")
+	config.BaseURL = "http://localhost/v1"
+	config.HTTPClient = &http.Client{Transport: fake}
+	opts = append([]openai.RetryOption{openai.WithRetryBackoff(time.Millisecond, 5*time.Millisecond)}, opts...)
+	return openai.NewClientWithConfig(config, openai.WithRetries(maxAttempts, opts...))
+}
+
+var retryTestRequest = openai.ChatCompletionRequest{
+	Model: openai.GPT3Dot5Turbo,
+	Messages: []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: "Hello!",
+		},
+	},
+}
+
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name         string
+		maxAttempts  int
+		attempts     []fakeAttempt
+		wantRequests int
+		wantStatus   int
+		wantErr      error
+	}{
+		{
+			name:        "retries server errors",
+			maxAttempts: 3,
+			attempts: []fakeAttempt{
+				{status: http.StatusServiceUnavailable, body: retryTestServerErr},
+				{status: http.StatusBadGateway, body: retryTestServerErr},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 3,
+		},
+		{
+			name:        "retries the default status codes like MaxRetries",
+			maxAttempts: 2,
+			attempts: []fakeAttempt{
+				{status: http.StatusGatewayTimeout, body: retryTestServerErr},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 2,
+		},
+		{
+			name:        "retries reset connections",
+			maxAttempts: 2,
+			attempts: []fakeAttempt{
+				{err: syscall.ECONNRESET},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 2,
+		},
+		{
+			name:        "retries failed dials",
+			maxAttempts: 2,
+			attempts: []fakeAttempt{
+				{err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 2,
+		},
+		{
+			name:         "gives up on rate limits",
+			maxAttempts:  2,
+			attempts:     []fakeAttempt{{status: http.StatusTooManyRequests, body: retryTestRateLimit}},
+			wantRequests: 2,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:         "gives up on reset connections",
+			maxAttempts:  3,
+			attempts:     []fakeAttempt{{err: syscall.ECONNRESET}},
+			wantRequests: 3,
+			wantErr:      syscall.ECONNRESET,
+		},
+		{
+			name:         "does not retry once the request may have been processed",
+			maxAttempts:  3,
+			attempts:     []fakeAttempt{{err: io.ErrUnexpectedEOF}},
+			wantRequests: 1,
+			wantErr:      io.ErrUnexpectedEOF,
+		},
+		{
+			name:        "does not retry client errors",
+			maxAttempts: 3,
+			attempts: []fakeAttempt{
+				{status: http.StatusBadRequest, body: retryTestServerErr},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeRetryTransport{attempts: tc.attempts}
+			client := newRetryTestClient(fake, tc.maxAttempts)
+
+			resp, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+			if len(fake.bodies) != tc.wantRequests {
+				t.Fatalf("sent %d requests, want %d", len(fake.bodies), tc.wantRequests)
+			}
+			for i, body := range fake.bodies {
+				if body != fake.bodies[0] || body == "" {
+					t.Errorf("request %d was sent with body %q, want %q", i, body, fake.bodies[0])
+				}
+			}
+
+			switch {
+			case tc.wantErr != nil:
+				var retryErr *openai.RetryError
+				if tc.wantRequests > 1 && (!errors.As(err, &retryErr) || retryErr.Attempts != tc.wantRequests) {
+					t.Fatalf("expected RetryError after %d attempts, got %v", tc.wantRequests, err)
+				}
+				checks.ErrorIs(t, err, tc.wantErr, "error does not wrap the last error")
+			case tc.wantStatus != 0:
+				var apiErr *openai.APIError
+				if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != tc.wantStatus {
+					t.Fatalf("expected APIError with status %d, got %v", tc.wantStatus, err)
+				}
+			default:
+				checks.NoError(t, err, "CreateChatCompletion error")
+				if resp.RetryAttempts() != tc.wantRequests {
+					t.Errorf("RetryAttempts() = %d, want %d", resp.RetryAttempts(), tc.wantRequests)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After-Ms": []string{"50"}},
+			body:   retryTestRateLimit,
+		},
+		{
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After": []string{"0.05"}},
+			body:   retryTestRateLimit,
+		},
+		{status: http.StatusOK, body: retryTestOK},
+	}}
+	client := newRetryTestClient(fake, 3, openai.WithRetryBackoff(time.Millisecond, time.Second))
+
+	start := time.Now()
+	_, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("retried after %s, want at least 100ms", elapsed)
+	}
+}
+
+func TestRetryTransportRespectsDeadline(t *testing.T) {
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After": []string{"10"}},
+			body:   retryTestRateLimit,
+		},
+		{status: http.StatusOK, body: retryTestOK},
+	}}
+	client := newRetryTestClient(fake, 3, openai.WithRetryBackoff(time.Millisecond, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := client.CreateChatCompletion(ctx, retryTestRequest)
+
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the rate limit error, got %v", err)
+	}
+	if len(fake.bodies) != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("should not wait past the context deadline")
+	}
+}
+
+func TestRetryTransportCapsRetryAfter(t *testing.T) {
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After": []string{"3600"}},
+			body:   retryTestRateLimit,
+		},
+		{status: http.StatusOK, body: retryTestOK},
+	}}
+	client := newRetryTestClient(fake, 3, openai.WithRetryBackoff(time.Millisecond, time.Second))
+
+	_, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+	if !openai.IsRateLimitError(err) {
+		t.Fatalf("expected the rate limit error, got %v", err)
+	}
+	if len(fake.bodies) != 1 {
+		t.Errorf("sent %d requests, want 1 when Retry-After exceeds MaxBackoff", len(fake.bodies))
+	}
+}
+
+type unreplayableBody struct {
+	io.Reader
+}
+
+func TestRetryTransportBuffersBody(t *testing.T) {
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{status: http.StatusInternalServerError, body: retryTestServerErr},
+		{status: http.StatusOK, body: retryTestOK},
+	}}
+	transport := openai.NewRetryTransport(2,
+		openai.WithRetryBase(fake),
+		openai.WithRetryBackoff(time.Millisecond, time.Millisecond),
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost/v1/files",
+		unreplayableBody{strings.NewReader("payload")})
+	checks.NoError(t, err, "NewRequest error")
+	resp, err := transport.RoundTrip(req)
+	checks.NoError(t, err, "RoundTrip error")
+	resp.Body.Close()
+
+	if len(fake.bodies) != 2 || fake.bodies[0] != "payload" || fake.bodies[1] != "payload" {
+		t.Errorf("body was not replayed: %q", fake.bodies)
+	}
+	if resp.Header.Get(openai.RetryAttemptsHeader) != "2" {
+		t.Errorf("attempts header = %q, want 2", resp.Header.Get(openai.RetryAttemptsHeader))
+	}
+}
+
+func TestRetryTransportStream(t *testing.T) {
+	//nolint:lll
+	stream := `data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"hi"}}]}` +
+		"\n\ndata: [DONE]\n\n"
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{status: http.StatusServiceUnavailable, body: retryTestServerErr},
+		{status: http.StatusOK, body: stream},
+	}}
+	client := newRetryTestClient(fake, 2)
+
+	s, err := client.CreateChatCompletionStream(context.Background(), retryTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer s.Close()
+
+	resp, err := s.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	if resp.Choices[0].Delta.Content != "hi" || s.RetryAttempts() != 2 {
+		t.Errorf("unexpected stream response %+v after %d attempts", resp, s.RetryAttempts())
+	}
+}
+
+func TestRetryTransportRateLimitedStream(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnRateLimit("/chat/completions", 10*time.Millisecond, openaitest.MatchNth(1))
+	server.OnRateLimit("/chat/completions", 10*time.Millisecond, openaitest.MatchNth(2))
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{
+		{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "hi"}}}},
+	}, 0)
+	client := server.Client(openai.WithRetries(3, openai.WithRetryBackoff(time.Millisecond, time.Second)))
+
+	start := time.Now()
+	s, err := client.CreateChatCompletionStream(context.Background(), retryTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer s.Close()
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("retried after %s, want Retry-After honored", elapsed)
+	}
+
+	resp, err := s.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	if resp.Choices[0].Delta.Content != "hi" || s.RetryAttempts() != 3 || len(server.Requests()) != 3 {
+		t.Errorf("unexpected stream response %+v after %d attempts", resp, s.RetryAttempts())
+	}
+}
+
+func TestClientMaxRetries(t *testing.T) {
+	retryNow := http.Header{"Retry-After-Ms": []string{"1"}}
+	retryLater := http.Header{"Retry-After": []string{"3600"}}
+	testCases := []struct {
+		name         string
+		maxRetries   int
+		statusCodes  []int
+		retryAfter   bool
+		attempts     []fakeAttempt
+		wantRequests int
+		wantStatus   int
+	}{
+		{
+			name:       "retries the default status codes",
+			maxRetries: 2,
+			retryAfter: true,
+			attempts: []fakeAttempt{
+				{status: http.StatusGatewayTimeout, header: retryNow, body: retryTestServerErr},
+				{status: http.StatusTooManyRequests, header: retryNow, body: retryTestRateLimit},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 3,
+		},
+		{
+			name:       "retries failed dials",
+			maxRetries: 1,
+			attempts: []fakeAttempt{
+				{err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 2,
+		},
+		{
+			name:         "gives up after max retries",
+			maxRetries:   2,
+			retryAfter:   true,
+			attempts:     []fakeAttempt{{status: http.StatusTooManyRequests, header: retryNow, body: retryTestRateLimit}},
+			wantRequests: 3,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:        "retries the configured status codes only",
+			maxRetries:  2,
+			statusCodes: []int{http.StatusConflict},
+			retryAfter:  true,
+			attempts: []fakeAttempt{
+				{status: http.StatusConflict, header: retryNow, body: retryTestServerErr},
+				{status: http.StatusServiceUnavailable, header: retryNow, body: retryTestServerErr},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 2,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name: "does not retry by default",
+			attempts: []fakeAttempt{
+				{status: http.StatusServiceUnavailable, header: retryNow, body: retryTestServerErr},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:       "returns the response when Retry-After is too long",
+			maxRetries: 1,
+			retryAfter: true,
+			attempts: []fakeAttempt{
+				{status: http.StatusTooManyRequests, header: retryLater, body: retryTestRateLimit},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 1,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:       "ignores Retry-After unless enabled",
+			maxRetries: 1,
+			attempts: []fakeAttempt{
+				{status: http.StatusTooManyRequests, header: retryLater, body: retryTestRateLimit},
+				{status: http.StatusOK, body: retryTestOK},
+			},
+			wantRequests: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeRetryTransport{attempts: tc.attempts}
+			config := openai.DefaultConfig("whatever")
+			config.BaseURL = "http://localhost/v1"
+			config.HTTPClient = &http.Client{Transport: fake}
+			config.MaxRetries = tc.maxRetries
+			config.RetryableStatusCodes = tc.statusCodes
+			config.RetryAfterHeader = tc.retryAfter
+			client := openai.NewClientWithConfig(config)
+
+			resp, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+			if len(fake.bodies) != tc.wantRequests {
+				t.Fatalf("sent %d requests, want %d", len(fake.bodies), tc.wantRequests)
+			}
+			for i, body := range fake.bodies {
+				if body != fake.bodies[0] || body == "" {
+					t.Errorf("request %d was sent with body %q, want %q", i, body, fake.bodies[0])
+				}
+			}
+
+			if tc.wantStatus != 0 {
+				var apiErr *openai.APIError
+				if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != tc.wantStatus {
+					t.Fatalf("expected APIError with status %d, got %v", tc.wantStatus, err)
+				}
+				return
+			}
+			checks.NoError(t, err, "CreateChatCompletion error")
+			if resp.RetryAttempts() != tc.wantRequests {
+				t.Errorf("RetryAttempts() = %d, want %d", resp.RetryAttempts(), tc.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClientMaxRetriesStream(t *testing.T) {
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{
+			status: http.StatusServiceUnavailable,
+			header: http.Header{"Retry-After-Ms": []string{"1"}},
+			body:   retryTestServerErr,
+		},
+		{
+			status: http.StatusOK,
+			header: http.Header{"Content-Type": []string{"text/event-stream"}},
+			body:   "data: " + retryTestOK + "\n\ndata: [DONE]\n\n",
+		},
+	}}
+	config := openai.DefaultConfig("whatever")
+	config.BaseURL = "http://localhost/v1"
+	config.HTTPClient = &http.Client{Transport: fake}
+	config.MaxRetries = 1
+	config.RetryAfterHeader = true
+	client := openai.NewClientWithConfig(config)
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), retryTestRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	if len(fake.bodies) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(fake.bodies))
+	}
+	if _, err = stream.Recv(); err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+}
+
+func TestClientMaxRetriesHTMLError(t *testing.T) {
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{
+		{
+			status: http.StatusServiceUnavailable,
+			header: http.Header{"Content-Type": []string{"text/html"}, "Retry-After-Ms": []string{"1"}},
+			body:   "<html><body><h1>503 Service Temporarily Unavailable</h1></body></html>",
+		},
+		{status: http.StatusOK, body: retryTestOK},
+	}}
+	config := openai.DefaultConfig("whatever")
+	config.BaseURL = "http://localhost/v1"
+	config.HTTPClient = &http.Client{Transport: fake}
+	config.MaxRetries = 1
+	config.RetryAfterHeader = true
+	client := openai.NewClientWithConfig(config)
+
+	resp, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.RetryAttempts() != 2 {
+		t.Errorf("RetryAttempts() = %d, want 2", resp.RetryAttempts())
+	}
+}
+
+func TestClientMaxRetriesOverloaded(t *testing.T) {
+	const overloaded = `{"error":{"message":"The engine is currently overloaded, please try again later",` +
+		`"type":"server_error"}}`
+	for _, tc := range []struct {
+		name  string
+		body  string
+		check func(elapsed time.Duration) bool
+	}{
+		// The first retry waits from 50 to 100ms, instead of 250 to 500ms.
+		{"overloaded", overloaded, func(elapsed time.Duration) bool { return elapsed < 200*time.Millisecond }},
+		{"server error", retryTestServerErr, func(elapsed time.Duration) bool { return elapsed >= 250*time.Millisecond }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeRetryTransport{attempts: []fakeAttempt{
+				{status: http.StatusServiceUnavailable, body: tc.body},
+				{status: http.StatusOK, body: retryTestOK},
+			}}
+			config := openai.DefaultConfig("whatever")
+			config.BaseURL = "http://localhost/v1"
+			config.HTTPClient = &http.Client{Transport: fake}
+			config.MaxRetries = 1
+			client := openai.NewClientWithConfig(config)
+
+			start := time.Now()
+			_, err := client.CreateChatCompletion(context.Background(), retryTestRequest)
+			checks.NoError(t, err, "CreateChatCompletion error")
+			if elapsed := time.Since(start); !tc.check(elapsed) {
+				t.Errorf("retried after %v", elapsed)
+			}
+		})
+	}
+
+	// The body read to tell is still returned when the deadline prevents the
+	// retry.
+	fake := &fakeRetryTransport{attempts: []fakeAttempt{{status: http.StatusServiceUnavailable, body: overloaded}}}
+	client := newRetryTestClient(fake, 2, openai.WithRetryBackoff(time.Second, 2*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.CreateChatCompletion(ctx, retryTestRequest)
+	checks.ErrorIs(t, err, openai.ErrModelOverloaded, "CreateChatCompletion should fail with the overloaded error")
+}
@@ -0,0 +1,56 @@
+package openai
+
+import (
+	"net/url"
+)
+
+// ModelRouter returns the backend serving model at the endpoint
+// endpointSuffix, e.g. "/chat/completions". Empty return values fall back to
+// the client config.
+type ModelRouter func(model, endpointSuffix string) (baseURL string, apiType APIType, authToken string)
+
+// WithModelRouting makes the client send each request to the backend router
+// returns for its model, e.g. llama models to a local server and everything
+// else to OpenAI. Requests without a model, such as file uploads, use the
+// client config. The other settings, such as APIVersion for Azure backends,
+// come from the config as well. All backends share the HTTP client, which
+// keeps a connection pool per host.
+func WithModelRouting(router ModelRouter) ClientOption {
+	return func(config *ClientConfig) {
+		config.ModelRouter = router
+	}
+}
+
+// route returns the client and URL to use for a request to rawURL, which was
+// built from the client config, for model.
+func (c *Client) route(rawURL, model string) (*Client, string) {
+	if c.config.ModelRouter == nil || model == "" {
+		return c, rawURL
+	}
+	suffix := c.endpoint(rawURL)
+	baseURL, apiType, authToken := c.config.ModelRouter(model, suffix)
+	if baseURL == "" && apiType == "" && authToken == "" {
+		return c, rawURL
+	}
+
+	routed := *c
+	if baseURL != "" {
+		routed.config.BaseURL = baseURL
+	}
+	if apiType != "" {
+		routed.config.APIType = apiType
+	}
+	if authToken != "" {
+		routed.config.authToken = authToken
+		// The router's token wins over the token provider of the client.
+		routed.tokens = nil
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		query := u.Query()
+		query.Del("api-version")
+		if len(query) > 0 {
+			suffix += "?" + query.Encode()
+		}
+	}
+	return &routed, routed.fullURL(suffix, model)
+}
@@ -0,0 +1,120 @@
+package openai_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// routingTestBackend records the requests it receives.
+type routingTestBackend struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newRoutingTestBackend(t *testing.T) *routingTestBackend {
+	b := &routingTestBackend{}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		b.requests = append(b.requests, r.URL.Path+" "+r.Header.Get("Authorization")+r.Header.Get(openai.AzureAPIKeyHeader))
+		b.mu.Unlock()
+		switch {
+		case r.Header.Get("Accept") == "text/event-stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+		case strings.HasSuffix(r.URL.Path, "/audio/transcriptions"):
+			_, _ = w.Write([]byte(`{"text":"hello"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func (b *routingTestBackend) received() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	requests := b.requests
+	b.requests = nil
+	return requests
+}
+
+func TestModelRouting(t *testing.T) {
+	openAIBackend := newRoutingTestBackend(t)
+	localBackend := newRoutingTestBackend(t)
+	azureBackend := newRoutingTestBackend(t)
+
+	var suffixes []string
+	config := openai.DefaultConfig("openai-token")
+	config.BaseURL = openAIBackend.URL + "/v1"
+	config.APIVersion = "2024-02-01"
+	client := openai.NewClientWithConfig(config, openai.WithModelRouting(
+		func(model, endpointSuffix string) (string, openai.APIType, string) {
+			suffixes = append(suffixes, endpointSuffix)
+			switch {
+			case strings.HasPrefix(model, "llama"):
+				return localBackend.URL + "/v1", "", "local-token"
+			case strings.HasPrefix(model, "text-embedding"):
+				return azureBackend.URL, openai.APITypeAzure, "azure-key"
+			}
+			return "", "", ""
+		},
+	))
+	ctx := context.Background()
+	chat := func(model string) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:    model,
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		}
+	}
+
+	_, err := client.CreateChatCompletion(ctx, chat("llama3"))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	stream, err := client.CreateChatCompletionStream(ctx, chat("llama3"))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	stream.Close()
+	_, err = client.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    "llama-whisper",
+		FilePath: "audio.mp3",
+		Reader:   strings.NewReader("audio"),
+	})
+	checks.NoError(t, err, "CreateTranscription error")
+	_, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequest{Input: []string{"hi"}, Model: openai.AdaEmbeddingV2})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	_, err = client.CreateChatCompletion(ctx, chat(openai.GPT4))
+	checks.NoError(t, err, "CreateChatCompletion error")
+	_, err = client.ListModels(ctx)
+	checks.NoError(t, err, "ListModels error")
+
+	checkRequests := func(name string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s backend received\n%s\nwant\n%s", name, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+	checkRequests("local", localBackend.received(),
+		"/v1/chat/completions Bearer local-token",
+		"/v1/chat/completions Bearer local-token",
+		"/v1/audio/transcriptions Bearer local-token",
+	)
+	checkRequests("azure", azureBackend.received(),
+		"/openai/deployments/text-embedding-ada-002/embeddings azure-key",
+	)
+	checkRequests("default", openAIBackend.received(),
+		"/v1/chat/completions Bearer openai-token",
+		"/v1/models Bearer openai-token",
+	)
+
+	wantSuffixes := "/chat/completions,/chat/completions,/audio/transcriptions,/embeddings,/chat/completions"
+	if strings.Join(suffixes, ",") != wantSuffixes {
+		t.Errorf("router got suffixes %v, want %s", suffixes, wantSuffixes)
+	}
+}
@@ -0,0 +1,86 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Speech models.
+const (
+	TTSModel1   = "tts-1"
+	TTSModel1HD = "tts-1-hd"
+)
+
+// Voices of the speech API.
+const (
+	VoiceAlloy   = "alloy"
+	VoiceEcho    = "echo"
+	VoiceFable   = "fable"
+	VoiceOnyx    = "onyx"
+	VoiceNova    = "nova"
+	VoiceShimmer = "shimmer"
+)
+
+// SpeechResponseFormat is the audio format of the speech API; mp3 is the
+// default.
+type SpeechResponseFormat string
+
+const (
+	SpeechResponseFormatMP3  SpeechResponseFormat = "mp3"
+	SpeechResponseFormatOpus SpeechResponseFormat = "opus"
+	SpeechResponseFormatAAC  SpeechResponseFormat = "aac"
+	SpeechResponseFormatFLAC SpeechResponseFormat = "flac"
+	SpeechResponseFormatWAV  SpeechResponseFormat = "wav"
+	// SpeechResponseFormatPCM is raw 24kHz 16-bit signed little-endian
+	// samples, without a header.
+	SpeechResponseFormatPCM SpeechResponseFormat = "pcm"
+)
+
+// SpeechRequest represents a request structure for the speech API.
+type SpeechRequest struct {
+	Model          string               `json:"model"`
+	Input          string               `json:"input"`
+	Voice          string               `json:"voice"`
+	ResponseFormat SpeechResponseFormat `json:"response_format,omitempty"`
+	// Speed is from 0.25 to 4, 1 if zero.
+	Speed float64 `json:"speed,omitempty"`
+}
+
+// CreateSpeech - API call to generate audio from text. The caller must close
+// the returned audio.
+func (c *Client) CreateSpeech(ctx context.Context, request SpeechRequest) (audio io.ReadCloser, err error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/audio/speech", request.Model), withBody(request))
+	if err != nil {
+		return
+	}
+
+	audio, err = c.sendRequestRaw(req)
+	return
+}
+
+// CreateSpeechToFile generates audio from text with CreateSpeech and writes
+// it to the file at path, which is created or truncated. The file is removed
+// if the audio could not be written entirely.
+func (c *Client) CreateSpeechToFile(ctx context.Context, request SpeechRequest, path string) (err error) {
+	audio, err := c.CreateSpeech(ctx, request)
+	if err != nil {
+		return
+	}
+	defer audio.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(file, audio)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		err = errors.Join(err, os.Remove(path))
+	}
+	return
+}
@@ -0,0 +1,101 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestCreateSpeech(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/audio/speech", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := `{"model":"tts-1","input":"Hello!","voice":"alloy","response_format":"opus","speed":1.5}`
+		if string(body) != want {
+			t.Errorf("got request %s, want %s", body, want)
+		}
+		w.Header().Set("Content-Type", "audio/ogg")
+		_, _ = w.Write([]byte("OggS audio"))
+	})
+
+	audio, err := client.CreateSpeech(context.Background(), openai.SpeechRequest{
+		Model:          openai.TTSModel1,
+		Input:          "Hello!",
+		Voice:          openai.VoiceAlloy,
+		ResponseFormat: openai.SpeechResponseFormatOpus,
+		Speed:          1.5,
+	})
+	checks.NoError(t, err, "CreateSpeech error")
+	defer audio.Close()
+	content, err := io.ReadAll(audio)
+	checks.NoError(t, err, "ReadAll error")
+	if string(content) != "OggS audio" {
+		t.Errorf("got audio %q", content)
+	}
+
+	// Unset parameters are left to the API defaults.
+	marshaled, err := json.Marshal(openai.SpeechRequest{Model: openai.TTSModel1HD, Input: "Hi", Voice: openai.VoiceNova})
+	checks.NoError(t, err, "Marshal error")
+	if string(marshaled) != `{"model":"tts-1-hd","input":"Hi","voice":"nova"}` {
+		t.Errorf("got %s", marshaled)
+	}
+}
+
+func TestCreateSpeechToFile(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/audio/speech", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.SpeechRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		switch request.Input {
+		case "invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid input","type":"invalid_request_error"}}`))
+		case "cut":
+			// The connection is closed before the announced length.
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte("ID3 partial"))
+		default:
+			_, _ = w.Write([]byte("ID3 audio"))
+		}
+	})
+	ctx := context.Background()
+	dir := t.TempDir()
+	request := openai.SpeechRequest{Model: openai.TTSModel1, Input: "Hello!", Voice: openai.VoiceEcho}
+
+	path := filepath.Join(dir, "speech.mp3")
+	checks.NoError(t, client.CreateSpeechToFile(ctx, request, path), "CreateSpeechToFile error")
+	content, err := os.ReadFile(path)
+	checks.NoError(t, err, "ReadFile error")
+	if string(content) != "ID3 audio" {
+		t.Errorf("got file content %q", content)
+	}
+
+	request.Input = "invalid"
+	path = filepath.Join(dir, "invalid.mp3")
+	err = client.CreateSpeechToFile(ctx, request, path)
+	checks.ErrorIs(t, err, openai.ErrBadRequest, "CreateSpeechToFile should fail on API errors")
+	if _, statErr := os.Stat(path); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("created a file for a failed request: %v", statErr)
+	}
+
+	request.Input = "cut"
+	path = filepath.Join(dir, "cut.mp3")
+	checks.HasError(t, client.CreateSpeechToFile(ctx, request, path), "CreateSpeechToFile should fail on cut audio")
+	if _, statErr := os.Stat(path); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("left the partial file: %v", statErr)
+	}
+
+	request.Input = "Hello!"
+	checks.HasError(t, client.CreateSpeechToFile(ctx, request, filepath.Join(dir, "missing", "speech.mp3")),
+		"CreateSpeechToFile should fail in a missing directory")
+}
@@ -3,27 +3,41 @@ package openai
 import (
 	"context"
 	"errors"
+	"io"
+	"net/http"
 )
 
 var (
 	ErrTooManyEmptyStreamMessages = errors.New("stream has sent too many empty messages")
 )
 
+// completionStreamChanSize is the buffer of the channels of
+// CreateCompletionStreamChan, which lets the stream be read ahead of a
+// receiver busy with earlier chunks.
+const completionStreamChanSize = 16
+
 type CompletionStream struct {
 	*streamReader[CompletionResponse]
 }
 
+// NewCompletionStream returns a stream reading the server-sent events of body,
+// see NewChatCompletionStream.
+func NewCompletionStream(body io.ReadCloser) *CompletionStream {
+	return &CompletionStream{streamReader: newStreamReader[CompletionResponse](body)}
+}
+
 // CreateCompletionStream — API call to create a completion w/ streaming
 // support. It sets whether to stream back partial progress. If set, tokens will be
 // sent as data-only server-sent events as they become available, with the
-// stream terminated by a data: [DONE] message.
+// stream terminated by a data: [DONE] message. request.Stream is set by this
+// method, CreateCompletion rejects requests with it set.
 func (c *Client) CreateCompletionStream(
 	ctx context.Context,
 	request CompletionRequest,
 ) (stream *CompletionStream, err error) {
-	urlSuffix := "/completions"
-	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
-		err = ErrCompletionUnsupportedModel
+	urlSuffix := completionsSuffix
+	if !c.supportsModel(urlSuffix, request.Model) {
+		err = ErrCompletionInvalidModel
 		return
 	}
 
@@ -33,7 +47,7 @@ func (c *Client) CreateCompletionStream(
 	}
 
 	request.Stream = true
-	req, err := c.newRequest(ctx, "POST", c.fullURL(urlSuffix, request.Model), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
 	if err != nil {
 		return nil, err
 	}
@@ -47,3 +61,49 @@ func (c *Client) CreateCompletionStream(
 	}
 	return
 }
+
+// CompletionStreamResult is a chunk of the stream of CreateCompletionStreamChan,
+// or the error that ended it.
+type CompletionStreamResult struct {
+	Response CompletionResponse
+	Err      error
+}
+
+// CreateCompletionStreamChan is CreateCompletionStream delivering the chunks
+// on a channel, for use in select statements. The channel is closed once the
+// stream ends; if it ends with an error, including the cancellation of ctx,
+// a last result carries that error. The stream is read by a goroutine, which
+// exits once the caller has received every result, so the channel must be
+// drained after ctx is canceled.
+func (c *Client) CreateCompletionStreamChan(
+	ctx context.Context,
+	request CompletionRequest,
+) (<-chan CompletionStreamResult, error) {
+	stream, err := c.CreateCompletionStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan CompletionStreamResult, completionStreamChanSize)
+	go func() {
+		defer close(results)
+		defer stream.Close()
+		for {
+			response, recvErr := stream.Recv()
+			if errors.Is(recvErr, io.EOF) {
+				return
+			}
+			if recvErr != nil {
+				results <- CompletionStreamResult{Err: recvErr}
+				return
+			}
+			select {
+			case results <- CompletionStreamResult{Response: response}:
+			case <-ctx.Done():
+				results <- CompletionStreamResult{Err: ctx.Err()}
+				return
+			}
+		}
+	}()
+	return results, nil
+}
@@ -6,17 +6,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	utils "github.com/zquestz/go-openai/internal"
 )
 
 var (
 	headerData  = []byte("data: ")
-	errorPrefix = []byte(`data: {"error":`)
+	errorField  = []byte(`"error"`)
+	sseFieldIDs = [][]byte{[]byte("event:"), []byte("id:"), []byte("retry:"), []byte(":")}
 )
 
 type streamable interface {
-	ChatCompletionStreamResponse | CompletionResponse
+	ChatCompletionStreamResponse | CompletionResponse | ResponseStreamEvent
 }
 
 type streamReader[T streamable] struct {
@@ -27,52 +29,88 @@ type streamReader[T streamable] struct {
 	response       *http.Response
 	errAccumulator utils.ErrorAccumulator
 	unmarshaler    utils.Unmarshaler
+	timer          *requestTimer
+
+	// start, chunks and firstChunk feed the StreamStats passed to onClose.
+	start      time.Time
+	chunks     int
+	firstChunk time.Duration
+	onClose    func(StreamStats)
 
 	httpHeader
 }
 
+// newStreamReader returns a streamReader reading the server-sent events of
+// body instead of an HTTP response.
+func newStreamReader[T streamable](body io.ReadCloser) *streamReader[T] {
+	return &streamReader[T]{
+		start:              time.Now(),
+		emptyMessagesLimit: defaultEmptyMessagesLimit,
+		reader:             bufio.NewReader(body),
+		response:           &http.Response{Body: body, Header: make(http.Header)},
+		errAccumulator:     utils.NewErrorAccumulator(),
+		unmarshaler:        &utils.JSONUnmarshaler{},
+		httpHeader:         make(httpHeader),
+	}
+}
+
 func (stream *streamReader[T]) Recv() (response T, err error) {
-	if stream.isFinished {
-		err = io.EOF
+	rawLine, err := stream.RecvRaw()
+	if err != nil {
 		return
 	}
 
-	response, err = stream.processLines()
+	err = stream.unmarshaler.Unmarshal(rawLine, &response)
+	if err != nil {
+		response = *new(T)
+		err = newDecodeError(stream.response, rawLine, err)
+	}
 	return
 }
 
+// RecvRaw returns the payload of the next data event without decoding it, e.g.
+// to read fields that OpenAI-compatible providers add to the typed responses.
+// The returned slice is not reused by later calls. Unlike
+// ChatCompletionStream.Recv, it does not resume dropped connections.
+func (stream *streamReader[T]) RecvRaw() ([]byte, error) {
+	if stream.isFinished {
+		return nil, io.EOF
+	}
+
+	return stream.processLines()
+}
+
 //nolint:gocognit
-func (stream *streamReader[T]) processLines() (T, error) {
-	var (
-		emptyMessagesCount uint
-		hasErrorPrefix     bool
-	)
+func (stream *streamReader[T]) processLines() ([]byte, error) {
+	var emptyMessagesCount uint
+	// Only the time spent waiting for events counts toward the idle timeout.
+	stream.timer.receivedEvent()
+	defer stream.timer.pauseIdle()
 
 	for {
 		rawLine, readErr := stream.reader.ReadBytes('\n')
-		if readErr != nil || hasErrorPrefix {
+		if readErr != nil {
 			respErr := stream.unmarshalError()
 			if respErr != nil {
-				return *new(T), fmt.Errorf("error, %w", respErr.Error)
+				return nil, fmt.Errorf("error, %w", respErr.Error)
 			}
-			return *new(T), readErr
+			return nil, stream.timer.wrap(readErr)
 		}
+		// Any line counts, so that keep-alive comments hold the stream open.
+		stream.timer.receivedEvent()
 
 		noSpaceLine := bytes.TrimSpace(rawLine)
-		if bytes.HasPrefix(noSpaceLine, errorPrefix) {
-			hasErrorPrefix = true
-		}
-		if !bytes.HasPrefix(noSpaceLine, headerData) || hasErrorPrefix {
-			if hasErrorPrefix {
-				noSpaceLine = bytes.TrimPrefix(noSpaceLine, headerData)
-			}
-			writeErr := stream.errAccumulator.Write(noSpaceLine)
-			if writeErr != nil {
-				return *new(T), writeErr
+		if !bytes.HasPrefix(noSpaceLine, headerData) {
+			// Errors may also be sent as plain, possibly multi-line, JSON.
+			if !isSSEField(noSpaceLine) {
+				writeErr := stream.errAccumulator.Write(noSpaceLine)
+				if writeErr != nil {
+					return nil, writeErr
+				}
 			}
 			emptyMessagesCount++
 			if emptyMessagesCount > stream.emptyMessagesLimit {
-				return *new(T), ErrTooManyEmptyStreamMessages
+				return nil, ErrTooManyEmptyStreamMessages
 			}
 
 			continue
@@ -81,16 +119,80 @@ func (stream *streamReader[T]) processLines() (T, error) {
 		noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
 		if string(noPrefixLine) == "[DONE]" {
 			stream.isFinished = true
-			return *new(T), io.EOF
+			return nil, io.EOF
 		}
 
-		var response T
-		unmarshalErr := stream.unmarshaler.Unmarshal(noPrefixLine, &response)
-		if unmarshalErr != nil {
-			return *new(T), unmarshalErr
+		if apiErr := stream.unmarshalDataError(noPrefixLine); apiErr != nil {
+			return nil, fmt.Errorf("error, %w", apiErr)
 		}
 
-		return response, nil
+		stream.chunks++
+		if stream.chunks == 1 {
+			stream.firstChunk = time.Since(stream.start)
+			stream.timer.receivedChunk()
+		}
+		return bytes.Clone(noPrefixLine), nil
+	}
+}
+
+func isSSEField(line []byte) bool {
+	for _, field := range sseFieldIDs {
+		if bytes.HasPrefix(line, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalDataError returns the error carried by a data event shaped like
+// {"error": {...}}, or nil if the event is not an error.
+func (stream *streamReader[T]) unmarshalDataError(data []byte) *APIError {
+	if !bytes.Contains(data, errorField) {
+		return nil
+	}
+
+	var errResp ErrorResponse
+	err := stream.unmarshaler.Unmarshal(data, &errResp)
+	if err != nil || errResp.Error == nil {
+		return nil
+	}
+
+	// Azure reports the equivalent HTTP status in the error object.
+	var status struct {
+		Error struct {
+			Status int `json:"status"`
+		} `json:"error"`
+	}
+	_ = stream.unmarshaler.Unmarshal(data, &status)
+	errResp.Error.HTTPStatusCode = status.Error.Status
+	if errResp.Error.HTTPStatusCode == 0 {
+		errResp.Error.HTTPStatusCode = streamErrorStatusCode(errResp.Error)
+	}
+	errResp.Error.RequestID = stream.RequestID()
+	errResp.Error.setRequest(stream.response.Request)
+	return errResp.Error
+}
+
+// streamErrorStatusCode returns the HTTP status code matching an error sent
+// within a stream, whose response status is always 200.
+func streamErrorStatusCode(e *APIError) int {
+	code := e.ErrorCode()
+	switch {
+	case e.Type == ErrorTypeInvalidRequest || code == ErrorCodeContentFilter:
+		return http.StatusBadRequest
+	case e.Type == ErrorTypeAuthentication || code == ErrorCodeInvalidAPIKey:
+		return http.StatusUnauthorized
+	case e.Type == ErrorTypePermission:
+		return http.StatusForbidden
+	case e.Type == ErrorTypeNotFound:
+		return http.StatusNotFound
+	case e.Type == ErrorTypeRateLimit || e.Type == "tokens" || e.Type == "requests" ||
+		code == ErrorCodeRateLimitExceeded || code == ErrorCodeInsufficientQuota:
+		return http.StatusTooManyRequests
+	case code == ErrorCodeModelOverloaded || e.Type == ErrorTypeOverloaded:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
@@ -101,13 +203,43 @@ func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
 	}
 
 	err := stream.unmarshaler.Unmarshal(errBytes, &errResp)
-	if err != nil {
+	if err != nil || errResp.Error == nil {
 		errResp = nil
+		return
 	}
 
+	if errResp.Error.HTTPStatusCode == 0 {
+		errResp.Error.HTTPStatusCode = streamErrorStatusCode(errResp.Error)
+	}
+	errResp.Error.RequestID = stream.RequestID()
 	return
 }
 
 func (stream *streamReader[T]) Close() {
-	stream.response.Body.Close()
+	_ = stream.close()
+}
+
+// CloseWithCancel cancels the request of the stream before closing it like
+// Close, so that the transport aborts it at once, resetting its HTTP/2 stream
+// or closing its HTTP/1 connection, and the server stops generating. It
+// returns the error of closing the response body. A Recv in progress fails
+// with a TimeoutError wrapping context.Canceled, where after Close it may
+// still return a chunk; later calls fail either way.
+func (stream *streamReader[T]) CloseWithCancel() error {
+	stream.timer.abort()
+	return stream.close()
+}
+
+func (stream *streamReader[T]) close() error {
+	err := stream.response.Body.Close()
+	stream.timer.release()
+	if onClose := stream.onClose; onClose != nil {
+		stream.onClose = nil
+		onClose(StreamStats{
+			Chunks:     stream.chunks,
+			FirstChunk: stream.firstChunk,
+			Duration:   time.Since(stream.start),
+		})
+	}
+	return err
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"testing"
 	"time"
@@ -103,6 +104,34 @@ func TestCreateCompletionStream(t *testing.T) {
 	}
 }
 
+func TestCreateCompletionStreamSetsStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/completions", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.CompletionRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		if !request.Stream {
+			t.Error("CreateCompletionStream should send stream: true")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err = w.Write([]byte("data: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateCompletionStream(context.Background(), openai.CompletionRequest{
+		Prompt: "Ex falso quodlibet",
+		Model:  "text-davinci-002",
+	})
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("stream.Recv() returned %v, want io.EOF", err)
+	}
+}
+
 func TestCreateCompletionStreamError(t *testing.T) {
 	client, server, teardown := setupOpenAITestServer()
 	defer teardown()
@@ -327,6 +356,55 @@ func TestCreateCompletionStreamReturnTimeoutError(t *testing.T) {
 }
 
 // Helper funcs.
+func TestCreateCompletionStreamChan(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, id := range []string{"1", "2"} {
+			_, _ = w.Write([]byte(`data: {"id":"` + id + `","choices":[{"text":"response` + id + `"}]}` + "\n\n"))
+		}
+		if r.URL.Query().Get("hang") == "" {
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	request := openai.CompletionRequest{Prompt: "Ex falso quodlibet", Model: "text-davinci-002"}
+
+	results, err := client.CreateCompletionStreamChan(context.Background(), request)
+	checks.NoError(t, err, "CreateCompletionStreamChan error")
+	var ids []string
+	for result := range results {
+		checks.NoError(t, result.Err, "stream error")
+		ids = append(ids, result.Response.ID)
+	}
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("received chunks %v, want 1 and 2", ids)
+	}
+
+	// A canceled stream ends with the cancellation error.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results, err = client.WithOptions(openai.WithQueryParams(url.Values{"hang": {"1"}})).
+		CreateCompletionStreamChan(ctx, request)
+	checks.NoError(t, err, "CreateCompletionStreamChan error")
+	for i := 0; i < 2; i++ {
+		checks.NoError(t, (<-results).Err, "stream error")
+	}
+	cancel()
+	select {
+	case result := <-results:
+		checks.ErrorIs(t, result.Err, context.Canceled, "canceled stream")
+	case <-time.After(time.Second):
+		t.Fatal("no result after cancellation")
+	}
+	if _, open := <-results; open {
+		t.Error("channel not closed after the error")
+	}
+}
+
 func compareResponses(r1, r2 openai.CompletionResponse) bool {
 	if r1.ID != r2.ID || r1.Object != r2.Object || r1.Created != r2.Created || r1.Model != r2.Model {
 		return false
@@ -0,0 +1,261 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// Phases of a request reported by TimeoutError.
+const (
+	TimeoutPhaseConnecting = "connecting"
+	TimeoutPhaseSending    = "sending"
+	TimeoutPhaseReceiving  = "receiving"
+	TimeoutPhaseStreaming  = "streaming"
+)
+
+// TimeoutError is returned when the context of a request is canceled or its
+// deadline is exceeded. Phase tells how far the request got, which separates
+// a deadline that was too short to connect from a server that was too slow
+// to answer. It wraps the original error, so errors.Is(err,
+// context.DeadlineExceeded) still holds.
+type TimeoutError struct {
+	Elapsed time.Duration
+	Phase   string
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	what := "timed out"
+	if errors.Is(e.Err, context.Canceled) {
+		what = "canceled"
+	}
+	return fmt.Sprintf("request %s while %s after %s: %v", what, e.Phase, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the deadline was exceeded, as opposed to the
+// context being canceled.
+func (e *TimeoutError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// Temporary reports whether retrying with a new deadline may succeed.
+func (e *TimeoutError) Temporary() bool {
+	return e.Timeout()
+}
+
+// errRequestTimeout cancels requests that exceed ClientConfig.RequestTimeout.
+var errRequestTimeout = fmt.Errorf("request timeout exceeded: %w", context.DeadlineExceeded)
+
+// ErrStreamIdleTimeout is wrapped in the TimeoutError returned by the Recv
+// method of streams that received no event for ClientConfig.StreamIdleTimeout.
+var ErrStreamIdleTimeout = fmt.Errorf("stream idle timeout exceeded: %w", context.DeadlineExceeded)
+
+// FirstTokenTimeoutError is wrapped in the TimeoutError returned by streams
+// that received no chunk within ClientConfig.FirstTokenTimeout of sending the
+// request, so that errors.As tells a slow first token from other timeouts and
+// cancellations. errors.Is(err, context.DeadlineExceeded) holds too.
+type FirstTokenTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *FirstTokenTimeoutError) Error() string {
+	return fmt.Sprintf("no stream chunk received within the first token timeout of %s", e.Timeout)
+}
+
+func (e *FirstTokenTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// WithFirstTokenTimeout sets ClientConfig.FirstTokenTimeout. Use it with
+// Client.WithOptions for a single call.
+func WithFirstTokenTimeout(timeout time.Duration) ClientOption {
+	return func(config *ClientConfig) {
+		config.FirstTokenTimeout = timeout
+	}
+}
+
+// WithStreamIdleTimeout sets ClientConfig.StreamIdleTimeout. Use it with
+// Client.WithOptions for a single call.
+func WithStreamIdleTimeout(timeout time.Duration) ClientOption {
+	return func(config *ClientConfig) {
+		config.StreamIdleTimeout = timeout
+	}
+}
+
+// requestTimer tracks the phase a request is in, from the first connection
+// attempt until its response is read, and enforces ClientConfig.RequestTimeout.
+type requestTimer struct {
+	start time.Time
+	phase atomic.Value
+
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	deadline *time.Timer
+
+	// idle cancels streams after idleTimeout without an event.
+	idle        *time.Timer
+	idleTimeout time.Duration
+
+	// firstChunk cancels streams receiving no chunk in time, see
+	// awaitFirstChunk.
+	firstChunk *time.Timer
+}
+
+// traceRequest returns a copy of req that reports its progress to the returned
+// requestTimer, and is canceled after timeout if it is positive. The caller
+// must call release once done with the response.
+func traceRequest(req *http.Request, timeout time.Duration) (*http.Request, *requestTimer) {
+	timer := &requestTimer{start: time.Now()}
+	timer.setPhase(TimeoutPhaseConnecting)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			timer.setPhase(TimeoutPhaseSending)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			timer.setPhase(TimeoutPhaseReceiving)
+		},
+	}
+
+	timer.ctx, timer.cancel = context.WithCancelCause(httptrace.WithClientTrace(req.Context(), trace))
+	if timeout > 0 {
+		timer.deadline = time.AfterFunc(timeout, func() {
+			timer.cancel(errRequestTimeout)
+		})
+	}
+	return req.WithContext(timer.ctx), timer
+}
+
+func (t *requestTimer) setPhase(phase string) {
+	t.phase.Store(phase)
+}
+
+// startStream lifts the timeout once a stream's headers have arrived, as
+// streams may legitimately run much longer than a single response, and
+// cancels the stream if Recv then waits for idleTimeout without receiving an
+// event, if it is positive.
+func (t *requestTimer) startStream(idleTimeout time.Duration) {
+	t.setPhase(TimeoutPhaseStreaming)
+	if t.deadline != nil {
+		t.deadline.Stop()
+	}
+	if idleTimeout > 0 {
+		t.idleTimeout = idleTimeout
+		t.idle = time.AfterFunc(idleTimeout, func() {
+			t.cancel(ErrStreamIdleTimeout)
+		})
+		// It runs while Recv waits for events.
+		t.idle.Stop()
+	}
+}
+
+// awaitFirstChunk cancels the stream with a FirstTokenTimeoutError if it
+// receives no chunk within timeout, if it is positive.
+func (t *requestTimer) awaitFirstChunk(timeout time.Duration) {
+	if timeout > 0 {
+		t.firstChunk = time.AfterFunc(timeout, func() {
+			t.cancel(&FirstTokenTimeoutError{Timeout: timeout})
+		})
+	}
+}
+
+// receivedChunk stops the first token timeout of a stream.
+func (t *requestTimer) receivedChunk() {
+	if t != nil && t.firstChunk != nil {
+		t.firstChunk.Stop()
+	}
+}
+
+// receivedEvent restarts the idle timeout of a stream. It is also called when
+// Recv starts waiting for the next event.
+func (t *requestTimer) receivedEvent() {
+	if t != nil && t.idle != nil {
+		t.idle.Reset(t.idleTimeout)
+	}
+}
+
+// pauseIdle stops the idle timeout of a stream while the caller processes
+// what Recv returned, so that slow readers of healthy streams do not time out.
+func (t *requestTimer) pauseIdle() {
+	if t != nil && t.idle != nil {
+		t.idle.Stop()
+	}
+}
+
+// abort cancels the request, unlike release without stopping the timers.
+func (t *requestTimer) abort() {
+	if t != nil && t.cancel != nil {
+		t.cancel(context.Canceled)
+	}
+}
+
+func (t *requestTimer) release() {
+	if t == nil || t.cancel == nil {
+		return
+	}
+	if t.deadline != nil {
+		t.deadline.Stop()
+	}
+	if t.idle != nil {
+		t.idle.Stop()
+	}
+	t.receivedChunk()
+	t.cancel(context.Canceled)
+}
+
+// wrap returns err as a TimeoutError if it was caused by the context of the
+// request, and err unchanged otherwise.
+func (t *requestTimer) wrap(err error) error {
+	if t == nil || err == nil {
+		return err
+	}
+	// Reads interrupted by the idle or first token timeouts fail with errors of
+	// the transport.
+	var firstToken *FirstTokenTimeoutError
+	if t.ctx != nil {
+		switch cause := context.Cause(t.ctx); {
+		case errors.Is(cause, ErrStreamIdleTimeout):
+			err = ErrStreamIdleTimeout
+		case errors.As(cause, &firstToken):
+			err = firstToken
+		}
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return err
+	}
+	if t.ctx != nil && errors.Is(context.Cause(t.ctx), errRequestTimeout) {
+		err = errRequestTimeout
+	}
+	phase, _ := t.phase.Load().(string)
+	return &TimeoutError{
+		Elapsed: time.Since(t.start),
+		Phase:   phase,
+		Err:     err,
+	}
+}
+
+// releaseOnClose releases the requestTimer of a response body handed to the
+// caller once the body is closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	timer *requestTimer
+}
+
+func (r releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.timer.release()
+	return err
+}
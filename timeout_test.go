@@ -0,0 +1,373 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+// Compile-time check that TimeoutError can be handled as a net.Error.
+var _ net.Error = (*openai.TimeoutError)(nil)
+
+func checkTimeoutError(t *testing.T, err error, phase string, timeout bool) {
+	t.Helper()
+	var timeoutErr *openai.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+	if timeoutErr.Phase != phase {
+		t.Errorf("Phase = %q, want %q", timeoutErr.Phase, phase)
+	}
+	if timeoutErr.Timeout() != timeout || timeoutErr.Temporary() != timeout {
+		t.Errorf("Timeout() = %v, want %v", timeoutErr.Timeout(), timeout)
+	}
+	if timeout {
+		checks.ErrorIs(t, err, context.DeadlineExceeded, "TimeoutError does not wrap the context error")
+	} else {
+		checks.ErrorIs(t, err, context.Canceled, "TimeoutError does not wrap the context error")
+	}
+}
+
+func TestCreateChatCompletionTimeout(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(_ http.ResponseWriter, r *http.Request) {
+		// Reading the body lets the server notice the client going away.
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	})
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	t.Run("receiving", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := client.CreateChatCompletion(ctx, request)
+		checkTimeoutError(t, err, openai.TimeoutPhaseReceiving, true)
+
+		var timeoutErr *openai.TimeoutError
+		if errors.As(err, &timeoutErr) && timeoutErr.Elapsed < 50*time.Millisecond {
+			t.Errorf("Elapsed = %s, want at least 50ms", timeoutErr.Elapsed)
+		}
+	})
+
+	t.Run("connecting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.CreateChatCompletion(ctx, request)
+		checkTimeoutError(t, err, openai.TimeoutPhaseConnecting, false)
+	})
+}
+
+func TestCreateChatCompletionStreamTimeout(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		_, err := w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n"))
+		checks.NoError(t, err, "Write error")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		Stream:   true,
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	_, err = stream.Recv()
+	checkTimeoutError(t, err, openai.TimeoutPhaseStreaming, true)
+}
+
+func TestCreateChatCompletionStreamOutlivesRequestTimeout(t *testing.T) {
+	server := openaitest.NewServer(t)
+	chunk := openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "hi"}}},
+	}
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{chunk, chunk, chunk}, 30*time.Millisecond)
+	client := server.Client(openai.WithTimeout(50 * time.Millisecond))
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	for i := 0; i < 3; i++ {
+		_, err = stream.Recv()
+		checks.NoError(t, err, "stream.Recv() failed")
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should end after its chunks")
+}
+
+func TestClientWithTimeout(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		if r.Header.Get("Accept") != "text/event-stream" {
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-3.5-turbo"}`))
+			return
+		}
+		// Stream chunks for longer than the timeout once the headers are sent.
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		for i := 0; i < 3; i++ {
+			time.Sleep(50 * time.Millisecond)
+			//nolint:lll
+			_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n"))
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	})
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	ctx := context.Background()
+
+	fast := client.WithOptions(openai.WithTimeout(50 * time.Millisecond))
+	start := time.Now()
+	_, err := fast.CreateChatCompletion(ctx, request)
+	checkTimeoutError(t, err, openai.TimeoutPhaseReceiving, true)
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("timed out after %s, want about 50ms", elapsed)
+	}
+
+	_, err = client.CreateChatCompletion(ctx, request)
+	checks.NoError(t, err, "the default client should not be affected by WithOptions")
+
+	// The timeout only bounds the wait for the stream headers.
+	streaming := client.WithOptions(openai.WithTimeout(300 * time.Millisecond))
+	stream, err := streaming.CreateChatCompletionStream(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	for {
+		_, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "stream.Recv() failed")
+	}
+}
+
+// keepAliveStream streams a chunk every 90ms, with a keep-alive comment every
+// 30ms in between, for duration.
+func keepAliveStream(duration time.Duration) http.HandlerFunc {
+	//nolint:lll
+	const chunk = `data: {"id":"1","object":"chat.completion.chunk","model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n"
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		for i := 1; time.Duration(i)*30*time.Millisecond <= duration; i++ {
+			select {
+			case <-time.After(30 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+			line := ": keep-alive\n\n"
+			if i%3 == 0 {
+				line = chunk
+			}
+			_, _ = w.Write([]byte(line))
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	server := openaitest.NewServer(t)
+	chunk := openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "hi"}}},
+	}
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{chunk, chunk}, 40*time.Millisecond,
+		openaitest.MatchNth(1))
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{chunk, chunk}, time.Second)
+	client := server.Client(openai.WithStreamIdleTimeout(70 * time.Millisecond))
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	// Chunks arriving in time keep the stream open.
+	stream, err := client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	for i := 0; i < 2; i++ {
+		_, err = stream.Recv()
+		checks.NoError(t, err, "stream.Recv() failed")
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should end after its chunks")
+	stream.Close()
+
+	stream, err = client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	start := time.Now()
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, openai.ErrStreamIdleTimeout, "stream.Recv() should time out")
+	checkTimeoutError(t, err, openai.TimeoutPhaseStreaming, true)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timed out after %s, want about 70ms", elapsed)
+	}
+}
+
+func TestStreamIdleTimeoutSlowReader(t *testing.T) {
+	server := openaitest.NewServer(t)
+	chunk := openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "hi"}}},
+	}
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{chunk, chunk, chunk}, 20*time.Millisecond)
+	client := server.Client(openai.WithStreamIdleTimeout(50 * time.Millisecond))
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	// The time spent processing the chunks does not count toward the timeout.
+	stream, err := client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		_, err = stream.Recv()
+		checks.NoError(t, err, "stream.Recv() failed")
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should end after its chunks")
+}
+
+func TestStreamIdleTimeoutKeepAlive(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.Handle("/chat/completions", keepAliveStream(300*time.Millisecond))
+	client := server.Client(openai.WithStreamIdleTimeout(70 * time.Millisecond))
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	// Chunks are 90ms apart, but keep-alive comments reset the timeout.
+	stream, err := client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	var chunks int
+	for {
+		_, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "stream.Recv() failed")
+		chunks++
+	}
+	stream.Close()
+	if chunks != 3 {
+		t.Errorf("received %d chunks, want 3", chunks)
+	}
+
+	// The deadline of the context still applies.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stream, err = client.CreateChatCompletionStream(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "stream.Recv() should stop at the context deadline")
+	if errors.Is(err, openai.ErrStreamIdleTimeout) {
+		t.Errorf("stream.Recv() returned %v, want the context deadline", err)
+	}
+}
+
+func TestFirstTokenTimeout(t *testing.T) {
+	server := openaitest.NewServer(t)
+	chunk := openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: "hi"}}},
+	}
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{chunk, chunk, chunk}, 50*time.Millisecond,
+		openaitest.MatchNth(1))
+	server.Handle("/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		// The response headers are late too.
+		select {
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	}, openaitest.MatchNth(2))
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{chunk}, time.Second)
+	client := server.Client(openai.WithFirstTokenTimeout(80 * time.Millisecond))
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	// Once the first chunk arrived, later ones may take longer.
+	stream, err := client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	for i := 0; i < 3; i++ {
+		_, err = stream.Recv()
+		checks.NoError(t, err, "stream.Recv() failed")
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should end after its chunks")
+	stream.Close()
+
+	checkFirstTokenTimeout := func(err error, phase string) {
+		t.Helper()
+		var firstTokenErr *openai.FirstTokenTimeoutError
+		if !errors.As(err, &firstTokenErr) || firstTokenErr.Timeout != 80*time.Millisecond {
+			t.Fatalf("expected FirstTokenTimeoutError, got %v", err)
+		}
+		checkTimeoutError(t, err, phase, true)
+	}
+	start := time.Now()
+	_, err = client.CreateChatCompletionStream(context.Background(), request)
+	checkFirstTokenTimeout(err, openai.TimeoutPhaseReceiving)
+
+	stream, err = client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	_, err = stream.Recv()
+	checkFirstTokenTimeout(err, openai.TimeoutPhaseStreaming)
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Errorf("timed out after %s, want about 160ms", elapsed)
+	}
+
+	// Other deadlines are not reported as first token timeouts.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	stream, err = client.CreateChatCompletionStream(ctx, request)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "stream.Recv() should stop at the context deadline")
+	var firstTokenErr *openai.FirstTokenTimeoutError
+	if errors.As(err, &firstTokenErr) {
+		t.Errorf("stream.Recv() returned %v, want the context deadline", err)
+	}
+}
@@ -0,0 +1,274 @@
+package openai
+
+import (
+	"log"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+)
+
+// contextWindows lists the context windows, in tokens, of openai.com/docs/models
+// in December 2024.
+var contextWindows = map[string]int{
+	"gpt-4o":              128_000,
+	"gpt-4o-mini":         128_000,
+	"o1":                  200_000,
+	"o1-preview":          128_000,
+	"o1-mini":             128_000,
+	"gpt-4-turbo":         128_000,
+	"gpt-4-0125-preview":  128_000,
+	GPT4TurboPreview:      128_000,
+	GPT4VisionPreview:     128_000,
+	GPT4:                  8_192,
+	GPT432K:               32_768,
+	GPT3Dot5Turbo:         16_385,
+	GPT3Dot5Turbo0301:     4_096,
+	GPT3Dot5Turbo0613:     4_096,
+	GPT3Dot5Turbo16K:      16_385,
+	GPT3Dot5TurboInstruct: 4_096,
+}
+
+// ContextWindow returns the number of tokens of the context window of a
+// well-known OpenAI model. Dated snapshots such as gpt-4o-2024-08-06 have the
+// context window of gpt-4o unless listed themselves.
+func ContextWindow(model string) (int, bool) {
+	return lookupModel(contextWindows, model)
+}
+
+// TokenBudget fits the messages of chat completion requests to the context
+// window of a model, see NewTokenBudgetMiddleware.
+type TokenBudget struct {
+	Model string
+	// ContextWindow is the size of the context window of Model in tokens,
+	// looked up with the package-level ContextWindow if zero.
+	ContextWindow int
+	// ReserveForCompletion is the number of tokens left for the completion of
+	// requests without MaxTokens.
+	ReserveForCompletion int
+	// WarnFunc receives the warnings about dropped and truncated messages,
+	// log.Printf if nil.
+	WarnFunc func(format string, args ...any)
+}
+
+// NewTokenBudgetMiddleware returns a function wrapping clients so that their
+// CreateChatCompletion calls fit the request to the context window of model,
+// minus MaxTokens or, if the request has none, reserveForCompletion.
+//
+// Requests estimated to exceed it lose their oldest messages that are not
+// system messages, along with the tool results answering dropped tool calls.
+// If the last message alone is still too long and is a user message, it is
+// truncated to the tokens that fit. A warning is logged with log.Printf when
+// that happens; use TokenBudget.Middleware to log elsewhere.
+//
+// Token counts are estimated from the text of the messages, erring on the
+// high side for long words, as the client has no tokenizer.
+func NewTokenBudgetMiddleware(model string, reserveForCompletion int) func(*Client) *Client {
+	return TokenBudget{Model: model, ReserveForCompletion: reserveForCompletion}.Middleware
+}
+
+// Middleware returns a copy of client fitting the requests of its
+// CreateChatCompletion calls to the budget, see NewTokenBudgetMiddleware.
+func (b TokenBudget) Middleware(client *Client) *Client {
+	warn := b.WarnFunc
+	if warn == nil {
+		warn = log.Printf
+	}
+	if b.ContextWindow == 0 {
+		var ok bool
+		b.ContextWindow, ok = ContextWindow(b.Model)
+		if !ok {
+			warn("openai: unknown context window of model %s, messages are not truncated", b.Model)
+		}
+	}
+
+	clone := client.WithOptions()
+	budget := func(request ChatCompletionRequest) ChatCompletionRequest {
+		total := len(request.Messages)
+		request, dropped, truncated := b.fit(request)
+		if dropped > 0 {
+			warn("openai: dropped %d of %d messages to fit the context window of %s", dropped, total, b.Model)
+		}
+		if truncated {
+			warn("openai: truncated the last user message to fit the context window of %s", b.Model)
+		}
+		return request
+	}
+	clone.chatMiddleware = append(clone.chatMiddleware[:len(clone.chatMiddleware):len(clone.chatMiddleware)], budget)
+	return clone
+}
+
+// fit returns request with the messages that fit the budget, the number of
+// messages it dropped and whether it truncated the last one.
+func (b TokenBudget) fit(request ChatCompletionRequest) (ChatCompletionRequest, int, bool) {
+	reserve := b.ReserveForCompletion
+	if request.MaxTokens > 0 {
+		reserve = request.MaxTokens
+	}
+	budget := b.ContextWindow - reserve
+	total := estimateMessagesTokens(request.Messages)
+	if b.ContextWindow <= 0 || budget <= 0 || total <= budget {
+		return request, 0, false
+	}
+
+	messages := slices.Clone(request.Messages)
+	last := len(messages) - 1
+	for last >= 0 && messages[last].Role == ChatMessageRoleSystem {
+		last--
+	}
+	dropped := 0
+	for total > budget {
+		i := slices.IndexFunc(messages, func(m ChatCompletionMessage) bool { return m.Role != ChatMessageRoleSystem })
+		if i < 0 || i >= last {
+			break
+		}
+		// The API rejects tool results without the tool call they answer, so
+		// the tool results are dropped along with it, or kept with it if the
+		// conversation ends with them.
+		n := 1
+		for i+n < len(messages) && messages[i+n].Role == ChatMessageRoleTool {
+			n++
+		}
+		if i+n > last {
+			break
+		}
+		for _, m := range messages[i : i+n] {
+			total -= estimateMessageTokens(m)
+		}
+		messages = slices.Delete(messages, i, i+n)
+		dropped += n
+		last -= n
+	}
+
+	truncated := false
+	if total > budget && last >= 0 && messages[last].Role == ChatMessageRoleUser {
+		keep := estimateContentTokens(messages[last]) - (total - budget)
+		if keep > 0 {
+			messages[last] = truncateMessage(messages[last], keep)
+			truncated = true
+		}
+	}
+	request.Messages = messages
+	return request, dropped, truncated
+}
+
+const (
+	// tokensPerMessage and tokensPerReply are the tokens the chat format adds
+	// around each message and before the reply.
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+	// imagePartTokens is the cost of a 1024x1024 image at high detail.
+	imagePartTokens = 765
+	// maxTokenBytes is the length above which a word is counted as several
+	// tokens.
+	maxTokenBytes = 6
+)
+
+// tokenPattern splits text like the pre-tokenizer of cl100k_base.
+var tokenPattern = regexp.MustCompile(
+	`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// scanTokens calls fn with the end offset of each estimated token of text
+// until it returns false.
+func scanTokens(text string, fn func(end int) bool) {
+	for _, loc := range tokenPattern.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		for start < end {
+			next := min(start+maxTokenBytes, end)
+			for next < end && !utf8.RuneStart(text[next]) {
+				next++
+			}
+			if !fn(next) {
+				return
+			}
+			start = next
+		}
+	}
+}
+
+func estimateTokens(text string) int {
+	n := 0
+	scanTokens(text, func(int) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// truncateTokens returns the first n estimated tokens of text.
+func truncateTokens(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	count, end := 0, 0
+	scanTokens(text, func(tokenEnd int) bool {
+		count, end = count+1, tokenEnd
+		return count < n
+	})
+	return text[:end]
+}
+
+func estimateMessagesTokens(messages []ChatCompletionMessage) int {
+	n := tokensPerReply
+	for _, m := range messages {
+		n += estimateMessageTokens(m)
+	}
+	return n
+}
+
+func estimateMessageTokens(m ChatCompletionMessage) int {
+	n := tokensPerMessage + estimateTokens(m.Role) + estimateContentTokens(m)
+	if m.Name != "" {
+		n += estimateTokens(m.Name) + 1
+	}
+	if m.FunctionCall != nil {
+		n += estimateTokens(m.FunctionCall.Name) + estimateTokens(m.FunctionCall.Arguments)
+	}
+	for _, call := range m.ToolCalls {
+		n += estimateTokens(call.ID) + estimateTokens(call.Function.Name) + estimateTokens(call.Function.Arguments)
+	}
+	return n + estimateTokens(m.ToolCallID)
+}
+
+func estimateContentTokens(m ChatCompletionMessage) int {
+	if m.Content != "" {
+		return estimateTokens(m.Content)
+	}
+	n := 0
+	for _, part := range m.Parts {
+		if part.Type == ContentTypeText {
+			n += estimateTokens(part.Text)
+		} else {
+			n += imagePartTokens
+		}
+	}
+	return n
+}
+
+// truncateMessage returns m with its content cut to keep tokens, dropping the
+// text parts that do not fit.
+func truncateMessage(m ChatCompletionMessage, keep int) ChatCompletionMessage {
+	if m.Content != "" {
+		// Parts may mirror Content, see ChatCompletionMessage.MarshalJSON.
+		mirrored := len(m.Parts) == 1 && m.Parts[0].Type == ContentTypeText && m.Parts[0].Text == m.Content
+		m.Content = truncateTokens(m.Content, keep)
+		if mirrored {
+			m.Parts = Parts{{Type: ContentTypeText, Text: m.Content}}
+		}
+		return m
+	}
+	parts := make(Parts, 0, len(m.Parts))
+	for _, part := range m.Parts {
+		if part.Type != ContentTypeText {
+			keep -= imagePartTokens
+			parts = append(parts, part)
+			continue
+		}
+		if keep > 0 {
+			part.Text = truncateTokens(part.Text, keep)
+			keep -= estimateTokens(part.Text)
+			parts = append(parts, part)
+		}
+	}
+	m.Parts = parts
+	return m
+}
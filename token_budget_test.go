@@ -0,0 +1,189 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+func TestContextWindow(t *testing.T) {
+	for model, want := range map[string]int{
+		"gpt-4o":            128_000,
+		"gpt-4o-2024-08-06": 128_000,
+		"gpt-4o-mini":       128_000,
+		openai.GPT40613:     8_192,
+		openai.GPT432K0613:  32_768,
+		"unknown":           0,
+	} {
+		got, ok := openai.ContextWindow(model)
+		if got != want || ok != (want != 0) {
+			t.Errorf("ContextWindow(%q) = %d, %t, want %d", model, got, ok, want)
+		}
+	}
+}
+
+// sentMessages returns the messages of the last request server received.
+func sentMessages(t *testing.T, server *openaitest.Server) []openai.ChatCompletionMessage {
+	t.Helper()
+	requests := server.Requests()
+	var request openai.ChatCompletionRequest
+	checks.NoError(t, json.Unmarshal(requests[len(requests)-1].Body, &request), "Unmarshal error")
+	return request.Messages
+}
+
+func TestTokenBudget(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{})
+	var warnings []string
+	budget := openai.TokenBudget{
+		Model:                openai.GPT4,
+		ContextWindow:        100,
+		ReserveForCompletion: 40,
+		WarnFunc: func(format string, args ...any) {
+			warnings = append(warnings, fmt.Sprintf(format, args...))
+		},
+	}
+	client := budget.Middleware(server.Client())
+	ctx := context.Background()
+	// The history is estimated to 101 tokens, 50 without its first three
+	// messages after the system message.
+	history := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "You are helpful."},
+		{Role: openai.ChatMessageRoleUser, Content: strings.Repeat(" first", 10)},
+		{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+			ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "lookup", Arguments: "{}"},
+		}}},
+		{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: strings.Repeat(" result", 10)},
+		{Role: openai.ChatMessageRoleAssistant, Content: strings.Repeat(" second", 10)},
+		{Role: openai.ChatMessageRoleUser, Content: strings.Repeat(" third", 10)},
+	}
+
+	_, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: openai.GPT4, Messages: history[:2]})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if got := sentMessages(t, server); len(got) != 2 || len(warnings) != 0 {
+		t.Errorf("request within budget: sent %d messages, warnings %q", len(got), warnings)
+	}
+
+	_, err = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: openai.GPT4, Messages: history})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	got := sentMessages(t, server)
+	if len(got) != 3 || got[0].Role != openai.ChatMessageRoleSystem || got[1].Content != history[4].Content ||
+		got[2].Content != history[5].Content {
+		t.Errorf("sent messages %+v, want the system message and the last two", got)
+	}
+	if len(warnings) != 1 || warnings[0] != "openai: dropped 3 of 6 messages to fit the context window of gpt-4" {
+		t.Errorf("got warnings %q", warnings)
+	}
+	if len(history) != 6 || history[1].Content != strings.Repeat(" first", 10) {
+		t.Error("the middleware modified the messages of the caller")
+	}
+
+	// MaxTokens replaces ReserveForCompletion.
+	warnings = nil
+	_, err = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: openai.GPT4, Messages: history, MaxTokens: 60,
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if got = sentMessages(t, server); len(got) != 2 || got[1].Content != history[5].Content {
+		t.Errorf("request with MaxTokens: sent messages %+v, want the system message and the last one", got)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "dropped 4 of 6 messages") {
+		t.Errorf("got warnings %q", warnings)
+	}
+
+	// Tool results ending the conversation are kept with their tool call.
+	warnings = nil
+	calls := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "You are helpful."},
+		{Role: openai.ChatMessageRoleUser, Content: strings.Repeat(" first", 60)},
+		{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{
+			{ID: "a", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "lookup", Arguments: "{}"}},
+			{ID: "b", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "lookup", Arguments: "{}"}},
+		}},
+		{Role: openai.ChatMessageRoleTool, ToolCallID: "a", Content: "first result"},
+		{Role: openai.ChatMessageRoleTool, ToolCallID: "b", Content: "second result"},
+	}
+	_, err = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{Model: openai.GPT4, Messages: calls})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	got = sentMessages(t, server)
+	if len(got) != 4 || got[1].Role != openai.ChatMessageRoleAssistant || got[2].ToolCallID != "a" ||
+		got[3].ToolCallID != "b" {
+		t.Errorf("request ending with tool results: sent messages %+v, want the tool call and its results", got)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "dropped 1 of 5 messages") {
+		t.Errorf("got warnings %q", warnings)
+	}
+
+	// Without the middleware, requests are sent unchanged.
+	_, err = server.Client().CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: openai.GPT4, Messages: history,
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if got = sentMessages(t, server); len(got) != 6 {
+		t.Errorf("request without middleware: sent %d messages", len(got))
+	}
+}
+
+func TestTokenBudgetTruncate(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{})
+	var warnings []string
+	client := openai.TokenBudget{
+		Model:         openai.GPT4,
+		ContextWindow: 100,
+		WarnFunc: func(format string, args ...any) {
+			warnings = append(warnings, fmt.Sprintf(format, args...))
+		},
+	}.Middleware(server.Client())
+
+	// The 14 tokens of the system message, 3 for the reply and 4 around the
+	// user message leave 79 words of its content.
+	long := strings.Repeat(" word", 1000)
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model: openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: strings.Repeat(" rule", 10)},
+			{Role: openai.ChatMessageRoleUser, Content: long},
+		},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	got := sentMessages(t, server)
+	if len(got) != 2 || got[1].Content != strings.Repeat(" word", 79) {
+		t.Errorf("sent messages %+v, want the user message truncated to 79 words", got)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "truncated the last user message") {
+		t.Errorf("got warnings %q", warnings)
+	}
+
+	// Words longer than 6 bytes count as several tokens, and are cut between
+	// them, never within a UTF-8 sequence.
+	_, err = client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: strings.Repeat("é", 1000)}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if got = sentMessages(t, server); got[0].Content != strings.Repeat("é", 3*93) {
+		t.Errorf("sent %d bytes, want 93 tokens of 3 runes", len(got[0].Content))
+	}
+}
+
+func TestNewTokenBudgetMiddleware(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{})
+	wrap := openai.NewTokenBudgetMiddleware(openai.GPT4, 8_000)
+	// 192 tokens are left, 7 of which go to the chat format.
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: strings.Repeat(" a", 500)}}
+	_, err := wrap(server.Client()).CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model: openai.GPT4, Messages: messages,
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if got := sentMessages(t, server); got[0].Content != strings.Repeat(" a", 185) {
+		t.Errorf("sent %q, want 185 words", got[0].Content)
+	}
+}
@@ -0,0 +1,39 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrToolCallIndexOutOfRange is returned by UnmarshalToolCallArguments for an
+// index the message has no tool call at.
+var ErrToolCallIndexOutOfRange = errors.New("tool call index out of range")
+
+// UnmarshalToolCallArguments decodes the arguments of the tool call at index
+// into v. Empty arguments leave v unchanged, as FunctionRouter does.
+func (m ChatCompletionMessage) UnmarshalToolCallArguments(index int, v any) error {
+	if index < 0 || index >= len(m.ToolCalls) {
+		return fmt.Errorf("%w: %d of %d", ErrToolCallIndexOutOfRange, index, len(m.ToolCalls))
+	}
+	function := m.ToolCalls[index].Function
+	if function.Arguments == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(function.Arguments), v); err != nil {
+		return fmt.Errorf("invalid arguments of %s: %w", function.Name, err)
+	}
+	return nil
+}
+
+// FindToolCall returns the first function call of the message to the function
+// name, which points into ToolCalls.
+func (m ChatCompletionMessage) FindToolCall(name string) (*ToolCall, bool) {
+	for i := range m.ToolCalls {
+		call := &m.ToolCalls[i]
+		if call.Raw == nil && call.Function.Name == name {
+			return call, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,54 @@
+package openai_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+func TestToolCallArguments(t *testing.T) {
+	message := openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{
+			{ID: "call_1", Type: "code_interpreter", Raw: json.RawMessage(`{"id":"call_1","type":"code_interpreter"}`)},
+			{ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{
+				Name: "get_weather", Arguments: `{"city":"Paris","days":3}`,
+			}},
+			{ID: "call_3", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{
+				Name: "get_time", Arguments: `{"zone":`,
+			}},
+		},
+	}
+
+	var weather struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	checks.NoError(t, message.UnmarshalToolCallArguments(1, &weather), "UnmarshalToolCallArguments error")
+	if weather.City != "Paris" || weather.Days != 3 {
+		t.Errorf("unexpected arguments %+v", weather)
+	}
+
+	var args map[string]any
+	err := message.UnmarshalToolCallArguments(2, &args)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), "get_time") {
+		t.Errorf("invalid arguments error = %v, want a syntax error naming the function", err)
+	}
+	checks.ErrorIs(t, message.UnmarshalToolCallArguments(3, &args), openai.ErrToolCallIndexOutOfRange,
+		"index out of range")
+	checks.ErrorIs(t, message.UnmarshalToolCallArguments(-1, &args), openai.ErrToolCallIndexOutOfRange,
+		"negative index")
+
+	call, ok := message.FindToolCall("get_time")
+	if !ok || call.ID != "call_3" {
+		t.Errorf("FindToolCall(get_time) = %+v, %t", call, ok)
+	}
+	if call, ok = message.FindToolCall(""); ok {
+		t.Errorf("FindToolCall matched the call of unknown type %+v", call)
+	}
+}
@@ -0,0 +1,136 @@
+package openai
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost is the number of idle connections to the API
+	// kept by the transport of NewTransport, against 2 for http.DefaultTransport
+	// which makes concurrent callers reconnect all the time.
+	DefaultMaxIdleConnsPerHost = 100
+
+	// unixSocketHost is the dummy host of the base URL set by WithUnixSocket, as
+	// requests need one even though it is never resolved.
+	unixSocketHost = "unix"
+
+	// defaultResponseHeaderTimeout is the longest wait for the response headers,
+	// which only arrive with the whole completion for requests not streamed.
+	defaultResponseHeaderTimeout = 10 * time.Minute
+)
+
+// defaultTransport is shared by the HTTP clients of DefaultConfig and its
+// siblings, so that clients of the same API share their connections.
+var defaultTransport = NewTransport()
+
+// NewTransport returns the transport of the default HTTP client: an
+// http.Transport like http.DefaultTransport, with HTTP/2 enabled, which keeps
+// DefaultMaxIdleConnsPerHost idle connections per host and bounds TLS
+// handshakes to 10 seconds and the wait for response headers to 10 minutes.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConnsPerHost,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: defaultResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the number of idle connections per host kept by
+// the transport of ClientConfig.HTTPClient, DefaultMaxIdleConnsPerHost by
+// default. Like WithHTTP2, it copies the HTTP client and its transport, and
+// only applies to an *http.Client whose transport is an *http.Transport or nil,
+// so it must come before options wrapping the transport such as WithRetries.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return withTransport(func(transport *http.Transport) {
+		transport.MaxIdleConnsPerHost = n
+		if transport.MaxIdleConns != 0 && transport.MaxIdleConns < n {
+			transport.MaxIdleConns = n
+		}
+	})
+}
+
+// WithHTTP2 sets whether the transport of ClientConfig.HTTPClient uses HTTP/2
+// with the servers supporting it, which it does by default. Disabling it may
+// help with proxies mishandling HTTP/2. See WithMaxIdleConnsPerHost for the
+// HTTP clients it applies to.
+func WithHTTP2(enabled bool) ClientOption {
+	return withTransport(func(transport *http.Transport) {
+		transport.ForceAttemptHTTP2 = enabled
+		if enabled {
+			transport.TLSNextProto = nil
+		} else {
+			// A non-nil empty map is how http.Transport disables HTTP/2.
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+	})
+}
+
+// WithDialContext makes the transport of ClientConfig.HTTPClient open its
+// connections with dial, e.g. to bind them to a network interface. See
+// WithMaxIdleConnsPerHost for the HTTP clients it applies to.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return withTransport(func(transport *http.Transport) {
+		transport.DialContext = dial
+	})
+}
+
+// WithUnixSocket sends the requests to the server listening on the unix socket
+// at path, such as a local inference server. The host of ClientConfig.BaseURL
+// is replaced with a dummy one, keeping its path, so it must be set before,
+// e.g. with DefaultOllamaConfig or WithBaseURL. See WithMaxIdleConnsPerHost for
+// the HTTP clients it applies to.
+func WithUnixSocket(path string) ClientOption {
+	dial := WithDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", path)
+	})
+	return func(config *ClientConfig) {
+		dial(config)
+		baseURL, err := url.Parse(config.BaseURL)
+		if err != nil {
+			baseURL = &url.URL{}
+		}
+		// Connections to the socket are never encrypted.
+		baseURL.Scheme, baseURL.Host = "http", unixSocketHost
+		config.BaseURL = baseURL.String()
+	}
+}
+
+// withTransport returns an option changing a copy of the transport of
+// ClientConfig.HTTPClient with tune, NewTransport if the client has none.
+func withTransport(tune func(*http.Transport)) ClientOption {
+	return func(config *ClientConfig) {
+		doer, ok := config.HTTPClient.(*http.Client)
+		if !ok || doer == nil {
+			return
+		}
+		var transport *http.Transport
+		switch base := doer.Transport.(type) {
+		case nil:
+			transport = NewTransport()
+		case *http.Transport:
+			transport = base.Clone()
+		default:
+			return
+		}
+		tune(transport)
+		httpClient := *doer
+		httpClient.Transport = transport
+		config.HTTPClient = &httpClient
+	}
+}
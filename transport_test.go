@@ -0,0 +1,160 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+)
+
+// newConnCountingServer starts a chat completions server counting the
+// connections it accepts. It replies after a millisecond, so that concurrent
+// calls overlap.
+func newConnCountingServer(t testing.TB) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var conns atomic.Int64
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4"}`))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts, &conns
+}
+
+// completeConcurrently sends calls chat completions from workers goroutines.
+func completeConcurrently(t testing.TB, client *openai.Client, workers, calls int) {
+	t.Helper()
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls/workers; j++ {
+				if _, err := client.CreateChatCompletion(context.Background(), request); err != nil {
+					t.Errorf("CreateChatCompletion error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDefaultTransportReusesConnections(t *testing.T) {
+	const workers, calls = 32, 640
+	ts, conns := newConnCountingServer(t)
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	completeConcurrently(t, openai.NewClientWithConfig(config), workers, calls)
+	// Connections may be dialed while others are being returned to the pool,
+	// but not one per call as when the pool is too small.
+	if n := conns.Load(); n > 2*workers {
+		t.Errorf("server accepted %d connections for %d workers, want them reused", n, workers)
+	}
+}
+
+func BenchmarkConcurrentChatCompletions(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []openai.ClientOption
+	}{
+		{"default", nil},
+		{"2-idle-per-host", []openai.ClientOption{openai.WithMaxIdleConnsPerHost(2)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ts, conns := newConnCountingServer(b)
+			config := openai.DefaultConfig(test.GetTestToken())
+			config.BaseURL = ts.URL + "/v1"
+			client := openai.NewClientWithConfig(config, bc.opts...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				completeConcurrently(b, client, 32, 320)
+			}
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
+
+func TestWithUnixSocket(t *testing.T) {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "openai.sock"))
+	checks.NoError(t, err, "Listen error")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if !request.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"` + request.Model + `"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	client := openai.NewClientWithConfig(openai.DefaultOllamaConfig(""), openai.WithUnixSocket(listener.Addr().String()))
+	request := openai.ChatCompletionRequest{
+		Model:    "llama3",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	resp, err := client.CreateChatCompletion(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.ID != "chatcmpl-1" || resp.Model != "llama3" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), request)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if chunk.ID != "chatcmpl-2" || chunk.Choices[0].Delta.Content != "Hi" {
+		t.Errorf("unexpected chunk %+v", chunk)
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream did not end")
+}
+
+func TestWithDialContext(t *testing.T) {
+	ts, _ := newConnCountingServer(t)
+	var dials atomic.Int64
+	dial := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		dials.Add(1)
+		var dialer net.Dialer
+		// Every host is redirected to the test server.
+		return dialer.DialContext(ctx, network, ts.Listener.Addr().String())
+	}
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = "http://api.example.invalid/v1"
+	client := openai.NewClientWithConfig(config, openai.WithDialContext(dial))
+	completeConcurrently(t, client, 1, 2)
+	if dials.Load() != 1 {
+		t.Errorf("dialed %d times, want 1", dials.Load())
+	}
+}
@@ -0,0 +1,97 @@
+package openai
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"sync"
+)
+
+// UsageRecorder receives the token usage of every successful call of a client
+// that reports one: chat completions, including streams requested with
+// StreamOptions.IncludeUsage, completions and embeddings. model is the model
+// of the request. ctx is the context of the call, so that recorders can
+// attribute the usage, e.g. to a tenant stored in it. Record must be safe for
+// concurrent use.
+type UsageRecorder interface {
+	Record(ctx context.Context, endpoint, model string, usage Usage)
+}
+
+// WithUsageRecorder sets ClientConfig.UsageRecorder.
+func WithUsageRecorder(recorder UsageRecorder) ClientOption {
+	return func(config *ClientConfig) {
+		config.UsageRecorder = recorder
+	}
+}
+
+// UsageTracker is a UsageRecorder keeping the total usage by model in memory,
+// token details included, and its cost if Pricing is set. It is safe for
+// concurrent use. The zero value is ready to use, like NewUsageTracker.
+type UsageTracker struct {
+	// Pricing, if set, prices each call, see Costs. It must be set before the
+	// tracker is used.
+	Pricing PricingTable
+
+	mu     sync.Mutex
+	totals map[string]Usage
+	costs  map[string]Cost
+}
+
+// NewUsageTracker creates a UsageTracker without any usage.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{totals: make(map[string]Usage), costs: make(map[string]Cost)}
+}
+
+func (t *UsageTracker) Record(_ context.Context, _, model string, usage Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.totals == nil {
+		t.totals = make(map[string]Usage)
+	}
+	t.totals[model] = t.totals[model].Add(usage)
+
+	if t.Pricing == nil {
+		return
+	}
+	// Calls are priced one by one, as their cached tokens differ.
+	if cost, err := t.Pricing.EstimateCost(model, usage); err == nil {
+		if t.costs == nil {
+			t.costs = make(map[string]Cost)
+		}
+		totalCost := t.costs[model]
+		totalCost.Input += cost.Input
+		totalCost.Output += cost.Output
+		totalCost.Total += cost.Total
+		t.costs[model] = totalCost
+	}
+}
+
+// Snapshot returns the total usage by model recorded so far.
+func (t *UsageTracker) Snapshot() map[string]Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.totals)
+}
+
+// Costs returns the total cost by model recorded so far, for the models
+// Pricing has prices for.
+func (t *UsageTracker) Costs() map[string]Cost {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.costs)
+}
+
+var usageType = reflect.TypeOf(Usage{})
+
+// usageOf returns the Usage field of a response, if it has one.
+func usageOf(v any) (Usage, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return Usage{}, false
+	}
+	field := rv.Elem().FieldByName("Usage")
+	if !field.IsValid() || field.Type() != usageType {
+		return Usage{}, false
+	}
+	return field.Interface().(Usage), true
+}
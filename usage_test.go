@@ -0,0 +1,211 @@
+package openai_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/zquestz/go-openai"
+	"github.com/zquestz/go-openai/internal/test"
+	"github.com/zquestz/go-openai/internal/test/checks"
+	"github.com/zquestz/go-openai/openaitest"
+)
+
+type tenantKey struct{}
+
+// tenantUsageRecorder totals usage by the tenant of the call context.
+type tenantUsageRecorder struct {
+	mu        sync.Mutex
+	endpoints []string
+	totals    map[string]int
+}
+
+func (r *tenantUsageRecorder) Record(ctx context.Context, endpoint, _ string, usage openai.Usage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	r.endpoints = append(r.endpoints, endpoint)
+	r.totals[tenant] += usage.TotalTokens
+}
+
+func setupUsageTestServer(t *testing.T, recorder openai.UsageRecorder) *openai.Client {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			request, _ := getChatCompletionBody(r)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4",` +
+				`"choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n"))
+			if request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+				_, _ = w.Write([]byte(`data: {"id":"1","object":"chat.completion.chunk","model":"gpt-4","choices":[],` +
+					`"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}` + "\n\n"))
+			}
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4-0613",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`))
+	})
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	})
+	server.RegisterHandler("/v1/completions", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","object":"text_completion","model":"gpt-3.5-turbo-instruct",` +
+			`"choices":[{"text":"Hi","index":0}],"usage":{"prompt_tokens":4,"completion_tokens":2,"total_tokens":6}}`))
+	})
+	server.RegisterHandler("/v1/embeddings", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-ada-002",` +
+			`"data":[{"object":"embedding","embedding":[0.1],"index":0}],"usage":{"prompt_tokens":3,"total_tokens":3}}`))
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	return openai.NewClientWithConfig(config, openai.WithUsageRecorder(recorder))
+}
+
+func TestUsageTracker(t *testing.T) {
+	tracker := openai.NewUsageTracker()
+	client := setupUsageTestServer(t, tracker)
+	ctx := context.Background()
+	chat := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(ctx, chat)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	_, err := client.CreateCompletion(ctx, openai.CompletionRequest{Model: openai.GPT3Dot5TurboInstruct, Prompt: "Hello"})
+	checks.NoError(t, err, "CreateCompletion error")
+	_, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequest{Input: []string{"Hello"}, Model: openai.AdaEmbeddingV2})
+	checks.NoError(t, err, "CreateEmbeddings error")
+
+	chat.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := client.CreateChatCompletionStream(ctx, chat)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	stream.Close()
+
+	want := map[string]openai.Usage{
+		openai.GPT4:                    {PromptTokens: 23, CompletionTokens: 7, TotalTokens: 30},
+		openai.GPT3Dot5TurboInstruct:   {PromptTokens: 4, CompletionTokens: 2, TotalTokens: 6},
+		openai.AdaEmbeddingV2.String(): {PromptTokens: 3, TotalTokens: 3},
+	}
+	snapshot := tracker.Snapshot()
+	if len(snapshot) != len(want) {
+		t.Errorf("got usage of %d models, want %d: %v", len(snapshot), len(want), snapshot)
+	}
+	for model, usage := range want {
+		if snapshot[model] != usage {
+			t.Errorf("%s: got usage %+v, want %+v", model, snapshot[model], usage)
+		}
+	}
+}
+
+func TestUsageTrackerZeroValue(t *testing.T) {
+	tracker := &openai.UsageTracker{Pricing: openai.DefaultPricingTable()}
+	usage := openai.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100}
+	tracker.Record(context.Background(), "/chat/completions", "gpt-4o", usage)
+
+	if got := tracker.Snapshot()["gpt-4o"]; got != usage {
+		t.Errorf("got usage %+v, want %+v", got, usage)
+	}
+	if cost := tracker.Costs()["gpt-4o"]; cost.Total == 0 {
+		t.Errorf("got cost %+v, want it priced", cost)
+	}
+}
+
+func TestUsageTrackerTokenDetails(t *testing.T) {
+	server := openaitest.NewServer(t)
+	server.OnChatCompletion(openai.ChatCompletionResponse{
+		Model: openai.GPT4,
+		Usage: openai.Usage{
+			PromptTokens:        2048,
+			CompletionTokens:    10,
+			TotalTokens:         2058,
+			PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 1024},
+		},
+	})
+	server.OnChatCompletionStream([]openai.ChatCompletionStreamResponse{
+		{Model: openai.GPT4, Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{
+			Content: "Hi",
+		}}}},
+		{Model: openai.GPT4, Usage: &openai.Usage{
+			PromptTokens:        1536,
+			CompletionTokens:    20,
+			TotalTokens:         1556,
+			PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 512, AudioTokens: 8},
+		}},
+	}, 0)
+	tracker := openai.NewUsageTracker()
+	client := server.Client(openai.WithUsageRecorder(tracker))
+	ctx := context.Background()
+	chat := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(ctx, chat)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	chat.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := client.CreateChatCompletionStream(ctx, chat)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	response, err := openai.AccumulateStream(stream)
+	stream.Close()
+	checks.NoError(t, err, "AccumulateStream error")
+	if details := response.Usage.PromptTokensDetails; details == nil || details.CachedTokens != 512 {
+		t.Errorf("got streamed prompt tokens details %+v, want 512 cached tokens", details)
+	}
+
+	want := openai.Usage{
+		PromptTokens:        5632,
+		CompletionTokens:    40,
+		TotalTokens:         5672,
+		PromptTokensDetails: &openai.PromptTokensDetails{CachedTokens: 2560, AudioTokens: 8},
+	}
+	if got := tracker.Snapshot()[openai.GPT4]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got usage %+v, want %+v", got, want)
+	}
+}
+
+func TestUsageRecorderContext(t *testing.T) {
+	recorder := &tenantUsageRecorder{totals: make(map[string]int)}
+	client := setupUsageTestServer(t, recorder)
+	chat := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+		_, err := client.CreateChatCompletion(ctx, chat)
+		checks.NoError(t, err, "CreateChatCompletion error")
+	}
+	// Streams without include_usage report nothing.
+	stream, err := client.CreateChatCompletionStream(context.WithValue(context.Background(), tenantKey{}, "acme"), chat)
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	stream.Close()
+	_, err = client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+
+	if recorder.totals["acme"] != 24 || recorder.totals["globex"] != 12 {
+		t.Errorf("got totals by tenant %v", recorder.totals)
+	}
+	if len(recorder.endpoints) != 3 || recorder.endpoints[0] != "/chat/completions" {
+		t.Errorf("got endpoints %v", recorder.endpoints)
+	}
+}